@@ -130,6 +130,14 @@ func SetRouter(routerName string, router v2.RouterConfiguration) {
 	tryDump()
 }
 
+func RemoveRouter(routerName string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(conf.routerConfigPath, routerName)
+	delete(conf.Routers, routerName)
+	tryDump()
+}
+
 // Dump
 // Dump all config
 func Dump() ([]byte, error) {
@@ -96,7 +96,8 @@ func StartService(inheritListeners []net.Listener) error {
 		}
 
 		for i, l := range inheritListeners {
-			if l == nil {
+			// the admin service only listens on tcp address
+			if _, ok := l.(*net.TCPListener); !ok {
 				continue
 			}
 			addr, err := net.ResolveTCPAddr("tcp", l.Addr().String())
@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+// Access log format types
+const (
+	AccessLogFormatText   = "text"
+	AccessLogFormatJSON   = "json"
+	AccessLogFormatLogfmt = "logfmt"
+)
+
+// Access log comparison operators
+const (
+	AccessLogCompareEQ = "eq"
+	AccessLogCompareNE = "ne"
+	AccessLogCompareGE = "ge"
+	AccessLogCompareGT = "gt"
+	AccessLogCompareLE = "le"
+	AccessLogCompareLT = "lt"
+)
+
+// AccessLogSinkConfig configures the sink of an access log, the config is parsed by the sink of the type
+type AccessLogSinkConfig struct {
+	Type   string                 `json:"type,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// AccessLogFilterConfig configures which requests are logged, a request is logged if it matches all of the configured conditions
+type AccessLogFilterConfig struct {
+	// StatusCode matches the response code of the request
+	StatusCode *AccessLogComparison `json:"status_code,omitempty"`
+	// Duration matches the duration of the request in milliseconds
+	Duration *AccessLogComparison `json:"duration,omitempty"`
+	// SampleRate is the ratio of the requests to be logged, in the range [0, 1]
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+}
+
+// AccessLogComparison compares a value of the request with Value, ge is used if Op is empty
+type AccessLogComparison struct {
+	Op    string `json:"op,omitempty"`
+	Value uint64 `json:"value"`
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"net"
+	"strings"
+)
+
+// UnixAddressPrefix is the prefix of the unix domain socket address, such as unix:/tmp/mosn.sock
+const UnixAddressPrefix = "unix:"
+
+// IsUnixAddress returns true if the address is a unix domain socket address
+func IsUnixAddress(address string) bool {
+	return strings.HasPrefix(address, UnixAddressPrefix)
+}
+
+// ResolveAddress resolves the tcp address, or the unix domain socket address with the unix: prefix
+func ResolveAddress(address string) (net.Addr, error) {
+	if IsUnixAddress(address) {
+		return net.ResolveUnixAddr("unix", strings.TrimPrefix(address, UnixAddressPrefix))
+	}
+	return net.ResolveTCPAddr("tcp", address)
+}
+
+// AddressString returns the address in config format, the unix domain socket address has the unix: prefix
+func AddressString(addr net.Addr) string {
+	if ua, ok := addr.(*net.UnixAddr); ok {
+		return UnixAddressPrefix + ua.Name
+	}
+	return addr.String()
+}
@@ -0,0 +1,45 @@
+package v2
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestResolveAddress(t *testing.T) {
+	addr, err := ResolveAddress("unix:/tmp/mosn.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ua, ok := addr.(*net.UnixAddr); !ok || ua.Name != "/tmp/mosn.sock" {
+		t.Errorf("unexpected unix address: %v", addr)
+	}
+	if AddressString(addr) != "unix:/tmp/mosn.sock" {
+		t.Errorf("unexpected unix address string: %s", AddressString(addr))
+	}
+	addr, err = ResolveAddress("127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := addr.(*net.TCPAddr); !ok || AddressString(addr) != "127.0.0.1:8080" {
+		t.Errorf("unexpected tcp address: %v", addr)
+	}
+}
+
+func TestUnixListenerMarshal(t *testing.T) {
+	addr, _ := ResolveAddress("unix:/tmp/mosn.sock")
+	ln := Listener{
+		ListenerConfig: ListenerConfig{
+			Name: "unix",
+		},
+		Addr: addr,
+	}
+	b, err := json.Marshal(ln)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"address":"unix:/tmp/mosn.sock"`) {
+		t.Errorf("unexpected listener config: %s", b)
+	}
+}
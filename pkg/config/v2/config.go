@@ -63,6 +63,9 @@ type MetricsConfig struct {
 	StatsMatcher StatsMatcher      `json:"stats_matcher"`
 	ShmZone      string            `json:"shm_zone"`
 	ShmSize      datasize.ByteSize `json:"shm_size"`
+	// HistogramBuckets configures the upper bounds of the histogram buckets by the metrics type,
+	// in the unit of the recorded values, for example nanoseconds for the request durations
+	HistogramBuckets map[string][]float64 `json:"histogram_buckets,omitempty"`
 }
 
 // PluginConfig for plugin config
@@ -52,6 +52,7 @@ const (
 	CONNECTION_MANAGER          = "connection_manager" // deprecated
 	DEFAULT_NETWORK_FILTER      = "proxy"
 	TCP_PROXY                   = "tcp_proxy"
+	UDP_PROXY                   = "udp_proxy"
 	FAULT_INJECT_NETWORK_FILTER = "fault_inject"
 	RPC_PROXY                   = "rpc_proxy"
 	X_PROXY                     = "x_proxy"
@@ -63,6 +64,8 @@ const (
 	MIXER        = "mixer"
 	FaultStream  = "fault"
 	PayloadLimit = "payload_limit"
+	CORS         = "cors"
+	CommonRule   = "commonrule"
 )
 
 // HealthCheckFilter
@@ -7,6 +7,24 @@ type LeastRequestLbConfig struct {
 func (lbconfig *LeastRequestLbConfig) isCluster_LbConfig() {
 }
 
+// RingHashLbConfig is the config of ring hash load balancer
+type RingHashLbConfig struct {
+	MinimumRingSize uint64 `json:"minimum_ring_size,omitempty"`
+	MaximumRingSize uint64 `json:"maximum_ring_size,omitempty"`
+}
+
+func (lbconfig *RingHashLbConfig) isCluster_LbConfig() {
+}
+
+// MaglevLbConfig is the config of maglev load balancer
+// the table size should be a prime number, otherwise it is rounded up to the next prime
+type MaglevLbConfig struct {
+	TableSize uint64 `json:"table_size,omitempty"`
+}
+
+func (lbconfig *MaglevLbConfig) isCluster_LbConfig() {
+}
+
 type IsCluster_LbConfig interface {
 	isCluster_LbConfig()
 }
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import "mosn.io/api"
+
+// OTLP protocols
+const (
+	OTLPProtocolGRPC string = "grpc"
+	OTLPProtocolHTTP string = "http"
+)
+
+// OTLPExporterConfig configures the exporter that pushes the telemetry data over the OpenTelemetry protocol
+type OTLPExporterConfig struct {
+	// Protocol is one of grpc and http, grpc is used if it is empty
+	Protocol string `json:"protocol,omitempty"`
+	// Endpoint is the address such as 127.0.0.1:4317 for grpc, and the base url such as
+	// http://127.0.0.1:4318 for http, the path of the signal such as /v1/traces is appended
+	Endpoint string `json:"endpoint"`
+	// ServiceName is the service.name attribute of the resource
+	ServiceName string `json:"service_name,omitempty"`
+	// Headers are sent in every export request, such as the authorization headers
+	Headers map[string]string `json:"headers,omitempty"`
+	// Timeout is the timeout of an export request
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+	// BatchSize is the max items in an export request
+	BatchSize int `json:"batch_size,omitempty"`
+	// BatchInterval is the max interval to wait before exporting the items
+	BatchInterval api.DurationConfig `json:"batch_interval,omitempty"`
+	// QueueSize is the max items waiting to be exported, the new items are dropped if the queue is full
+	QueueSize int `json:"queue_size,omitempty"`
+	// MaxRetries is the max retries of a failed export request, the request is retried only if the error is retryable
+	MaxRetries int `json:"max_retries,omitempty"`
+	// RetryBackOff is the interval before the first retry, and is doubled for each retry
+	RetryBackOff api.DurationConfig `json:"retry_back_off,omitempty"`
+}
+
+// OTLPTraceConfig is the config of the OpenTelemetry tracer driver
+type OTLPTraceConfig struct {
+	OTLPExporterConfig
+	// SampleRate is the rate of the new traces to be sampled, in the range [0, 1].
+	// the sampling decision of the downstream is used if it exists
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+}
@@ -17,7 +17,11 @@
 
 package v2
 
-import "time"
+import (
+	"time"
+
+	"mosn.io/api"
+)
 
 // TCPProxy
 type TCPProxy struct {
@@ -28,6 +32,13 @@ type TCPProxy struct {
 	Routes             []*TCPRoute    `json:"routes,omitempty"`
 }
 
+// UDPProxy
+type UDPProxy struct {
+	StatPrefix  string              `json:"stat_prefix,omitempty"`
+	Cluster     string              `json:"cluster,omitempty"`
+	IdleTimeout *api.DurationConfig `json:"idle_timeout,omitempty"`
+}
+
 // WebSocketProxy
 type WebSocketProxy struct {
 	StatPrefix         string
@@ -43,8 +54,30 @@ type Proxy struct {
 	RouterConfigName   string                 `json:"router_config_name,omitempty"`
 	ValidateClusters   bool                   `json:"validate_clusters,omitempty"`
 	ExtendConfig       map[string]interface{} `json:"extend_config,omitempty"`
+	// RouteBodyBuffer enables buffering the request body before the route matching,
+	// so the routes can be matched by the request body
+	RouteBodyBuffer *RouteBodyBuffer `json:"route_body_buffer,omitempty"`
 }
 
+// Actions taken when the request body exceeds the route body buffer limit
+const (
+	// RouteBodyExceedReject responds the request with 413
+	RouteBodyExceedReject = "reject"
+	// RouteBodyExceedHeaderOnly matches the routes by the headers only
+	RouteBodyExceedHeaderOnly = "header_only"
+)
+
+// RouteBodyBuffer limits the request body buffered for the route matching
+type RouteBodyBuffer struct {
+	// MaxBytes is the max size of the buffered body, DefaultRouteBodyBufferBytes is used if it is not configured
+	MaxBytes uint32 `json:"max_bytes,omitempty"`
+	// OnExceed is one of RouteBodyExceedReject and RouteBodyExceedHeaderOnly, default is RouteBodyExceedReject
+	OnExceed string `json:"on_exceed,omitempty"`
+}
+
+// DefaultRouteBodyBufferBytes is the default max size of the body buffered for the route matching
+const DefaultRouteBodyBufferBytes = 64 * 1024
+
 // XProxyExtendConfig
 type XProxyExtendConfig struct {
 	SubProtocol string `json:"sub_protocol,omitempty"`
@@ -37,30 +37,79 @@ type RouterConfigurationConfig struct {
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
 	RouterConfigPath        string               `json:"router_configs,omitempty"`
 	StaticVirtualHosts      []*VirtualHost       `json:"virtual_hosts,omitempty"`
+	// ScopedRoutes makes the router configuration select a route table by the scope key,
+	// the virtual hosts are ignored if it is configured
+	ScopedRoutes *ScopedRoutes `json:"scoped_routes,omitempty"`
+}
+
+// ScopedRoutes selects a route table for a request by the scope key built from the request headers.
+// The route tables are the router configurations referenced by the scopes, so a route table can be updated independently
+type ScopedRoutes struct {
+	// Name is the name of the scoped routes, the scopes discovered by the xDS are keyed by the name
+	// of the scoped routes that subscribe them.
+	Name            string          `json:"name,omitempty"`
+	ScopeKeyBuilder ScopeKeyBuilder `json:"scope_key_builder,omitempty"`
+	Scopes          []*RouteScope   `json:"scopes,omitempty"`
+	// ScopedRDS means the scopes are discovered by the xDS, the static Scopes is ignored
+	ScopedRDS bool `json:"scoped_rds,omitempty"`
+}
+
+// ScopeKeyBuilder builds the scope key from the request headers, each fragment builds a part of the key
+type ScopeKeyBuilder struct {
+	Fragments []ScopeKeyFragment `json:"fragments,omitempty"`
+}
+
+// ScopeKeyFragment extracts a fragment of the scope key from the header value.
+// If ElementSeparator is empty, the whole header value is the fragment. Otherwise the header value is split
+// into elements by ElementSeparator, and the element at Index is the fragment if ElementKey is empty,
+// or the value of the element whose key is ElementKey is the fragment, the key and value of an element are
+// separated by KeyValueSeparator, "=" is used if it is empty.
+type ScopeKeyFragment struct {
+	HeaderName        string `json:"header_name,omitempty"`
+	ElementSeparator  string `json:"element_separator,omitempty"`
+	Index             uint32 `json:"index,omitempty"`
+	ElementKey        string `json:"element_key,omitempty"`
+	KeyValueSeparator string `json:"key_value_separator,omitempty"`
+}
+
+// RouteScope selects the route table named RouterConfigName if the request's scope key equals to the Key
+type RouteScope struct {
+	Name             string   `json:"name,omitempty"`
+	RouterConfigName string   `json:"router_config_name,omitempty"`
+	Key              []string `json:"key,omitempty"`
 }
 
 type RouterConfig struct {
 	Match           RouterMatch            `json:"match,omitempty"`
 	Route           RouteAction            `json:"route,omitempty"`
 	DirectResponse  *DirectResponseAction  `json:"direct_response,omitempty"`
+	Redirect        *RedirectAction        `json:"redirect,omitempty"`
 	MetadataConfig  *MetadataConfig        `json:"metadata,omitempty"`
 	PerFilterConfig map[string]interface{} `json:"per_filter_config,omitempty"`
 }
 
 type RouterActionConfig struct {
-	ClusterName             string               `json:"cluster_name,omitempty"`
-	UpstreamProtocol        string               `json:"upstream_protocol,omitempty"`
-	ClusterHeader           string               `json:"cluster_header,omitempty"`
-	WeightedClusters        []WeightedCluster    `json:"weighted_clusters,omitempty"`
-	MetadataConfig          *MetadataConfig      `json:"metadata_match,omitempty"`
-	TimeoutConfig           api.DurationConfig   `json:"timeout,omitempty"`
-	RetryPolicy             *RetryPolicy         `json:"retry_policy,omitempty"`
-	PrefixRewrite           string               `json:"prefix_rewrite,omitempty"`
-	HostRewrite             string               `json:"host_rewrite,omitempty"`
-	AutoHostRewrite         bool                 `json:"auto_host_rewrite,omitempty"`
-	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
-	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
-	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	ClusterName             string                `json:"cluster_name,omitempty"`
+	UpstreamProtocol        string                `json:"upstream_protocol,omitempty"`
+	ClusterHeader           string                `json:"cluster_header,omitempty"`
+	WeightedClusters        []WeightedCluster     `json:"weighted_clusters,omitempty"`
+	MetadataConfig          *MetadataConfig       `json:"metadata_match,omitempty"`
+	TimeoutConfig           api.DurationConfig    `json:"timeout,omitempty"`
+	RetryPolicy             *RetryPolicy          `json:"retry_policy,omitempty"`
+	PrefixRewrite           string                `json:"prefix_rewrite,omitempty"`
+	RegexRewrite            *RegexRewrite         `json:"regex_rewrite,omitempty"`
+	HostRewrite             string                `json:"host_rewrite,omitempty"`
+	AutoHostRewrite         bool                  `json:"auto_host_rewrite,omitempty"`
+	RequestHeadersToAdd     []*HeaderValueOption  `json:"request_headers_to_add,omitempty"`
+	ResponseHeadersToAdd    []*HeaderValueOption  `json:"response_headers_to_add,omitempty"`
+	ResponseHeadersToRemove []string              `json:"response_headers_to_remove,omitempty"`
+	HashPolicy              []HashPolicy          `json:"hash_policy,omitempty"`
+	RequestMirrorPolicies   []RequestMirrorPolicy `json:"request_mirror_policies,omitempty"`
+	// Cors overrides the virtual host's cors policy
+	Cors *CorsPolicy `json:"cors,omitempty"`
+	// ClusterSpecifier chooses the cluster by the request,
+	// the cluster_name or weighted_clusters are used if no cluster is chosen
+	ClusterSpecifier *ClusterSpecifierConfig `json:"cluster_specifier,omitempty"`
 }
 
 type ClusterWeightConfig struct {
@@ -73,6 +122,60 @@ type RetryPolicyConfig struct {
 	RetryOn            bool               `json:"retry_on,omitempty"`
 	RetryTimeoutConfig api.DurationConfig `json:"retry_timeout,omitempty"`
 	NumRetries         uint32             `json:"num_retries,omitempty"`
+	// RetryConditions specifies the conditions under which retry takes place,
+	// the default conditions are used if it is empty.
+	RetryConditions []string `json:"retry_conditions,omitempty"`
+	// RetriableStatusCodes is used by the retriable-status-codes condition, the codes are http status codes,
+	// and the status codes of xprotocol responses are compared with the codes mapped by the sub protocol.
+	RetriableStatusCodes []uint32      `json:"retriable_status_codes,omitempty"`
+	RetryBackOff         *RetryBackOff `json:"retry_back_off,omitempty"`
+	// RetryHostPredicates decide whether a host should be rejected when choosing the host for retries
+	RetryHostPredicates []string `json:"retry_host_predicates,omitempty"`
+	// HostSelectionRetryMaxAttempts is the max attempts to choose a host that is not rejected by the predicates
+	HostSelectionRetryMaxAttempts uint32 `json:"host_selection_retry_max_attempts,omitempty"`
+}
+
+// Retry conditions
+const (
+	// RetryOn5xx retries if the upstream responds any 5xx status code, or resets, connects failed or timeout
+	RetryOn5xx = "5xx"
+	// RetryOnGatewayError retries if the upstream responds 502, 503 or 504, or resets, connects failed or timeout
+	RetryOnGatewayError = "gateway-error"
+	// RetryOnConnectFailure retries if connects to the upstream failed
+	RetryOnConnectFailure = "connect-failure"
+	// RetryOnReset retries if the upstream does not respond at all, such as disconnect, reset or timeout
+	RetryOnReset = "reset"
+	// RetryOnRetriableStatusCodes retries if the upstream responds a status code in the retriable status codes
+	RetryOnRetriableStatusCodes = "retriable-status-codes"
+)
+
+// Retry host predicates
+const (
+	// RetryHostPredicatePreviousHosts rejects the hosts that have been tried
+	RetryHostPredicatePreviousHosts = "previous_hosts"
+)
+
+// RetryBackOff is the jittered exponential back off between the retries.
+// The interval is a random value in [0, (2^N-1)*BaseInterval), N is the retry times, and is limited by MaxInterval.
+// The retries are made in a fixed 10ms interval if the back off is not configured.
+type RetryBackOff struct {
+	BaseInterval *api.DurationConfig `json:"base_interval,omitempty"`
+	// MaxInterval is ten times of BaseInterval if it is not configured
+	MaxInterval *api.DurationConfig `json:"max_interval,omitempty"`
+}
+
+// RequestMirrorPolicy mirrors the requests to a shadow cluster, the responses of shadow cluster are ignored
+type RequestMirrorPolicy struct {
+	Cluster string `json:"cluster,omitempty"`
+	// Percent is the percentage of requests to be mirrored, in the range [0, 100].
+	// all of the requests are mirrored if it is not configured
+	Percent *uint32 `json:"percent,omitempty"`
+}
+
+// ClusterSpecifierConfig configures a cluster specifier registered in the router by the type
+type ClusterSpecifierConfig struct {
+	Type   string                 `json:"type,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty"`
 }
 
 // Router, the list of routes that will be matched, in order, for incoming requests.
@@ -265,14 +368,37 @@ type VirtualHost struct {
 	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
 	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	Cors                    *CorsPolicy          `json:"cors,omitempty"`
+	// PerFilterConfig is the stream filters' configurations of all the routes in the virtual host,
+	// which can be overridden by the route's PerFilterConfig
+	PerFilterConfig map[string]interface{} `json:"per_filter_config,omitempty"`
+}
+
+// CorsPolicy is the cross origin resource sharing policy, which is handled by the cors stream filter
+type CorsPolicy struct {
+	// AllowOrigins specifies the origins that are allowed exactly, "*" allows all of the origins
+	AllowOrigins []string `json:"allow_origins,omitempty"`
+	// AllowOriginRegex specifies the regex patterns of the allowed origins
+	AllowOriginRegex []string           `json:"allow_origin_regex,omitempty"`
+	AllowMethods     []string           `json:"allow_methods,omitempty"`
+	AllowHeaders     []string           `json:"allow_headers,omitempty"`
+	ExposeHeaders    []string           `json:"expose_headers,omitempty"`
+	MaxAge           api.DurationConfig `json:"max_age,omitempty"`
+	AllowCredentials bool               `json:"allow_credentials,omitempty"`
 }
 
 // RouterMatch represents the route matching parameters
 type RouterMatch struct {
-	Prefix  string          `json:"prefix,omitempty"`  // Match request's Path with Prefix Comparing
-	Path    string          `json:"path,omitempty"`    // Match request's Path with Exact Comparing
-	Regex   string          `json:"regex,omitempty"`   // Match request's Path with Regex Comparing
-	Headers []HeaderMatcher `json:"headers,omitempty"` // Match request's Headers
+	Prefix          string                  `json:"prefix,omitempty"`           // Match request's Path with Prefix Comparing
+	Path            string                  `json:"path,omitempty"`             // Match request's Path with Exact Comparing
+	Regex           string                  `json:"regex,omitempty"`            // Match request's Path with Regex Comparing
+	CaseSensitive   *bool                   `json:"case_sensitive,omitempty"`   // Match request's Path case sensitive or not, the Path is case insensitive by default, the Prefix and Regex are case sensitive by default
+	Headers         []HeaderMatcher         `json:"headers,omitempty"`          // Match request's Headers
+	Methods         []string                `json:"methods,omitempty"`          // Match request's Method, one of the methods should be matched
+	QueryParameters []QueryParameterMatcher `json:"query_parameters,omitempty"` // Match request's Query Parameters
+	Cookies         []CookieMatcher         `json:"cookies,omitempty"`          // Match request's Cookies
+	RuntimeFraction *RuntimeFraction        `json:"runtime_fraction,omitempty"` // Match a fraction of requests
+	Body            []BodyMatcher           `json:"body,omitempty"`             // Match request's Body, the proxy should enable the route body buffer
 }
 
 // DirectResponseAction represents the direct response parameters
@@ -281,6 +407,26 @@ type DirectResponseAction struct {
 	Body       string `json:"body,omitempty"`
 }
 
+// RedirectAction represents the redirect response parameters, the location is rebuilt from the request
+type RedirectAction struct {
+	// ResponseCode should be one of 301, 302, 303, 307 and 308, 301 is used if it is not configured
+	ResponseCode   int    `json:"response_code,omitempty"`
+	SchemeRedirect string `json:"scheme_redirect,omitempty"`
+	HostRedirect   string `json:"host_redirect,omitempty"`
+	PortRedirect   uint32 `json:"port_redirect,omitempty"`
+	PathRedirect   string `json:"path_redirect,omitempty"`
+	// HTTPSRedirect replaces the scheme with https, it is same as SchemeRedirect is https
+	HTTPSRedirect bool `json:"https_redirect,omitempty"`
+	StripQuery    bool `json:"strip_query,omitempty"`
+}
+
+// RegexRewrite rewrites the path matched the pattern with the substitution,
+// the substitution can contain the capture groups, such as $1 or ${name}
+type RegexRewrite struct {
+	Pattern      string `json:"pattern,omitempty"`
+	Substitution string `json:"substitution,omitempty"`
+}
+
 // WeightedCluster.
 // Multiple upstream clusters unsupport stream filter type:  healthcheckcan be specified for a given route.
 // The request is routed to one of the upstream
@@ -289,11 +435,87 @@ type WeightedCluster struct {
 	Cluster ClusterWeight `json:"cluster,omitempty"`
 }
 
+// HashPolicy specifies how to generate the hash key for the consistent hash load balancers.
+// Only one of the policy fields should be set in a HashPolicy.
+type HashPolicy struct {
+	Header   *HeaderHashPolicy   `json:"header,omitempty"`
+	Cookie   *CookieHashPolicy   `json:"cookie,omitempty"`
+	SourceIP *SourceIPHashPolicy `json:"source_ip,omitempty"`
+	Variable *VariableHashPolicy `json:"variable,omitempty"`
+}
+
+// HeaderHashPolicy uses the request header's value as the hash key
+type HeaderHashPolicy struct {
+	Key string `json:"key,omitempty"`
+}
+
+// CookieHashPolicy uses the request cookie's value as the hash key
+type CookieHashPolicy struct {
+	Name string `json:"name,omitempty"`
+}
+
+// SourceIPHashPolicy uses the downstream connection's source ip as the hash key
+type SourceIPHashPolicy struct {
+}
+
+// VariableHashPolicy uses the variable's value as the hash key
+type VariableHashPolicy struct {
+	Name string `json:"name,omitempty"`
+}
+
 // HeaderMatcher specifies a set of headers that the route should match on.
+// If Present is set, only the presence (true) or absence (false) of the header is matched.
+// InvertMatch inverts the match result.
 type HeaderMatcher struct {
-	Name  string `json:"name,omitempty"`
-	Value string `json:"value,omitempty"`
-	Regex bool   `json:"regex,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Regex       bool   `json:"regex,omitempty"`
+	Present     *bool  `json:"present,omitempty"`
+	InvertMatch bool   `json:"invert_match,omitempty"`
+}
+
+// QueryParameterMatcher specifies a query parameter that the route should match on.
+// An empty value matches the presence of the query parameter.
+// If Present is set, only the presence (true) or absence (false) of the query parameter is matched.
+type QueryParameterMatcher struct {
+	Name    string `json:"name,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Regex   bool   `json:"regex,omitempty"`
+	Present *bool  `json:"present,omitempty"`
+}
+
+// CookieMatcher specifies a cookie that the route should match on.
+// The cookie is matched as the QueryParameterMatcher
+type CookieMatcher struct {
+	Name    string `json:"name,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Regex   bool   `json:"regex,omitempty"`
+	Present *bool  `json:"present,omitempty"`
+}
+
+// Body matcher types
+const (
+	BodyMatcherJSON = "json"
+	BodyMatcherForm = "form"
+)
+
+// BodyMatcher specifies a field of the request body that the route should match on.
+// Type is one of BodyMatcherJSON and BodyMatcherForm. For the json body, the Name is a path
+// separated by dots, such as "params.0.name", for the form body, the Name is the form field name.
+// The field is matched as the QueryParameterMatcher
+type BodyMatcher struct {
+	Type    string `json:"type,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Regex   bool   `json:"regex,omitempty"`
+	Present *bool  `json:"present,omitempty"`
+}
+
+// RuntimeFraction matches Numerator/Denominator of the requests.
+// The Denominator should be one of 100, 10000 and 1000000, default is 100.
+type RuntimeFraction struct {
+	Numerator   uint32 `json:"numerator,omitempty"`
+	Denominator uint32 `json:"denominator,omitempty"`
 }
 
 // TCP Proxy Route
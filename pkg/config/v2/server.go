@@ -50,15 +50,22 @@ type ListenerType string
 const EGRESS ListenerType = "egress"
 const INGRESS ListenerType = "ingress"
 
+// Listener's network
+const (
+	NetworkTCP = "tcp"
+	NetworkUDP = "udp"
+)
+
 type ListenerConfig struct {
 	Name                  string              `json:"name,omitempty"`
 	Type                  ListenerType        `json:"type,omitempty"`
+	Network               string              `json:"network,omitempty"` // tcp or udp, default is tcp. the tcp listener address with unix: prefix is a unix domain socket
 	AddrConfig            string              `json:"address,omitempty"`
 	BindToPort            bool                `json:"bind_port,omitempty"`
 	UseOriginalDst        bool                `json:"use_original_dst,omitempty"`
 	AccessLogs            []AccessLog         `json:"access_logs,omitempty"`
 	ListenerFilters       []Filter            `json:"listener_filters,omitempty"`
-	FilterChains          []FilterChain       `json:"filter_chains,omitempty"` // only one filterchains at this time
+	FilterChains          []FilterChain       `json:"filter_chains,omitempty"` // the filter chain is chosen by the filter_chain_match
 	StreamFilters         []Filter            `json:"stream_filters,omitempty"`
 	Inspector             bool                `json:"inspector,omitempty"`
 	ConnectionIdleTimeout *api.DurationConfig `json:"connection_idle_timeout,omitempty"`
@@ -67,17 +74,18 @@ type ListenerConfig struct {
 // Listener contains the listener's information
 type Listener struct {
 	ListenerConfig
-	Addr                    net.Addr         `json:"-"`
-	ListenerTag             uint64           `json:"-"`
-	ListenerScope           string           `json:"-"`
-	PerConnBufferLimitBytes uint32           `json:"-"` // do not support config
-	InheritListener         *net.TCPListener `json:"-"`
-	Remain                  bool             `json:"-"`
+	Addr                    net.Addr     `json:"-"`
+	ListenerTag             uint64       `json:"-"`
+	ListenerScope           string       `json:"-"`
+	PerConnBufferLimitBytes uint32       `json:"-"` // do not support config
+	InheritListener         net.Listener `json:"-"`
+	InheritPacketConn       *net.UDPConn `json:"-"`
+	Remain                  bool         `json:"-"`
 }
 
 func (l Listener) MarshalJSON() (b []byte, err error) {
 	if l.Addr != nil {
-		l.AddrConfig = l.Addr.String()
+		l.AddrConfig = AddressString(l.Addr)
 	}
 	return json.Marshal(l.ListenerConfig)
 }
@@ -86,6 +94,14 @@ func (l Listener) MarshalJSON() (b []byte, err error) {
 type AccessLog struct {
 	Path   string `json:"log_path,omitempty"`
 	Format string `json:"log_format,omitempty"`
+	// FormatType is one of text, json and logfmt, text is used if it is empty
+	FormatType string `json:"log_format_type,omitempty"`
+	// Fields maps the keys of the json or logfmt access log to the formats, such as "%response_code%"
+	Fields map[string]string `json:"log_fields,omitempty"`
+	// Sink is where the access log is written to, the file of Path is used if it is nil
+	Sink *AccessLogSinkConfig `json:"sink,omitempty"`
+	// Filter chooses the requests to be logged, all of the requests are logged if it is nil
+	Filter *AccessLogFilterConfig `json:"filter,omitempty"`
 }
 
 // FilterChain wraps a set of match criteria, an option TLS context,
@@ -130,8 +146,34 @@ type Filter struct {
 }
 
 type FilterChainConfig struct {
-	FilterChainMatch string      `json:"match,omitempty"`
-	TLSConfig        *TLSConfig  `json:"tls_context,omitempty"`
-	TLSConfigs       []TLSConfig `json:"tls_context_set,omitempty"`
-	Filters          []Filter    `json:"filters,omitempty"`
+	FilterChainMatch string            `json:"match,omitempty"`
+	Match            *FilterChainMatch `json:"filter_chain_match,omitempty"`
+	TLSConfig        *TLSConfig        `json:"tls_context,omitempty"`
+	TLSConfigs       []TLSConfig       `json:"tls_context_set,omitempty"`
+	Filters          []Filter          `json:"filters,omitempty"`
+	// StreamFilters overrides the listener's stream filters for the connections of this filter chain
+	StreamFilters []Filter `json:"stream_filters,omitempty"`
+}
+
+// FilterChainMatch specifies the match criteria for selecting a filter chain for a connection.
+// An empty criteria matches all the connections, if more than one filter chains are matched,
+// the most specific one is selected.
+type FilterChainMatch struct {
+	// DestinationPort matches the original destination port of the connection, or the local port
+	DestinationPort uint32 `json:"destination_port,omitempty"`
+	// PrefixRanges matches the destination ip of the connection, in CIDR format
+	PrefixRanges []string `json:"prefix_ranges,omitempty"`
+	// ServerNames matches the SNI of a tls connection, a wildcard such as "*.example.com" is supported
+	ServerNames []string `json:"server_names,omitempty"`
+	// TransportProtocol can be "tls" or "raw_buffer"
+	TransportProtocol string `json:"transport_protocol,omitempty"`
+	// ApplicationProtocols matches the ALPN of a tls connection
+	ApplicationProtocols []string `json:"application_protocols,omitempty"`
+	// SourcePrefixRanges matches the source ip of the connection, in CIDR format
+	SourcePrefixRanges []string `json:"source_prefix_ranges,omitempty"`
 }
+
+const (
+	TransportProtocolTLS       = "tls"
+	TransportProtocolRawBuffer = "raw_buffer"
+)
@@ -22,6 +22,7 @@ import (
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	"github.com/gogo/protobuf/jsonpb"
+	"mosn.io/api"
 )
 
 // TLSConfig is a configuration of tls context
@@ -44,6 +45,34 @@ type TLSConfig struct {
 	Fallback          bool                   `json:"fall_back,omitempty"`
 	ExtendVerify      map[string]interface{} `json:"extend_verify,omitempty"`
 	SdsConfig         *SdsConfig             `json:"sds_source,omitempty"`
+	// WatchFiles makes the cert_chain, private_key and ca_cert files reloaded when they are changed,
+	// the files are checked every WatchInterval
+	WatchFiles    bool                `json:"watch_files,omitempty"`
+	WatchInterval *api.DurationConfig `json:"watch_interval,omitempty"`
+	// MatchSubjectAltNames is the acceptable subject alternative names of the peer certificate,
+	// the peer certificate is accepted if any of its subject alternative names is matched.
+	// a server tls config with MatchSubjectAltNames must set RequireClientCert too.
+	MatchSubjectAltNames []SubjectAltNameMatcher `json:"match_subject_alt_names,omitempty"`
+}
+
+// SanType is the type of the subject alternative names
+type SanType string
+
+// Group of subject alternative name type
+const (
+	SAN_DNS   SanType = "DNS"
+	SAN_URI   SanType = "URI"
+	SAN_EMAIL SanType = "EMAIL"
+	SAN_IP    SanType = "IP"
+)
+
+// SubjectAltNameMatcher matches a subject alternative name of the SanType, such as a dns name or
+// an uri like spiffe://trust-domain/path. only one of the Exact, Prefix and Regex should be set.
+type SubjectAltNameMatcher struct {
+	SanType SanType `json:"san_type,omitempty"`
+	Exact   string  `json:"exact,omitempty"`
+	Prefix  string  `json:"prefix,omitempty"`
+	Regex   string  `json:"regex,omitempty"`
 }
 
 type SdsConfig struct {
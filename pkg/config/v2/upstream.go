@@ -58,6 +58,11 @@ const (
 	SIMPLE_CLUSTER  ClusterType = "SIMPLE"
 	DYNAMIC_CLUSTER ClusterType = "DYNAMIC"
 	EDS_CLUSTER     ClusterType = "EDS"
+	// the hosts of dns cluster are resolved by dns periodically,
+	// strict dns cluster uses all the resolved addresses as hosts,
+	// logical dns cluster uses only one of the resolved addresses for each domain name.
+	STRICT_DNS_CLUSTER  ClusterType = "STRICT_DNS"
+	LOGICAL_DNS_CLUSTER ClusterType = "LOGICAL_DNS"
 )
 
 // LbType
@@ -65,9 +70,11 @@ type LbType string
 
 // Group of load balancer type
 const (
-	LB_RANDOM     LbType = "LB_RANDOM"
-	LB_ROUNDROBIN LbType = "LB_ROUNDROBIN"
+	LB_RANDOM        LbType = "LB_RANDOM"
+	LB_ROUNDROBIN    LbType = "LB_ROUNDROBIN"
 	LB_LEAST_REQUEST LbType = "LB_LEAST_REQUEST"
+	LB_RING_HASH     LbType = "LB_RING_HASH"
+	LB_MAGLEV        LbType = "LB_MAGLEV"
 )
 
 // Cluster represents a cluster's information
@@ -87,6 +94,12 @@ type Cluster struct {
 	Hosts                []Host              `json:"hosts,omitempty"`
 	ConnectTimeout       *api.DurationConfig `json:"connect_timeout,omitempty"`
 	LbConfig             IsCluster_LbConfig  `json:"lbconfig,omitempty"`
+	RingHashLbConfig     *RingHashLbConfig   `json:"ring_hash_lb_config,omitempty"`
+	MaglevLbConfig       *MaglevLbConfig     `json:"maglev_lb_config,omitempty"`
+	OutlierDetection     *OutlierDetection   `json:"outlier_detection,omitempty"`
+	DNSRefreshRate       *api.DurationConfig `json:"dns_refresh_rate,omitempty"`
+	RespectDNSTTL        bool                `json:"respect_dns_ttl,omitempty"`
+	DNSResolvers         []string            `json:"dns_resolvers,omitempty"`
 }
 
 // HealthCheck is a configuration of health check
@@ -116,6 +129,29 @@ func (hc *HealthCheck) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// OutlierDetection is a configuration of passive outlier detection
+// a host is ejected from the load balancing pool if it is detected as an outlier
+type OutlierDetection struct {
+	// Consecutive5xx is the number of consecutive 5xx responses before a host is ejected, 0 means disabled
+	Consecutive5xx uint32 `json:"consecutive_5xx,omitempty"`
+	// ConsecutiveConnectionFailure is the number of consecutive connection failures or resets before a host is ejected, 0 means disabled
+	ConsecutiveConnectionFailure uint32 `json:"consecutive_connection_failure,omitempty"`
+	// Interval is the time interval between success rate analysis and ejection sweeps
+	Interval api.DurationConfig `json:"interval,omitempty"`
+	// BaseEjectionTime is the base time that a host is ejected for, the real time grows exponentially with the ejected times
+	BaseEjectionTime api.DurationConfig `json:"base_ejection_time,omitempty"`
+	// MaxEjectionTime is the max time that a host is ejected for
+	MaxEjectionTime api.DurationConfig `json:"max_ejection_time,omitempty"`
+	// MaxEjectionPercent is the max percentage of hosts in a cluster that can be ejected
+	MaxEjectionPercent uint32 `json:"max_ejection_percent,omitempty"`
+	// SuccessRateMinimumHosts is the number of hosts with enough request volume needed to do success rate analysis, 0 means disabled
+	SuccessRateMinimumHosts uint32 `json:"success_rate_minimum_hosts,omitempty"`
+	// SuccessRateRequestVolume is the minimum number of requests a host needs in an interval to be included in success rate analysis
+	SuccessRateRequestVolume uint32 `json:"success_rate_request_volume,omitempty"`
+	// SuccessRateStdevFactor is used to determine the ejection threshold: mean - (stdev * factor / 1000)
+	SuccessRateStdevFactor uint32 `json:"success_rate_stdev_factor,omitempty"`
+}
+
 // Host represenets a host information
 type Host struct {
 	HostConfig
@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import "mosn.io/api"
+
+// Zipkin propagation formats
+const (
+	// ZipkinPropagationB3 is the B3 multiple headers, such as X-B3-TraceId and X-B3-SpanId
+	ZipkinPropagationB3 string = "b3"
+	// ZipkinPropagationB3Single is the B3 single header: b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+	ZipkinPropagationB3Single string = "b3_single"
+	// ZipkinPropagationW3C is the W3C trace context headers: traceparent and tracestate
+	ZipkinPropagationW3C string = "w3c"
+)
+
+// ZipkinTraceConfig is the config of the Zipkin tracer driver.
+// The trace context is extracted from all of the propagation formats,
+// and is injected into the upstream requests with the formats in Propagation.
+type ZipkinTraceConfig struct {
+	ServiceName string `json:"service_name"`
+	// ReporterEndpoint is the url that receives the spans in Zipkin v2 json format,
+	// such as http://127.0.0.1:9411/api/v2/spans
+	ReporterEndpoint string `json:"reporter_endpoint"`
+	// SampleRate is the rate of the new traces to be sampled, in the range [0, 1].
+	// the sampling decision of the downstream is used if it exists
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+	// Propagation is the formats to inject into the upstream requests, b3 is used if it is empty
+	Propagation []string `json:"propagation,omitempty"`
+	// BatchSize is the max spans in a report request
+	BatchSize int `json:"batch_size,omitempty"`
+	// BatchInterval is the max interval to wait before reporting the spans
+	BatchInterval api.DurationConfig `json:"batch_interval,omitempty"`
+	// QueueSize is the max spans waiting to be reported, the new spans are dropped if the queue is full
+	QueueSize int `json:"queue_size,omitempty"`
+	// Timeout is the timeout of a report request
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+	// MaxRetries is the max retries of a failed report request, the request is retried only if the error is retryable
+	MaxRetries int `json:"max_retries,omitempty"`
+	// RetryBackOff is the interval before the first retry, and is doubled for each retry
+	RetryBackOff api.DurationConfig `json:"retry_back_off,omitempty"`
+}
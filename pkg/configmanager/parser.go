@@ -26,6 +26,7 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/protocol"
 )
@@ -164,22 +165,28 @@ func ParseLogLevel(level string) log.Level {
 }
 
 // ParseListenerConfig
-func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.Listener {
+func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener, inheritPacketConns []net.PacketConn) *v2.Listener {
 	if lc.AddrConfig == "" {
 		log.StartLogger.Fatalf("[config] [parse listener] Address is required in listener config")
 	}
+	if lc.Network == v2.NetworkUDP {
+		return parseUDPListenerConfig(lc, inheritPacketConns)
+	}
+	if v2.IsUnixAddress(lc.AddrConfig) {
+		return parseUnixListenerConfig(lc, inheritListeners)
+	}
 	addr, err := net.ResolveTCPAddr("tcp", lc.AddrConfig)
 	if err != nil {
 		log.StartLogger.Fatalf("[config] [parse listener] Address not valid: %v", lc.AddrConfig)
 	}
 	//try inherit legacy listener
-	var old *net.TCPListener
+	var old net.Listener
 
 	for i, il := range inheritListeners {
-		if il == nil {
+		tl, ok := il.(*net.TCPListener)
+		if !ok {
 			continue
 		}
-		tl := il.(*net.TCPListener)
 		ilAddr, err := net.ResolveTCPAddr("tcp", tl.Addr().String())
 		if err != nil {
 			log.StartLogger.Fatalf("[config] [parse listener] inheritListener not valid: %s", tl.Addr().String())
@@ -189,9 +196,7 @@ func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.L
 			continue
 		}
 
-		if (addr.IP.IsUnspecified() && ilAddr.IP.IsUnspecified()) ||
-			(addr.IP.IsLoopback() && ilAddr.IP.IsLoopback()) ||
-			addr.IP.Equal(ilAddr.IP) {
+		if isSameIP(addr.IP, ilAddr.IP) {
 			log.StartLogger.Infof("[config] [parse listener] inherit listener addr: %s", lc.AddrConfig)
 			old = tl
 			inheritListeners[i] = nil
@@ -205,6 +210,63 @@ func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.L
 	return lc
 }
 
+func parseUnixListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.Listener {
+	addr, err := v2.ResolveAddress(lc.AddrConfig)
+	if err != nil {
+		log.StartLogger.Fatalf("[config] [parse listener] Address not valid: %v", lc.AddrConfig)
+	}
+	//try inherit legacy listener
+	var old net.Listener
+	for i, il := range inheritListeners {
+		ul, ok := il.(*net.UnixListener)
+		if !ok {
+			continue
+		}
+		if ul.Addr().String() == addr.String() {
+			log.StartLogger.Infof("[config] [parse listener] inherit unix listener addr: %s", lc.AddrConfig)
+			old = ul
+			inheritListeners[i] = nil
+			break
+		}
+	}
+	lc.Addr = addr
+	lc.PerConnBufferLimitBytes = 1 << 15
+	lc.InheritListener = old
+	return lc
+}
+
+func parseUDPListenerConfig(lc *v2.Listener, inheritPacketConns []net.PacketConn) *v2.Listener {
+	addr, err := net.ResolveUDPAddr("udp", lc.AddrConfig)
+	if err != nil {
+		log.StartLogger.Fatalf("[config] [parse listener] Address not valid: %v", lc.AddrConfig)
+	}
+	//try inherit legacy listener
+	var old *net.UDPConn
+	for i, ipc := range inheritPacketConns {
+		if ipc == nil {
+			continue
+		}
+		uc := ipc.(*net.UDPConn)
+		ilAddr := uc.LocalAddr().(*net.UDPAddr)
+		if addr.Port == ilAddr.Port && isSameIP(addr.IP, ilAddr.IP) {
+			log.StartLogger.Infof("[config] [parse listener] inherit udp listener addr: %s", lc.AddrConfig)
+			old = uc
+			inheritPacketConns[i] = nil
+			break
+		}
+	}
+	lc.Addr = addr
+	lc.PerConnBufferLimitBytes = 1 << 15
+	lc.InheritPacketConn = old
+	return lc
+}
+
+func isSameIP(ip, inheritIP net.IP) bool {
+	return (ip.IsUnspecified() && inheritIP.IsUnspecified()) ||
+		(ip.IsLoopback() && inheritIP.IsLoopback()) ||
+		ip.Equal(inheritIP)
+}
+
 func ParseRouterConfiguration(c *v2.FilterChain) (*v2.RouterConfiguration, error) {
 	routerConfiguration := &v2.RouterConfiguration{}
 	for _, f := range c.Filters {
@@ -265,6 +327,7 @@ func GetListenerFilters(configs []v2.Filter) []api.ListenerFilterChainFactory {
 }
 
 // GetStreamFilters returns a stream filter factory by filter.Type
+// the filters created by the factory are named by the filter.Type, so they can be disabled by the per-route config
 func GetStreamFilters(configs []v2.Filter) []api.StreamFilterChainFactory {
 	var factories []api.StreamFilterChainFactory
 
@@ -274,7 +337,7 @@ func GetStreamFilters(configs []v2.Filter) []api.StreamFilterChainFactory {
 			log.DefaultLogger.Errorf("[config] get stream filter failed, type: %s, error: %v", c.Type, err)
 			continue
 		}
-		factories = append(factories, sfcc)
+		factories = append(factories, filter.NewNamedStreamFilterChainFactory(c.Type, sfcc))
 	}
 
 	return factories
@@ -19,8 +19,10 @@ package configmanager
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -144,7 +146,7 @@ func TestParseListenerConfig(t *testing.T) {
 			AddrConfig: tcpListener.Addr().String(),
 		},
 	}
-	ln := ParseListenerConfig(lc, inherit)
+	ln := ParseListenerConfig(lc, inherit, nil)
 	if !(ln.Addr != nil &&
 		ln.Addr.String() == tcpListener.Addr().String() &&
 		ln.PerConnBufferLimitBytes == 1<<15 &&
@@ -157,6 +159,41 @@ func TestParseListenerConfig(t *testing.T) {
 	}
 }
 
+func TestParseUnixListenerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_parse_listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mosn.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+	inherit := []net.Listener{tcpListener, listener}
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			AddrConfig: "unix:" + path,
+		},
+	}
+	ln := ParseListenerConfig(lc, inherit, nil)
+	if !(ln.Addr != nil &&
+		ln.Addr.Network() == "unix" &&
+		ln.Addr.String() == path &&
+		ln.InheritListener == listener) {
+		t.Errorf("unix listener parse unexpected: %v", ln.Addr)
+	}
+	if inherit[0] == nil || inherit[1] != nil {
+		t.Error("unexpected inherit listener")
+	}
+}
+
 func TestParseRouterConfig(t *testing.T) {
 	filterStr := `{
 		"filters": [{
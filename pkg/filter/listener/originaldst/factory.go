@@ -19,6 +19,7 @@ package originaldst
 
 import (
 	"fmt"
+	"net"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
@@ -49,6 +50,10 @@ func (filter *originalDst) OnAccept(cb api.ListenerFilterChainFactoryCallbacks)
 	if !cb.GetUseOriginalDst() {
 		return api.Continue
 	}
+	// only the redirected tcp connections have the original destination, such as the unix listener has no one
+	if _, ok := cb.Conn().(*net.TCPConn); !ok {
+		return api.Continue
+	}
 
 	ip, port, err := getOriginalAddr(cb.Conn())
 	if err != nil {
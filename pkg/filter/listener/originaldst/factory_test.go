@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package originaldst
+
+import (
+	"net"
+	"testing"
+
+	"mosn.io/api"
+)
+
+type mockCallbacks struct {
+	api.ListenerFilterChainFactoryCallbacks
+	conn net.Conn
+}
+
+func (cb *mockCallbacks) Conn() net.Conn {
+	return cb.conn
+}
+
+func (cb *mockCallbacks) GetUseOriginalDst() bool {
+	return true
+}
+
+func TestOriginalDstUnixConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	// the connection is not a tcp connection, the filter is skipped
+	if status := NewOriginalDst().OnAccept(&mockCallbacks{conn: server}); status != api.Continue {
+		t.Fatalf("expected status continue, but got %v", status)
+	}
+	if _, _, err := getOriginalAddr(server); err == nil {
+		t.Fatal("get original addr of non-tcp connection should be failed")
+	}
+}
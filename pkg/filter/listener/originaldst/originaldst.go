@@ -33,7 +33,10 @@ const (
 )
 
 func getOriginalAddr(conn net.Conn) ([]byte, int, error) {
-	tc := conn.(*net.TCPConn)
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil, 0, errors.New("conn is not a tcp connection")
+	}
 
 	f, err := tc.File()
 	if err != nil {
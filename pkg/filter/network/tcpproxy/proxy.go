@@ -437,3 +437,8 @@ func (c *LbContext) DownstreamContext() context.Context {
 func (c *LbContext) DownstreamCluster() types.ClusterInfo {
 	return c.cluster
 }
+
+// TCP Proxy have no route hash policy
+func (c *LbContext) HashKey() (uint64, bool) {
+	return 0, false
+}
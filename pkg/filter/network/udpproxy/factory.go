@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	api.RegisterNetwork(v2.UDP_PROXY, CreateUDPProxyFactory)
+}
+
+type udpProxyFilterConfigFactory struct {
+	Proxy *v2.UDPProxy
+}
+
+// CreateFilterChain does nothing, udp proxy works only in the udp listener
+func (f *udpProxyFilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	log.DefaultLogger.Errorf("[udpproxy] udp proxy should be used in udp listener")
+}
+
+func (f *udpProxyFilterConfigFactory) CreateUDPListenerFilter(context context.Context, cb types.UDPListenerFilterCallbacks) types.UDPListenerFilter {
+	return NewProxy(context, f.Proxy, cb)
+}
+
+func CreateUDPProxyFactory(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+	p, err := ParseUDPProxy(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &udpProxyFilterConfigFactory{
+		Proxy: p,
+	}, nil
+}
+
+// ParseUDPProxy
+func ParseUDPProxy(cfg map[string]interface{}) (*v2.UDPProxy, error) {
+	proxy := &v2.UDPProxy{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("[config] config is not a udp proxy config: %v", err)
+	}
+	if err := json.Unmarshal(data, proxy); err != nil {
+		return nil, fmt.Errorf("[config] config is not a udp proxy config: %v", err)
+	}
+	if proxy.Cluster == "" {
+		return nil, errors.New("[config] udp proxy cluster is required")
+	}
+	return proxy, nil
+}
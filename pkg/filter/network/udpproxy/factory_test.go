@@ -0,0 +1,43 @@
+package udpproxy
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestParseUDPProxy(t *testing.T) {
+	m := map[string]interface{}{
+		"stat_prefix":  "udp_proxy",
+		"cluster":      "cluster",
+		"idle_timeout": "30s",
+	}
+	proxy, err := ParseUDPProxy(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(proxy.StatPrefix == "udp_proxy" &&
+		proxy.Cluster == "cluster" &&
+		proxy.IdleTimeout != nil &&
+		proxy.IdleTimeout.Duration == 30*time.Second) {
+		t.Errorf("parse udp proxy failed: %+v", proxy)
+	}
+	if _, err := ParseUDPProxy(map[string]interface{}{
+		"stat_prefix": "udp_proxy",
+	}); err == nil {
+		t.Error("udp proxy without cluster expected an error")
+	}
+}
+
+func TestCreateUDPProxyFactory(t *testing.T) {
+	factory, err := CreateUDPProxyFactory(map[string]interface{}{
+		"cluster": "cluster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := factory.(types.UDPListenerFilterFactory); !ok {
+		t.Error("udp proxy factory should create udp listener filters")
+	}
+}
@@ -0,0 +1,328 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udpproxy
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/utils"
+)
+
+// DefaultIdleTimeout is the idle timeout of a session if it is not configured
+const DefaultIdleTimeout = time.Minute
+
+type proxyStats struct {
+	DownstreamDatagramsRx gometrics.Counter
+	DownstreamBytesRx     gometrics.Counter
+	DownstreamDatagramsTx gometrics.Counter
+	DownstreamBytesTx     gometrics.Counter
+	DownstreamSendError   gometrics.Counter
+	UpstreamDatagramsRx   gometrics.Counter
+	UpstreamBytesRx       gometrics.Counter
+	UpstreamDatagramsTx   gometrics.Counter
+	UpstreamBytesTx       gometrics.Counter
+	UpstreamSendError     gometrics.Counter
+	UpstreamReceiveError  gometrics.Counter
+	SessionTotal          gometrics.Counter
+	SessionActive         gometrics.Gauge
+	SessionIdleTimeout    gometrics.Counter
+	SessionOverflow       gometrics.Counter
+	NoHealthyUpstream     gometrics.Counter
+}
+
+func newProxyStats(statPrefix string) *proxyStats {
+	s := metrics.NewUDPProxyStats(statPrefix)
+	return &proxyStats{
+		DownstreamDatagramsRx: s.Counter(metrics.UDPProxyDownstreamDatagramsRx),
+		DownstreamBytesRx:     s.Counter(metrics.UDPProxyDownstreamBytesRx),
+		DownstreamDatagramsTx: s.Counter(metrics.UDPProxyDownstreamDatagramsTx),
+		DownstreamBytesTx:     s.Counter(metrics.UDPProxyDownstreamBytesTx),
+		DownstreamSendError:   s.Counter(metrics.UDPProxyDownstreamSendError),
+		UpstreamDatagramsRx:   s.Counter(metrics.UDPProxyUpstreamDatagramsRx),
+		UpstreamBytesRx:       s.Counter(metrics.UDPProxyUpstreamBytesRx),
+		UpstreamDatagramsTx:   s.Counter(metrics.UDPProxyUpstreamDatagramsTx),
+		UpstreamBytesTx:       s.Counter(metrics.UDPProxyUpstreamBytesTx),
+		UpstreamSendError:     s.Counter(metrics.UDPProxyUpstreamSendError),
+		UpstreamReceiveError:  s.Counter(metrics.UDPProxyUpstreamReceiveError),
+		SessionTotal:          s.Counter(metrics.UDPProxySessionTotal),
+		SessionActive:         s.Gauge(metrics.UDPProxySessionActive),
+		SessionIdleTimeout:    s.Counter(metrics.UDPProxySessionIdleTimeout),
+		SessionOverflow:       s.Counter(metrics.UDPProxySessionOverflow),
+		NoHealthyUpstream:     s.Counter(metrics.UDPProxyNoHealthyUpstream),
+	}
+}
+
+// proxy forwards the datagrams from a downstream to an upstream host.
+// each downstream address has its own session, the upstream host is chosen when the session is created.
+type proxy struct {
+	ctx            context.Context
+	config         *v2.UDPProxy
+	idleTimeout    time.Duration
+	cb             types.UDPListenerFilterCallbacks
+	clusterManager types.ClusterManager
+	stats          *proxyStats
+	mutex          sync.Mutex
+	sessions       map[string]*session
+	closed         bool
+}
+
+// NewProxy creates a udp proxy
+func NewProxy(ctx context.Context, config *v2.UDPProxy, cb types.UDPListenerFilterCallbacks) types.UDPListenerFilter {
+	statPrefix := config.StatPrefix
+	if statPrefix == "" {
+		statPrefix = config.Cluster
+	}
+	p := &proxy{
+		ctx:            ctx,
+		config:         config,
+		idleTimeout:    DefaultIdleTimeout,
+		cb:             cb,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		stats:          newProxyStats(statPrefix),
+		sessions:       make(map[string]*session),
+	}
+	if config.IdleTimeout != nil && config.IdleTimeout.Duration > 0 {
+		p.idleTimeout = config.IdleTimeout.Duration
+	}
+	return p
+}
+
+func (p *proxy) OnData(data []byte, remoteAddr net.Addr) {
+	p.stats.DownstreamDatagramsRx.Inc(1)
+	p.stats.DownstreamBytesRx.Inc(int64(len(data)))
+	s := p.getSession(remoteAddr)
+	if s == nil {
+		return
+	}
+	s.write(data)
+}
+
+func (p *proxy) OnClose() {
+	p.mutex.Lock()
+	p.closed = true
+	sessions := p.sessions
+	p.sessions = make(map[string]*session)
+	p.mutex.Unlock()
+	for _, s := range sessions {
+		s.close()
+	}
+}
+
+// getSession returns the session of the downstream address, a new session is created if not exists
+func (p *proxy) getSession(remoteAddr net.Addr) *session {
+	key := remoteAddr.String()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return nil
+	}
+	if s, ok := p.sessions[key]; ok {
+		return s
+	}
+	s := p.newSession(remoteAddr)
+	if s == nil {
+		return nil
+	}
+	p.sessions[key] = s
+	p.stats.SessionTotal.Inc(1)
+	p.stats.SessionActive.Update(int64(len(p.sessions)))
+	utils.GoWithRecover(func() {
+		s.readLoop()
+	}, nil)
+	return s
+}
+
+func (p *proxy) removeSession(s *session) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.sessions[s.key] == s {
+		delete(p.sessions, s.key)
+		p.stats.SessionActive.Update(int64(len(p.sessions)))
+	}
+}
+
+func (p *proxy) newSession(remoteAddr net.Addr) *session {
+	snapshot := p.clusterManager.GetClusterSnapshot(context.Background(), p.config.Cluster)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.DefaultLogger.Errorf("[udpproxy] cluster %s is not found", p.config.Cluster)
+		p.stats.NoHealthyUpstream.Inc(1)
+		return nil
+	}
+	resource := snapshot.ClusterInfo().ResourceManager().Connections()
+	if !resource.CanCreate() {
+		p.stats.SessionOverflow.Inc(1)
+		return nil
+	}
+	host := snapshot.LoadBalancer().ChooseHost(&lbContext{
+		ctx:     p.ctx,
+		cluster: snapshot.ClusterInfo(),
+	})
+	if host == nil {
+		p.stats.NoHealthyUpstream.Inc(1)
+		return nil
+	}
+	upstreamAddr, err := net.ResolveUDPAddr("udp", host.AddressString())
+	if err != nil {
+		log.DefaultLogger.Errorf("[udpproxy] resolve upstream address %s failed: %v", host.AddressString(), err)
+		p.stats.NoHealthyUpstream.Inc(1)
+		return nil
+	}
+	upstream, err := net.DialUDP("udp", nil, upstreamAddr)
+	if err != nil {
+		log.DefaultLogger.Errorf("[udpproxy] dial upstream %s failed: %v", host.AddressString(), err)
+		p.stats.NoHealthyUpstream.Inc(1)
+		return nil
+	}
+	resource.Increase()
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[udpproxy] new session from %s to %s", remoteAddr, host.AddressString())
+	}
+	return &session{
+		proxy:      p,
+		key:        remoteAddr.String(),
+		downstream: remoteAddr,
+		host:       host,
+		upstream:   upstream,
+		resource:   resource,
+		lastActive: time.Now().UnixNano(),
+	}
+}
+
+// session is the datagrams between a downstream address and the chosen upstream host
+type session struct {
+	proxy      *proxy
+	key        string
+	downstream net.Addr
+	host       types.Host
+	upstream   *net.UDPConn
+	resource   types.Resource
+	lastActive int64 // unix nano
+	closed     uint32
+}
+
+func (s *session) write(data []byte) {
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+	n, err := s.upstream.Write(data)
+	if err != nil {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[udpproxy] send datagram to upstream %s failed: %v", s.host.AddressString(), err)
+		}
+		s.proxy.stats.UpstreamSendError.Inc(1)
+		return
+	}
+	s.proxy.stats.UpstreamDatagramsTx.Inc(1)
+	s.proxy.stats.UpstreamBytesTx.Inc(int64(n))
+}
+
+// readLoop forwards the upstream datagrams to the downstream until the session is idle or closed
+func (s *session) readLoop() {
+	defer func() {
+		s.close()
+		s.proxy.removeSession(s)
+	}()
+	buf := make([]byte, network.MaxUDPDatagramSize)
+	idleTimeout := s.proxy.idleTimeout
+	for {
+		deadline := time.Unix(0, atomic.LoadInt64(&s.lastActive)).Add(idleTimeout)
+		s.upstream.SetReadDeadline(deadline)
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				// the downstream may be active after the deadline is set
+				if time.Since(time.Unix(0, atomic.LoadInt64(&s.lastActive))) < idleTimeout {
+					continue
+				}
+				s.proxy.stats.SessionIdleTimeout.Inc(1)
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[udpproxy] session from %s to %s idle timeout", s.key, s.host.AddressString())
+				}
+				return
+			}
+			if atomic.LoadUint32(&s.closed) == 1 {
+				return
+			}
+			// the upstream may be not ready, such as icmp port unreachable.
+			// the session is closed, and the next datagram from the downstream creates a new session
+			s.proxy.stats.UpstreamReceiveError.Inc(1)
+			if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+				log.DefaultLogger.Debugf("[udpproxy] read datagram from upstream %s failed, close the session: %v", s.host.AddressString(), err)
+			}
+			return
+		}
+		atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+		s.proxy.stats.UpstreamDatagramsRx.Inc(1)
+		s.proxy.stats.UpstreamBytesRx.Inc(int64(n))
+		if _, err := s.proxy.cb.WriteTo(buf[:n], s.downstream); err != nil {
+			s.proxy.stats.DownstreamSendError.Inc(1)
+			continue
+		}
+		s.proxy.stats.DownstreamDatagramsTx.Inc(1)
+		s.proxy.stats.DownstreamBytesTx.Inc(int64(n))
+	}
+}
+
+func (s *session) close() {
+	if !atomic.CompareAndSwapUint32(&s.closed, 0, 1) {
+		return
+	}
+	s.upstream.Close()
+	s.resource.Decrease()
+}
+
+// lbContext is the load balancer context of udp proxy, there is no downstream connection
+type lbContext struct {
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *lbContext) DownstreamConnection() net.Conn {
+	return nil
+}
+
+func (c *lbContext) DownstreamHeaders() api.HeaderMap {
+	return nil
+}
+
+func (c *lbContext) DownstreamContext() context.Context {
+	return c.ctx
+}
+
+func (c *lbContext) DownstreamCluster() types.ClusterInfo {
+	return c.cluster
+}
+
+func (c *lbContext) HashKey() (uint64, bool) {
+	return 0, false
+}
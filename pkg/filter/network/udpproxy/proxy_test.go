@@ -0,0 +1,74 @@
+package udpproxy
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockHost struct {
+	types.Host
+	addr string
+}
+
+func (h *mockHost) AddressString() string {
+	return h.addr
+}
+
+type mockResource struct {
+	types.Resource
+	count int64
+}
+
+func (r *mockResource) Decrease() {
+	atomic.AddInt64(&r.count, -1)
+}
+
+func TestSessionUpstreamReadError(t *testing.T) {
+	// no server listens on the address, the read fails by icmp port unreachable
+	ln, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.LocalAddr().(*net.UDPAddr)
+	ln.Close()
+	upstream, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy{
+		idleTimeout: time.Minute,
+		stats:       newProxyStats("TestSessionUpstreamReadError"),
+		sessions:    make(map[string]*session),
+	}
+	s := &session{
+		proxy:      p,
+		key:        "downstream",
+		host:       &mockHost{addr: addr.String()},
+		upstream:   upstream,
+		resource:   &mockResource{count: 1},
+		lastActive: time.Now().UnixNano(),
+	}
+	p.sessions[s.key] = s
+	done := make(chan struct{})
+	go func() {
+		s.readLoop()
+		close(done)
+	}()
+	s.write([]byte("hello"))
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		s.close()
+		t.Fatal("session should be closed after the upstream read error")
+	}
+	if atomic.LoadUint32(&s.closed) != 1 || len(p.sessions) != 0 {
+		t.Error("session should be closed and removed")
+	}
+	if p.stats.UpstreamReceiveError.Count() != 1 {
+		t.Errorf("upstream receive error expected 1, but got %d", p.stats.UpstreamReceiveError.Count())
+	}
+}
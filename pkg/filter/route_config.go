@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// PerRouteConfigDisabled is the key in the per-route config of a stream filter,
+// the filter is not executed for the route if it is true.
+const PerRouteConfigDisabled = "disabled"
+
+// PerRouteConfigParser parses the per-route configuration of a stream filter.
+// the config is parsed when the route is created, the result is used by the filter
+// to override the listener-level configuration when the route is matched.
+type PerRouteConfigParser func(config map[string]interface{}) (interface{}, error)
+
+var (
+	perRouteConfigParsers      = map[string]PerRouteConfigParser{}
+	perRouteConfigParsersMutex sync.RWMutex
+)
+
+// RegisterPerRouteConfigParser registers the per-route config parser of the stream filter type
+func RegisterPerRouteConfigParser(filterType string, parser PerRouteConfigParser) {
+	perRouteConfigParsersMutex.Lock()
+	defer perRouteConfigParsersMutex.Unlock()
+	perRouteConfigParsers[filterType] = parser
+}
+
+// GetPerRouteConfigParser returns the per-route config parser of the stream filter type, nil if it is not registered
+func GetPerRouteConfigParser(filterType string) PerRouteConfigParser {
+	perRouteConfigParsersMutex.RLock()
+	defer perRouteConfigParsersMutex.RUnlock()
+	return perRouteConfigParsers[filterType]
+}
+
+// ParsePerFilterConfig merges the per_filter_config of the virtual host and the route, the fields
+// configured in the route override the fields configured in the virtual host.
+// the merged config of each filter is parsed by the registered parser, the filters without a parser are ignored.
+// disabled contains the filters that are disabled.
+func ParsePerFilterConfig(virtualHostConfig, routeConfig map[string]interface{}) (configs map[string]interface{}, disabled map[string]bool, err error) {
+	merged := make(map[string]map[string]interface{}, len(virtualHostConfig)+len(routeConfig))
+	for _, perFilterConfig := range []map[string]interface{}{virtualHostConfig, routeConfig} {
+		for filterType, raw := range perFilterConfig {
+			cfg, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("per filter config of %s is not an object", filterType)
+			}
+			m, ok := merged[filterType]
+			if !ok {
+				m = make(map[string]interface{}, len(cfg))
+				merged[filterType] = m
+			}
+			for k, v := range cfg {
+				m[k] = v
+			}
+		}
+	}
+	for filterType, cfg := range merged {
+		if v, ok := cfg[PerRouteConfigDisabled]; ok {
+			off, ok := v.(bool)
+			if !ok {
+				return nil, nil, fmt.Errorf("%s of per filter config %s is not a bool", PerRouteConfigDisabled, filterType)
+			}
+			if off {
+				if disabled == nil {
+					disabled = make(map[string]bool)
+				}
+				disabled[filterType] = true
+				continue
+			}
+			delete(cfg, PerRouteConfigDisabled)
+		}
+		parser := GetPerRouteConfigParser(filterType)
+		if parser == nil || len(cfg) == 0 {
+			continue
+		}
+		parsed, err := parser(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse per filter config of %s failed: %v", filterType, err)
+		}
+		if configs == nil {
+			configs = make(map[string]interface{})
+		}
+		configs[filterType] = parsed
+	}
+	return configs, disabled, nil
+}
+
+// GetPerRouteConfig returns the parsed per-route config of the stream filter type in the matched route, nil if it is not configured.
+// the route rule that is not a types.FilterConfigRouteRule is parsed by its PerFilterConfig
+func GetPerRouteConfig(route api.Route, filterType string) interface{} {
+	if route == nil || route.RouteRule() == nil {
+		return nil
+	}
+	rule := route.RouteRule()
+	if r, ok := rule.(types.FilterConfigRouteRule); ok {
+		return r.FilterConfig(filterType)
+	}
+	raw, ok := rule.PerFilterConfig()[filterType]
+	if !ok {
+		return nil
+	}
+	configs, _, err := ParsePerFilterConfig(nil, map[string]interface{}{filterType: raw})
+	if err != nil {
+		return nil
+	}
+	return configs[filterType]
+}
+
+// NamedStreamFilterChainFactoryCallbacks is a api.StreamFilterChainFactoryCallbacks that records
+// the stream filter type of the filters, so the filters can be disabled by the per-route config
+type NamedStreamFilterChainFactoryCallbacks interface {
+	AddNamedStreamReceiverFilter(filterType string, filter api.StreamReceiverFilter, p api.FilterPhase)
+	AddNamedStreamSenderFilter(filterType string, filter api.StreamSenderFilter)
+}
+
+// namedStreamFilterChainFactory adds the filters with the stream filter type
+type namedStreamFilterChainFactory struct {
+	filterType string
+	factory    api.StreamFilterChainFactory
+}
+
+// NewNamedStreamFilterChainFactory wraps the factory created by the stream filter type
+func NewNamedStreamFilterChainFactory(filterType string, factory api.StreamFilterChainFactory) api.StreamFilterChainFactory {
+	return &namedStreamFilterChainFactory{
+		filterType: filterType,
+		factory:    factory,
+	}
+}
+
+func (f *namedStreamFilterChainFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	if cb, ok := callbacks.(NamedStreamFilterChainFactoryCallbacks); ok {
+		callbacks = &namedStreamFilterChainFactoryCallbacks{
+			StreamFilterChainFactoryCallbacks: callbacks,
+			filterType:                        f.filterType,
+			named:                             cb,
+		}
+	}
+	f.factory.CreateFilterChain(context, callbacks)
+}
+
+type namedStreamFilterChainFactoryCallbacks struct {
+	api.StreamFilterChainFactoryCallbacks
+	filterType string
+	named      NamedStreamFilterChainFactoryCallbacks
+}
+
+func (cb *namedStreamFilterChainFactoryCallbacks) AddStreamReceiverFilter(filter api.StreamReceiverFilter, p api.FilterPhase) {
+	cb.named.AddNamedStreamReceiverFilter(cb.filterType, filter, p)
+}
+
+func (cb *namedStreamFilterChainFactoryCallbacks) AddStreamSenderFilter(filter api.StreamSenderFilter) {
+	cb.named.AddNamedStreamSenderFilter(cb.filterType, filter)
+}
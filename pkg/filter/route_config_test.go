@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mosn.io/api"
+)
+
+type testPerRouteConfig struct {
+	fields map[string]interface{}
+}
+
+func testPerRouteConfigParser(config map[string]interface{}) (interface{}, error) {
+	if _, ok := config["error"]; ok {
+		return nil, errors.New("error")
+	}
+	return &testPerRouteConfig{fields: config}, nil
+}
+
+func TestParsePerFilterConfig(t *testing.T) {
+	RegisterPerRouteConfigParser("test_per_route", testPerRouteConfigParser)
+	vhConfig := map[string]interface{}{
+		"test_per_route": map[string]interface{}{
+			"a": "vh",
+			"b": "vh",
+		},
+		"test_disabled": map[string]interface{}{
+			PerRouteConfigDisabled: true,
+		},
+		"test_enabled": map[string]interface{}{
+			PerRouteConfigDisabled: true,
+		},
+	}
+	routeConfig := map[string]interface{}{
+		"test_per_route": map[string]interface{}{
+			"b": "route",
+		},
+		"test_enabled": map[string]interface{}{
+			PerRouteConfigDisabled: false,
+		},
+		"test_no_parser": map[string]interface{}{
+			"a": "route",
+		},
+	}
+	configs, disabled, err := ParsePerFilterConfig(vhConfig, routeConfig)
+	if err != nil {
+		t.Fatalf("parse per filter config failed: %v", err)
+	}
+	cfg, ok := configs["test_per_route"].(*testPerRouteConfig)
+	if !ok || cfg.fields["a"] != "vh" || cfg.fields["b"] != "route" {
+		t.Errorf("unexpected merged config: %+v", configs["test_per_route"])
+	}
+	if _, ok := configs["test_no_parser"]; ok {
+		t.Error("config without parser should be ignored")
+	}
+	if !disabled["test_disabled"] || disabled["test_enabled"] || disabled["test_per_route"] {
+		t.Errorf("unexpected disabled filters: %v", disabled)
+	}
+	// the virtual host's config is not changed by the merge
+	if vhConfig["test_per_route"].(map[string]interface{})["b"] != "vh" {
+		t.Error("virtual host config is changed")
+	}
+	// invalid configs
+	for i, invalid := range []map[string]interface{}{
+		{"test_per_route": "not an object"},
+		{"test_per_route": map[string]interface{}{PerRouteConfigDisabled: "yes"}},
+		{"test_per_route": map[string]interface{}{"error": true}},
+	} {
+		if _, _, err := ParsePerFilterConfig(nil, invalid); err == nil {
+			t.Errorf("#%d expected an error", i)
+		}
+	}
+}
+
+type testStreamFilterChainFactoryCallbacks struct {
+	api.StreamFilterChainFactoryCallbacks
+	receivers map[string]api.StreamReceiverFilter
+	senders   map[string]api.StreamSenderFilter
+}
+
+func (cb *testStreamFilterChainFactoryCallbacks) AddNamedStreamReceiverFilter(filterType string, filter api.StreamReceiverFilter, p api.FilterPhase) {
+	cb.receivers[filterType] = filter
+}
+
+func (cb *testStreamFilterChainFactoryCallbacks) AddNamedStreamSenderFilter(filterType string, filter api.StreamSenderFilter) {
+	cb.senders[filterType] = filter
+}
+
+type testReceiverFilter struct {
+	api.StreamReceiverFilter
+}
+
+type testSenderFilter struct {
+	api.StreamSenderFilter
+}
+
+type testAddFilterFactory struct{}
+
+func (f *testAddFilterFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	callbacks.AddStreamReceiverFilter(&testReceiverFilter{}, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(&testSenderFilter{})
+}
+
+func TestNamedStreamFilterChainFactory(t *testing.T) {
+	cb := &testStreamFilterChainFactoryCallbacks{
+		receivers: map[string]api.StreamReceiverFilter{},
+		senders:   map[string]api.StreamSenderFilter{},
+	}
+	NewNamedStreamFilterChainFactory("test_named", &testAddFilterFactory{}).CreateFilterChain(context.Background(), cb)
+	if cb.receivers["test_named"] == nil || cb.senders["test_named"] == nil {
+		t.Errorf("filters are not added with the name, receivers: %v, senders: %v", cb.receivers, cb.senders)
+	}
+}
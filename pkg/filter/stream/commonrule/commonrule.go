@@ -23,6 +23,8 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/filter/stream/commonrule/model"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
@@ -32,7 +34,8 @@ import (
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
 func init() {
-	api.RegisterStream("commonrule", CreateCommonRuleFilterFactory)
+	api.RegisterStream(v2.CommonRule, CreateCommonRuleFilterFactory)
+	filter.RegisterPerRouteConfigParser(v2.CommonRule, parsePerRouteConfig)
 }
 
 func parseCommonRuleConfig(config map[string]interface{}) *model.CommonRuleConfig {
@@ -46,6 +49,19 @@ func parseCommonRuleConfig(config map[string]interface{}) *model.CommonRuleConfi
 	return commonRuleConfig
 }
 
+// parsePerRouteConfig creates the rule engines of the route, which replace the filter-level rule engines
+func parsePerRouteConfig(config map[string]interface{}) (interface{}, error) {
+	commonRuleConfig := &model.CommonRuleConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, commonRuleConfig); err != nil {
+		return nil, err
+	}
+	return NewRuleEngineFactory(commonRuleConfig), nil
+}
+
 type commmonRuleFilter struct {
 	context           context.Context
 	handler           api.StreamReceiverFilterHandler
@@ -72,7 +88,11 @@ func NewCommonRuleFilter(context context.Context, config *model.CommonRuleConfig
 }
 
 func (f *commmonRuleFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
-	if f.RuleEngineFactory.invoke(headers) {
+	ruleEngineFactory := f.RuleEngineFactory
+	if routeFactory, ok := filter.GetPerRouteConfig(f.handler.Route(), v2.CommonRule).(*RuleEngineFactory); ok {
+		ruleEngineFactory = routeFactory
+	}
+	if ruleEngineFactory.invoke(headers) {
 		return api.StreamFilterContinue
 	}
 	headers.Set(types.HeaderStatus, strconv.Itoa(types.LimitExceededCode))
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cors
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// cors headers
+const (
+	HeaderOrigin                        = "Origin"
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+	HeaderVary                          = "Vary"
+)
+
+// corsFilter answers the preflight requests directly, and decorates the responses of the
+// actual cors requests, by the cors policy of the matched route.
+// it is an implementation of StreamReceiverFilter and StreamSenderFilter
+type corsFilter struct {
+	ctx            context.Context
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	policy         types.CorsPolicy
+	origin         string
+}
+
+func newCorsFilter(ctx context.Context) *corsFilter {
+	return &corsFilter{
+		ctx: ctx,
+	}
+}
+
+func (f *corsFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *corsFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *corsFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	route := f.receiveHandler.Route()
+	if route == nil {
+		return api.StreamFilterContinue
+	}
+	rule, ok := route.RouteRule().(types.CorsPolicyRouteRule)
+	if !ok {
+		return api.StreamFilterContinue
+	}
+	policy := rule.CorsPolicy()
+	if policy == nil {
+		return api.StreamFilterContinue
+	}
+	// not a cors request, or the origin is not allowed, the request is handled as usual
+	origin, _ := headers.Get(HeaderOrigin)
+	if !policy.AllowOrigin(origin) {
+		return api.StreamFilterContinue
+	}
+	method, _ := headers.Get(protocol.MosnHeaderMethod)
+	requestMethod, _ := headers.Get(HeaderAccessControlRequestMethod)
+	if method == http.MethodOptions && requestMethod != "" {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [cors] answer the preflight request, origin: %s", origin)
+		}
+		f.sendPreflightResponse(headers, policy, origin)
+		return api.StreamFilterStop
+	}
+	f.policy = policy
+	f.origin = origin
+	return api.StreamFilterContinue
+}
+
+// sendPreflightResponse sends the preflight response directly without the upstream
+func (f *corsFilter) sendPreflightResponse(requestHeaders api.HeaderMap, policy types.CorsPolicy, origin string) {
+	headers := newResponseHeaders(requestHeaders)
+	headers.Set(types.HeaderStatus, strconv.Itoa(http.StatusOK))
+	setAllowOrigin(headers, policy, origin)
+	if methods := policy.AllowMethods(); methods != "" {
+		headers.Set(HeaderAccessControlAllowMethods, methods)
+	}
+	if allowHeaders := policy.AllowHeaders(); allowHeaders != "" {
+		headers.Set(HeaderAccessControlAllowHeaders, allowHeaders)
+	}
+	if maxAge := policy.MaxAge(); maxAge != "" {
+		headers.Set(HeaderAccessControlMaxAge, maxAge)
+	}
+	f.receiveHandler.RequestInfo().SetResponseCode(http.StatusOK)
+	f.receiveHandler.SendDirectResponse(headers, nil, nil)
+}
+
+// newResponseHeaders creates the response headers that can be encoded by the downstream protocol
+func newResponseHeaders(requestHeaders api.HeaderMap) api.HeaderMap {
+	if _, ok := requestHeaders.(mosnhttp.RequestHeader); ok {
+		return mosnhttp.ResponseHeader{
+			ResponseHeader: &fasthttp.ResponseHeader{},
+		}
+	}
+	return protocol.CommonHeader{}
+}
+
+// setAllowOrigin sets the allowed origin, the response varies by the Origin header
+// unless any origin is allowed, so the caches do not serve it to the other origins.
+func setAllowOrigin(headers api.HeaderMap, policy types.CorsPolicy, origin string) {
+	headers.Set(HeaderAccessControlAllowOrigin, origin)
+	if origin != "*" {
+		addVary(headers, HeaderOrigin)
+	}
+	if policy.AllowCredentials() {
+		headers.Set(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// addVary appends the header name to the Vary header if it is not listed yet
+func addVary(headers api.HeaderMap, name string) {
+	vary, ok := headers.Get(HeaderVary)
+	if !ok || vary == "" {
+		headers.Set(HeaderVary, name)
+		return
+	}
+	for _, v := range strings.Split(vary, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.EqualFold(v, name) {
+			return
+		}
+	}
+	headers.Set(HeaderVary, vary+", "+name)
+}
+
+func (f *corsFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.policy == nil || headers == nil {
+		return api.StreamFilterContinue
+	}
+	setAllowOrigin(headers, f.policy, f.origin)
+	if exposeHeaders := f.policy.ExposeHeaders(); exposeHeaders != "" {
+		headers.Set(HeaderAccessControlExposeHeaders, exposeHeaders)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *corsFilter) OnDestroy() {}
@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockRequestInfo struct {
+	api.RequestInfo
+	code int
+}
+
+func (info *mockRequestInfo) SetResponseCode(code int) {
+	info.code = code
+}
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	route   api.Route
+	info    *mockRequestInfo
+	headers api.HeaderMap
+}
+
+func (h *mockReceiverHandler) Route() api.Route {
+	return h.route
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendDirectResponse(headers api.HeaderMap, buf types.IoBuffer, trailers api.HeaderMap) {
+	h.headers = headers
+}
+
+func newCorsRoute(t *testing.T, cors *v2.CorsPolicy) api.Route {
+	cfg := v2.Router{}
+	cfg.Match.Prefix = "/"
+	cfg.Route.ClusterName = "test"
+	cfg.Route.Cors = cors
+	vh, err := router.NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{cfg},
+	})
+	if err != nil {
+		t.Fatalf("create virtual host failed: %v", err)
+	}
+	return vh.GetRouteFromEntries(protocol.CommonHeader{types.HeaderPath: "/"}, 1)
+}
+
+func newHTTPRequest(method string, headers map[string]string) api.HeaderMap {
+	h := mosnhttp.RequestHeader{RequestHeader: &fasthttp.RequestHeader{}}
+	h.Set(protocol.MosnHeaderMethod, method)
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return h
+}
+
+func TestCorsPreflight(t *testing.T) {
+	route := newCorsRoute(t, &v2.CorsPolicy{
+		AllowOrigins:     []string{"http://example.com"},
+		AllowMethods:     []string{"GET", "PUT"},
+		AllowHeaders:     []string{"x-custom"},
+		AllowCredentials: true,
+	})
+	handler := &mockReceiverHandler{
+		route: route,
+		info:  &mockRequestInfo{},
+	}
+	f := newCorsFilter(context.Background())
+	f.SetReceiveFilterHandler(handler)
+	req := newHTTPRequest("OPTIONS", map[string]string{
+		HeaderOrigin:                     "http://example.com",
+		HeaderAccessControlRequestMethod: "PUT",
+	})
+	if status := f.OnReceive(context.Background(), req, nil, nil); status != api.StreamFilterStop {
+		t.Fatalf("preflight request should be answered directly")
+	}
+	if _, ok := handler.headers.(mosnhttp.ResponseHeader); !ok || handler.info.code != 200 {
+		t.Fatalf("unexpected preflight response: %v, code: %d", handler.headers, handler.info.code)
+	}
+	for k, v := range map[string]string{
+		types.HeaderStatus:                  "200",
+		HeaderAccessControlAllowOrigin:      "http://example.com",
+		HeaderAccessControlAllowCredentials: "true",
+		HeaderAccessControlAllowMethods:     "GET,PUT",
+		HeaderAccessControlAllowHeaders:     "x-custom",
+		HeaderVary:                          "Origin",
+	} {
+		if got, _ := handler.headers.Get(k); got != v {
+			t.Errorf("preflight response header %s expected %s, but got %s", k, v, got)
+		}
+	}
+	if _, ok := handler.headers.Get(HeaderAccessControlMaxAge); ok {
+		t.Error("max age is not configured")
+	}
+	// the origin is not allowed
+	handler.headers = nil
+	req = newHTTPRequest("OPTIONS", map[string]string{
+		HeaderOrigin:                     "http://other.com",
+		HeaderAccessControlRequestMethod: "PUT",
+	})
+	if status := f.OnReceive(context.Background(), req, nil, nil); status != api.StreamFilterContinue || handler.headers != nil {
+		t.Fatalf("preflight request with not allowed origin should be continued")
+	}
+}
+
+func TestCorsActualRequest(t *testing.T) {
+	route := newCorsRoute(t, &v2.CorsPolicy{
+		AllowOriginRegex: []string{`^http://.*\.example\.com$`},
+		ExposeHeaders:    []string{"x-expose"},
+	})
+	testcases := []struct {
+		origin   string
+		expected bool
+	}{
+		{"http://foo.example.com", true},
+		{"http://other.com", false},
+		{"", false},
+	}
+	for i, tc := range testcases {
+		handler := &mockReceiverHandler{
+			route: route,
+			info:  &mockRequestInfo{},
+		}
+		f := newCorsFilter(context.Background())
+		f.SetReceiveFilterHandler(handler)
+		req := protocol.CommonHeader{
+			protocol.MosnHeaderMethod: "GET",
+		}
+		if tc.origin != "" {
+			req[HeaderOrigin] = tc.origin
+		}
+		if status := f.OnReceive(context.Background(), req, nil, nil); status != api.StreamFilterContinue {
+			t.Fatalf("#%d actual request should be continued", i)
+		}
+		resp := protocol.CommonHeader{
+			HeaderVary: "Accept-Encoding",
+		}
+		f.Append(context.Background(), resp, nil, nil)
+		origin, ok := resp.Get(HeaderAccessControlAllowOrigin)
+		if ok != tc.expected || (ok && origin != tc.origin) {
+			t.Errorf("#%d unexpected allow origin: %s", i, origin)
+		}
+		// the response varies by the origin if the allowed origin is not *
+		expectedVary := "Accept-Encoding"
+		if tc.expected {
+			expectedVary = "Accept-Encoding, Origin"
+		}
+		if vary, _ := resp.Get(HeaderVary); vary != expectedVary {
+			t.Errorf("#%d unexpected vary: %s", i, vary)
+		}
+		if expose, _ := resp.Get(HeaderAccessControlExposeHeaders); tc.expected && expose != "x-expose" {
+			t.Errorf("#%d unexpected expose headers: %s", i, expose)
+		}
+		if _, ok := resp.Get(HeaderAccessControlAllowCredentials); ok {
+			t.Errorf("#%d allow credentials is not configured", i)
+		}
+	}
+}
+
+func TestCorsWithoutPolicy(t *testing.T) {
+	handler := &mockReceiverHandler{
+		route: newCorsRoute(t, nil),
+		info:  &mockRequestInfo{},
+	}
+	f := newCorsFilter(context.Background())
+	f.SetReceiveFilterHandler(handler)
+	req := newHTTPRequest("OPTIONS", map[string]string{
+		HeaderOrigin:                     "http://example.com",
+		HeaderAccessControlRequestMethod: "PUT",
+	})
+	if status := f.OnReceive(context.Background(), req, nil, nil); status != api.StreamFilterContinue || handler.headers != nil {
+		t.Fatal("request should be continued if no cors policy configured")
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	for _, tc := range []struct {
+		vary     string
+		expected string
+	}{
+		{"", "Origin"},
+		{"Accept-Encoding", "Accept-Encoding, Origin"},
+		{"Accept-Encoding, origin", "Accept-Encoding, origin"},
+		{"*", "*"},
+	} {
+		headers := protocol.CommonHeader{}
+		if tc.vary != "" {
+			headers[HeaderVary] = tc.vary
+		}
+		addVary(headers, HeaderOrigin)
+		if vary, _ := headers.Get(HeaderVary); vary != tc.expected {
+			t.Errorf("vary %q expected %q, but got %q", tc.vary, tc.expected, vary)
+		}
+	}
+}
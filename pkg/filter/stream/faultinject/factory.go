@@ -23,11 +23,13 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 )
 
 func init() {
 	api.RegisterStream(v2.FaultStream, CreateFaultInjectFilterFactory)
+	filter.RegisterPerRouteConfigParser(v2.FaultStream, parsePerRouteConfig)
 }
 
 type FilterConfigFactory struct {
@@ -19,12 +19,12 @@ package faultinject
 
 import (
 	"context"
-	"encoding/json"
 	"math/rand"
 	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
@@ -57,22 +57,41 @@ func makefaultInjectConfig(cfg *v2.StreamFaultInject) *faultInjectConfig {
 	return faultConfig
 }
 
-// TODO: this is a hack for per route config parse
-// delete it later, when per route config changes to map[string]interface{}
-func parseStreamFaultInjectConfig(c interface{}) (*faultInjectConfig, bool) {
-	conf := make(map[string]interface{})
-	b, err := json.Marshal(c)
-	if err != nil {
-		log.DefaultLogger.Errorf("config is not a json, %v", err)
-		return nil, false
-	}
-	json.Unmarshal(b, &conf)
+// faultInjectOverride is parsed from the per-route config, the configured fields override the filter-level config
+type faultInjectOverride struct {
+	cfg     *v2.StreamFaultInject
+	headers []*types.HeaderData
+}
+
+func parsePerRouteConfig(conf map[string]interface{}) (interface{}, error) {
 	cfg, err := ParseStreamFaultInjectFilter(conf)
 	if err != nil {
-		log.DefaultLogger.Errorf("config is not stream fault inject", err)
-		return nil, false
+		return nil, err
+	}
+	return &faultInjectOverride{
+		cfg:     cfg,
+		headers: router.GetRouterHeaders(cfg.Headers),
+	}, nil
+}
+
+// override returns a new config that is merged with the per-route config
+func (c *faultInjectConfig) override(o *faultInjectOverride) *faultInjectConfig {
+	config := *c
+	if o.cfg.Delay != nil {
+		config.fixedDelay = o.cfg.Delay.Delay
+		config.delayPercent = o.cfg.Delay.Percent
+	}
+	if o.cfg.Abort != nil {
+		config.abortStatus = o.cfg.Abort.Status
+		config.abortPercent = o.cfg.Abort.Percent
+	}
+	if o.cfg.UpstreamCluster != "" {
+		config.upstream = o.cfg.UpstreamCluster
 	}
-	return makefaultInjectConfig(cfg), true
+	if len(o.cfg.Headers) > 0 {
+		config.headers = o.headers
+	}
+	return &config
 }
 
 // streamFaultInjectFilter is an implement of api.StreamReceiverFilter
@@ -97,19 +116,16 @@ func NewFilter(ctx context.Context, cfg *v2.StreamFaultInject) api.StreamReceive
 	}
 }
 
-// ReadPerRouteConfig makes route-level configuration override filter-level configuration
-func (f *streamFaultInjectFilter) ReadPerRouteConfig(cfg map[string]interface{}) {
-	if cfg == nil {
+// readPerRouteConfig merges the per-route config of the matched route with the filter-level config
+func (f *streamFaultInjectFilter) readPerRouteConfig(route api.Route) {
+	o, ok := filter.GetPerRouteConfig(route, v2.FaultStream).(*faultInjectOverride)
+	if !ok {
 		return
 	}
-	if fault, ok := cfg[v2.FaultStream]; ok {
-		if config, ok := parseStreamFaultInjectConfig(fault); ok {
-			if log.Proxy.GetLogLevel() >= log.DEBUG {
-				log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] use router config to replace stream filter config, config: %v", fault)
-			}
-			f.config = config
-		}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] use router config to override stream filter config, config: %+v", o.cfg)
 	}
+	f.config = f.config.override(o)
 }
 
 func (f *streamFaultInjectFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
@@ -120,11 +136,8 @@ func (f *streamFaultInjectFilter) OnReceive(ctx context.Context, headers api.Hea
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] fault inject filter do receive headers")
 	}
-	if route := f.handler.Route(); route != nil {
-		// TODO: makes ReadPerRouteConfig as the StreamReceiverFilter's function
-		f.ReadPerRouteConfig(route.RouteRule().PerFilterConfig())
-	}
-	if !f.matchUpstream() {
+	f.readPerRouteConfig(f.handler.Route())
+	if !f.matchUpstream(ctx, headers) {
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] upstream is not matched")
 		}
@@ -168,13 +181,14 @@ func (f *streamFaultInjectFilter) OnDestroy() {
 
 // matches and inject
 
-func (f *streamFaultInjectFilter) matchUpstream() bool {
+func (f *streamFaultInjectFilter) matchUpstream(ctx context.Context, headers api.HeaderMap) bool {
 	if f.config.upstream != "" {
 		if route := f.handler.Route(); route != nil {
+			clusterName := upstreamClusterName(ctx, headers, route.RouteRule())
 			if log.Proxy.GetLogLevel() >= log.DEBUG {
-				log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] current cluster name %s, fault inject cluster name %s", route.RouteRule().ClusterName(), f.config.upstream)
+				log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] current cluster name %s, fault inject cluster name %s", clusterName, f.config.upstream)
 			}
-			return route.RouteRule().ClusterName() == f.config.upstream
+			return clusterName == f.config.upstream
 		}
 	}
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
@@ -183,6 +197,15 @@ func (f *streamFaultInjectFilter) matchUpstream() bool {
 	return true
 }
 
+// upstreamClusterName returns the cluster that the request is routed to,
+// the cluster specifier of the route rule is used if it is configured.
+func upstreamClusterName(ctx context.Context, headers api.HeaderMap, rule api.RouteRule) string {
+	if r, ok := rule.(types.ClusterSpecifierRouteRule); ok {
+		return r.ClusterNameWithContext(ctx, headers)
+	}
+	return rule.ClusterName()
+}
+
 func (f *streamFaultInjectFilter) getDelayDuration() time.Duration {
 	// percent is 0 or delay is 0 means no delay
 	if f.config.delayPercent == 0 || f.config.fixedDelay == 0 {
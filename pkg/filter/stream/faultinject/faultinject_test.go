@@ -31,8 +31,11 @@ import (
 
 func TestMatchUpstream(t *testing.T) {
 	faultUpstream := "fault_upstream"
+	headers := protocol.CommonHeader{
+		"x-cluster": faultUpstream,
+	}
 	testCases := []struct {
-		rule     *mockRouteRule
+		rule     api.RouteRule
 		expected bool
 	}{
 		{
@@ -47,6 +50,25 @@ func TestMatchUpstream(t *testing.T) {
 			},
 			expected: false,
 		},
+		// the cluster selected by the cluster specifier is matched
+		{
+			rule: &mockSpecifierRouteRule{
+				mockRouteRule: mockRouteRule{
+					clustername: "default_cluster",
+				},
+				clusterHeader: "x-cluster",
+			},
+			expected: true,
+		},
+		{
+			rule: &mockSpecifierRouteRule{
+				mockRouteRule: mockRouteRule{
+					clustername: faultUpstream,
+				},
+				clusterHeader: "x-other-cluster",
+			},
+			expected: true,
+		},
 	}
 	for i, tc := range testCases {
 		f := &streamFaultInjectFilter{
@@ -59,7 +81,7 @@ func TestMatchUpstream(t *testing.T) {
 				},
 			},
 		}
-		if f.matchUpstream() != tc.expected {
+		if f.matchUpstream(context.Background(), headers) != tc.expected {
 			t.Errorf("#%d match upstream failed", i)
 		}
 	}
@@ -67,7 +89,7 @@ func TestMatchUpstream(t *testing.T) {
 	f := &streamFaultInjectFilter{
 		config: &faultInjectConfig{},
 	}
-	if !f.matchUpstream() {
+	if !f.matchUpstream(context.Background(), headers) {
 		t.Error("empty upstream not matched")
 	}
 
@@ -387,3 +409,40 @@ func TestFaultInject_RouteConfigOverride(t *testing.T) {
 		t.Error("timeout")
 	}
 }
+
+func TestFaultInject_RouteConfigMerge(t *testing.T) {
+	cfg := &v2.StreamFaultInject{
+		Delay: &v2.DelayInject{
+			Delay: time.Second,
+			DelayInjectConfig: v2.DelayInjectConfig{
+				Percent: 100,
+			},
+		},
+		UpstreamCluster: "listener_cluster",
+	}
+	o, err := parsePerRouteConfig(map[string]interface{}{
+		"abort": map[string]interface{}{
+			"status":     503,
+			"percentage": 50,
+		},
+		"headers": []interface{}{
+			map[string]interface{}{
+				"name":  "user",
+				"value": "alice",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parse per route config failed: %v", err)
+	}
+	config := makefaultInjectConfig(cfg).override(o.(*faultInjectOverride))
+	// the fields not configured in the route are kept
+	if !(config.fixedDelay == time.Second &&
+		config.delayPercent == 100 &&
+		config.upstream == "listener_cluster" &&
+		config.abortStatus == 503 &&
+		config.abortPercent == 50 &&
+		len(config.headers) == 1) {
+		t.Errorf("unexpected merged config: %+v", config)
+	}
+}
@@ -17,7 +17,11 @@
 
 package faultinject
 
-import "mosn.io/api"
+import (
+	"context"
+
+	"mosn.io/api"
+)
 
 // this file mocks the interface that used for test
 // only implement the function that used in test
@@ -45,7 +49,7 @@ func (cb *mockStreamReceiverFilterCallbacks) SendHijackReply(code int, headers a
 
 type mockRoute struct {
 	api.Route
-	rule *mockRouteRule
+	rule api.RouteRule
 }
 
 func (r *mockRoute) RouteRule() api.RouteRule {
@@ -65,6 +69,19 @@ func (r *mockRouteRule) PerFilterConfig() map[string]interface{} {
 	return r.config
 }
 
+// mockSpecifierRouteRule selects the cluster by the cluster header
+type mockSpecifierRouteRule struct {
+	mockRouteRule
+	clusterHeader string
+}
+
+func (r *mockSpecifierRouteRule) ClusterNameWithContext(ctx context.Context, headers api.HeaderMap) string {
+	if clusterName, ok := headers.Get(r.clusterHeader); ok {
+		return clusterName
+	}
+	return r.clustername
+}
+
 type mockRequestInfo struct {
 	api.RequestInfo
 	flag api.ResponseFlag
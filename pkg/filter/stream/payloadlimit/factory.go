@@ -23,11 +23,13 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 )
 
 func init() {
 	api.RegisterStream(v2.PayloadLimit, CreatePayloadLimitFilterFactory)
+	filter.RegisterPerRouteConfigParser(v2.PayloadLimit, parsePerRouteConfig)
 }
 
 type FilterConfigFactory struct {
@@ -48,6 +50,11 @@ func CreatePayloadLimitFilterFactory(conf map[string]interface{}) (api.StreamFil
 	return &FilterConfigFactory{cfg}, nil
 }
 
+// parsePerRouteConfig parses the per-route config, which overrides the filter-level config
+func parsePerRouteConfig(conf map[string]interface{}) (interface{}, error) {
+	return ParseStreamPayloadLimitFilter(conf)
+}
+
 // ParseStreamPayloadLimitFilter
 func ParseStreamPayloadLimitFilter(cfg map[string]interface{}) (*v2.StreamPayloadLimit, error) {
 	filterConfig := &v2.StreamPayloadLimit{}
@@ -3,10 +3,9 @@ package payloadlimit
 import (
 	"context"
 
-	"encoding/json"
-
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/pkg/buffer"
 )
@@ -41,33 +40,25 @@ func makePayloadLimitConfig(cfg *v2.StreamPayloadLimit) *payloadLimitConfig {
 	}
 	return config
 }
-func parseStreamPayloadLimitConfig(c interface{}) (*payloadLimitConfig, bool) {
-	conf := make(map[string]interface{})
-	b, err := json.Marshal(c)
-	if err != nil {
-		log.DefaultLogger.Errorf("config is not a json, %v", err)
-		return nil, false
-	}
-	json.Unmarshal(b, &conf)
-	cfg, err := ParseStreamPayloadLimitFilter(conf)
-	if err != nil {
-		log.DefaultLogger.Errorf("config is not stream payload limit", err)
-		return nil, false
-	}
-	return makePayloadLimitConfig(cfg), true
-}
 
-// ReadPerRouteConfig makes route-level configuration override filter-level configuration
-func (f *streamPayloadLimitFilter) ReadPerRouteConfig(cfg map[string]interface{}) {
-	if cfg == nil {
+// readPerRouteConfig merges the per-route config of the matched route with the filter-level config,
+// the fields that are not configured in the route are not overridden
+func (f *streamPayloadLimitFilter) readPerRouteConfig(route api.Route) {
+	cfg, ok := filter.GetPerRouteConfig(route, v2.PayloadLimit).(*v2.StreamPayloadLimit)
+	if !ok {
 		return
 	}
-	if payloadLimit, ok := cfg[v2.PayloadLimit]; ok {
-		if config, ok := parseStreamPayloadLimitConfig(payloadLimit); ok {
-			log.DefaultLogger.Errorf("use router config to replace stream filter config, config: %v", payloadLimit)
-			f.config = config
-		}
+	config := *f.config
+	if cfg.MaxEntitySize != 0 {
+		config.maxEntitySize = cfg.MaxEntitySize
 	}
+	if cfg.HttpStatus != 0 {
+		config.status = cfg.HttpStatus
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("use router config to override stream filter config, config: %+v", cfg)
+	}
+	f.config = &config
 }
 
 func (f *streamPayloadLimitFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
@@ -78,10 +69,7 @@ func (f *streamPayloadLimitFilter) OnReceive(ctx context.Context, headers api.He
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.DefaultLogger.Debugf("payload limit stream do receive headers")
 	}
-	if route := f.handler.Route(); route != nil {
-		// TODO: makes ReadPerRouteConfig as the StreamReceiverFilter's function
-		f.ReadPerRouteConfig(route.RouteRule().PerFilterConfig())
-	}
+	f.readPerRouteConfig(f.handler.Route())
 	f.headers = headers
 
 	// buf is nil means request method is GET?
@@ -22,11 +22,13 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 )
 
 // stream factory
 func init() {
 	api.RegisterStream(v2.Transcoder, createFilterChainFactory)
+	filter.RegisterPerRouteConfigParser(v2.Transcoder, parsePerRouteConfig)
 }
 
 type filterChainFactory struct {
@@ -49,6 +51,10 @@ func createFilterChainFactory(conf map[string]interface{}) (api.StreamFilterChai
 	return &filterChainFactory{cfg}, nil
 }
 
+func parsePerRouteConfig(conf map[string]interface{}) (interface{}, error) {
+	return parseConfig(conf)
+}
+
 // transcoder factory
 var transcoderFactory = make(map[string]Transcoder)
 
@@ -23,6 +23,7 @@ import (
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 )
@@ -58,19 +59,22 @@ func newTranscodeFilter(ctx context.Context, cfg *config) *transcodeFilter {
 	}
 }
 
-// ReadPerRouteConfig makes route-level configuration override filter-level configuration
-func (f *transcodeFilter) readPerRouteConfig(ctx context.Context, cfg map[string]interface{}) {
-	if cfg == nil {
+// readPerRouteConfig makes route-level configuration override filter-level configuration
+func (f *transcodeFilter) readPerRouteConfig(ctx context.Context, route api.Route) {
+	cfg, ok := filter.GetPerRouteConfig(route, v2.Transcoder).(*config)
+	if !ok || cfg.Type == "" || cfg.Type == f.cfg.Type {
 		return
 	}
-	if transcodeCfg, ok := cfg[v2.Transcoder]; ok {
-		if config, err := parseConfig(transcodeCfg); err == nil {
-			if log.Proxy.GetLogLevel() >= log.DEBUG {
-				log.Proxy.Debugf(ctx, "[stream filter][transcoder] use router config to replace stream filter config, config: %v", config)
-			}
-			f.cfg = config
-		}
+	transcoder := GetTranscoder(cfg.Type)
+	if transcoder == nil {
+		log.Proxy.Errorf(ctx, "[stream filter][transcoder] ignore router config, no such transcoder type: %s", cfg.Type)
+		return
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter][transcoder] use router config to replace stream filter config, config: %v", cfg)
 	}
+	f.cfg = cfg
+	f.transcoder = transcoder
 }
 
 func (f *transcodeFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
@@ -78,6 +82,8 @@ func (f *transcodeFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilt
 }
 
 func (f *transcodeFilter) OnReceive(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	f.readPerRouteConfig(ctx, f.receiveHandler.Route())
+
 	// check accept
 	if !f.transcoder.Accept(ctx, headers, buf, trailers) {
 		return api.StreamFilterContinue
@@ -90,11 +96,6 @@ func (f *transcodeFilter) OnReceive(ctx context.Context, headers types.HeaderMap
 		log.Proxy.Debugf(ctx, "[stream filter][transcoder] receive request: %+v", headers)
 	}
 
-	if route := f.receiveHandler.Route(); route != nil {
-		// TODO: makes ReadPerRouteConfig as the StreamReceiverFilter's function
-		f.readPerRouteConfig(ctx, route.RouteRule().PerFilterConfig())
-	}
-
 	// do transcoding
 	outHeaders, outBuf, outTrailers, err := f.transcoder.TranscodingRequest(ctx, headers, buf, trailers)
 	if err != nil {
@@ -20,22 +20,29 @@ package log
 import (
 	"context"
 	"errors"
+	"io"
+	"sync"
 
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/variable"
 	"mosn.io/pkg/buffer"
-	"mosn.io/pkg/log"
 )
 
 // RequestInfoFuncMap is a map which key is the format-key, value is the func to get corresponding string value
 var (
 	DefaultDisableAccessLog bool
 	accessLogs              []*accesslog
+	accessLogsMutex         sync.Mutex
 
 	ErrLogFormatUndefined = errors.New("access log format undefined")
 	ErrEmptyVarDef        = errors.New("access log format error: empty variable definition")
 	ErrUnclosedVarDef     = errors.New("access log format error: unclosed variable definition")
+
+	ErrUnknownLogFormatType = errors.New("access log format type must be one of text, json and logfmt")
+	ErrEmptyLogFields       = errors.New("access log fields must be configured for json and logfmt format")
+	ErrInvalidLogFieldKey   = errors.New("access log field key of logfmt must not be empty or contain space, '=' and '\"'")
 )
 
 const AccessLogLen = 1 << 8
@@ -46,16 +53,24 @@ func init() {
 
 func DisableAllAccessLog() {
 	DefaultDisableAccessLog = true
+	accessLogsMutex.Lock()
+	defer accessLogsMutex.Unlock()
 	for _, lg := range accessLogs {
-		lg.logger.Toggle(true)
+		lg.sink.Toggle(true)
 	}
 }
 
 // types.AccessLog
 type accesslog struct {
 	output  string
-	entries []*logEntry
-	logger  *log.Logger
+	encoder accessLogEncoder
+	sink    AccessLogSink
+	filter  *accessLogFilter
+}
+
+// accessLogEncoder writes an access log into the buffer
+type accessLogEncoder interface {
+	encode(ctx context.Context, buf buffer.IoBuffer)
 }
 
 type logEntry struct {
@@ -63,58 +78,135 @@ type logEntry struct {
 	variable variable.Variable
 }
 
-func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer) {
+func (le *logEntry) value(ctx context.Context) string {
 	if le.text != "" {
-		buf.WriteString(le.text)
-	} else {
-		value, err := variable.GetVariableValue(ctx, le.variable.Name())
-		if err != nil {
-			buf.WriteString(variable.ValueNotFound)
-		} else {
-			buf.WriteString(value)
-		}
+		return le.text
+	}
+	value, err := variable.GetVariableValue(ctx, le.variable.Name())
+	if err != nil {
+		return variable.ValueNotFound
+	}
+	return value
+}
+
+func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer) {
+	buf.WriteString(le.value(ctx))
+}
+
+// textEncoder writes the access log as the format
+type textEncoder []*logEntry
+
+func (entries textEncoder) encode(ctx context.Context, buf buffer.IoBuffer) {
+	for idx := range entries {
+		entries[idx].log(ctx, buf)
 	}
 }
 
 // NewAccessLog
 func NewAccessLog(output string, format string) (api.AccessLog, error) {
-	lg, err := log.GetOrCreateLogger(output, nil)
+	return NewAccessLogWithConfig(&v2.AccessLog{
+		Path:   output,
+		Format: format,
+	})
+}
+
+// NewAccessLogWithConfig creates an access log with the format type, sink and filter in config
+func NewAccessLogWithConfig(config *v2.AccessLog) (api.AccessLog, error) {
+	var encoder accessLogEncoder
+	switch config.FormatType {
+	case "", v2.AccessLogFormatText:
+		entries, err := parseFormat(config.Format)
+		if err != nil {
+			return nil, err
+		}
+		encoder = textEncoder(entries)
+	case v2.AccessLogFormatJSON, v2.AccessLogFormatLogfmt:
+		fields, err := parseFields(config.Fields, config.FormatType == v2.AccessLogFormatLogfmt)
+		if err != nil {
+			return nil, err
+		}
+		if config.FormatType == v2.AccessLogFormatJSON {
+			encoder = jsonEncoder(fields)
+		} else {
+			encoder = logfmtEncoder(fields)
+		}
+	default:
+		return nil, ErrUnknownLogFormatType
+	}
+
+	filter, err := newAccessLogFilter(config.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	entries, err := parseFormat(format)
+	sinkConfig := config.Sink
+	if sinkConfig == nil {
+		sinkConfig = &v2.AccessLogSinkConfig{
+			Type: AccessLogSinkFile,
+			Config: map[string]interface{}{
+				"path": config.Path,
+			},
+		}
+	}
+	sink, err := CreateAccessLogSink(sinkConfig.Type, sinkConfig.Config)
 	if err != nil {
 		return nil, err
 	}
 
 	l := &accesslog{
-		output:  output,
-		entries: entries,
-		logger:  lg,
+		output:  config.Path,
+		encoder: encoder,
+		sink:    sink,
+		filter:  filter,
 	}
 
 	if DefaultDisableAccessLog {
-		lg.Toggle(true) // disable accesslog by default
+		sink.Toggle(true) // disable accesslog by default
 	}
 	// save all access logs
+	accessLogsMutex.Lock()
 	accessLogs = append(accessLogs, l)
+	accessLogsMutex.Unlock()
 
 	return l, nil
 }
 
+// CloseAccessLog removes the access log created by NewAccessLogWithConfig,
+// and closes its sink if the sink implements io.Closer, such as the sinks that send the logs in background.
+// the access log should not be used after it is closed
+func CloseAccessLog(al api.AccessLog) {
+	l, ok := al.(*accesslog)
+	if !ok {
+		return
+	}
+	accessLogsMutex.Lock()
+	for i, lg := range accessLogs {
+		if lg == l {
+			accessLogs = append(accessLogs[:i], accessLogs[i+1:]...)
+			break
+		}
+	}
+	accessLogsMutex.Unlock()
+	if closer, ok := l.sink.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			DefaultLogger.Errorf("[accesslog] close access log %s failed: %v", l.output, err)
+		}
+	}
+}
+
 func (l *accesslog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
 	// return directly
-	if l.logger.Disable() {
+	if l.sink.Disable() {
+		return
+	}
+	if l.filter != nil && !l.filter.match(requestInfo) {
 		return
 	}
 
 	buf := buffer.GetIoBuffer(AccessLogLen)
-	for idx := range l.entries {
-		l.entries[idx].log(ctx, buf)
-	}
+	l.encoder.encode(ctx, buf)
 	buf.WriteString("\n")
-	l.logger.Print(buf, true)
+	l.sink.Write(ctx, reqHeaders, respHeaders, requestInfo, buf)
 }
 
 func parseFormat(format string) ([]*logEntry, error) {
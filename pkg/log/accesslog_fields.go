@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+// logField is a field of the json or logfmt access log
+type logField struct {
+	key     string
+	entries []*logEntry
+}
+
+// value returns the value of the field, ok is false if the value is empty.
+// number is true if the field is a single variable whose value is a number
+func (f *logField) value(ctx context.Context) (value string, number bool, ok bool) {
+	if len(f.entries) == 1 {
+		value = f.entries[0].value(ctx)
+		if f.entries[0].text == "" {
+			if value == variable.ValueNotFound {
+				return "", false, false
+			}
+			number = isNumber(value)
+		}
+		return value, number, value != ""
+	}
+	var sb strings.Builder
+	for _, entry := range f.entries {
+		sb.WriteString(entry.value(ctx))
+	}
+	value = sb.String()
+	return value, false, value != ""
+}
+
+// parseFields parses the formats of the fields, the fields are sorted by the keys
+func parseFields(fields map[string]string, logfmt bool) ([]*logField, error) {
+	if len(fields) == 0 {
+		return nil, ErrEmptyLogFields
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if logfmt && (key == "" || strings.ContainsAny(key, " =\"")) {
+			return nil, ErrInvalidLogFieldKey
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*logField, 0, len(keys))
+	for _, key := range keys {
+		format := fields[key]
+		// an empty format means the field is always omitted
+		if format == "" {
+			continue
+		}
+		entries, err := parseFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &logField{
+			key:     key,
+			entries: entries,
+		})
+	}
+	return result, nil
+}
+
+// jsonEncoder writes the access log as a json object, the empty fields are omitted
+type jsonEncoder []*logField
+
+func (fields jsonEncoder) encode(ctx context.Context, buf buffer.IoBuffer) {
+	buf.WriteByte('{')
+	first := true
+	for _, field := range fields {
+		value, number, ok := field.value(ctx)
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(buf, field.key)
+		buf.WriteByte(':')
+		if number {
+			buf.WriteString(value)
+		} else {
+			writeJSONString(buf, value)
+		}
+	}
+	buf.WriteByte('}')
+}
+
+// logfmtEncoder writes the access log as key=value pairs, the empty fields are omitted
+type logfmtEncoder []*logField
+
+func (fields logfmtEncoder) encode(ctx context.Context, buf buffer.IoBuffer) {
+	first := true
+	for _, field := range fields {
+		value, number, ok := field.value(ctx)
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		buf.WriteString(field.key)
+		buf.WriteByte('=')
+		if number || !needsQuote(value) {
+			buf.WriteString(value)
+		} else {
+			buf.WriteString(strconv.Quote(value))
+		}
+	}
+}
+
+// isNumber returns true if s is a valid json number
+func isNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	// integer part, leading zeros are not allowed
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+	default:
+		return false
+	}
+	// fraction part
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	// exponent part
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes s as a quoted json string
+func writeJSONString(buf buffer.IoBuffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		buf.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString("\\n")
+		case '\r':
+			buf.WriteString("\\r")
+		case '\t':
+			buf.WriteString("\\t")
+		default:
+			buf.WriteString("\\u00")
+			buf.WriteByte(hexDigits[c>>4])
+			buf.WriteByte(hexDigits[c&0xf])
+		}
+		start = i + 1
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+// needsQuote returns true if the logfmt value contains space, '=', '"' or the characters that are not printable
+func needsQuote(s string) bool {
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/buffer"
+)
+
+// recordSink records the access logs
+type recordSink struct {
+	logs     []string
+	disabled bool
+}
+
+func (s *recordSink) Write(_ context.Context, _ api.HeaderMap, _ api.HeaderMap, _ api.RequestInfo, buf buffer.IoBuffer) {
+	s.logs = append(s.logs, buf.String())
+	buffer.PutIoBuffer(buf)
+}
+
+func (s *recordSink) Toggle(disable bool) {
+	s.disabled = disable
+}
+
+func (s *recordSink) Disable() bool {
+	return s.disabled
+}
+
+func newRecordAccessLog(t *testing.T, config *v2.AccessLog) (api.AccessLog, *recordSink) {
+	sink := &recordSink{}
+	RegisterAccessLogSink("record", func(_ map[string]interface{}) (AccessLogSink, error) {
+		return sink, nil
+	})
+	config.Sink = &v2.AccessLogSinkConfig{Type: "record"}
+	lg, err := NewAccessLogWithConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lg, sink
+}
+
+func TestJSONAccessLog(t *testing.T) {
+	registerTestVarDefs()
+	DefaultDisableAccessLog = false
+
+	lg, sink := newRecordAccessLog(t, &v2.AccessLog{
+		FormatType: v2.AccessLogFormatJSON,
+		Fields: map[string]string{
+			"bytes_sent":    "%bytes_sent%",
+			"upstream":      "%upstream_local_address%",
+			"upstream_host": "%upstream_host%",
+			"message":       "say \"hi\"\n",
+			"sent":          "%bytes_sent% bytes",
+		},
+	})
+	lg.Log(prepareLocalIpv6Ctx(), nil, nil, nil)
+	if len(sink.logs) != 1 {
+		t.Fatalf("expected 1 log, but got %d", len(sink.logs))
+	}
+	expected := `{"bytes_sent":2048,"message":"say \"hi\"\n","sent":"2048 bytes","upstream":"127.0.0.1:23456"}` + "\n"
+	if sink.logs[0] != expected {
+		t.Fatalf("unexpected json log: %s", sink.logs[0])
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(sink.logs[0]), &m); err != nil {
+		t.Fatalf("invalid json log: %v", err)
+	}
+}
+
+func TestLogfmtAccessLog(t *testing.T) {
+	registerTestVarDefs()
+	DefaultDisableAccessLog = false
+
+	lg, sink := newRecordAccessLog(t, &v2.AccessLog{
+		FormatType: v2.AccessLogFormatLogfmt,
+		Fields: map[string]string{
+			"bytes_sent":    "%bytes_sent%",
+			"upstream_host": "%upstream_host%",
+			"message":       "say \"hi\"",
+			"text":          "a=b",
+			"plain":         "mosn",
+		},
+	})
+	lg.Log(prepareLocalIpv6Ctx(), nil, nil, nil)
+	expected := `bytes_sent=2048 message="say \"hi\"" plain=mosn text="a=b"` + "\n"
+	if len(sink.logs) != 1 || sink.logs[0] != expected {
+		t.Fatalf("unexpected logfmt log: %v", sink.logs)
+	}
+}
+
+func TestAccessLogConfig(t *testing.T) {
+	for i, cfg := range []*v2.AccessLog{
+		{FormatType: "unknown"},
+		{FormatType: v2.AccessLogFormatJSON},
+		{FormatType: v2.AccessLogFormatLogfmt, Fields: map[string]string{"a b": "%bytes_sent%"}},
+		{FormatType: v2.AccessLogFormatJSON, Fields: map[string]string{"a": "%bytes_sent"}},
+		{Sink: &v2.AccessLogSinkConfig{Type: "unknown"}},
+		{Sink: &v2.AccessLogSinkConfig{Type: AccessLogSinkSyslog, Config: map[string]interface{}{"network": "tcp"}}},
+		{Filter: &v2.AccessLogFilterConfig{StatusCode: &v2.AccessLogComparison{Op: "unknown"}}},
+	} {
+		if _, err := NewAccessLogWithConfig(cfg); err == nil {
+			t.Errorf("#%d invalid config should be failed", i)
+		}
+	}
+}
+
+func TestAccessLogFilter(t *testing.T) {
+	registerTestVarDefs()
+	DefaultDisableAccessLog = false
+
+	zero := 0.0
+	for i, tc := range []struct {
+		filter   *v2.AccessLogFilterConfig
+		code     int
+		duration time.Duration
+		logged   bool
+	}{
+		{&v2.AccessLogFilterConfig{StatusCode: &v2.AccessLogComparison{Value: 500}}, 503, 0, true},
+		{&v2.AccessLogFilterConfig{StatusCode: &v2.AccessLogComparison{Value: 500}}, 200, 0, false},
+		{&v2.AccessLogFilterConfig{StatusCode: &v2.AccessLogComparison{Op: v2.AccessLogCompareEQ, Value: 404}}, 404, 0, true},
+		{&v2.AccessLogFilterConfig{StatusCode: &v2.AccessLogComparison{Op: v2.AccessLogCompareNE, Value: 200}}, 200, 0, false},
+		{&v2.AccessLogFilterConfig{Duration: &v2.AccessLogComparison{Op: v2.AccessLogCompareGT, Value: 100}}, 200, time.Second, true},
+		{&v2.AccessLogFilterConfig{Duration: &v2.AccessLogComparison{Op: v2.AccessLogCompareGT, Value: 100}}, 200, time.Millisecond, false},
+		{&v2.AccessLogFilterConfig{
+			StatusCode: &v2.AccessLogComparison{Op: v2.AccessLogCompareLT, Value: 300},
+			Duration:   &v2.AccessLogComparison{Op: v2.AccessLogCompareLE, Value: 100},
+		}, 200, time.Second, false},
+		{&v2.AccessLogFilterConfig{SampleRate: &zero}, 200, 0, false},
+	} {
+		lg, sink := newRecordAccessLog(t, &v2.AccessLog{
+			Format: "%response_code%",
+			Filter: tc.filter,
+		})
+		info := &mock_requestInfo{
+			responseCode: tc.code,
+			startTime:    time.Now().Add(-tc.duration),
+		}
+		ctx := context.WithValue(prepareLocalIpv6Ctx(), requestInfoKey, api.RequestInfo(info))
+		lg.Log(ctx, nil, nil, info)
+		if logged := len(sink.logs) == 1; logged != tc.logged {
+			t.Errorf("#%d logged expected %v, but got %v", i, tc.logged, logged)
+		}
+	}
+}
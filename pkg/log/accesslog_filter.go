@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+var (
+	ErrUnknownCompareOp  = errors.New("access log filter op must be one of eq, ne, ge, gt, le and lt")
+	ErrInvalidSampleRate = errors.New("access log filter sample_rate should be in the range [0, 1]")
+)
+
+// accessLogFilter chooses the requests to be logged
+type accessLogFilter struct {
+	statusCode *comparison
+	// duration is compared in milliseconds
+	duration   *comparison
+	sampleRate float64
+	sample     bool
+}
+
+type comparison struct {
+	op    string
+	value uint64
+}
+
+func newComparison(cfg *v2.AccessLogComparison) (*comparison, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	c := &comparison{
+		op:    cfg.Op,
+		value: cfg.Value,
+	}
+	switch c.op {
+	case "":
+		c.op = v2.AccessLogCompareGE
+	case v2.AccessLogCompareEQ, v2.AccessLogCompareNE, v2.AccessLogCompareGE,
+		v2.AccessLogCompareGT, v2.AccessLogCompareLE, v2.AccessLogCompareLT:
+	default:
+		return nil, ErrUnknownCompareOp
+	}
+	return c, nil
+}
+
+func (c *comparison) match(v uint64) bool {
+	switch c.op {
+	case v2.AccessLogCompareEQ:
+		return v == c.value
+	case v2.AccessLogCompareNE:
+		return v != c.value
+	case v2.AccessLogCompareGT:
+		return v > c.value
+	case v2.AccessLogCompareLE:
+		return v <= c.value
+	case v2.AccessLogCompareLT:
+		return v < c.value
+	default:
+		return v >= c.value
+	}
+}
+
+// newAccessLogFilter returns nil if no filter is configured
+func newAccessLogFilter(cfg *v2.AccessLogFilterConfig) (*accessLogFilter, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var err error
+	f := &accessLogFilter{}
+	if f.statusCode, err = newComparison(cfg.StatusCode); err != nil {
+		return nil, err
+	}
+	if f.duration, err = newComparison(cfg.Duration); err != nil {
+		return nil, err
+	}
+	if cfg.SampleRate != nil {
+		if *cfg.SampleRate < 0 || *cfg.SampleRate > 1 {
+			return nil, ErrInvalidSampleRate
+		}
+		f.sample = true
+		f.sampleRate = *cfg.SampleRate
+	}
+	return f, nil
+}
+
+// match returns true if the request matches all of the conditions,
+// the request is not matched if a condition of the request info is configured but the request info is nil
+func (f *accessLogFilter) match(requestInfo api.RequestInfo) bool {
+	if f.statusCode != nil || f.duration != nil {
+		if requestInfo == nil {
+			return false
+		}
+		if f.statusCode != nil && !f.statusCode.match(uint64(requestInfo.ResponseCode())) {
+			return false
+		}
+		if f.duration != nil && !f.duration.match(uint64(requestInfo.Duration()/time.Millisecond)) {
+			return false
+		}
+	}
+	if f.sample && rand.Float64() >= f.sampleRate {
+		return false
+	}
+	return true
+}
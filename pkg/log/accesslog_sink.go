@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mosn.io/api"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/log"
+)
+
+// The built-in access log sinks
+const (
+	AccessLogSinkFile   = "file"
+	AccessLogSinkStdout = "stdout"
+	AccessLogSinkSyslog = "syslog"
+)
+
+var (
+	ErrInvalidSyslogNetwork = errors.New("access log syslog network must be one of unix, tcp and udp")
+	ErrEmptySyslogAddress   = errors.New("access log syslog address must be configured for tcp and udp")
+)
+
+// AccessLogSink writes the access logs
+type AccessLogSink interface {
+	// Write writes an access log, buf is the encoded log that ends with a newline, and it is owned by the sink.
+	// the request is provided for the sinks that build the logs by themselves
+	Write(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo, buf buffer.IoBuffer)
+	// Toggle disables the sink if disable is true, otherwise enables it
+	Toggle(disable bool)
+	// Disable returns true if the sink is disabled
+	Disable() bool
+}
+
+// AccessLogSinkCreator creates an AccessLogSink according to config
+type AccessLogSinkCreator func(config map[string]interface{}) (AccessLogSink, error)
+
+var accessLogSinkFactory = map[string]AccessLogSinkCreator{
+	AccessLogSinkFile:   createFileSink,
+	AccessLogSinkStdout: createStdoutSink,
+	AccessLogSinkSyslog: createSyslogSink,
+}
+
+// RegisterAccessLogSink registers the sinkType as AccessLogSinkCreator
+func RegisterAccessLogSink(sinkType string, creator AccessLogSinkCreator) {
+	accessLogSinkFactory[sinkType] = creator
+}
+
+// CreateAccessLogSink creates an AccessLogSink according to sinkType
+func CreateAccessLogSink(sinkType string, config map[string]interface{}) (AccessLogSink, error) {
+	if creator, ok := accessLogSinkFactory[sinkType]; ok {
+		sink, err := creator(config)
+		if err != nil {
+			return nil, fmt.Errorf("create access log sink failed: %v", err)
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("unsupported access log sink type: %v", sinkType)
+}
+
+// loggerSink writes the access logs by the logger
+type loggerSink struct {
+	*log.Logger
+}
+
+func (s *loggerSink) Write(_ context.Context, _ api.HeaderMap, _ api.HeaderMap, _ api.RequestInfo, buf buffer.IoBuffer) {
+	s.Print(buf, true)
+}
+
+// fileSinkConfig configures the file sink
+type fileSinkConfig struct {
+	Path string `json:"path,omitempty"`
+	// Roller is the rotation of the file, such as "size=100 age=7 keep=10 compress=on", see log.ParseRoller
+	Roller string `json:"roller,omitempty"`
+}
+
+func createFileSink(config map[string]interface{}) (AccessLogSink, error) {
+	cfg := &fileSinkConfig{}
+	if err := parseSinkConfig(config, cfg); err != nil {
+		return nil, err
+	}
+	var roller *log.Roller
+	if cfg.Roller != "" {
+		r, err := log.ParseRoller(cfg.Roller)
+		if err != nil {
+			return nil, err
+		}
+		roller = r
+	}
+	lg, err := log.GetOrCreateLogger(cfg.Path, roller)
+	if err != nil {
+		return nil, err
+	}
+	return &loggerSink{lg}, nil
+}
+
+func createStdoutSink(_ map[string]interface{}) (AccessLogSink, error) {
+	lg, err := log.GetOrCreateLogger("stdout", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &loggerSink{lg}, nil
+}
+
+// syslogSinkConfig configures the syslog sink
+type syslogSinkConfig struct {
+	// Network is one of unix, tcp and udp, unix means the local syslog daemon
+	Network string `json:"network,omitempty"`
+	// Address is the address of the remote syslog server
+	Address string `json:"address,omitempty"`
+}
+
+func createSyslogSink(config map[string]interface{}) (AccessLogSink, error) {
+	cfg := &syslogSinkConfig{}
+	if err := parseSinkConfig(config, cfg); err != nil {
+		return nil, err
+	}
+	var output string
+	switch cfg.Network {
+	case "", "unix":
+		output = "syslog"
+	case "tcp", "udp":
+		if cfg.Address == "" {
+			return nil, ErrEmptySyslogAddress
+		}
+		output = "syslog+" + cfg.Network + "://" + cfg.Address
+	default:
+		return nil, ErrInvalidSyslogNetwork
+	}
+	lg, err := log.GetOrCreateLogger(output, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &loggerSink{lg}, nil
+}
+
+func parseSinkConfig(config map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -225,7 +225,7 @@ func TestAccessLogManage(t *testing.T) {
 	// all accesslog is disabled
 	for _, lg := range logs {
 		alg := lg.(*accesslog)
-		if !alg.logger.Disable() {
+		if !alg.sink.Disable() {
 			t.Fatal("some access log is enabled")
 		}
 	}
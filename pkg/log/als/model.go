@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package als
+
+import (
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/types"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// The messages are the subset of the envoy access log service (envoy.service.accesslog.v2) that is used by MOSN,
+// which is not contained in the vendored packages. The unused fields are omitted, and the oneof fields
+// of the messages are declared as plain pointer fields as the wire format is the same.
+
+// HTTP protocol versions of HTTPAccessLogEntry
+const (
+	ProtocolUnspecified int32 = 0
+	ProtocolHTTP10      int32 = 1
+	ProtocolHTTP11      int32 = 2
+	ProtocolHTTP2       int32 = 3
+)
+
+// StreamAccessLogsMessage is the message sent in the StreamAccessLogs stream,
+// only one of HttpLogs and TcpLogs should be set
+type StreamAccessLogsMessage struct {
+	// Identifier is only set in the first message of the stream
+	Identifier *StreamAccessLogsMessage_Identifier           `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	HttpLogs   *StreamAccessLogsMessage_HTTPAccessLogEntries `protobuf:"bytes,2,opt,name=http_logs,json=httpLogs,proto3" json:"http_logs,omitempty"`
+	TcpLogs    *StreamAccessLogsMessage_TCPAccessLogEntries  `protobuf:"bytes,3,opt,name=tcp_logs,json=tcpLogs,proto3" json:"tcp_logs,omitempty"`
+}
+
+func (m *StreamAccessLogsMessage) Reset()         { *m = StreamAccessLogsMessage{} }
+func (m *StreamAccessLogsMessage) String() string { return proto.CompactTextString(m) }
+func (*StreamAccessLogsMessage) ProtoMessage()    {}
+
+// StreamAccessLogsMessage_Identifier identifies the node and the log of the stream
+type StreamAccessLogsMessage_Identifier struct {
+	Node    *core.Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	LogName string     `protobuf:"bytes,2,opt,name=log_name,json=logName,proto3" json:"log_name,omitempty"`
+}
+
+func (m *StreamAccessLogsMessage_Identifier) Reset()         { *m = StreamAccessLogsMessage_Identifier{} }
+func (m *StreamAccessLogsMessage_Identifier) String() string { return proto.CompactTextString(m) }
+func (*StreamAccessLogsMessage_Identifier) ProtoMessage()    {}
+
+type StreamAccessLogsMessage_HTTPAccessLogEntries struct {
+	LogEntry []*HTTPAccessLogEntry `protobuf:"bytes,1,rep,name=log_entry,json=logEntry,proto3" json:"log_entry,omitempty"`
+}
+
+func (m *StreamAccessLogsMessage_HTTPAccessLogEntries) Reset() {
+	*m = StreamAccessLogsMessage_HTTPAccessLogEntries{}
+}
+func (m *StreamAccessLogsMessage_HTTPAccessLogEntries) String() string {
+	return proto.CompactTextString(m)
+}
+func (*StreamAccessLogsMessage_HTTPAccessLogEntries) ProtoMessage() {}
+
+type StreamAccessLogsMessage_TCPAccessLogEntries struct {
+	LogEntry []*TCPAccessLogEntry `protobuf:"bytes,1,rep,name=log_entry,json=logEntry,proto3" json:"log_entry,omitempty"`
+}
+
+func (m *StreamAccessLogsMessage_TCPAccessLogEntries) Reset() {
+	*m = StreamAccessLogsMessage_TCPAccessLogEntries{}
+}
+func (m *StreamAccessLogsMessage_TCPAccessLogEntries) String() string {
+	return proto.CompactTextString(m)
+}
+func (*StreamAccessLogsMessage_TCPAccessLogEntries) ProtoMessage() {}
+
+// StreamAccessLogsResponse is returned when the stream is closed
+type StreamAccessLogsResponse struct {
+}
+
+func (m *StreamAccessLogsResponse) Reset()         { *m = StreamAccessLogsResponse{} }
+func (m *StreamAccessLogsResponse) String() string { return proto.CompactTextString(m) }
+func (*StreamAccessLogsResponse) ProtoMessage()    {}
+
+// TCPAccessLogEntry is the access log of a tcp connection
+type TCPAccessLogEntry struct {
+	CommonProperties *AccessLogCommon `protobuf:"bytes,1,opt,name=common_properties,json=commonProperties,proto3" json:"common_properties,omitempty"`
+}
+
+func (m *TCPAccessLogEntry) Reset()         { *m = TCPAccessLogEntry{} }
+func (m *TCPAccessLogEntry) String() string { return proto.CompactTextString(m) }
+func (*TCPAccessLogEntry) ProtoMessage()    {}
+
+// HTTPAccessLogEntry is the access log of a http request
+type HTTPAccessLogEntry struct {
+	CommonProperties *AccessLogCommon        `protobuf:"bytes,1,opt,name=common_properties,json=commonProperties,proto3" json:"common_properties,omitempty"`
+	ProtocolVersion  int32                   `protobuf:"varint,2,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Request          *HTTPRequestProperties  `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	Response         *HTTPResponseProperties `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+}
+
+func (m *HTTPAccessLogEntry) Reset()         { *m = HTTPAccessLogEntry{} }
+func (m *HTTPAccessLogEntry) String() string { return proto.CompactTextString(m) }
+func (*HTTPAccessLogEntry) ProtoMessage()    {}
+
+// AccessLogCommon contains the properties that are common to the http and tcp access logs
+type AccessLogCommon struct {
+	DownstreamRemoteAddress    *core.Address    `protobuf:"bytes,2,opt,name=downstream_remote_address,json=downstreamRemoteAddress,proto3" json:"downstream_remote_address,omitempty"`
+	DownstreamLocalAddress     *core.Address    `protobuf:"bytes,3,opt,name=downstream_local_address,json=downstreamLocalAddress,proto3" json:"downstream_local_address,omitempty"`
+	StartTime                  *types.Timestamp `protobuf:"bytes,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	TimeToLastRxByte           *types.Duration  `protobuf:"bytes,6,opt,name=time_to_last_rx_byte,json=timeToLastRxByte,proto3" json:"time_to_last_rx_byte,omitempty"`
+	TimeToFirstUpstreamRxByte  *types.Duration  `protobuf:"bytes,9,opt,name=time_to_first_upstream_rx_byte,json=timeToFirstUpstreamRxByte,proto3" json:"time_to_first_upstream_rx_byte,omitempty"`
+	TimeToLastDownstreamTxByte *types.Duration  `protobuf:"bytes,12,opt,name=time_to_last_downstream_tx_byte,json=timeToLastDownstreamTxByte,proto3" json:"time_to_last_downstream_tx_byte,omitempty"`
+	UpstreamRemoteAddress      *core.Address    `protobuf:"bytes,13,opt,name=upstream_remote_address,json=upstreamRemoteAddress,proto3" json:"upstream_remote_address,omitempty"`
+	UpstreamLocalAddress       *core.Address    `protobuf:"bytes,14,opt,name=upstream_local_address,json=upstreamLocalAddress,proto3" json:"upstream_local_address,omitempty"`
+	UpstreamCluster            string           `protobuf:"bytes,15,opt,name=upstream_cluster,json=upstreamCluster,proto3" json:"upstream_cluster,omitempty"`
+}
+
+func (m *AccessLogCommon) Reset()         { *m = AccessLogCommon{} }
+func (m *AccessLogCommon) String() string { return proto.CompactTextString(m) }
+func (*AccessLogCommon) ProtoMessage()    {}
+
+// HTTPRequestProperties contains the properties of a http request
+type HTTPRequestProperties struct {
+	RequestMethod    core.RequestMethod `protobuf:"varint,1,opt,name=request_method,json=requestMethod,proto3,enum=envoy.api.v2.core.RequestMethod" json:"request_method,omitempty"`
+	Scheme           string             `protobuf:"bytes,2,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Authority        string             `protobuf:"bytes,3,opt,name=authority,proto3" json:"authority,omitempty"`
+	Path             string             `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	UserAgent        string             `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Referer          string             `protobuf:"bytes,7,opt,name=referer,proto3" json:"referer,omitempty"`
+	ForwardedFor     string             `protobuf:"bytes,8,opt,name=forwarded_for,json=forwardedFor,proto3" json:"forwarded_for,omitempty"`
+	RequestId        string             `protobuf:"bytes,9,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	RequestBodyBytes uint64             `protobuf:"varint,12,opt,name=request_body_bytes,json=requestBodyBytes,proto3" json:"request_body_bytes,omitempty"`
+}
+
+func (m *HTTPRequestProperties) Reset()         { *m = HTTPRequestProperties{} }
+func (m *HTTPRequestProperties) String() string { return proto.CompactTextString(m) }
+func (*HTTPRequestProperties) ProtoMessage()    {}
+
+// HTTPResponseProperties contains the properties of a http response
+type HTTPResponseProperties struct {
+	ResponseCode      *types.UInt32Value `protobuf:"bytes,1,opt,name=response_code,json=responseCode,proto3" json:"response_code,omitempty"`
+	ResponseBodyBytes uint64             `protobuf:"varint,3,opt,name=response_body_bytes,json=responseBodyBytes,proto3" json:"response_body_bytes,omitempty"`
+}
+
+func (m *HTTPResponseProperties) Reset()         { *m = HTTPResponseProperties{} }
+func (m *HTTPResponseProperties) String() string { return proto.CompactTextString(m) }
+func (*HTTPResponseProperties) ProtoMessage()    {}
@@ -0,0 +1,331 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package als
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/types"
+	proto "github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/http2"
+	mosnsync "mosn.io/mosn/pkg/sync"
+	mosntypes "mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	// AccessLogSinkGRPC is the sink type of the grpc access log service
+	AccessLogSinkGRPC = "grpc"
+
+	streamAccessLogsMethod = "/envoy.service.accesslog.v2.AccessLogService/StreamAccessLogs"
+)
+
+var (
+	defaultLogName       = "mosn"
+	defaultBufferSize    = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	// the max time to wait for the response of the stream when the sink is closed
+	defaultCloseTimeout = time.Second
+
+	ErrEmptyAddress = errors.New("grpc access log service address must be configured")
+)
+
+func init() {
+	log.RegisterAccessLogSink(AccessLogSinkGRPC, createSink)
+}
+
+// sinkConfig configures the grpc access log service sink
+type sinkConfig struct {
+	// Address is the address of the access log service
+	Address string `json:"address,omitempty"`
+	// LogName identifies the access log in the access log service
+	LogName     string `json:"log_name,omitempty"`
+	NodeID      string `json:"node_id,omitempty"`
+	NodeCluster string `json:"node_cluster,omitempty"`
+	// BufferSize is the max number of the access logs waiting to be sent, the new logs are dropped if it is full
+	BufferSize int `json:"buffer_size,omitempty"`
+	// BatchSize is the max number of the access logs in a message
+	BatchSize     int                `json:"batch_size,omitempty"`
+	FlushInterval api.DurationConfig `json:"flush_interval,omitempty"`
+}
+
+// sink streams the access logs to the access log service, the access logs are built from the request info
+// and the headers, the encoded logs are not used.
+// the requests with headers are sent as http logs, and the others are sent as tcp logs
+type sink struct {
+	config    *sinkConfig
+	conn      *grpc.ClientConn
+	batcher   *mosnsync.Batcher
+	disabled  int32
+	closeOnce sync.Once
+	// stream is used only in the sending goroutine, cancel cancels the context of the stream
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func createSink(config map[string]interface{}) (log.AccessLogSink, error) {
+	cfg := &sinkConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Address == "" {
+		return nil, ErrEmptyAddress
+	}
+	// set default value
+	if cfg.LogName == "" {
+		cfg.LogName = defaultLogName
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval.Duration <= 0 {
+		cfg.FlushInterval.Duration = defaultFlushInterval
+	}
+	// the connection is established in background, and is reconnected if it is broken
+	conn, err := grpc.Dial(cfg.Address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	s := &sink{
+		config: cfg,
+		conn:   conn,
+	}
+	s.batcher = mosnsync.NewBatcher(cfg.BufferSize, cfg.BatchSize, cfg.FlushInterval.Duration, s.send)
+	return s, nil
+}
+
+func (s *sink) Toggle(disable bool) {
+	if disable {
+		atomic.StoreInt32(&s.disabled, 1)
+	} else {
+		atomic.StoreInt32(&s.disabled, 0)
+	}
+}
+
+func (s *sink) Disable() bool {
+	return atomic.LoadInt32(&s.disabled) == 1
+}
+
+func (s *sink) Write(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo, buf buffer.IoBuffer) {
+	buffer.PutIoBuffer(buf)
+	if requestInfo == nil {
+		return
+	}
+	var entry proto.Message
+	common := newAccessLogCommon(requestInfo)
+	if reqHeaders == nil {
+		entry = &TCPAccessLogEntry{
+			CommonProperties: common,
+		}
+	} else {
+		entry = newHTTPAccessLogEntry(common, reqHeaders, requestInfo)
+	}
+	if !s.batcher.Add(entry) {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[accesslog] [als] buffer is full, access log is dropped")
+		}
+	}
+}
+
+// Close sends the access logs in the buffer, and closes the stream and the connection
+func (s *sink) Close() error {
+	s.closeOnce.Do(func() {
+		s.batcher.Close()
+		// the sending goroutine is stopped, so the stream can be closed here.
+		// the response is waited, so the sent access logs are not discarded by closing the connection
+		if s.stream != nil {
+			timer := time.AfterFunc(defaultCloseTimeout, s.cancel)
+			if err := s.stream.CloseSend(); err == nil {
+				s.stream.RecvMsg(&StreamAccessLogsResponse{})
+			}
+			timer.Stop()
+			s.cancel()
+			s.stream = nil
+		}
+		s.conn.Close()
+	})
+	return nil
+}
+
+// send sends a batch of the access logs, the http logs and the tcp logs are sent in separate messages
+func (s *sink) send(batch []interface{}) {
+	httpLogs := &StreamAccessLogsMessage_HTTPAccessLogEntries{}
+	tcpLogs := &StreamAccessLogsMessage_TCPAccessLogEntries{}
+	for _, entry := range batch {
+		switch e := entry.(type) {
+		case *HTTPAccessLogEntry:
+			httpLogs.LogEntry = append(httpLogs.LogEntry, e)
+		case *TCPAccessLogEntry:
+			tcpLogs.LogEntry = append(tcpLogs.LogEntry, e)
+		}
+	}
+	if len(httpLogs.LogEntry) > 0 {
+		s.sendMessage(&StreamAccessLogsMessage{HttpLogs: httpLogs})
+	}
+	if len(tcpLogs.LogEntry) > 0 {
+		s.sendMessage(&StreamAccessLogsMessage{TcpLogs: tcpLogs})
+	}
+}
+
+// sendMessage sends the message in the stream, a new stream is created if there is no stream or the stream is broken.
+// the identifier is sent in the first message of the stream
+func (s *sink) sendMessage(msg *StreamAccessLogsMessage) {
+	if s.stream == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := s.conn.NewStream(ctx, &grpc.StreamDesc{
+			StreamName:    "StreamAccessLogs",
+			ClientStreams: true,
+		}, streamAccessLogsMethod)
+		if err != nil {
+			cancel()
+			log.DefaultLogger.Errorf("[accesslog] [als] create stream to %s failed: %v", s.config.Address, err)
+			return
+		}
+		s.stream = stream
+		s.cancel = cancel
+		msg.Identifier = &StreamAccessLogsMessage_Identifier{
+			Node: &core.Node{
+				Id:      s.config.NodeID,
+				Cluster: s.config.NodeCluster,
+			},
+			LogName: s.config.LogName,
+		}
+	}
+	if err := s.stream.SendMsg(msg); err != nil {
+		log.DefaultLogger.Errorf("[accesslog] [als] send access logs to %s failed: %v", s.config.Address, err)
+		s.cancel()
+		s.stream = nil
+	}
+}
+
+func newAccessLogCommon(requestInfo api.RequestInfo) *AccessLogCommon {
+	common := &AccessLogCommon{
+		DownstreamRemoteAddress:    socketAddress(requestInfo.DownstreamRemoteAddress()),
+		DownstreamLocalAddress:     socketAddress(requestInfo.DownstreamLocalAddress()),
+		UpstreamLocalAddress:       parseSocketAddress(requestInfo.UpstreamLocalAddress()),
+		TimeToLastRxByte:           types.DurationProto(requestInfo.RequestReceivedDuration()),
+		TimeToFirstUpstreamRxByte:  types.DurationProto(requestInfo.ResponseReceivedDuration()),
+		TimeToLastDownstreamTxByte: types.DurationProto(requestInfo.Duration()),
+	}
+	if startTime := requestInfo.StartTime(); !startTime.IsZero() {
+		common.StartTime, _ = types.TimestampProto(startTime)
+	}
+	if host := requestInfo.UpstreamHost(); host != nil {
+		common.UpstreamRemoteAddress = parseSocketAddress(host.AddressString())
+		if h, ok := host.(mosntypes.Host); ok && h.ClusterInfo() != nil {
+			common.UpstreamCluster = h.ClusterInfo().Name()
+		}
+	}
+	return common
+}
+
+func newHTTPAccessLogEntry(common *AccessLogCommon, headers api.HeaderMap, requestInfo api.RequestInfo) *HTTPAccessLogEntry {
+	entry := &HTTPAccessLogEntry{
+		CommonProperties: common,
+		Request: &HTTPRequestProperties{
+			RequestBodyBytes: requestInfo.BytesReceived(),
+		},
+		Response: &HTTPResponseProperties{
+			ResponseBodyBytes: requestInfo.BytesSent(),
+		},
+	}
+	if code := requestInfo.ResponseCode(); code > 0 {
+		entry.Response.ResponseCode = &types.UInt32Value{Value: uint32(code)}
+	}
+	req := entry.Request
+	var method string
+	switch h := headers.(type) {
+	case http.RequestHeader:
+		if h.RequestHeader == nil {
+			return entry
+		}
+		entry.ProtocolVersion = ProtocolHTTP11
+		method = string(h.Method())
+		req.Scheme = "http"
+		req.Authority = string(h.Host())
+		req.Path = string(h.RequestURI())
+		req.UserAgent = string(h.UserAgent())
+		req.Referer = string(h.Referer())
+	case *http2.ReqHeader:
+		if h.Req == nil {
+			return entry
+		}
+		entry.ProtocolVersion = ProtocolHTTP2
+		method = h.Req.Method
+		req.Authority = h.Req.Host
+		if h.Req.URL != nil {
+			req.Scheme = h.Req.URL.Scheme
+			req.Path = h.Req.URL.RequestURI()
+		}
+		req.UserAgent = h.Req.UserAgent()
+		req.Referer = h.Req.Referer()
+	}
+	req.RequestMethod = core.RequestMethod(core.RequestMethod_value[method])
+	req.ForwardedFor, _ = headers.Get("x-forwarded-for")
+	req.RequestId, _ = headers.Get("x-request-id")
+	return entry
+}
+
+func socketAddress(addr net.Addr) *core.Address {
+	if addr == nil {
+		return nil
+	}
+	return parseSocketAddress(addr.String())
+}
+
+// parseSocketAddress parses the address as host:port, nil is returned if it is invalid
+func parseSocketAddress(addr string) *core.Address {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	portValue, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &core.Address{
+		Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address: host,
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: uint32(portValue),
+				},
+			},
+		},
+	}
+}
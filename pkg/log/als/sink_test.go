@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package als
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	gogoproto "github.com/gogo/protobuf/proto"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/pkg/buffer"
+)
+
+// gogoMessage decodes the message by gogo proto, as the oneof of the vendored envoy messages can not be decoded by golang proto
+type gogoMessage struct {
+	*StreamAccessLogsMessage
+}
+
+func (m gogoMessage) Unmarshal(b []byte) error {
+	return gogoproto.Unmarshal(b, m.StreamAccessLogsMessage)
+}
+
+// service is an in-process access log service
+type service struct {
+	server   *grpc.Server
+	addr     string
+	mutex    sync.Mutex
+	messages []*StreamAccessLogsMessage
+}
+
+func newService(t *testing.T) *service {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &service{
+		server: grpc.NewServer(),
+		addr:   ln.Addr().String(),
+	}
+	s.server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "envoy.service.accesslog.v2.AccessLogService",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "StreamAccessLogs",
+			ClientStreams: true,
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				for {
+					msg := &StreamAccessLogsMessage{}
+					if err := stream.RecvMsg(gogoMessage{msg}); err != nil {
+						if err == io.EOF {
+							return stream.SendMsg(&StreamAccessLogsResponse{})
+						}
+						return err
+					}
+					s.mutex.Lock()
+					s.messages = append(s.messages, msg)
+					s.mutex.Unlock()
+				}
+			},
+		}},
+	}, struct{}{})
+	go s.server.Serve(ln)
+	return s
+}
+
+func (s *service) Messages() []*StreamAccessLogsMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*StreamAccessLogsMessage{}, s.messages...)
+}
+
+func TestAccessLogService(t *testing.T) {
+	svc := newService(t)
+	defer svc.server.Stop()
+	sink, err := log.CreateAccessLogSink(AccessLogSinkGRPC, map[string]interface{}{
+		"address":        svc.addr,
+		"log_name":       "test",
+		"node_id":        "node",
+		"flush_interval": "10ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.RequestHeader{RequestHeader: &fasthttp.RequestHeader{}}
+	header.SetMethod("POST")
+	header.SetRequestURI("/test")
+	header.SetHost("mosn.io")
+	header.Set("X-Request-Id", "req-1")
+	requestInfo := network.NewRequestInfo()
+	requestInfo.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 12345})
+	requestInfo.SetUpstreamLocalAddress("127.0.0.1:8080")
+	requestInfo.SetResponseCode(200)
+	requestInfo.SetBytesSent(10)
+	sink.Write(nil, header, nil, requestInfo, buffer.GetIoBuffer(16))
+	// the connection without request headers is sent as tcp log
+	sink.Write(nil, nil, nil, requestInfo, buffer.GetIoBuffer(16))
+
+	var messages []*StreamAccessLogsMessage
+	for i := 0; i < 100; i++ {
+		if messages = svc.Messages(); len(messages) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, but got %d", len(messages))
+	}
+	httpMsg, tcpMsg := messages[0], messages[1]
+	if id := httpMsg.Identifier; id == nil || id.LogName != "test" || id.Node.Id != "node" {
+		t.Fatalf("the identifier should be sent in the first message: %v", id)
+	}
+	if tcpMsg.Identifier != nil || tcpMsg.TcpLogs == nil || len(tcpMsg.TcpLogs.LogEntry) != 1 {
+		t.Fatalf("unexpected tcp logs message: %v", tcpMsg)
+	}
+	if httpMsg.HttpLogs == nil || len(httpMsg.HttpLogs.LogEntry) != 1 {
+		t.Fatalf("unexpected http logs message: %v", httpMsg)
+	}
+	entry := httpMsg.HttpLogs.LogEntry[0]
+	if entry.ProtocolVersion != ProtocolHTTP11 || entry.Request.RequestMethod != core.POST ||
+		entry.Request.Path != "/test" || entry.Request.Authority != "mosn.io" || entry.Request.RequestId != "req-1" {
+		t.Fatalf("unexpected request: %v", entry.Request)
+	}
+	if entry.Response.ResponseCode.GetValue() != 200 || entry.Response.ResponseBodyBytes != 10 {
+		t.Fatalf("unexpected response: %v", entry.Response)
+	}
+	remote := entry.CommonProperties.DownstreamRemoteAddress.GetSocketAddress()
+	local := entry.CommonProperties.UpstreamLocalAddress.GetSocketAddress()
+	if remote.GetAddress() != "10.0.0.1" || remote.GetPortValue() != 12345 || local.GetPortValue() != 8080 {
+		t.Fatalf("unexpected common properties: %v", entry.CommonProperties)
+	}
+
+	// the disabled sink drops the logs
+	sink.Toggle(true)
+	if !sink.Disable() {
+		t.Fatal("sink should be disabled")
+	}
+}
+
+func TestAccessLogServiceConfig(t *testing.T) {
+	if _, err := log.CreateAccessLogSink(AccessLogSinkGRPC, map[string]interface{}{}); err == nil {
+		t.Fatal("empty address should be failed")
+	}
+}
+
+func TestAccessLogServiceClose(t *testing.T) {
+	svc := newService(t)
+	defer svc.server.Stop()
+	al, err := log.NewAccessLogWithConfig(&v2.AccessLog{
+		Format: "als",
+		Sink: &v2.AccessLogSinkConfig{
+			Type: AccessLogSinkGRPC,
+			Config: map[string]interface{}{
+				"address":        svc.addr,
+				"flush_interval": "1h",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestInfo := network.NewRequestInfo()
+	al.Log(nil, nil, nil, requestInfo)
+	// the buffered access logs are sent when the access log is closed
+	log.CloseAccessLog(al)
+	var messages []*StreamAccessLogsMessage
+	for i := 0; i < 100; i++ {
+		if messages = svc.Messages(); len(messages) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(messages) != 1 || messages[0].TcpLogs == nil || len(messages[0].TcpLogs.LogEntry) != 1 {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+	// the closed access log drops the logs
+	al.Log(nil, nil, nil, requestInfo)
+}
@@ -0,0 +1,404 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shm
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"unsafe"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+// ShmHistogram is a histogram with cumulative buckets, each of the count, sum, min, max and
+// bucket counts is stored in an entry of the shared memory, so the values are kept after hot upgrade.
+// min and max are encoded so the zero value of a new entry means no value is recorded.
+// the sum is stored as the bits of float64, so it does not wrap around if the sum of the durations in nanoseconds
+// exceeds math.MaxInt64, which is about 292 years. Sum returns math.MaxInt64 in that case, while Mean is still correct
+type ShmHistogram struct {
+	buckets []float64
+	count   *int64
+	sum     *uint64
+	min     *uint64
+	max     *uint64
+	// counts are the counts of the values in each bucket, not cumulative. the values larger than
+	// the last bucket are only counted in count
+	counts []*int64
+	// entries are freed when the histogram is stopped, empty if the histogram is not in the shared memory
+	entries []*hashEntry
+}
+
+// encodeMax maps int64 to uint64 in the same order, so the zero value means math.MinInt64
+func encodeMax(v int64) uint64 {
+	return uint64(v) ^ (1 << 63)
+}
+
+func decodeMax(v uint64) int64 {
+	return int64(v ^ (1 << 63))
+}
+
+// encodeMin maps int64 to uint64 in the reverse order, so the zero value means math.MaxInt64
+func encodeMin(v int64) uint64 {
+	return ^encodeMax(v)
+}
+
+func decodeMin(v uint64) int64 {
+	return decodeMax(^v)
+}
+
+// storeLarger stores v if it is larger than the current value
+func storeLarger(addr *uint64, v uint64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if v <= old || atomic.CompareAndSwapUint64(addr, old, v) {
+			return
+		}
+	}
+}
+
+// addFloat adds v to the float64 stored as bits in addr
+func addFloat(addr *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// floatToInt64 converts the float64 to int64, the values out of the range of int64 are limited
+func floatToInt64(v float64) int64 {
+	switch {
+	case v >= math.MaxInt64:
+		return math.MaxInt64
+	case v <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(v)
+}
+
+// NewShmHistogramFunc returns a function that creates the histogram with the buckets,
+// the buckets should be in increasing order
+func NewShmHistogramFunc(name string, buckets []float64) func() gometrics.Histogram {
+	return func() gometrics.Histogram {
+		if defaultZone != nil {
+			if h := allocShmHistogram(name, buckets); h != nil {
+				return h
+			}
+		} else if fallback {
+			return newHistogram(buckets, func(int) unsafe.Pointer {
+				return unsafe.Pointer(new(int64))
+			})
+		}
+		return gometrics.NilHistogram{}
+	}
+}
+
+// allocShmHistogram allocates the entries with the names suffixed by the fields, and the buckets are suffixed by the upper bounds,
+// so the values of the buckets are not reused if the buckets are changed. nil is returned if the zone is full
+func allocShmHistogram(name string, buckets []float64) *ShmHistogram {
+	names := make([]string, 0, len(buckets)+4)
+	names = append(names, name+".count", name+".sum", name+".min", name+".max")
+	for _, bucket := range buckets {
+		names = append(names, name+".le_"+strconv.FormatFloat(bucket, 'g', -1, 64))
+	}
+	entries := make([]*hashEntry, 0, len(names))
+	for _, n := range names {
+		entry, err := defaultZone.alloc(n)
+		if err != nil {
+			for _, e := range entries {
+				defaultZone.free(e)
+			}
+			return nil
+		}
+		entries = append(entries, entry)
+	}
+	h := newHistogram(buckets, func(i int) unsafe.Pointer {
+		return unsafe.Pointer(&entries[i].value)
+	})
+	h.entries = entries
+	return h
+}
+
+// newHistogram creates the histogram, value returns the address of the i-th field in the order of
+// count, sum, min, max and the buckets
+func newHistogram(buckets []float64, value func(i int) unsafe.Pointer) *ShmHistogram {
+	h := &ShmHistogram{
+		buckets: buckets,
+		count:   (*int64)(value(0)),
+		sum:     (*uint64)(value(1)),
+		min:     (*uint64)(value(2)),
+		max:     (*uint64)(value(3)),
+		counts:  make([]*int64, len(buckets)),
+	}
+	for i := range buckets {
+		h.counts[i] = (*int64)(value(i + 4))
+	}
+	return h
+}
+
+// Update records the value
+func (h *ShmHistogram) Update(v int64) {
+	if i := sort.SearchFloat64s(h.buckets, float64(v)); i < len(h.buckets) {
+		atomic.AddInt64(h.counts[i], 1)
+	}
+	addFloat(h.sum, float64(v))
+	storeLarger(h.min, encodeMin(v))
+	storeLarger(h.max, encodeMax(v))
+	atomic.AddInt64(h.count, 1)
+}
+
+// Clear clears all of the values
+func (h *ShmHistogram) Clear() {
+	atomic.StoreInt64(h.count, 0)
+	atomic.StoreUint64(h.sum, 0)
+	atomic.StoreUint64(h.min, 0)
+	atomic.StoreUint64(h.max, 0)
+	for _, c := range h.counts {
+		atomic.StoreInt64(c, 0)
+	}
+}
+
+// Snapshot returns a read-only copy of the histogram.
+// the buckets are loaded before the count, as Update increases the bucket before the count,
+// and the count is limited to be not less than the cumulative count of the last bucket, which may be
+// broken by a concurrent Clear
+func (h *ShmHistogram) Snapshot() gometrics.Histogram {
+	s := &HistogramSnapshot{
+		buckets: h.buckets,
+		counts:  make([]uint64, len(h.buckets)),
+	}
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += uint64(atomic.LoadInt64(c))
+		s.counts[i] = cumulative
+	}
+	s.count = atomic.LoadInt64(h.count)
+	if s.count < int64(cumulative) {
+		s.count = int64(cumulative)
+	}
+	s.sum = math.Float64frombits(atomic.LoadUint64(h.sum))
+	if s.count > 0 {
+		s.min = decodeMin(atomic.LoadUint64(h.min))
+		s.max = decodeMax(atomic.LoadUint64(h.max))
+	}
+	return s
+}
+
+func (h *ShmHistogram) Buckets() []float64 {
+	return h.buckets
+}
+
+func (h *ShmHistogram) BucketCounts() []uint64 {
+	return h.snapshot().counts
+}
+
+func (h *ShmHistogram) Count() int64 {
+	return atomic.LoadInt64(h.count)
+}
+
+func (h *ShmHistogram) Sum() int64 {
+	return floatToInt64(math.Float64frombits(atomic.LoadUint64(h.sum)))
+}
+
+func (h *ShmHistogram) Min() int64 {
+	return h.snapshot().Min()
+}
+
+func (h *ShmHistogram) Max() int64 {
+	return h.snapshot().Max()
+}
+
+func (h *ShmHistogram) Mean() float64 {
+	return h.snapshot().Mean()
+}
+
+func (h *ShmHistogram) Percentile(p float64) float64 {
+	return h.snapshot().Percentile(p)
+}
+
+func (h *ShmHistogram) Percentiles(ps []float64) []float64 {
+	return h.snapshot().Percentiles(ps)
+}
+
+func (h *ShmHistogram) StdDev() float64 {
+	return h.snapshot().StdDev()
+}
+
+func (h *ShmHistogram) Variance() float64 {
+	return h.snapshot().Variance()
+}
+
+// Sample returns a NilSample, the values are not sampled
+func (h *ShmHistogram) Sample() gometrics.Sample {
+	return gometrics.NilSample{}
+}
+
+func (h *ShmHistogram) snapshot() *HistogramSnapshot {
+	return h.Snapshot().(*HistogramSnapshot)
+}
+
+// stoppable
+func (h *ShmHistogram) Stop() {
+	if defaultZone != nil {
+		for _, entry := range h.entries {
+			defaultZone.free(entry)
+		}
+	}
+}
+
+// HistogramSnapshot is a read-only copy of ShmHistogram
+type HistogramSnapshot struct {
+	buckets []float64
+	// counts are cumulative
+	counts []uint64
+	count  int64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+func (s *HistogramSnapshot) Buckets() []float64 {
+	return s.buckets
+}
+
+func (s *HistogramSnapshot) BucketCounts() []uint64 {
+	return s.counts
+}
+
+func (s *HistogramSnapshot) Clear() {
+	panic("Clear called on a HistogramSnapshot")
+}
+
+func (s *HistogramSnapshot) Update(int64) {
+	panic("Update called on a HistogramSnapshot")
+}
+
+func (s *HistogramSnapshot) Snapshot() gometrics.Histogram {
+	return s
+}
+
+func (s *HistogramSnapshot) Sample() gometrics.Sample {
+	return gometrics.NilSample{}
+}
+
+func (s *HistogramSnapshot) Count() int64 {
+	return s.count
+}
+
+func (s *HistogramSnapshot) Sum() int64 {
+	return floatToInt64(s.sum)
+}
+
+func (s *HistogramSnapshot) Min() int64 {
+	return s.min
+}
+
+func (s *HistogramSnapshot) Max() int64 {
+	return s.max
+}
+
+func (s *HistogramSnapshot) Mean() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.sum / float64(s.count)
+}
+
+// Percentile estimates the percentile by the linear interpolation in the bucket that contains it,
+// the result is limited by the min and max
+func (s *HistogramSnapshot) Percentile(p float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := p * float64(s.count)
+	i := sort.Search(len(s.counts), func(i int) bool {
+		return float64(s.counts[i]) >= rank
+	})
+	lower, upper := float64(s.min), float64(s.max)
+	var below uint64
+	if i > 0 {
+		below = s.counts[i-1]
+		lower = math.Max(lower, s.buckets[i-1])
+	}
+	if i < len(s.buckets) {
+		upper = math.Min(upper, s.buckets[i])
+	}
+	inBucket := s.bucketCount(i)
+	if inBucket == 0 || upper <= lower {
+		return upper
+	}
+	return lower + (upper-lower)*(rank-float64(below))/float64(inBucket)
+}
+
+func (s *HistogramSnapshot) Percentiles(ps []float64) []float64 {
+	values := make([]float64, len(ps))
+	for i, p := range ps {
+		values[i] = s.Percentile(p)
+	}
+	return values
+}
+
+// Variance estimates the variance by the midpoints of the buckets
+func (s *HistogramSnapshot) Variance() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	mean := s.Mean()
+	var sum float64
+	for i := 0; i <= len(s.buckets); i++ {
+		n := s.bucketCount(i)
+		if n == 0 {
+			continue
+		}
+		lower, upper := float64(s.min), float64(s.max)
+		if i > 0 {
+			lower = math.Max(lower, s.buckets[i-1])
+		}
+		if i < len(s.buckets) {
+			upper = math.Min(upper, s.buckets[i])
+		}
+		d := (lower+upper)/2 - mean
+		sum += d * d * float64(n)
+	}
+	return sum / float64(s.count)
+}
+
+func (s *HistogramSnapshot) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// bucketCount returns the count of the values in the i-th bucket, the bucket after the last one is +Inf
+func (s *HistogramSnapshot) bucketCount(i int) uint64 {
+	total := uint64(s.count)
+	if i < len(s.counts) {
+		total = s.counts[i]
+	}
+	if i > 0 {
+		return total - s.counts[i-1]
+	}
+	return total
+}
+
+var (
+	_ types.BucketHistogram = (*ShmHistogram)(nil)
+	_ types.BucketHistogram = (*HistogramSnapshot)(nil)
+)
@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shm
+
+import (
+	"math"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+func testHistogram(t *testing.T, h gometrics.Histogram) {
+	bh, ok := h.(types.BucketHistogram)
+	if !ok {
+		t.Fatalf("histogram is not a bucket histogram: %T", h)
+	}
+	if bh.Count() != 0 || bh.Min() != 0 || bh.Max() != 0 || bh.Percentile(0.5) != 0 {
+		t.Error("empty histogram expected zero values")
+	}
+	for _, v := range []int64{5, 10, 15, 30, 200} {
+		bh.Update(v)
+	}
+	snapshot := bh.Snapshot().(types.BucketHistogram)
+	if !reflect.DeepEqual(snapshot.BucketCounts(), []uint64{2, 3, 4}) {
+		t.Errorf("unexpected bucket counts: %v", snapshot.BucketCounts())
+	}
+	if snapshot.Count() != 5 || snapshot.Sum() != 260 || snapshot.Min() != 5 || snapshot.Max() != 200 {
+		t.Errorf("unexpected snapshot: count %d, sum %d, min %d, max %d", snapshot.Count(), snapshot.Sum(), snapshot.Min(), snapshot.Max())
+	}
+	if snapshot.Mean() != 52 {
+		t.Errorf("unexpected mean: %f", snapshot.Mean())
+	}
+	// rank 2.5 is in the bucket (10, 20]
+	if p := snapshot.Percentile(0.5); math.Abs(p-15) > 1e-9 {
+		t.Errorf("unexpected p50: %f", p)
+	}
+	// rank 5 is in the bucket (50, +Inf), which is limited by max
+	if p := snapshot.Percentile(1); p != 200 {
+		t.Errorf("unexpected p100: %f", p)
+	}
+	// the snapshot is not changed
+	bh.Update(-1)
+	if snapshot.Count() != 5 || bh.Count() != 6 || bh.Min() != -1 {
+		t.Errorf("unexpected count after update: snapshot %d, histogram %d, min %d", snapshot.Count(), bh.Count(), bh.Min())
+	}
+	bh.Clear()
+	if bh.Count() != 0 || bh.Sum() != 0 || bh.Max() != 0 || !reflect.DeepEqual(bh.BucketCounts(), []uint64{0, 0, 0}) {
+		t.Error("histogram is not cleared")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	// just for test
+	originPath := types.MosnConfigPath
+	types.MosnConfigPath = "."
+
+	defer func() {
+		types.MosnConfigPath = originPath
+	}()
+	zone := InitMetricsZone("TestHistogram", 10*1024)
+	defer func() {
+		zone.Detach()
+		Reset()
+	}()
+
+	buckets := []float64{10, 20, 50}
+	h := NewShmHistogramFunc("TestHistogram", buckets)()
+	testHistogram(t, h)
+
+	// the values are kept in the shared memory
+	h.Update(15)
+	h2 := NewShmHistogramFunc("TestHistogram", buckets)()
+	if h2.Count() != 1 || h2.Max() != 15 {
+		t.Errorf("histogram values are not shared, count %d, max %d", h2.Count(), h2.Max())
+	}
+	h.(*ShmHistogram).Stop()
+	h2.(*ShmHistogram).Stop()
+}
+
+func TestHistogramFallback(t *testing.T) {
+	testHistogram(t, NewShmHistogramFunc("TestHistogramFallback", []float64{10, 20, 50})())
+}
+
+func TestHistogramSnapshotConsistency(t *testing.T) {
+	h := newHistogram([]float64{10, 20}, func(int) unsafe.Pointer {
+		return unsafe.Pointer(new(int64))
+	})
+	// a bucket is increased without the count, as a concurrent update or clear
+	h.Update(5)
+	atomic.AddInt64(h.counts[1], 1)
+	snapshot := h.Snapshot()
+	if snapshot.Count() != 2 {
+		t.Fatalf("the count should not be less than the last bucket: %d", snapshot.Count())
+	}
+	if v := snapshot.Variance(); math.IsNaN(v) || v > 100 {
+		t.Errorf("unexpected variance: %f", v)
+	}
+	if p := snapshot.Percentile(0.99); p > 20 {
+		t.Errorf("unexpected p99: %f", p)
+	}
+
+	// the sum does not wrap around
+	h.Clear()
+	h.Update(math.MaxInt64)
+	h.Update(math.MaxInt64)
+	if h.Sum() != math.MaxInt64 || h.Mean() != math.MaxInt64 {
+		t.Errorf("unexpected sum %d and mean %f", h.Sum(), h.Mean())
+	}
+}
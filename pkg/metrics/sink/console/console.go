@@ -66,6 +66,14 @@ func (sink *consoleSink) Flush(writer io.Writer, ms []types.Metrics) {
 				h := metric.Snapshot()
 				namespaceData[key+"_min"] = strconv.FormatInt(h.Min(), 10)
 				namespaceData[key+"_max"] = strconv.FormatInt(h.Max(), 10)
+				if bh, ok := h.(types.BucketHistogram); ok {
+					namespaceData[key+"_count"] = strconv.FormatInt(bh.Count(), 10)
+					namespaceData[key+"_sum"] = strconv.FormatInt(bh.Sum(), 10)
+					counts := bh.BucketCounts()
+					for i, bucket := range bh.Buckets() {
+						namespaceData[key+"_bucket_"+strconv.FormatFloat(bucket, 'g', -1, 64)] = strconv.FormatUint(counts[i], 10)
+					}
+				}
 			default: //unsupport metrics, ignore
 				return
 			}
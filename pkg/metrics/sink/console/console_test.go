@@ -138,6 +138,36 @@ func TestConsoleMetrics(t *testing.T) {
 	}
 }
 
+func TestConsoleBucketHistogram(t *testing.T) {
+	metrics.ResetAll()
+	defer metrics.ResetAll()
+	if err := metrics.SetHistogramBuckets(map[string][]float64{"t1": {10, 20}}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1"})
+	for _, v := range []int64{5, 15, 25} {
+		m.Histogram("k1").Update(v)
+	}
+	buf := &bytes.Buffer{}
+	NewConsoleSink().Flush(buf, metrics.GetAll())
+	datas := make(map[string]map[string]map[string]string)
+	json.Unmarshal(buf.Bytes(), &datas)
+	ns := datas["t1"]["lbk1.lbv1"]
+	expected := map[string]string{
+		"k1_min":       "5",
+		"k1_max":       "25",
+		"k1_count":     "3",
+		"k1_sum":       "45",
+		"k1_bucket_10": "1",
+		"k1_bucket_20": "2",
+	}
+	for k, v := range expected {
+		if ns[k] != v {
+			t.Errorf("%s expected %s, but got %s", k, v, ns[k])
+		}
+	}
+}
+
 func BenchmarkGetMetrics(b *testing.B) {
 	metrics.ResetAll()
 	// init metrics data
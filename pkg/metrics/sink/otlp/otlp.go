@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/metrics/sink"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+var (
+	sinkType             = "otlp"
+	defaultFlushInterval = 10 * time.Second
+	// the quantiles 0 and 1 are the min and max of the histogram
+	defaultPercentiles = []float64{0, 0.5, 0.75, 0.95, 0.99, 1}
+)
+
+func init() {
+	sink.RegisterSink(sinkType, builder)
+}
+
+// otlpConfig contains config for the OTLP sink
+type otlpConfig struct {
+	v2.OTLPExporterConfig
+	// FlushInterval is the interval to push all of the metrics
+	FlushInterval api.DurationConfig `json:"flush_interval,omitempty"`
+	// Percentiles are the quantiles of the histograms to push, in the range [0, 1]
+	Percentiles []float64 `json:"percentiles,omitempty"`
+}
+
+// otlpSink pushes the metrics to the OpenTelemetry collector.
+// counters are mapped to cumulative monotonic sums, gauges are mapped to gauges,
+// and histograms are mapped to summaries with the quantiles
+type otlpSink struct {
+	exporter    *mosnotlp.Exporter
+	percentiles []float64
+	startTime   uint64
+}
+
+// ~ MetricsSink
+// the metrics are put into the exporter's queue, the writer is not used
+func (osink *otlpSink) Flush(_ io.Writer, ms []types.Metrics) {
+	now := uint64(time.Now().UnixNano())
+	for _, m := range ms {
+		labelKeys, labelVals := m.SortedLabels()
+		if sink.IsExclusionLabels(labelKeys) {
+			continue
+		}
+		attributes := make([]*mosnotlp.KeyValue, 0, len(labelKeys))
+		for i := range labelKeys {
+			attributes = append(attributes, mosnotlp.StringKeyValue(labelKeys[i], labelVals[i]))
+		}
+		prefix := m.Type() + "_"
+
+		m.Each(func(name string, i interface{}) {
+			if sink.IsExclusionKeys(name) {
+				return
+			}
+			var metric *mosnotlp.Metric
+			switch v := i.(type) {
+			case gometrics.Counter:
+				metric = &mosnotlp.Metric{
+					Sum: &mosnotlp.Sum{
+						DataPoints:             []*mosnotlp.NumberDataPoint{osink.numberDataPoint(attributes, now, v.Count())},
+						AggregationTemporality: mosnotlp.AggregationTemporalityCumulative,
+						IsMonotonic:            true,
+					},
+				}
+			case gometrics.Gauge:
+				metric = &mosnotlp.Metric{
+					Gauge: &mosnotlp.Gauge{
+						DataPoints: []*mosnotlp.NumberDataPoint{osink.numberDataPoint(attributes, now, v.Value())},
+					},
+				}
+			case gometrics.Histogram:
+				metric = &mosnotlp.Metric{
+					Summary: &mosnotlp.Summary{
+						DataPoints: []*mosnotlp.SummaryDataPoint{osink.summaryDataPoint(attributes, now, v.Snapshot())},
+					},
+				}
+			default: //unsupport metrics, ignore
+				return
+			}
+			metric.Name = prefix + name
+			osink.exporter.ExportMetric(metric)
+		})
+	}
+}
+
+func (osink *otlpSink) numberDataPoint(attributes []*mosnotlp.KeyValue, now uint64, value int64) *mosnotlp.NumberDataPoint {
+	return &mosnotlp.NumberDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: osink.startTime,
+		TimeUnixNano:      now,
+		Value:             &mosnotlp.NumberDataPoint_AsInt{AsInt: value},
+	}
+}
+
+func (osink *otlpSink) summaryDataPoint(attributes []*mosnotlp.KeyValue, now uint64, snapshot gometrics.Histogram) *mosnotlp.SummaryDataPoint {
+	point := &mosnotlp.SummaryDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: osink.startTime,
+		TimeUnixNano:      now,
+		Count:             uint64(snapshot.Count()),
+		Sum:               float64(snapshot.Sum()),
+	}
+	values := snapshot.Percentiles(osink.percentiles)
+	for i, quantile := range osink.percentiles {
+		value := values[i]
+		// the percentiles of go-metrics are interpolated, the exact min and max are used
+		switch quantile {
+		case 0:
+			value = float64(snapshot.Min())
+		case 1:
+			value = float64(snapshot.Max())
+		}
+		point.QuantileValues = append(point.QuantileValues, &mosnotlp.SummaryDataPoint_ValueAtQuantile{
+			Quantile: quantile,
+			Value:    value,
+		})
+	}
+	return point
+}
+
+// run pushes all of the metrics periodically
+func (osink *otlpSink) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		osink.Flush(nil, metrics.GetAll())
+	}
+}
+
+// NewOTLPSink returns a metrics sink that pushes the metrics over the OpenTelemetry protocol
+func NewOTLPSink(config *otlpConfig) (types.MetricsSink, error) {
+	exporter, err := mosnotlp.NewMetricsExporter(&config.OTLPExporterConfig)
+	if err != nil {
+		return nil, err
+	}
+	osink := &otlpSink{
+		exporter:    exporter,
+		percentiles: config.Percentiles,
+		startTime:   uint64(time.Now().UnixNano()),
+	}
+	if len(osink.percentiles) == 0 {
+		osink.percentiles = defaultPercentiles
+	}
+	return osink, nil
+}
+
+// factory
+func builder(cfg map[string]interface{}) (types.MetricsSink, error) {
+	// parse config
+	otlpCfg := &otlpConfig{}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing otlp sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if err := json.Unmarshal(data, otlpCfg); err != nil {
+		return nil, fmt.Errorf("parsing otlp sink error, err: %v, cfg: %v", err, cfg)
+	}
+	for _, quantile := range otlpCfg.Percentiles {
+		if quantile < 0 || quantile > 1 {
+			return nil, fmt.Errorf("invalid percentile: %v", quantile)
+		}
+	}
+
+	osink, err := NewOTLPSink(otlpCfg)
+	if err != nil {
+		return nil, err
+	}
+	interval := otlpCfg.FlushInterval.Duration
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	utils.GoWithRecover(func() {
+		osink.(*otlpSink).run(interval)
+	}, nil)
+	return osink, nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	proto "github.com/golang/protobuf/proto"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/metrics/sink"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+)
+
+func TestOTLPMetrics(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1"})
+	m.Counter("k1").Inc(3)
+	m.Gauge("k2").Update(0)
+	for i := int64(1); i <= 4; i++ {
+		m.Histogram("k3").Update(i)
+	}
+
+	var mutex sync.Mutex
+	received := map[string]*mosnotlp.Metric{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &mosnotlp.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("unmarshal request failed: %v", err)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, metric := range sm.Metrics {
+					received[metric.Name] = metric
+				}
+			}
+		}
+	}))
+	defer server.Close()
+
+	_, err := sink.CreateMetricsSink("otlp", map[string]interface{}{
+		"protocol":       "http",
+		"endpoint":       server.URL,
+		"flush_interval": "10ms",
+		"batch_interval": "10ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		mutex.Lock()
+		n := len(received)
+		mutex.Unlock()
+		if n == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 3 {
+		t.Fatalf("expected 3 metrics, but got %v", received)
+	}
+	counter := received["t1_k1"]
+	if counter == nil || counter.Sum == nil || !counter.Sum.IsMonotonic || counter.Sum.DataPoints[0].GetAsInt() != 3 {
+		t.Fatalf("unexpected counter: %v", counter)
+	}
+	if attr := counter.Sum.DataPoints[0].Attributes[0]; attr.Key != "lbk1" || attr.Value.GetStringValue() != "lbv1" {
+		t.Fatalf("unexpected attributes: %v", attr)
+	}
+	gauge := received["t1_k2"]
+	if gauge == nil || gauge.Gauge == nil {
+		t.Fatalf("unexpected gauge: %v", gauge)
+	}
+	if _, ok := gauge.Gauge.DataPoints[0].Value.(*mosnotlp.NumberDataPoint_AsInt); !ok {
+		t.Fatalf("zero gauge value should be kept: %v", gauge)
+	}
+	histogram := received["t1_k3"]
+	if histogram == nil || histogram.Summary == nil {
+		t.Fatalf("unexpected histogram: %v", histogram)
+	}
+	point := histogram.Summary.DataPoints[0]
+	quantiles := point.QuantileValues
+	if point.Count != 4 || point.Sum != 10 || quantiles[0].Value != 1 || quantiles[len(quantiles)-1].Value != 4 {
+		t.Fatalf("unexpected summary: %v", point)
+	}
+}
+
+func TestOTLPConfig(t *testing.T) {
+	for i, cfg := range []map[string]interface{}{
+		{},
+		{"endpoint": "127.0.0.1:4317", "percentiles": []float64{1.5}},
+		{"endpoint": "127.0.0.1:4317", "protocol": "unknown"},
+	} {
+		if _, err := builder(cfg); err == nil {
+			t.Errorf("#%d invalid config should be failed", i)
+		}
+	}
+}
@@ -130,9 +130,56 @@ func (psink *promSink) flushHistogram(tracker map[string]bool, buf types.IoBuffe
 	psink.flushGauge(tracker, buf, name+"_min", labels, float64(snapshot.Min()))
 	// max
 	psink.flushGauge(tracker, buf, name+"_max", labels, float64(snapshot.Max()))
+	if h, ok := snapshot.(types.BucketHistogram); ok {
+		psink.flushBuckets(tracker, buf, name, labels, h)
+	}
 	// TODO: flush P90 P95 P99 if configured
 }
 
+// flushBuckets flushes the cumulative buckets, sum and count as a prometheus histogram
+func (psink *promSink) flushBuckets(tracker map[string]bool, buf types.IoBuffer, name string, labels string, h types.BucketHistogram) {
+	// type
+	if !tracker[name] {
+		buf.WriteString("# TYPE ")
+		buf.WriteString(name)
+		buf.WriteString(" histogram\n")
+		tracker[name] = true
+	}
+	leLabels := "le=\""
+	if labels != "" {
+		leLabels = labels + ",le=\""
+	}
+	counts := h.BucketCounts()
+	for i, bucket := range h.Buckets() {
+		writeBucket(buf, name, leLabels, strconv.FormatFloat(bucket, 'g', -1, 64), counts[i])
+	}
+	writeBucket(buf, name, leLabels, "+Inf", uint64(h.Count()))
+
+	buf.WriteString(name)
+	buf.WriteString("_sum{")
+	buf.WriteString(labels)
+	buf.WriteString("} ")
+	writeFloat(buf, float64(h.Sum()))
+	buf.WriteString("\n")
+
+	buf.WriteString(name)
+	buf.WriteString("_count{")
+	buf.WriteString(labels)
+	buf.WriteString("} ")
+	writeFloat(buf, float64(h.Count()))
+	buf.WriteString("\n")
+}
+
+func writeBucket(buf types.IoBuffer, name string, leLabels string, le string, count uint64) {
+	buf.WriteString(name)
+	buf.WriteString("_bucket{")
+	buf.WriteString(leLabels)
+	buf.WriteString(le)
+	buf.WriteString("\"} ")
+	writeFloat(buf, float64(count))
+	buf.WriteString("\n")
+}
+
 func (psink *promSink) flushGauge(tracker map[string]bool, buf types.IoBuffer, name string, labels string, val float64) {
 	// type
 	if !tracker[name] {
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 
@@ -239,6 +240,40 @@ func TestPrometheusMetricsFilter(t *testing.T) {
 	}
 }
 
+func TestPrometheusHistogramBuckets(t *testing.T) {
+	metrics.ResetAll()
+	defer metrics.ResetAll()
+	sink.SetFilterLabels(nil)
+	sink.SetFilterKeys(nil)
+	if err := metrics.SetHistogramBuckets(map[string][]float64{"t1": {10, 20}}); err != nil {
+		t.Fatal(err)
+	}
+	m1, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1"})
+	m2, _ := metrics.NewMetrics("t1", nil)
+	for _, v := range []int64{5, 15, 25} {
+		m1.Histogram("k1").Update(v)
+		m2.Histogram("k1").Update(v)
+	}
+	psink := &promSink{config: &promConfig{}}
+	buf := &bytes.Buffer{}
+	psink.Flush(buf, metrics.GetAll())
+	body := buf.String()
+	for _, expected := range []string{
+		"# TYPE t1_k1 histogram\n",
+		"t1_k1_bucket{lbk1=\"lbv1\",le=\"10\"} 1.0\n",
+		"t1_k1_bucket{lbk1=\"lbv1\",le=\"20\"} 2.0\n",
+		"t1_k1_bucket{lbk1=\"lbv1\",le=\"+Inf\"} 3.0\n",
+		"t1_k1_sum{lbk1=\"lbv1\"} 45.0\n",
+		"t1_k1_count{lbk1=\"lbv1\"} 3.0\n",
+		"t1_k1_bucket{le=\"20\"} 2.0\n",
+		"t1_k1_count{} 3.0\n",
+	} {
+		if strings.Count(body, expected) != 1 {
+			t.Errorf("%q is expected once in the output: %s", expected, body)
+		}
+	}
+}
+
 func TestPrometheusFlatternKey(t *testing.T) {
 	testcase := []struct {
 		input  string
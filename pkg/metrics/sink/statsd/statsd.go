@@ -0,0 +1,333 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statsd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/metrics/sink"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+var (
+	sinkType             = "statsd"
+	defaultAddress       = "127.0.0.1:8125"
+	defaultFlushInterval = 10 * time.Second
+	// the max payload of an udp packet that is not fragmented in the ethernet
+	defaultMaxPacketSize = 1432
+	defaultPercentiles   = []float64{0.5, 0.75, 0.9, 0.99}
+)
+
+func init() {
+	sink.RegisterSink(sinkType, builder)
+}
+
+// statsdConfig contains config for the statsd sink
+type statsdConfig struct {
+	// Address is the udp address of the statsd server
+	Address string `json:"address,omitempty"`
+	// Prefix is prepended to all of the metrics names
+	Prefix string `json:"prefix,omitempty"`
+	// FlushInterval is the interval to send all of the metrics
+	FlushInterval api.DurationConfig `json:"flush_interval,omitempty"`
+	// DogStatsD sends the labels as DogStatsD tags, otherwise the labels are added into the metrics names
+	DogStatsD bool `json:"dogstatsd,omitempty"`
+	// MaxPacketSize is the max size of the udp packet, the metrics lines are batched under it
+	MaxPacketSize int `json:"max_packet_size,omitempty"`
+	// Percentiles are the quantiles of the histograms to send, in the range (0, 1]
+	Percentiles []float64 `json:"percentiles,omitempty"`
+	// HistogramAsTimer sends the histogram percentiles as timers, otherwise they are sent as gauges
+	HistogramAsTimer bool `json:"histogram_as_timer,omitempty"`
+}
+
+// statsdSink sends the metrics to a statsd server.
+// counters are sent as the deltas since the last flush, gauges are sent as gauges,
+// and the percentiles of histograms are sent as gauges or timers.
+// the buckets, count and sum of bucket histograms are sent as counters too
+type statsdSink struct {
+	config *statsdConfig
+	mutex  sync.Mutex
+	// counters records the counts of the last flush, which are used to calculate the deltas
+	counters map[string]int64
+	// conn is the udp connection to the statsd server, it is closed when the sink is stopped
+	conn     net.Conn
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// ~ MetricsSink
+// the metrics lines are batched into packets, each packet is written by a Write call
+func (ssink *statsdSink) Flush(w io.Writer, ms []types.Metrics) {
+	ssink.mutex.Lock()
+	defer ssink.mutex.Unlock()
+
+	pw := &packetWriter{
+		w:    w,
+		size: ssink.config.MaxPacketSize,
+	}
+	for _, m := range ms {
+		labelKeys, labelVals := m.SortedLabels()
+		if sink.IsExclusionLabels(labelKeys) {
+			continue
+		}
+		prefix, tags := ssink.nameAndTags(m.Type(), labelKeys, labelVals)
+
+		m.Each(func(key string, i interface{}) {
+			if sink.IsExclusionKeys(key) {
+				return
+			}
+			name := prefix + sanitize(key)
+			switch v := i.(type) {
+			case gometrics.Counter:
+				ssink.writeCounter(pw, name, tags, v.Count())
+			case gometrics.Gauge:
+				writeGauge(pw, name, tags, strconv.FormatInt(v.Value(), 10), v.Value() < 0)
+			case gometrics.Histogram:
+				ssink.writeHistogram(pw, name, tags, v.Snapshot())
+			default: //unsupport metrics, ignore
+				return
+			}
+		})
+	}
+	pw.Flush()
+}
+
+// nameAndTags returns the prefix of the metrics names and the tags of a types.Metrics
+func (ssink *statsdSink) nameAndTags(typ string, labelKeys, labelVals []string) (string, string) {
+	prefix := ""
+	if ssink.config.Prefix != "" {
+		prefix = ssink.config.Prefix + "."
+	}
+	prefix += sanitize(typ) + "."
+	if ssink.config.DogStatsD {
+		if len(labelKeys) == 0 {
+			return prefix, ""
+		}
+		tags := make([]string, 0, len(labelKeys))
+		for i := range labelKeys {
+			tags = append(tags, sanitizeTag(labelKeys[i])+":"+sanitizeTag(labelVals[i]))
+		}
+		return prefix, "|#" + strings.Join(tags, ",")
+	}
+	// the dots in label values are replaced, so the labels do not change the hierarchy of the names
+	for i := range labelKeys {
+		prefix += sanitizeLabel(labelKeys[i]) + "." + sanitizeLabel(labelVals[i]) + "."
+	}
+	return prefix, ""
+}
+
+func (ssink *statsdSink) writeCounter(pw *packetWriter, name, tags string, count int64) {
+	id := name + tags
+	delta := count - ssink.counters[id]
+	// the counter is reset
+	if delta < 0 {
+		delta = count
+	}
+	ssink.counters[id] = count
+	if delta == 0 {
+		return
+	}
+	pw.WriteLine(name + ":" + strconv.FormatInt(delta, 10) + "|c" + tags)
+}
+
+// writeGauge sends the gauge, a signed value is a delta of the gauge in statsd,
+// so the gauge is set to zero before the negative value is sent
+func writeGauge(pw *packetWriter, name, tags, value string, negative bool) {
+	if negative {
+		pw.WriteLine(name + ":0|g" + tags)
+	}
+	pw.WriteLine(name + ":" + value + "|g" + tags)
+}
+
+func (ssink *statsdSink) writeHistogram(pw *packetWriter, name, tags string, snapshot gometrics.Histogram) {
+	if h, ok := snapshot.(types.BucketHistogram); ok {
+		ssink.writeBuckets(pw, name, tags, h)
+	}
+	if snapshot.Count() == 0 {
+		return
+	}
+	values := snapshot.Percentiles(ssink.config.Percentiles)
+	for i, quantile := range ssink.config.Percentiles {
+		pname := name + "." + percentileName(quantile)
+		value := strconv.FormatFloat(values[i], 'f', -1, 64)
+		if ssink.config.HistogramAsTimer {
+			pw.WriteLine(pname + ":" + value + "|ms" + tags)
+		} else {
+			writeGauge(pw, pname, tags, value, values[i] < 0)
+		}
+	}
+}
+
+// writeBuckets sends the counts of the buckets, the count and the sum of a bucket histogram as counters,
+// so they can be aggregated in the statsd server. the bucket of the upper bound 0.5 is named as bucket.le_0_5
+func (ssink *statsdSink) writeBuckets(pw *packetWriter, name, tags string, h types.BucketHistogram) {
+	counts := h.BucketCounts()
+	for i, bucket := range h.Buckets() {
+		le := strings.Replace(strconv.FormatFloat(bucket, 'g', -1, 64), ".", "_", -1)
+		ssink.writeCounter(pw, name+".bucket.le_"+le, tags, int64(counts[i]))
+	}
+	ssink.writeCounter(pw, name+".count", tags, h.Count())
+	ssink.writeCounter(pw, name+".sum", tags, h.Sum())
+}
+
+// percentileName returns the suffix of a percentile, such as p50 for 0.5 and p999 for 0.999
+func percentileName(quantile float64) string {
+	if quantile >= 1 {
+		return "p100"
+	}
+	s := strings.TrimPrefix(strconv.FormatFloat(quantile, 'f', -1, 64), "0.")
+	if len(s) < 2 {
+		s += "0"
+	}
+	return "p" + s
+}
+
+var (
+	nameReplacer  = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+	labelReplacer = strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+	tagReplacer   = strings.NewReplacer("|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+)
+
+// sanitize replaces the characters that are reserved in the statsd protocol
+func sanitize(s string) string {
+	return nameReplacer.Replace(s)
+}
+
+func sanitizeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func sanitizeTag(s string) string {
+	return tagReplacer.Replace(s)
+}
+
+// packetWriter batches the lines into packets whose size is not larger than size,
+// a line that is larger than size is sent in its own packet
+type packetWriter struct {
+	w    io.Writer
+	size int
+	buf  []byte
+}
+
+func (pw *packetWriter) WriteLine(line string) {
+	if len(pw.buf) > 0 && len(pw.buf)+1+len(line) > pw.size {
+		pw.Flush()
+	}
+	if len(pw.buf) > 0 {
+		pw.buf = append(pw.buf, '\n')
+	}
+	pw.buf = append(pw.buf, line...)
+}
+
+func (pw *packetWriter) Flush() {
+	if len(pw.buf) == 0 {
+		return
+	}
+	if _, err := pw.w.Write(pw.buf); err != nil {
+		log.DefaultLogger.Debugf("[metrics] [sink] [statsd] send metrics failed: %v", err)
+	}
+	pw.buf = pw.buf[:0]
+}
+
+// run sends all of the metrics periodically until the sink is stopped
+func (ssink *statsdSink) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ssink.Flush(ssink.conn, metrics.GetAll())
+		case <-ssink.stop:
+			return
+		}
+	}
+}
+
+// Stop stops sending the metrics and closes the connection
+func (ssink *statsdSink) Stop() {
+	ssink.stopOnce.Do(func() {
+		close(ssink.stop)
+		if ssink.conn != nil {
+			ssink.conn.Close()
+		}
+	})
+}
+
+// NewStatsdSink returns a metrics sink that writes the metrics in the statsd protocol
+func NewStatsdSink(config *statsdConfig) types.MetricsSink {
+	if config.MaxPacketSize <= 0 {
+		config.MaxPacketSize = defaultMaxPacketSize
+	}
+	if len(config.Percentiles) == 0 {
+		config.Percentiles = defaultPercentiles
+	}
+	return &statsdSink{
+		config:   config,
+		counters: make(map[string]int64),
+		stop:     make(chan struct{}),
+	}
+}
+
+// factory
+func builder(cfg map[string]interface{}) (types.MetricsSink, error) {
+	// parse config
+	statsdCfg := &statsdConfig{}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing statsd sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if err := json.Unmarshal(data, statsdCfg); err != nil {
+		return nil, fmt.Errorf("parsing statsd sink error, err: %v, cfg: %v", err, cfg)
+	}
+	for _, quantile := range statsdCfg.Percentiles {
+		if quantile <= 0 || quantile > 1 {
+			return nil, fmt.Errorf("invalid percentile: %v", quantile)
+		}
+	}
+	if statsdCfg.Address == "" {
+		statsdCfg.Address = defaultAddress
+	}
+	conn, err := net.Dial("udp", statsdCfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("dial statsd server %s error: %v", statsdCfg.Address, err)
+	}
+
+	ssink := NewStatsdSink(statsdCfg).(*statsdSink)
+	ssink.conn = conn
+	interval := statsdCfg.FlushInterval.Duration
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	utils.GoWithRecover(func() {
+		ssink.run(interval)
+	}, nil)
+	return ssink, nil
+}
@@ -0,0 +1,237 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/metrics/sink"
+)
+
+// packetRecorder records the packets written
+type packetRecorder struct {
+	packets []string
+}
+
+func (r *packetRecorder) Write(p []byte) (int, error) {
+	r.packets = append(r.packets, string(p))
+	return len(p), nil
+}
+
+func (r *packetRecorder) Lines() []string {
+	var lines []string
+	for _, p := range r.packets {
+		lines = append(lines, strings.Split(p, "\n")...)
+	}
+	return lines
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStatsdFlush(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1.x"})
+	m.Counter("k1").Inc(3)
+	m.Gauge("k2").Update(-2)
+	for i := int64(1); i <= 4; i++ {
+		m.Histogram("k3").Update(i)
+	}
+
+	ssink := NewStatsdSink(&statsdConfig{
+		Prefix:      "mosn",
+		Percentiles: []float64{0.5, 1},
+	})
+	r := &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	lines := r.Lines()
+	for _, expected := range []string{
+		"mosn.t1.lbk1.lbv1_x.k1:3|c",
+		"mosn.t1.lbk1.lbv1_x.k2:0|g",
+		"mosn.t1.lbk1.lbv1_x.k2:-2|g",
+		"mosn.t1.lbk1.lbv1_x.k3.p50:2.5|g",
+		"mosn.t1.lbk1.lbv1_x.k3.p100:4|g",
+	} {
+		if !contains(lines, expected) {
+			t.Errorf("line %s is not found in %v", expected, lines)
+		}
+	}
+	if len(r.packets) != 1 {
+		t.Errorf("lines should be batched into one packet, but got %d", len(r.packets))
+	}
+
+	// counters are sent as deltas
+	m.Counter("k1").Inc(2)
+	r = &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	if lines := r.Lines(); !contains(lines, "mosn.t1.lbk1.lbv1_x.k1:2|c") {
+		t.Errorf("unexpected counter delta: %v", lines)
+	}
+	// the counter is not sent if it is not changed
+	r = &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	for _, line := range r.Lines() {
+		if strings.Contains(line, "|c") {
+			t.Errorf("unchanged counter should not be sent: %s", line)
+		}
+	}
+}
+
+func TestStatsdBucketHistogram(t *testing.T) {
+	metrics.ResetAll()
+	defer metrics.ResetAll()
+	if err := metrics.SetHistogramBuckets(map[string][]float64{"t1": {0.5, 10}}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := metrics.NewMetrics("t1", nil)
+	for _, v := range []int64{5, 15} {
+		m.Histogram("k1").Update(v)
+	}
+	ssink := NewStatsdSink(&statsdConfig{
+		Percentiles: []float64{1},
+	})
+	r := &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	lines := r.Lines()
+	for _, expected := range []string{
+		"t1.k1.bucket.le_10:1|c",
+		"t1.k1.count:2|c",
+		"t1.k1.sum:20|c",
+		"t1.k1.p100:15|g",
+	} {
+		if !contains(lines, expected) {
+			t.Errorf("line %s is not found in %v", expected, lines)
+		}
+	}
+	// the empty bucket is not sent
+	for _, line := range lines {
+		if strings.HasPrefix(line, "t1.k1.bucket.le_0_5") {
+			t.Errorf("unexpected line: %s", line)
+		}
+	}
+	// the buckets are sent as deltas
+	m.Histogram("k1").Update(1)
+	r = &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	if lines := r.Lines(); !contains(lines, "t1.k1.bucket.le_10:1|c") || !contains(lines, "t1.k1.count:1|c") {
+		t.Errorf("unexpected bucket deltas: %v", lines)
+	}
+}
+
+func TestStatsdDogStatsD(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1", "lbk2": "127.0.0.1:80"})
+	m.Counter("k1").Inc(1)
+	m.Histogram("k2").Update(10)
+
+	ssink := NewStatsdSink(&statsdConfig{
+		DogStatsD:        true,
+		HistogramAsTimer: true,
+		Percentiles:      []float64{0.999},
+	})
+	r := &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	lines := r.Lines()
+	for _, expected := range []string{
+		"t1.k1:1|c|#lbk1:lbv1,lbk2:127.0.0.1:80",
+		"t1.k2.p999:10|ms|#lbk1:lbv1,lbk2:127.0.0.1:80",
+	} {
+		if !contains(lines, expected) {
+			t.Errorf("line %s is not found in %v", expected, lines)
+		}
+	}
+}
+
+func TestStatsdPacketSize(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", nil)
+	for i := 0; i < 100; i++ {
+		m.Gauge("gauge_" + strings.Repeat("x", i)).Update(1)
+	}
+	ssink := NewStatsdSink(&statsdConfig{
+		MaxPacketSize: 512,
+	})
+	r := &packetRecorder{}
+	ssink.Flush(r, metrics.GetAll())
+	if len(r.packets) < 2 {
+		t.Fatalf("lines should be split into packets, but got %d", len(r.packets))
+	}
+	for _, p := range r.packets {
+		// a single line larger than the packet size is allowed
+		if len(p) > 512 && strings.Contains(p, "\n") {
+			t.Errorf("packet size %d is larger than 512", len(p))
+		}
+	}
+	if n := len(r.Lines()); n != 100 {
+		t.Errorf("expected 100 lines, but got %d", n)
+	}
+}
+
+func TestStatsdSinkUDP(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", nil)
+	m.Counter("k1").Inc(1)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s, err := sink.CreateMetricsSink("statsd", map[string]interface{}{
+		"address":        conn.LocalAddr().String(),
+		"flush_interval": "10ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssink := s.(*statsdSink)
+	defer func() {
+		ssink.Stop()
+		if _, err := ssink.conn.Write([]byte("t1.k1:1|c")); err == nil {
+			t.Error("the connection should be closed after the sink is stopped")
+		}
+	}()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, defaultMaxPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet := string(buf[:n]); !strings.Contains(packet, "t1.k1:1|c") {
+		t.Fatalf("unexpected packet: %s", packet)
+	}
+}
+
+func TestStatsdConfig(t *testing.T) {
+	if _, err := builder(map[string]interface{}{"percentiles": []float64{0}}); err == nil {
+		t.Error("invalid percentile should be failed")
+	}
+	if name := percentileName(0.95); name != "p95" {
+		t.Errorf("unexpected percentile name: %s", name)
+	}
+}
@@ -18,6 +18,7 @@
 package metrics
 
 import (
+	"errors"
 	"strings"
 	"sync"
 
@@ -37,9 +38,14 @@ var (
 	errLabelCountExceeded = fmt.Errorf("label count exceeded, max is %d", maxLabelCount)
 )
 
+// ErrInvalidHistogramBuckets is returned if the histogram buckets are not in increasing order
+var ErrInvalidHistogramBuckets = errors.New("histogram buckets should be in increasing order")
+
 // stats memory store
 type store struct {
 	matcher *metricsMatcher
+	// buckets are the histogram buckets by the metrics type
+	buckets map[string][]float64
 
 	metrics map[string]types.Metrics
 	mutex   sync.RWMutex
@@ -53,6 +59,8 @@ type metrics struct {
 	prefix    string
 	labelKeys []string
 	labelVals []string
+	// buckets is not empty if the histograms count values in buckets
+	buckets []float64
 
 	registry gometrics.Registry
 }
@@ -80,6 +88,23 @@ func SetStatsMatcher(all bool, exclusionLabels, exclusionKeys []string) {
 	}
 }
 
+// SetHistogramBuckets sets the upper bounds of the histogram buckets by the metrics type,
+// the histograms of the metrics created after it count values in the buckets
+func SetHistogramBuckets(buckets map[string][]float64) error {
+	for typ, bounds := range buckets {
+		for i := 1; i < len(bounds); i++ {
+			if bounds[i] <= bounds[i-1] {
+				return fmt.Errorf("metrics type %s: %v", typ, ErrInvalidHistogramBuckets)
+			}
+		}
+	}
+	defaultStore.mutex.Lock()
+	defer defaultStore.mutex.Unlock()
+
+	defaultStore.buckets = buckets
+	return nil
+}
+
 // NewMetrics returns a metrics
 // Same (type + labels) pair will leading to the same Metrics instance
 func NewMetrics(typ string, labels map[string]string) (types.Metrics, error) {
@@ -107,6 +132,7 @@ func NewMetrics(typ string, labels map[string]string) (types.Metrics, error) {
 		labelKeys: keys,
 		labelVals: values,
 		prefix:    name + ".",
+		buckets:   defaultStore.buckets[typ],
 		registry:  gometrics.NewRegistry(),
 	}
 
@@ -171,6 +197,10 @@ func (s *metrics) Histogram(key string) gometrics.Histogram {
 		return gometrics.NilHistogram{}
 	}
 
+	if len(s.buckets) > 0 {
+		return s.registry.GetOrRegister(key, shm.NewShmHistogramFunc(s.fullName(key), s.buckets)).(gometrics.Histogram)
+	}
+
 	// TODO: notice the histogram only keeps 100 values as we set
 	return s.registry.GetOrRegister(key, func() gometrics.Histogram { return gometrics.NewHistogram(gometrics.NewUniformSample(100)) }).(gometrics.Histogram)
 }
@@ -208,6 +238,7 @@ func ResetAll() {
 	}
 	defaultStore.metrics = make(map[string]types.Metrics, 100)
 	defaultStore.matcher = defaultMatcher
+	defaultStore.buckets = nil
 }
 
 func fullName(typ string, labels map[string]string) (fullName string, keys, values []string) {
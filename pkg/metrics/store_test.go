@@ -211,3 +211,22 @@ func BenchmarkNewMetrics_DifferentLabels(b *testing.B) {
 		b.Errorf("different labels gets same metrics, total %d, registered %d", total, registered)
 	}
 }
+
+func TestSetHistogramBuckets(t *testing.T) {
+	ResetAll()
+	defer ResetAll()
+	if err := SetHistogramBuckets(map[string][]float64{"t1": {10, 10}}); err == nil {
+		t.Error("buckets not in increasing order should be invalid")
+	}
+	if err := SetHistogramBuckets(map[string][]float64{"t1": {10, 20}}); err != nil {
+		t.Fatal(err)
+	}
+	m1, _ := NewMetrics("t1", nil)
+	if _, ok := m1.Histogram("h").(types.BucketHistogram); !ok {
+		t.Error("histogram of the configured type should be a bucket histogram")
+	}
+	m2, _ := NewMetrics("t2", nil)
+	if _, ok := m2.Histogram("h").(types.BucketHistogram); ok {
+		t.Error("histogram of the type without buckets should not be a bucket histogram")
+	}
+}
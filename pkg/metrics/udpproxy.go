@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// UDPProxyType represents udp proxy metrics type
+const UDPProxyType = "udpproxy"
+
+// metrics key in udp proxy
+const (
+	UDPProxyDownstreamDatagramsRx = "downstream_datagrams_rx"
+	UDPProxyDownstreamBytesRx     = "downstream_bytes_rx"
+	UDPProxyDownstreamDatagramsTx = "downstream_datagrams_tx"
+	UDPProxyDownstreamBytesTx     = "downstream_bytes_tx"
+	UDPProxyDownstreamSendError   = "downstream_send_error"
+	UDPProxyUpstreamDatagramsRx   = "upstream_datagrams_rx"
+	UDPProxyUpstreamBytesRx       = "upstream_bytes_rx"
+	UDPProxyUpstreamDatagramsTx   = "upstream_datagrams_tx"
+	UDPProxyUpstreamBytesTx       = "upstream_bytes_tx"
+	UDPProxyUpstreamSendError     = "upstream_send_error"
+	UDPProxyUpstreamReceiveError  = "upstream_receive_error"
+	UDPProxySessionTotal          = "session_total"
+	UDPProxySessionActive         = "session_active"
+	UDPProxySessionIdleTimeout    = "session_idle_timeout"
+	UDPProxySessionOverflow       = "session_overflow"
+	UDPProxyNoHealthyUpstream     = "no_healthy_upstream"
+)
+
+// NewUDPProxyStats returns a stats with namespace prefix udpproxy
+func NewUDPProxyStats(statPrefix string) types.Metrics {
+	metrics, _ := NewMetrics(UDPProxyType, map[string]string{"proxy": statPrefix})
+	return metrics
+}
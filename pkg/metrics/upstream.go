@@ -47,18 +47,28 @@ const (
 	UpstreamRequestDurationTotal                   = "request_duration_time_total"
 	UpstreamResponseSuccess                        = "response_success"
 	UpstreamResponseFailed                         = "response_failed"
+	UpstreamOutlierEjected                         = "outlier_ejected"
 )
 
 //  key in cluster
 const (
 	UpstreamRequestRetry         = "request_retry"
 	UpstreamRequestRetryOverflow = "request_retry_overflow"
+	UpstreamRequestMirrorSuccess = "request_mirror_success"
+	UpstreamRequestMirrorFailure = "request_mirror_failure"
 	UpstreamLBSubSetsFallBack    = "lb_subsets_fallback"
 	UpstreamLBSubsetsCreated     = "lb_subsets_created"
 	UpstreamBytesReadTotal       = "connection_bytes_read_total"
 	UpstreamBytesReadBuffered    = "connection_bytes_read_buffered"
 	UpstreamBytesWriteTotal      = "connection_bytes_write"
 	UpstreamBytesWriteBuffered   = "connection_bytes_write_buffered"
+	// outlier detection
+	UpstreamOutlierEjectTotal                     = "outlier_eject_total"
+	UpstreamOutlierEjectActive                    = "outlier_eject_active"
+	UpstreamOutlierEjectConsecutive5xx            = "outlier_eject_consecutive_5xx"
+	UpstreamOutlierEjectConsecutiveConnectFailure = "outlier_eject_consecutive_connect_failure"
+	UpstreamOutlierEjectSuccessRate               = "outlier_eject_success_rate"
+	UpstreamOutlierEjectOverflow                  = "outlier_eject_overflow"
 )
 
 // NewHostStats returns a stats that namespace contains cluster and host address
@@ -52,8 +52,10 @@ type Mosn struct {
 	xdsClient      *xds.Client
 	wg             sync.WaitGroup
 	// for smooth upgrade. reconfigure
-	inheritListeners []net.Listener
-	listenSockConn   net.Conn
+	inheritListeners   []net.Listener
+	inheritPacketConns []net.PacketConn
+	listenSockConn     net.Conn
+	metricsSinks       []types.MetricsSink
 }
 
 // NewMosn
@@ -67,7 +69,7 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 	store.SetMosnConfig(c)
 
 	//get inherit fds
-	inheritListeners, listenSockConn, err := server.GetInheritListeners()
+	inheritListeners, inheritPacketConns, listenSockConn, err := server.GetInheritListeners()
 	if err != nil {
 		log.StartLogger.Fatalf("[mosn] [NewMosn] getInheritListeners failed, exit")
 	}
@@ -85,13 +87,15 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 		}
 	}
 
-	initializeMetrics(c.Metrics)
+	sinks := initializeMetrics(c.Metrics)
 
 	m := &Mosn{
-		config:           c,
-		wg:               sync.WaitGroup{},
-		inheritListeners: inheritListeners,
-		listenSockConn:   listenSockConn,
+		config:             c,
+		metricsSinks:       sinks,
+		wg:                 sync.WaitGroup{},
+		inheritListeners:   inheritListeners,
+		inheritPacketConns: inheritPacketConns,
+		listenSockConn:     listenSockConn,
 	}
 	mode := c.Mode()
 
@@ -156,7 +160,7 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 
 			for idx, _ := range serverConfig.Listeners {
 				// parse ListenerConfig
-				lc := configmanager.ParseListenerConfig(&serverConfig.Listeners[idx], inheritListeners)
+				lc := configmanager.ParseListenerConfig(&serverConfig.Listeners[idx], inheritListeners, inheritPacketConns)
 				// deprecated: keep compatible for route config in listener's connection_manager
 				deprecatedRouter, err := configmanager.ParseRouterConfiguration(&lc.FilterChains[0])
 				if err != nil {
@@ -223,6 +227,12 @@ func (m *Mosn) beforeStart() {
 			ln.Close()
 		}
 	}
+	for _, pc := range m.inheritPacketConns {
+		if pc != nil {
+			log.StartLogger.Infof("[mosn] [NewMosn] close useless legacy udp listener: %s", pc.LocalAddr().String())
+			pc.Close()
+		}
+	}
 
 	// start dump config process
 	utils.GoWithRecover(func() {
@@ -286,6 +296,12 @@ func (m *Mosn) Close() {
 	}
 	m.xdsClient.Stop()
 	m.clustermanager.Destroy()
+	// stop the metrics sinks that send the metrics in background
+	for _, s := range m.metricsSinks {
+		if stopper, ok := s.(interface{ Stop() }); ok {
+			stopper.Stop()
+		}
+	}
 	m.wg.Done()
 }
 
@@ -315,7 +331,7 @@ func initializeTracing(config v2.TracingConfig) {
 	}
 }
 
-func initializeMetrics(config v2.MetricsConfig) {
+func initializeMetrics(config v2.MetricsConfig) []types.MetricsSink {
 	// init shm zone
 	if config.ShmZone != "" && config.ShmSize > 0 {
 		shm.InitDefaultMetricsZone(config.ShmZone, int(config.ShmSize), store.GetMosnState() != store.Active_Reconfiguring)
@@ -324,16 +340,22 @@ func initializeMetrics(config v2.MetricsConfig) {
 	// set metrics package
 	statsMatcher := config.StatsMatcher
 	metrics.SetStatsMatcher(statsMatcher.RejectAll, statsMatcher.ExclusionLabels, statsMatcher.ExclusionKeys)
+	if err := metrics.SetHistogramBuckets(config.HistogramBuckets); err != nil {
+		log.StartLogger.Errorf("[mosn] [init metrics] set histogram buckets failed: %v, the sampling histograms are used", err)
+	}
 	// create sinks
+	sinks := make([]types.MetricsSink, 0, len(config.SinkConfigs))
 	for _, cfg := range config.SinkConfigs {
-		_, err := sink.CreateMetricsSink(cfg.Type, cfg.Config)
+		s, err := sink.CreateMetricsSink(cfg.Type, cfg.Config)
 		// abort
 		if err != nil {
 			log.StartLogger.Errorf("[mosn] [init metrics] %s. %v metrics sink is turned off", err, cfg.Type)
-			return
+			return sinks
 		}
+		sinks = append(sinks, s)
 		log.StartLogger.Infof("[mosn] [init metrics] create metrics sink: %v", cfg.Type)
 	}
+	return sinks
 }
 
 func initializePidFile(pid string) {
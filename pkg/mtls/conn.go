@@ -21,6 +21,7 @@ import (
 	gotls "crypto/tls"
 	"encoding/gob"
 	"errors"
+	"io"
 	"net"
 	"strings"
 	"time"
@@ -51,42 +52,184 @@ func (c *TLSConn) Read(b []byte) (int, error) {
 // It implements the net.Conn interface.
 type Conn struct {
 	net.Conn
-	peek    [1]byte
-	haspeek bool
+	peek []byte
 }
 
+// ClientHello contains the information in TLS ClientHello
+type ClientHello struct {
+	ServerName string
+	ALPN       []string
+}
+
+const (
+	recordTypeHandshake      = 0x16
+	handshakeTypeClientHello = 0x01
+	recordHeaderLen          = 5
+	maxRecordLen             = 16384 + 2048
+	extensionServerName      = 0
+	extensionALPN            = 16
+)
+
+var errInvalidClientHello = errors.New("invalid tls client hello")
+
 // Peek returns 1 byte from connection, without draining any buffered data.
 func (c *Conn) Peek() ([]byte, error) {
-	b := make([]byte, 1, 1)
+	if err := c.peekN(1); err != nil {
+		return nil, err
+	}
+	return c.peek[:1], nil
+}
+
+// PeekClientHello peeks the first tls record from connection, and returns the
+// ClientHello in it. If the connection is not a tls connection, returns nil.
+func (c *Conn) PeekClientHello() (*ClientHello, error) {
+	if err := c.peekN(1); err != nil {
+		return nil, err
+	}
+	if c.peek[0] != recordTypeHandshake {
+		return nil, nil
+	}
+	if err := c.peekN(recordHeaderLen); err != nil {
+		return nil, err
+	}
+	n := int(c.peek[3])<<8 | int(c.peek[4])
+	if n > maxRecordLen {
+		return nil, errInvalidClientHello
+	}
+	if err := c.peekN(recordHeaderLen + n); err != nil {
+		return nil, err
+	}
+	return parseClientHello(c.peek[recordHeaderLen : recordHeaderLen+n])
+}
+
+// peekN reads data from connection until n bytes is peeked
+func (c *Conn) peekN(n int) error {
+	if len(c.peek) >= n {
+		return nil
+	}
+	buf := make([]byte, n)
+	copy(buf, c.peek)
 	c.Conn.SetReadDeadline(time.Now().Add(types.DefaultIdleTimeout))
-	_, err := c.Conn.Read(b)
+	_, err := io.ReadFull(c.Conn, buf[len(c.peek):])
 	c.Conn.SetReadDeadline(time.Time{}) // clear read deadline
 	if err != nil {
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf("[mtls] TLS Peek() error: %v", err)
 		}
-		return nil, err
+		return err
 	}
-	c.peek[0] = b[0]
-	c.haspeek = true
-	return b, nil
+	c.peek = buf
+	return nil
 }
 
 // Read reads data from the connection.
 func (c *Conn) Read(b []byte) (int, error) {
-	peek := 0
-	if c.haspeek {
-		c.haspeek = false
-		b[0] = c.peek[0]
-		if len(b) == 1 {
-			return 1, nil
+	if len(c.peek) > 0 {
+		n := copy(b, c.peek)
+		c.peek = c.peek[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
+
+// parseClientHello parses the server name and alpn in the ClientHello handshake message
+func parseClientHello(data []byte) (*ClientHello, error) {
+	// handshake type, length and client version
+	if len(data) < 6 || data[0] != handshakeTypeClientHello {
+		return nil, errInvalidClientHello
+	}
+	p := &helloParser{data: data[6:]}
+	// random
+	p.skip(32)
+	// session id
+	p.skip(int(p.uint8()))
+	// cipher suites
+	p.skip(int(p.uint16()))
+	// compression methods
+	p.skip(int(p.uint8()))
+	hello := &ClientHello{}
+	if p.err != nil {
+		return nil, p.err
+	}
+	// no extensions
+	if len(p.data) == 0 {
+		return hello, nil
+	}
+	exts := &helloParser{data: p.bytes(int(p.uint16()))}
+	for p.err == nil && exts.err == nil && len(exts.data) > 0 {
+		typ := exts.uint16()
+		ext := &helloParser{data: exts.bytes(int(exts.uint16()))}
+		switch typ {
+		case extensionServerName:
+			names := &helloParser{data: ext.bytes(int(ext.uint16()))}
+			for names.err == nil && len(names.data) > 0 {
+				nameType := names.uint8()
+				name := names.bytes(int(names.uint16()))
+				if nameType == 0 && names.err == nil {
+					hello.ServerName = strings.ToLower(string(name))
+				}
+			}
+			ext.err = names.err
+		case extensionALPN:
+			protos := &helloParser{data: ext.bytes(int(ext.uint16()))}
+			for protos.err == nil && len(protos.data) > 0 {
+				proto := protos.bytes(int(protos.uint8()))
+				if protos.err == nil {
+					hello.ALPN = append(hello.ALPN, string(proto))
+				}
+			}
+			ext.err = protos.err
 		}
-		peek = 1
-		b = b[peek:]
+		if ext.err != nil {
+			return nil, ext.err
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if exts.err != nil {
+		return nil, exts.err
 	}
+	return hello, nil
+}
 
-	n, err := c.Conn.Read(b)
-	return n + peek, err
+// helloParser reads the fields in ClientHello, the err is set if data is not enough
+type helloParser struct {
+	data []byte
+	err  error
+}
+
+func (p *helloParser) bytes(n int) []byte {
+	if p.err != nil {
+		return nil
+	}
+	if len(p.data) < n {
+		p.err = errInvalidClientHello
+		return nil
+	}
+	b := p.data[:n]
+	p.data = p.data[n:]
+	return b
+}
+
+func (p *helloParser) skip(n int) {
+	p.bytes(n)
+}
+
+func (p *helloParser) uint8() uint8 {
+	b := p.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (p *helloParser) uint16() uint16 {
+	b := p.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return uint16(b[0])<<8 | uint16(b[1])
 }
 
 // ConnectionState records basic TLS details about the connection.
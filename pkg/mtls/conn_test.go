@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"bytes"
+	gotls "crypto/tls"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPeekClientHello(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		conn := gotls.Client(client, &gotls.Config{
+			ServerName:         "www.Test.com",
+			NextProtos:         []string{"h2", "http/1.1"},
+			InsecureSkipVerify: true,
+		})
+		conn.SetDeadline(time.Now().Add(time.Second))
+		conn.Handshake()
+		client.Close()
+	}()
+	conn := &Conn{Conn: server}
+	hello, err := conn.PeekClientHello()
+	if err != nil {
+		t.Fatalf("peek client hello failed: %v", err)
+	}
+	if hello == nil || hello.ServerName != "www.test.com" || !reflect.DeepEqual(hello.ALPN, []string{"h2", "http/1.1"}) {
+		t.Fatalf("unexpected client hello: %+v", hello)
+	}
+	// the peeked data can be read again
+	peeked := append([]byte{}, conn.peek...)
+	b, err := conn.Peek()
+	if err != nil || b[0] != recordTypeHandshake {
+		t.Fatalf("peek failed: %v", err)
+	}
+	data := make([]byte, len(peeked))
+	if _, err := io.ReadFull(conn, data); err != nil || !bytes.Equal(data, peeked) {
+		t.Fatalf("read peeked data failed: %v", err)
+	}
+}
+
+func TestPeekClientHelloNonTLS(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		client.Write([]byte("GET / HTTP/1.1\r\n"))
+		client.Close()
+	}()
+	conn := &Conn{Conn: server}
+	hello, err := conn.PeekClientHello()
+	if err != nil || hello != nil {
+		t.Fatalf("non tls connection expected no client hello, but got %+v, %v", hello, err)
+	}
+	data := make([]byte, 3)
+	if _, err := io.ReadFull(conn, data); err != nil || string(data) != "GET" {
+		t.Fatalf("read data failed: %s, %v", data, err)
+	}
+}
+
+func TestParseClientHelloInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		{handshakeTypeClientHello, 0, 0, 1},
+		{0x02, 0, 0, 2, 3, 3},
+		append([]byte{handshakeTypeClientHello, 0, 0, 0x30, 3, 3}, make([]byte, 10)...),
+	} {
+		if _, err := parseClientHello(data); err == nil {
+			t.Errorf("parse %v expected an error", data)
+		}
+	}
+}
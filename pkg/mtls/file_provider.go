@@ -0,0 +1,309 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// DefaultWatchInterval is the interval to check the certificate files if it is not configured
+const DefaultWatchInterval = 10 * time.Second
+
+var fileProviders = &fileProviderManager{
+	providers: make(map[string]*fileProvider),
+}
+
+// fileProviderManager stores the file providers, the providers with same config share the watcher,
+// a provider is stopped when it is released by all of the tls context managers
+type fileProviderManager struct {
+	mutex     sync.Mutex
+	providers map[string]*fileProvider
+}
+
+func getOrCreateFileProvider(cfg *v2.TLSConfig) (*fileProvider, error) {
+	return fileProviders.getOrCreateProvider(cfg)
+}
+
+func releaseFileProvider(p *fileProvider) {
+	fileProviders.releaseProvider(p)
+}
+
+// ClearFileProviders stops all of the file providers
+func ClearFileProviders() {
+	fileProviders.mutex.Lock()
+	defer fileProviders.mutex.Unlock()
+	for _, p := range fileProviders.providers {
+		p.stop()
+	}
+	fileProviders.providers = make(map[string]*fileProvider)
+	log.DefaultLogger.Infof("[mtls] [file provider] clear all providers")
+}
+
+func (mng *fileProviderManager) getOrCreateProvider(cfg *v2.TLSConfig) (*fileProvider, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key := string(b)
+	mng.mutex.Lock()
+	defer mng.mutex.Unlock()
+	if p, ok := mng.providers[key]; ok {
+		p.refs++
+		return p, nil
+	}
+	p, err := newFileProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.key = key
+	p.refs = 1
+	mng.providers[key] = p
+	p.start()
+	log.DefaultLogger.Infof("[mtls] [file provider] add a new file provider, cert: %s, key: %s, ca: %s",
+		cfg.CertChain, cfg.PrivateKey, cfg.CACert)
+	return p, nil
+}
+
+func (mng *fileProviderManager) releaseProvider(p *fileProvider) {
+	mng.mutex.Lock()
+	defer mng.mutex.Unlock()
+	// the provider is cleared already
+	if mng.providers[p.key] != p {
+		return
+	}
+	p.refs--
+	if p.refs > 0 {
+		return
+	}
+	delete(mng.providers, p.key)
+	p.stop()
+	log.DefaultLogger.Infof("[mtls] [file provider] remove the file provider, cert: %s, key: %s, ca: %s",
+		p.config.CertChain, p.config.PrivateKey, p.config.CACert)
+}
+
+// fileProvider is an implementation of types.Provider
+// fileProvider stored a tls context that makes by the certificate files,
+// the tls context is rebuilt when the files are changed, only the new handshakes use the new one.
+type fileProvider struct {
+	value    atomic.Value // stored tlsContext
+	config   *v2.TLSConfig
+	info     *secretInfo
+	interval time.Duration
+	// contents is the files content that the current tls context made from
+	contents [][]byte
+	// lastErr is the content of files that failed to make a tls context, avoid reporting a failure repeatedly
+	lastErr       [][]byte
+	rotateSuccess gometrics.Counter
+	rotateFailure gometrics.Counter
+	stopChan      chan struct{}
+	// key and refs are protected by the fileProviderManager's mutex
+	key  string
+	refs int
+}
+
+func newFileProvider(cfg *v2.TLSConfig) (*fileProvider, error) {
+	p := &fileProvider{
+		config: cfg,
+		info: &secretInfo{
+			Certificate: cfg.CertChain,
+			PrivateKey:  cfg.PrivateKey,
+			Validation:  cfg.CACert,
+		},
+		interval: DefaultWatchInterval,
+		stopChan: make(chan struct{}),
+	}
+	if cfg.WatchInterval != nil && cfg.WatchInterval.Duration > 0 {
+		p.interval = cfg.WatchInterval.Duration
+	}
+	certName := cfg.CertChain
+	if certName == "" {
+		certName = cfg.CACert
+	}
+	stats := metrics.NewTLSStats(certName)
+	p.rotateSuccess = stats.Counter(metrics.TLSCertRotateSuccess)
+	p.rotateFailure = stats.Counter(metrics.TLSCertRotateFailure)
+	contents, err := p.readFiles()
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := p.newTLSContext(contents)
+	if err != nil {
+		return nil, err
+	}
+	p.contents = contents
+	p.value.Store(ctx)
+	return p, nil
+}
+
+func (p *fileProvider) start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopChan:
+				return
+			case <-ticker.C:
+				p.check()
+			}
+		}
+	}, nil)
+}
+
+func (p *fileProvider) stop() {
+	close(p.stopChan)
+}
+
+// files returns the watched files, the pem strings are not watched
+func (p *fileProvider) files() []string {
+	var files []string
+	for _, f := range []string{p.info.Certificate, p.info.PrivateKey, p.info.Validation} {
+		if f != "" && !strings.Contains(f, "-----BEGIN") {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// secretFromContents returns a secret info that the watched files are replaced by the contents,
+// the contents are in the same order as files.
+func (p *fileProvider) secretFromContents(contents [][]byte) *secretInfo {
+	secret := *p.info
+	i := 0
+	for _, f := range []*string{&secret.Certificate, &secret.PrivateKey, &secret.Validation} {
+		if *f != "" && !strings.Contains(*f, "-----BEGIN") && i < len(contents) {
+			*f = string(contents[i])
+			i++
+		}
+	}
+	return &secret
+}
+
+func (p *fileProvider) readFiles() ([][]byte, error) {
+	files := p.files()
+	contents := make([][]byte, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, b)
+	}
+	return contents, nil
+}
+
+// check rebuilds the tls context if the files are changed
+func (p *fileProvider) check() {
+	contents, err := p.readFiles()
+	if err != nil {
+		// the files may be replaced now, try it in next check
+		log.DefaultLogger.Warnf("[mtls] [file provider] read certificate files failed: %v", err)
+		return
+	}
+	if sameContents(contents, p.contents) || sameContents(contents, p.lastErr) {
+		return
+	}
+	ctx, err := p.newTLSContext(contents)
+	if err != nil {
+		p.lastErr = contents
+		p.rotateFailure.Inc(1)
+		log.DefaultLogger.Alertf(types.ErrorKeyTLSRotate, "[mtls] [file provider] rotate certificate %s failed: %v", p.info.Certificate, err)
+		return
+	}
+	p.contents = contents
+	p.lastErr = nil
+	p.value.Store(ctx)
+	p.rotateSuccess.Inc(1)
+	log.DefaultLogger.Infof("[mtls] [file provider] rotate certificate %s success", p.info.Certificate)
+}
+
+// newTLSContext makes a tls context by the contents read from the files, and validates the certificates.
+// the files are not read again, so the certificate and private key are always a matched pair.
+func (p *fileProvider) newTLSContext(contents [][]byte) (*tlsContext, error) {
+	ctx, err := newTLSContext(p.config, p.secretFromContents(contents))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, cert := range ctx.client.Certificates {
+		if len(cert.Certificate) == 0 {
+			continue
+		}
+		x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+		if now.After(x509Cert.NotAfter) {
+			return nil, fmt.Errorf("certificate is expired at %v", x509Cert.NotAfter)
+		}
+	}
+	return ctx, nil
+}
+
+func sameContents(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *fileProvider) tlsContext() *tlsContext {
+	ctx, _ := p.value.Load().(*tlsContext)
+	return ctx
+}
+
+func (p *fileProvider) GetTLSConfig(client bool) *tls.Config {
+	return p.tlsContext().GetTLSConfig(client)
+}
+
+func (p *fileProvider) MatchedServerName(sn string) bool {
+	return p.tlsContext().MatchedServerName(sn)
+}
+
+func (p *fileProvider) MatchedALPN(protos []string) bool {
+	return p.tlsContext().MatchedALPN(protos)
+}
+
+func (p *fileProvider) Ready() bool {
+	return true
+}
+
+func (p *fileProvider) Empty() bool {
+	return p.tlsContext().server == nil
+}
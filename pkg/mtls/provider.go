@@ -36,7 +36,7 @@ func (p *staticProvider) Empty() bool {
 }
 
 // NewProvider returns a types.Provider.
-// we support sds provider and static provider.
+// we support sds provider, file provider and static provider.
 func NewProvider(cfg *v2.TLSConfig) (types.TLSProvider, error) {
 	if !cfg.Status {
 		return nil, nil
@@ -46,6 +46,12 @@ func NewProvider(cfg *v2.TLSConfig) (types.TLSProvider, error) {
 			return nil, ErrorNoCertConfigure
 		}
 		return getOrCreateProvider(cfg), nil
+	} else if cfg.WatchFiles {
+		p, err := getOrCreateFileProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	} else {
 		// static provider
 		secret := &secretInfo{
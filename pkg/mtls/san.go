@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// ErrorSubjectAltNameNotMatched represents the peer certificate has no acceptable subject alternative name
+var ErrorSubjectAltNameNotMatched = errors.New("no subject alternative name matched")
+
+// ErrorSubjectAltNameWithoutClientCert represents the server config matches the subject alternative names
+// of client certificates, but the client certificates are not required
+var ErrorSubjectAltNameWithoutClientCert = errors.New("match_subject_alt_names requires require_client_cert in server tls config")
+
+// sanMatcher matches the subject alternative names of a type of the peer certificate
+type sanMatcher struct {
+	sanType v2.SanType
+	exact   string
+	prefix  string
+	regex   *regexp.Regexp
+}
+
+func newSANMatchers(cfgs []v2.SubjectAltNameMatcher) ([]*sanMatcher, error) {
+	matchers := make([]*sanMatcher, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		switch cfg.SanType {
+		case v2.SAN_DNS, v2.SAN_URI, v2.SAN_EMAIL, v2.SAN_IP:
+		default:
+			return nil, fmt.Errorf("invalid subject alternative name type: %q", cfg.SanType)
+		}
+		m := &sanMatcher{
+			sanType: cfg.SanType,
+		}
+		switch {
+		case cfg.Exact != "":
+			m.exact = cfg.Exact
+		case cfg.Prefix != "":
+			m.prefix = cfg.Prefix
+		case cfg.Regex != "":
+			regex, err := regexp.Compile(cfg.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid subject alternative name regex %s: %v", cfg.Regex, err)
+			}
+			m.regex = regex
+		default:
+			return nil, errors.New("empty subject alternative name matcher")
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// match returns true if any of the subject alternative names of the matcher's type is matched
+func (m *sanMatcher) match(cert *x509.Certificate) bool {
+	for _, name := range subjectAltNames(cert, m.sanType) {
+		if m.matchName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *sanMatcher) matchName(name string) bool {
+	switch {
+	case m.exact != "":
+		return name == m.exact
+	case m.prefix != "":
+		return strings.HasPrefix(name, m.prefix)
+	case m.regex != nil:
+		return m.regex.MatchString(name)
+	}
+	return false
+}
+
+// subjectAltNames returns the subject alternative names of the type in the certificate
+func subjectAltNames(cert *x509.Certificate, sanType v2.SanType) []string {
+	var names []string
+	switch sanType {
+	case v2.SAN_DNS:
+		names = cert.DNSNames
+	case v2.SAN_URI:
+		for _, uri := range cert.URIs {
+			names = append(names, uri.String())
+		}
+	case v2.SAN_EMAIL:
+		names = cert.EmailAddresses
+	case v2.SAN_IP:
+		for _, ip := range cert.IPAddresses {
+			names = append(names, ip.String())
+		}
+	}
+	return names
+}
+
+// verifySubjectAltNames returns a function used as tls.Config.VerifyPeerCertificate,
+// the verify is called first if it is not nil, and then the subject alternative names of the peer certificate are matched.
+func verifySubjectAltNames(matchers []*sanMatcher, verify func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if verify != nil {
+			if err := verify(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+		if len(rawCerts) == 0 {
+			return errors.New("no peer certificate")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		for _, m := range matchers {
+			if m.match(cert) {
+				return nil
+			}
+		}
+		return ErrorSubjectAltNameNotMatched
+	}
+}
+
+// verifyChain returns a function that verifies the peer certificate chain by the roots without the host name verification,
+// the subject alternative names matching is used instead of the host name.
+func verifyChain(roots *x509.CertPool) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+		if len(certs) == 0 {
+			return errors.New("no peer certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+// PeerIdentity returns the identity of the verified peer certificate of a tls connection,
+// the identity is the first uri subject alternative name (such as a spiffe id) if exists,
+// otherwise the first dns subject alternative name or the common name.
+// an empty string is returned if the connection is not a tls connection or has no peer certificate.
+func PeerIdentity(conn net.Conn) string {
+	tlsConn, ok := conn.(*TLSConn)
+	if !ok || tlsConn == nil || tlsConn.Conn == nil {
+		return ""
+	}
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return ""
+	}
+	cert := certs[0]
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,302 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls/certtool"
+)
+
+// createSPIFFESecret creates a certificate with an uri subject alternative name
+func createSPIFFESecret(t *testing.T, cn, spiffeID string) *secretInfo {
+	priv, err := certtool.GeneratePrivateKey("P256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := certtool.CreateTemplate(cn, false, []string{cn + ".example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(spiffeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.URIs = []*url.URL{uri}
+	cert, err := certtool.SignCertificate(tmpl, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &secretInfo{
+		Certificate: cert.CertPem,
+		PrivateKey:  cert.KeyPem,
+		Validation:  certtool.GetRootCA().CertPem,
+	}
+}
+
+func secretTLSConfig(secret *secretInfo) v2.TLSConfig {
+	return v2.TLSConfig{
+		Status:     true,
+		CACert:     secret.Validation,
+		CertChain:  secret.Certificate,
+		PrivateKey: secret.PrivateKey,
+	}
+}
+
+// handshakeResult is the result of a tls handshake, the connections are not closed
+type handshakeResult struct {
+	serverConn net.Conn
+	serverErr  error
+	clientConn net.Conn
+	clientErr  error
+}
+
+func (r *handshakeResult) close() {
+	if r.serverConn != nil {
+		r.serverConn.Close()
+	}
+	if r.clientConn != nil {
+		r.clientConn.Close()
+	}
+}
+
+func handshake(t *testing.T, serverCfg, clientCfg *v2.TLSConfig) *handshakeResult {
+	srvMng, err := NewTLSServerContextManager(&v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			FilterChains: []v2.FilterChain{
+				{
+					TLSContexts: []v2.TLSConfig{*serverCfg},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create server context manager failed: %v", err)
+	}
+	cltMng, err := NewTLSClientContextManager(clientCfg)
+	if err != nil {
+		t.Fatalf("create client context manager failed: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	result := &handshakeResult{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c, err := ln.Accept()
+		if err != nil {
+			result.serverErr = err
+			return
+		}
+		conn, err := srvMng.Conn(c)
+		if err != nil {
+			c.Close()
+			result.serverErr = err
+			return
+		}
+		result.serverConn = conn
+		result.serverErr = conn.(*TLSConn).Handshake()
+	}()
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn, err := cltMng.Conn(c)
+	if err != nil {
+		t.Fatalf("create tls conn failed: %v", err)
+	}
+	result.clientConn = conn
+	result.clientErr = conn.(*TLSConn).Handshake()
+	if result.clientErr != nil {
+		// the server is waiting the handshake finished
+		conn.Close()
+	}
+	<-done
+	return result
+}
+
+func TestClientMatchSubjectAltNames(t *testing.T) {
+	serverSecret := createSPIFFESecret(t, "server", "spiffe://example.org/ns/default/sa/server")
+	serverCfg := secretTLSConfig(serverSecret)
+	for _, tc := range []struct {
+		name     string
+		matchers []v2.SubjectAltNameMatcher
+		success  bool
+	}{
+		{
+			name:     "exact uri",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Exact: "spiffe://example.org/ns/default/sa/server"}},
+			success:  true,
+		},
+		{
+			name:     "prefix uri",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Prefix: "spiffe://example.org/"}},
+			success:  true,
+		},
+		{
+			name:     "regex dns",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_DNS, Regex: `^.*\.example\.com$`}},
+			success:  true,
+		},
+		{
+			name: "any matched",
+			matchers: []v2.SubjectAltNameMatcher{
+				{SanType: v2.SAN_URI, Exact: "spiffe://other.org/ns/default/sa/server"},
+				{SanType: v2.SAN_DNS, Exact: "server.example.com"},
+			},
+			success: true,
+		},
+		{
+			name:     "not matched",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Prefix: "spiffe://other.org/"}},
+			success:  false,
+		},
+		{
+			name:     "only the names of the type are matched",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_DNS, Exact: "spiffe://example.org/ns/default/sa/server"}},
+			success:  false,
+		},
+		{
+			name:     "exact ip",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_IP, Exact: "127.0.0.1"}},
+			success:  true,
+		},
+		{
+			name:     "no email",
+			matchers: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_EMAIL, Regex: ".*"}},
+			success:  false,
+		},
+	} {
+		clientCfg := &v2.TLSConfig{
+			Status:               true,
+			CACert:               serverSecret.Validation,
+			ServerName:           "not.matched.host",
+			MatchSubjectAltNames: tc.matchers,
+		}
+		result := handshake(t, &serverCfg, clientCfg)
+		if (result.clientErr == nil) != tc.success {
+			t.Errorf("case %s expected handshake success %v, but got error: %v", tc.name, tc.success, result.clientErr)
+		}
+		if tc.success {
+			if id := PeerIdentity(result.clientConn); id != "spiffe://example.org/ns/default/sa/server" {
+				t.Errorf("case %s unexpected peer identity: %s", tc.name, id)
+			}
+		}
+		result.close()
+	}
+}
+
+func TestClientMatchSubjectAltNamesUntrusted(t *testing.T) {
+	serverSecret := createSPIFFESecret(t, "server", "spiffe://example.org/ns/default/sa/server")
+	serverCfg := secretTLSConfig(serverSecret)
+	// the chain is verified even if the subject alternative name is matched
+	otherCA, err := certtool.CreateTemplate("other ca", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := certtool.GeneratePrivateKey("P256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	caInfo, err := certtool.CreateCertificateInfo(otherCA, otherCA, priv, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCfg := &v2.TLSConfig{
+		Status:               true,
+		CACert:               caInfo.CertPem,
+		MatchSubjectAltNames: []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Prefix: "spiffe://example.org/"}},
+	}
+	result := handshake(t, &serverCfg, clientCfg)
+	defer result.close()
+	if result.clientErr == nil {
+		t.Fatal("expected handshake failed with an untrusted certificate")
+	}
+}
+
+func TestServerMatchSubjectAltNames(t *testing.T) {
+	serverSecret := createSPIFFESecret(t, "server", "spiffe://example.org/ns/default/sa/server")
+	serverCfg := secretTLSConfig(serverSecret)
+	serverCfg.RequireClientCert = true
+	serverCfg.VerifyClient = true
+	serverCfg.MatchSubjectAltNames = []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Exact: "spiffe://example.org/ns/default/sa/client"}}
+	for _, tc := range []struct {
+		spiffeID string
+		success  bool
+	}{
+		{"spiffe://example.org/ns/default/sa/client", true},
+		{"spiffe://example.org/ns/default/sa/other", false},
+	} {
+		clientCfg := secretTLSConfig(createSPIFFESecret(t, "client", tc.spiffeID))
+		clientCfg.InsecureSkip = true
+		result := handshake(t, &serverCfg, &clientCfg)
+		if (result.serverErr == nil) != tc.success {
+			t.Errorf("client %s expected handshake success %v, but got error: %v", tc.spiffeID, tc.success, result.serverErr)
+		}
+		if tc.success {
+			if id := PeerIdentity(result.serverConn); id != tc.spiffeID {
+				t.Errorf("unexpected peer identity: %s", id)
+			}
+		}
+		result.close()
+	}
+}
+
+func TestInvalidSubjectAltNameMatcher(t *testing.T) {
+	for _, matchers := range [][]v2.SubjectAltNameMatcher{
+		{{}},
+		{{SanType: v2.SAN_DNS}},
+		{{Exact: "server.example.com"}},
+		{{SanType: "OTHER", Exact: "server.example.com"}},
+		{{SanType: v2.SAN_DNS, Regex: "[a-z"}},
+	} {
+		if _, err := NewTLSClientContextManager(&v2.TLSConfig{
+			Status:               true,
+			MatchSubjectAltNames: matchers,
+		}); err == nil {
+			t.Errorf("expected error for invalid matchers: %v", matchers)
+		}
+	}
+}
+
+func TestServerMatchSubjectAltNamesWithoutClientCert(t *testing.T) {
+	serverCfg := secretTLSConfig(createSPIFFESecret(t, "server", "spiffe://example.org/ns/default/sa/server"))
+	serverCfg.MatchSubjectAltNames = []v2.SubjectAltNameMatcher{{SanType: v2.SAN_URI, Exact: "spiffe://example.org/ns/default/sa/client"}}
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			FilterChains: []v2.FilterChain{
+				{
+					TLSContexts: []v2.TLSConfig{serverCfg},
+				},
+			},
+		},
+	}
+	if _, err := NewTLSServerContextManager(lc); err != ErrorSubjectAltNameWithoutClientCert {
+		t.Fatalf("expected error %v, but got %v", ErrorSubjectAltNameWithoutClientCert, err)
+	}
+	// the client config does not require the client certificate
+	if _, err := NewTLSClientContextManager(&serverCfg); err != nil {
+		t.Fatalf("create client context manager failed: %v", err)
+	}
+}
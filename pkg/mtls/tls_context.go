@@ -46,6 +46,8 @@ type tlsContext struct {
 	matches    map[string]struct{}
 	client     *tls.Config
 	server     *tls.Config
+	// sanMatchers verifies the subject alternative names of the peer certificate
+	sanMatchers []*sanMatcher
 }
 
 func (ctx *tlsContext) buildMatch() {
@@ -90,6 +92,9 @@ func (ctx *tlsContext) setServerConfig(tmpl tls.Config, cfg *v2.TLSConfig, hooks
 		}
 	}
 	tlsConfig.VerifyPeerCertificate = hooks.ServerHandshakeVerify(tlsConfig)
+	if cfg.RequireClientCert && len(ctx.sanMatchers) > 0 {
+		tlsConfig.VerifyPeerCertificate = verifySubjectAltNames(ctx.sanMatchers, tlsConfig.VerifyPeerCertificate)
+	}
 	ctx.server = tlsConfig
 	// build matches
 	ctx.buildMatch()
@@ -106,6 +111,14 @@ func (ctx *tlsContext) setClientConfig(tmpl tls.Config, cfg *v2.TLSConfig, hooks
 	if cfg.InsecureSkip {
 		tlsConfig.InsecureSkipVerify = true
 		tlsConfig.VerifyPeerCertificate = nil
+	} else if len(ctx.sanMatchers) > 0 {
+		// the subject alternative names matching replaces the host name verification
+		verify := tlsConfig.VerifyPeerCertificate
+		if verify == nil {
+			verify = verifyChain(tlsConfig.RootCAs)
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifySubjectAltNames(ctx.sanMatchers, verify)
 	}
 	ctx.client = tlsConfig
 }
@@ -157,6 +170,10 @@ func newTLSContext(cfg *v2.TLSConfig, secret *secretInfo) (*tlsContext, error) {
 	if err != nil {
 		return nil, err
 	}
+	matchers, err := newSANMatchers(cfg.MatchSubjectAltNames)
+	if err != nil {
+		return nil, err
+	}
 	// extension config
 	factory := getFactory(cfg.Type)
 	hooks := factory.CreateConfigHooks(cfg.ExtendVerify)
@@ -169,8 +186,9 @@ func newTLSContext(cfg *v2.TLSConfig, secret *secretInfo) (*tlsContext, error) {
 	tmpl.ClientCAs = pool
 	// set tls context
 	ctx := &tlsContext{
-		serverName: cfg.ServerName,
-		ticket:     cfg.Ticket,
+		serverName:  cfg.ServerName,
+		ticket:      cfg.Ticket,
+		sanMatchers: matchers,
 	}
 	cert, err := hooks.GetCertificate(secret.Certificate, secret.PrivateKey)
 	switch err {
@@ -20,6 +20,7 @@ package mtls
 import (
 	"net"
 	"reflect"
+	"sync"
 
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
@@ -33,7 +34,8 @@ type serverContextManager struct {
 	// if inspector is true, the manager support non-tls connections
 	inspector bool
 	// config is a tls.config with GetConfigForClient
-	config *tls.Config
+	config      *tls.Config
+	releaseOnce sync.Once
 }
 
 // NewTLSServerContextManager returns a types.TLSContextManager used in TLS Server
@@ -47,15 +49,23 @@ func NewTLSServerContextManager(cfg *v2.Listener) (types.TLSContextManager, erro
 	}
 	for _, c := range cfg.FilterChains {
 		for _, tlsCfg := range c.TLSContexts {
+			// the subject alternative names can be verified only if the client certificate is required
+			if tlsCfg.Status && len(tlsCfg.MatchSubjectAltNames) > 0 && !tlsCfg.RequireClientCert {
+				mng.release()
+				return nil, ErrorSubjectAltNameWithoutClientCert
+			}
 			provider, err := NewProvider(&tlsCfg)
 			if err != nil {
+				mng.release()
 				return nil, err
 			}
 			// provider is an interface, needs to check by reflect
 			if provider != nil && !reflect.ValueOf(provider).IsNil() {
 				// if a server receive a empty provider and do not support fallback, it should be failed
 				if provider.Empty() {
+					releaseProvider(provider)
 					if !tlsCfg.Fallback {
+						mng.release()
 						return nil, ErrorNoCertConfigure
 					}
 					log.DefaultLogger.Alertf(types.ErrorKeyTLSFallback, "listener enable tls without certificate, fallback tls")
@@ -92,8 +102,12 @@ func (mng *serverContextManager) GetConfigForClient(info *tls.ClientHelloInfo) (
 }
 
 func (mng *serverContextManager) Conn(c net.Conn) (net.Conn, error) {
-	if _, ok := c.(*net.TCPConn); !ok {
-		return c, nil
+	// the connection may be peeked already, such as the filter chain matching
+	conn, peeked := c.(*Conn)
+	if !peeked {
+		if _, ok := c.(*net.TCPConn); !ok {
+			return c, nil
+		}
 	}
 	if !mng.Enabled() {
 		return c, nil
@@ -104,8 +118,10 @@ func (mng *serverContextManager) Conn(c net.Conn) (net.Conn, error) {
 		}, nil
 	}
 	// inspector
-	conn := &Conn{
-		Conn: c,
+	if !peeked {
+		conn = &Conn{
+			Conn: c,
+		}
 	}
 	buf, err := conn.Peek()
 	if err != nil {
@@ -123,6 +139,14 @@ func (mng *serverContextManager) Conn(c net.Conn) (net.Conn, error) {
 	}
 }
 
+func (mng *serverContextManager) release() {
+	mng.releaseOnce.Do(func() {
+		for _, p := range mng.providers {
+			releaseProvider(p)
+		}
+	})
+}
+
 func (mng *serverContextManager) Enabled() bool {
 	for _, p := range mng.providers {
 		if p.Ready() {
@@ -134,7 +158,8 @@ func (mng *serverContextManager) Enabled() bool {
 
 type clientContextManager struct {
 	// client support only one certificate
-	provider types.TLSProvider
+	provider    types.TLSProvider
+	releaseOnce sync.Once
 }
 
 // NewTLSClientContextManager returns a types.TLSContextManager used in TLS Client
@@ -164,3 +189,27 @@ func (mng *clientContextManager) Conn(c net.Conn) (net.Conn, error) {
 func (mng *clientContextManager) Enabled() bool {
 	return mng.provider != nil && mng.provider.Ready()
 }
+
+func (mng *clientContextManager) release() {
+	mng.releaseOnce.Do(func() {
+		if mng.provider != nil {
+			releaseProvider(mng.provider)
+		}
+	})
+}
+
+// ReleaseTLSContextManager releases the providers used by the tls context manager,
+// it should be called when the listener or cluster that owns the manager is removed or updated.
+// the connections made by the manager are not affected.
+func ReleaseTLSContextManager(mng types.TLSContextManager) {
+	if m, ok := mng.(interface{ release() }); ok && !reflect.ValueOf(mng).IsNil() {
+		m.release()
+	}
+}
+
+// releaseProvider stops the background work of the provider if it is not used any more
+func releaseProvider(p types.TLSProvider) {
+	if fp, ok := p.(*fileProvider); ok && fp != nil {
+		releaseFileProvider(fp)
+	}
+}
@@ -955,7 +955,8 @@ func (cc *clientConnection) Connect() (err error) {
 
 		addr := cc.RemoteAddr()
 		if addr != nil {
-			cc.rawConnection, err = net.DialTimeout("tcp", cc.RemoteAddr().String(), timeout)
+			// the network of the address is tcp or unix
+			cc.rawConnection, err = net.DialTimeout(addr.Network(), addr.String(), timeout)
 		} else {
 			err = errors.New("ClientConnection RemoteAddr is nil")
 		}
@@ -976,8 +977,8 @@ func (cc *clientConnection) Connect() (err error) {
 			// ensure ioEnabled and UseNetpollMode
 			if UseNetpollMode {
 				// store fd
-				if tc, ok := cc.rawConnection.(*net.TCPConn); ok {
-					cc.file, err = tc.File()
+				if fc, ok := cc.rawConnection.(fileConn); ok {
+					cc.file, err = fc.File()
 					if err != nil {
 						return
 					}
@@ -22,7 +22,9 @@ import (
 	"net"
 	"os"
 	"runtime/debug"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"mosn.io/mosn/pkg/config/v2"
@@ -44,6 +46,13 @@ const (
 	ListenerStopped
 )
 
+// rawListener is the tcp listener or the unix domain socket listener
+type rawListener interface {
+	net.Listener
+	SetDeadline(t time.Time) error
+	File() (f *os.File, err error)
+}
+
 // listener impl based on golang net package
 type listener struct {
 	name                    string
@@ -53,7 +62,7 @@ type listener struct {
 	perConnBufferLimitBytes uint32
 	useOriginalDst          bool
 	cb                      types.ListenerEventListener
-	rawl                    *net.TCPListener
+	rawl                    rawListener
 	config                  *v2.Listener
 	mutex                   sync.Mutex
 	// listener state indicates the listener's running state. The listener state effects if a listener binded to a port
@@ -61,6 +70,9 @@ type listener struct {
 }
 
 func NewListener(lc *v2.Listener) types.Listener {
+	if lc.Network == v2.NetworkUDP {
+		return newUDPListener(lc)
+	}
 
 	l := &listener{
 		name:                    lc.Name,
@@ -72,9 +84,9 @@ func NewListener(lc *v2.Listener) types.Listener {
 		config:                  lc,
 	}
 
-	if lc.InheritListener != nil {
+	if rawl, ok := lc.InheritListener.(rawListener); ok {
 		//inherit old process's listener
-		l.rawl = lc.InheritListener
+		l.rawl = rawl
 	}
 	return l
 }
@@ -178,6 +190,10 @@ func (l *listener) SetListenerTag(tag uint64) {
 }
 
 func (l *listener) ListenerFile() (*os.File, error) {
+	// the socket file is used by the new process after the listener is inherited
+	if ul, ok := l.rawl.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
 	return l.rawl.File()
 }
 
@@ -217,10 +233,18 @@ func (l *listener) Close(lctx context.Context) error {
 }
 
 func (l *listener) listen(lctx context.Context) error {
-	var err error
+	if addr, ok := l.localAddress.(*net.UnixAddr); ok {
+		removeStaleUnixSocket(addr.Name)
+		rawl, err := net.ListenUnix("unix", addr)
+		if err != nil {
+			return err
+		}
+		l.rawl = rawl
+		return nil
+	}
 
-	var rawl *net.TCPListener
-	if rawl, err = net.ListenTCP("tcp", l.localAddress.(*net.TCPAddr)); err != nil {
+	rawl, err := net.ListenTCP("tcp", l.localAddress.(*net.TCPAddr))
+	if err != nil {
 		return err
 	}
 
@@ -229,6 +253,40 @@ func (l *listener) listen(lctx context.Context) error {
 	return nil
 }
 
+// removeStaleUnixSocket removes the socket file left by the exited process, otherwise the listen will be failed.
+// the socket is removed only if no process listens on it, such as the old mosn during hot upgrade
+func removeStaleUnixSocket(path string) {
+	// abstract unix domain socket has no file
+	if strings.HasPrefix(path, "@") {
+		return
+	}
+	if fi, err := os.Lstat(path); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		log.DefaultLogger.Warnf("[network] [listener] unix domain socket %s is in use, keep it", path)
+		return
+	}
+	if !isConnRefused(err) {
+		log.DefaultLogger.Warnf("[network] [listener] probe unix domain socket %s failed, keep it: %v", path, err)
+		return
+	}
+	log.DefaultLogger.Infof("[network] [listener] remove stale unix domain socket %s", path)
+	os.Remove(path)
+}
+
+func isConnRefused(err error) bool {
+	if oe, ok := err.(*net.OpError); ok {
+		err = oe.Err
+	}
+	if se, ok := err.(*os.SyscallError); ok {
+		err = se.Err
+	}
+	return err == syscall.ECONNREFUSED
+}
+
 func (l *listener) accept(lctx context.Context) error {
 	rawc, err := l.rawl.Accept()
 
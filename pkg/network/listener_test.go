@@ -19,7 +19,10 @@ package network
 
 import (
 	"context"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -98,3 +101,113 @@ func TestListenerStart(t *testing.T) {
 	}
 
 }
+
+type acceptEventListener struct {
+	mockEventListener
+	accepted chan net.Conn
+}
+
+func (e *acceptEventListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemoteAddr net.Addr, c chan api.Connection, buf []byte) {
+	e.accepted <- rawc
+}
+
+func TestUnixListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_unix_listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mosn.sock")
+	// a stale socket file left by the exited process
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	addr, err := v2.ResolveAddress(v2.UnixAddressPrefix + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:       "test_unix_listener",
+			BindToPort: true,
+		},
+		Addr: addr,
+	}
+	ln := NewListener(cfg)
+	cb := &acceptEventListener{
+		accepted: make(chan net.Conn, 1),
+	}
+	ln.SetListenerCallbacks(cb)
+	go ln.Start(nil, false)
+	time.Sleep(time.Second)
+
+	cc := NewClientConnection(nil, time.Second, nil, addr, nil)
+	if err := cc.Connect(); err != nil {
+		t.Fatalf("connect unix listener failed: %v", err)
+	}
+	defer cc.Close(api.NoFlush, api.LocalClose)
+	select {
+	case rawc := <-cb.accepted:
+		if v2.AddressString(rawc.LocalAddr()) != v2.UnixAddressPrefix+path {
+			t.Errorf("unexpected local address: %s", rawc.LocalAddr())
+		}
+		rawc.Close()
+	case <-time.After(time.Second):
+		t.Fatal("no connection accepted")
+	}
+	// the socket file is kept for the new process after the listener file is transferred
+	f, err := ln.ListenerFile()
+	if err != nil {
+		t.Fatalf("get listener file failed: %v", err)
+	}
+	f.Close()
+	ln.Close(nil)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("socket file should not be removed: %v", err)
+	}
+}
+
+func TestUDPListenerNotStarted(t *testing.T) {
+	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:10103")
+	ln := newUDPListener(&v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name: "test_udp_listener",
+		},
+		Addr: addr,
+	})
+	if err := ln.Stop(); err != nil {
+		t.Fatalf("stop a listener that is not started should be ok, but got %v", err)
+	}
+	if _, err := ln.ListenerFile(); err != errUDPListenerNotStarted {
+		t.Fatalf("expected error %v, but got %v", errUDPListenerNotStarted, err)
+	}
+}
+
+func TestRemoveStaleUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_unix_socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mosn.sock")
+	// the socket is in use, such as the old process during hot upgrade
+	live, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	removeStaleUnixSocket(path)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("socket in use should not be removed: %v", err)
+	}
+	live.SetUnlinkOnClose(false)
+	live.Close()
+	// no process listens on it
+	removeStaleUnixSocket(path)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("stale socket should be removed: %v", err)
+	}
+}
@@ -224,15 +224,20 @@ func transferWrite(c *connection, id uint64) error {
 	return nil
 }
 
+// fileConn is the tcp conn or the unix domain socket conn
+type fileConn interface {
+	File() (f *os.File, err error)
+}
+
 func transferGetFile(c *connection) (file *os.File, tlsConn *mtls.TLSConn, err error) {
 	switch conn := c.rawConnection.(type) {
-	case *net.TCPConn:
-		file, err = conn.File()
+	case *net.TCPConn, *net.UnixConn:
+		file, err = conn.(fileConn).File()
 		if err != nil {
 			return nil, nil, fmt.Errorf("TCP File failed %v", err)
 		}
 	case *mtls.Conn:
-		mtlsConn, ok := conn.Conn.(*net.TCPConn)
+		mtlsConn, ok := conn.Conn.(fileConn)
 		if !ok {
 			return nil, nil, errors.New("unexpected Conn type")
 		}
@@ -245,7 +250,7 @@ func transferGetFile(c *connection) (file *os.File, tlsConn *mtls.TLSConn, err e
 		netConn := conn.GetRawConn()
 		switch conn := netConn.(type) {
 		case *mtls.Conn:
-			mtlsConn, ok := conn.Conn.(*net.TCPConn)
+			mtlsConn, ok := conn.Conn.(fileConn)
 			if !ok {
 				return nil, nil, errors.New("unexpected Conn type")
 			}
@@ -253,8 +258,8 @@ func transferGetFile(c *connection) (file *os.File, tlsConn *mtls.TLSConn, err e
 			if err != nil {
 				return nil, nil, fmt.Errorf("mtls.Conn File failed %v", err)
 			}
-		case *net.TCPConn:
-			file, err = conn.File()
+		case *net.TCPConn, *net.UnixConn:
+			file, err = conn.(fileConn).File()
 			if err != nil {
 				return nil, nil, fmt.Errorf("TCPConn File failed %v", err)
 			}
@@ -262,7 +267,7 @@ func transferGetFile(c *connection) (file *os.File, tlsConn *mtls.TLSConn, err e
 			return nil, nil, errors.New("unexpected Conn type")
 		}
 	default:
-		return nil, nil, fmt.Errorf("unexpected net.Conn type; expected TCPConn or mtls.TLSConn, got %T", conn)
+		return nil, nil, fmt.Errorf("unexpected net.Conn type; expected TCPConn, UnixConn or mtls.TLSConn, got %T", conn)
 	}
 	return
 }
@@ -567,6 +572,14 @@ func transferNewConn(conn net.Conn, dataBuf, tlsBuf []byte, handler types.Connec
 }
 
 func transferFindListen(addr net.Addr, handler types.ConnectionHandler) types.Listener {
+	// the unix domain socket listener is found by the path
+	if _, ok := addr.(*net.UnixAddr); ok {
+		if listener := handler.FindListenerByAddress(addr); listener != nil {
+			return listener
+		}
+		log.DefaultLogger.Errorf("[network] [transfer] Find Listener failed %v", addr)
+		return nil
+	}
 	address := addr.(*net.TCPAddr)
 	port := strconv.FormatInt(int64(address.Port), 10)
 	ipv4, _ := net.ResolveTCPAddr("tcp", "0.0.0.0:"+port)
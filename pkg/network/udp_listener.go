@@ -0,0 +1,239 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+// MaxUDPDatagramSize is the max size of a udp datagram
+const MaxUDPDatagramSize = 65535
+
+var (
+	errUDPListenerCallbacks  = errors.New("udp listener callbacks should implement types.UDPListenerEventListener")
+	errUDPListenerNotStarted = errors.New("udp listener is not started")
+)
+
+// udpListener impl based on golang net package.
+// udpListener has no connections, the datagrams are delivered to the listener callbacks.
+type udpListener struct {
+	name                    string
+	localAddress            net.Addr
+	bindToPort              bool
+	listenerTag             uint64
+	perConnBufferLimitBytes uint32
+	cb                      types.ListenerEventListener
+	rawc                    *net.UDPConn
+	config                  *v2.Listener
+	mutex                   sync.Mutex
+	state                   ListenerState
+}
+
+func newUDPListener(lc *v2.Listener) *udpListener {
+	l := &udpListener{
+		name:                    lc.Name,
+		localAddress:            lc.Addr,
+		bindToPort:              lc.BindToPort,
+		listenerTag:             lc.ListenerTag,
+		perConnBufferLimitBytes: lc.PerConnBufferLimitBytes,
+		config:                  lc,
+	}
+	if lc.InheritPacketConn != nil {
+		//inherit old process's listener
+		l.rawc = lc.InheritPacketConn
+	}
+	return l
+}
+
+func (l *udpListener) Config() *v2.Listener {
+	return l.config
+}
+
+func (l *udpListener) SetConfig(config *v2.Listener) {
+	l.config = config
+}
+
+func (l *udpListener) Name() string {
+	return l.name
+}
+
+func (l *udpListener) Addr() net.Addr {
+	return l.localAddress
+}
+
+func (l *udpListener) Start(lctx context.Context, restart bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Alertf("listener.start", "[network] [udp listener start] panic %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+
+	if !l.bindToPort {
+		return
+	}
+	cb, ok := l.cb.(types.UDPListenerEventListener)
+	if !ok {
+		log.DefaultLogger.Alertf("listener.start", "[network] [udp listener start] %s start failed: %v", l.name, errUDPListenerCallbacks)
+		return
+	}
+	ignore := func() bool {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		switch l.state {
+		case ListenerRunning:
+			log.DefaultLogger.Debugf("[network] [udp listener start] %s is running", l.name)
+			return true
+		case ListenerStopped:
+			if !restart {
+				return true
+			}
+			log.DefaultLogger.Infof("[network] [udp listener start] %s restart listener ", l.name)
+			if err := l.listen(lctx); err != nil {
+				log.DefaultLogger.Alertf("listener.start", "[network] [udp listener start] [listen] %s listen failed, %v", l.name, err)
+				return true
+			}
+		default:
+			if l.rawc == nil {
+				if err := l.listen(lctx); err != nil {
+					log.StartLogger.Fatalf("[network] [udp listener start] [listen] %s listen failed, %v", l.name, err)
+				}
+			}
+		}
+		l.state = ListenerRunning
+		metrics.AddListenerAddr(l.rawc.LocalAddr().String())
+		return false
+	}()
+	if ignore {
+		return
+	}
+	l.readLoop(cb)
+}
+
+func (l *udpListener) readLoop(cb types.UDPListenerEventListener) {
+	rawc := l.rawc
+	buf := make([]byte, MaxUDPDatagramSize)
+	for {
+		n, addr, err := rawc.ReadFrom(buf)
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				log.DefaultLogger.Infof("[network] [udp listener start] [read] listener %s stop reading datagrams by deadline", l.name)
+				return
+			}
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			log.DefaultLogger.Infof("[network] [udp listener start] [read] listener %s %s closed: %v", l.name, l.Addr(), err)
+			return
+		}
+		cb.OnDatagram(buf[:n], addr)
+	}
+}
+
+// WriteTo sends a datagram by the listener's socket
+func (l *udpListener) WriteTo(data []byte, addr net.Addr) (int, error) {
+	l.mutex.Lock()
+	rawc := l.rawc
+	l.mutex.Unlock()
+	if rawc == nil {
+		return 0, errUDPListenerNotStarted
+	}
+	return rawc.WriteTo(data, addr)
+}
+
+func (l *udpListener) Stop() error {
+	l.mutex.Lock()
+	rawc := l.rawc
+	l.mutex.Unlock()
+	// the listener is not started, such as bind_port is false
+	if rawc == nil {
+		return nil
+	}
+	return rawc.SetReadDeadline(time.Now())
+}
+
+func (l *udpListener) ListenerTag() uint64 {
+	return l.listenerTag
+}
+
+func (l *udpListener) SetListenerTag(tag uint64) {
+	l.listenerTag = tag
+}
+
+func (l *udpListener) ListenerFile() (*os.File, error) {
+	l.mutex.Lock()
+	rawc := l.rawc
+	l.mutex.Unlock()
+	if rawc == nil {
+		return nil, errUDPListenerNotStarted
+	}
+	return rawc.File()
+}
+
+func (l *udpListener) PerConnBufferLimitBytes() uint32 {
+	return l.perConnBufferLimitBytes
+}
+
+func (l *udpListener) SetPerConnBufferLimitBytes(limitBytes uint32) {
+	l.perConnBufferLimitBytes = limitBytes
+}
+
+// SetUseOriginalDst is ignored, udp listener does not support original dst
+func (l *udpListener) SetUseOriginalDst(use bool) {}
+
+func (l *udpListener) UseOriginalDst() bool {
+	return false
+}
+
+func (l *udpListener) SetListenerCallbacks(cb types.ListenerEventListener) {
+	l.cb = cb
+}
+
+func (l *udpListener) GetListenerCallbacks() types.ListenerEventListener {
+	return l.cb
+}
+
+func (l *udpListener) Close(lctx context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.state = ListenerStopped
+	if l.rawc != nil {
+		l.cb.OnClose()
+		return l.rawc.Close()
+	}
+	return nil
+}
+
+func (l *udpListener) listen(lctx context.Context) error {
+	rawc, err := net.ListenUDP("udp", l.localAddress.(*net.UDPAddr))
+	if err != nil {
+		return err
+	}
+	l.rawc = rawc
+	return nil
+}
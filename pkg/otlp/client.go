@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	proto "github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// client sends the export requests to the collector
+type client interface {
+	// export sends the request, retryable is true if the request failed and can be retried
+	export(ctx context.Context, request, response proto.Message) (retryable bool, err error)
+	close()
+}
+
+// grpcClient sends the requests over OTLP/gRPC
+type grpcClient struct {
+	conn    *grpc.ClientConn
+	method  string
+	headers metadata.MD
+}
+
+func newGRPCClient(endpoint, method string, headers map[string]string) (*grpcClient, error) {
+	// the connection is established in background, and is reconnected if it is broken
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return &grpcClient{
+		conn:    conn,
+		method:  method,
+		headers: metadata.New(headers),
+	}, nil
+}
+
+func (c *grpcClient) export(ctx context.Context, request, response proto.Message) (bool, error) {
+	if len(c.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.headers)
+	}
+	err := c.conn.Invoke(ctx, c.method, request, response)
+	if err == nil {
+		return false, nil
+	}
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return true, err
+	default:
+		return false, err
+	}
+}
+
+func (c *grpcClient) close() {
+	c.conn.Close()
+}
+
+// httpClient sends the requests over OTLP/HTTP in binary protobuf encoding
+type httpClient struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPClient(url string, headers map[string]string) (*httpClient, error) {
+	return &httpClient{
+		client:  &http.Client{},
+		url:     url,
+		headers: headers,
+	}, nil
+}
+
+func (c *httpClient) export(ctx context.Context, request, response proto.Message) (bool, error) {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// the network errors can be retried
+		return true, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		if err == nil && len(data) > 0 {
+			proto.Unmarshal(data, response)
+		}
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func (c *httpClient) close() {
+	c.client.CloseIdleConnections()
+}
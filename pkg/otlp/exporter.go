@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	proto "github.com/golang/protobuf/proto"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnsync "mosn.io/mosn/pkg/sync"
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultBatchSize     = 512
+	defaultBatchInterval = 5 * time.Second
+	defaultQueueSize     = 2048
+	defaultMaxRetries    = 3
+	defaultRetryBackOff  = time.Second
+
+	// ScopeName is the name of the instrumentation scope of the telemetry data produced by MOSN
+	ScopeName = "mosn"
+)
+
+var (
+	ErrEmptyEndpoint   = errors.New("otlp exporter must configure the endpoint")
+	ErrUnknownProtocol = errors.New("otlp exporter protocol should be one of grpc and http")
+)
+
+// signal is the type of the telemetry data, which decides the request sent to the collector
+type signal struct {
+	name        string
+	grpcMethod  string
+	httpPath    string
+	newRequest  func(resource *Resource, scope *InstrumentationScope, items []interface{}) proto.Message
+	newResponse func() proto.Message
+}
+
+var traceSignal = &signal{
+	name:       "traces",
+	grpcMethod: "/opentelemetry.proto.collector.trace.v1.TraceService/Export",
+	httpPath:   "/v1/traces",
+	newRequest: func(resource *Resource, scope *InstrumentationScope, items []interface{}) proto.Message {
+		spans := make([]*Span, 0, len(items))
+		for _, item := range items {
+			spans = append(spans, item.(*Span))
+		}
+		return &ExportTraceServiceRequest{
+			ResourceSpans: []*ResourceSpans{{
+				Resource: resource,
+				ScopeSpans: []*ScopeSpans{{
+					Scope: scope,
+					Spans: spans,
+				}},
+			}},
+		}
+	},
+	newResponse: func() proto.Message {
+		return &ExportTraceServiceResponse{}
+	},
+}
+
+var metricsSignal = &signal{
+	name:       "metrics",
+	grpcMethod: "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export",
+	httpPath:   "/v1/metrics",
+	newRequest: func(resource *Resource, scope *InstrumentationScope, items []interface{}) proto.Message {
+		metrics := make([]*Metric, 0, len(items))
+		for _, item := range items {
+			metrics = append(metrics, item.(*Metric))
+		}
+		return &ExportMetricsServiceRequest{
+			ResourceMetrics: []*ResourceMetrics{{
+				Resource: resource,
+				ScopeMetrics: []*ScopeMetrics{{
+					Scope:   scope,
+					Metrics: metrics,
+				}},
+			}},
+		}
+	},
+	newResponse: func() proto.Message {
+		return &ExportMetricsServiceResponse{}
+	},
+}
+
+// Exporter pushes the spans or the metrics to the collector in batches.
+// The items are put into a bounded queue, and the failed requests are retried with back off
+type Exporter struct {
+	signal       *signal
+	client       client
+	resource     *Resource
+	scope        *InstrumentationScope
+	timeout      time.Duration
+	maxRetries   int
+	retryBackOff time.Duration
+	batcher      *mosnsync.Batcher
+}
+
+// NewTraceExporter creates an exporter that exports the spans
+func NewTraceExporter(cfg *v2.OTLPExporterConfig) (*Exporter, error) {
+	return newExporter(traceSignal, cfg)
+}
+
+// NewMetricsExporter creates an exporter that exports the metrics
+func NewMetricsExporter(cfg *v2.OTLPExporterConfig) (*Exporter, error) {
+	return newExporter(metricsSignal, cfg)
+}
+
+func newExporter(s *signal, cfg *v2.OTLPExporterConfig) (*Exporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, ErrEmptyEndpoint
+	}
+	var c client
+	var err error
+	switch cfg.Protocol {
+	case "", v2.OTLPProtocolGRPC:
+		c, err = newGRPCClient(cfg.Endpoint, s.grpcMethod, cfg.Headers)
+	case v2.OTLPProtocolHTTP:
+		c, err = newHTTPClient(cfg.Endpoint+s.httpPath, cfg.Headers)
+	default:
+		err = ErrUnknownProtocol
+	}
+	if err != nil {
+		return nil, err
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = v2.DefaultServiceName
+	}
+	e := &Exporter{
+		signal: s,
+		client: c,
+		resource: &Resource{
+			Attributes: []*KeyValue{StringKeyValue("service.name", serviceName)},
+		},
+		scope: &InstrumentationScope{
+			Name: ScopeName,
+		},
+		timeout:      cfg.Timeout.Duration,
+		maxRetries:   cfg.MaxRetries,
+		retryBackOff: cfg.RetryBackOff.Duration,
+	}
+	if e.timeout <= 0 {
+		e.timeout = defaultTimeout
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	batchInterval := cfg.BatchInterval.Duration
+	if batchInterval <= 0 {
+		batchInterval = defaultBatchInterval
+	}
+	if e.maxRetries <= 0 {
+		e.maxRetries = defaultMaxRetries
+	}
+	if e.retryBackOff <= 0 {
+		e.retryBackOff = defaultRetryBackOff
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	e.batcher = mosnsync.NewBatcher(queueSize, batchSize, batchInterval, e.send)
+	return e, nil
+}
+
+// ExportSpan puts the span into the queue, returns false if the queue is full and the span is dropped
+func (e *Exporter) ExportSpan(span *Span) bool {
+	return e.enqueue(span)
+}
+
+// ExportMetric puts the metric into the queue, returns false if the queue is full and the metric is dropped
+func (e *Exporter) ExportMetric(metric *Metric) bool {
+	return e.enqueue(metric)
+}
+
+func (e *Exporter) enqueue(item interface{}) bool {
+	if !e.batcher.Add(item) {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[otlp] [exporter] %s queue is full, item is dropped", e.signal.name)
+		}
+		return false
+	}
+	return true
+}
+
+// Close exports the items in the queue and stops the exporter
+func (e *Exporter) Close() {
+	e.batcher.Close()
+	e.client.close()
+}
+
+func (e *Exporter) send(items []interface{}) {
+	request := e.signal.newRequest(e.resource, e.scope, items)
+	retried, err := e.batcher.Retry(e.maxRetries, e.retryBackOff, func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		defer cancel()
+		retryable, err := e.client.export(ctx, request, e.signal.newResponse())
+		if err != nil && retryable {
+			log.DefaultLogger.Warnf("[otlp] [exporter] export %d %s failed: %v", len(items), e.signal.name, err)
+		}
+		return retryable, err
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("[otlp] [exporter] export %d %s failed after %d retries: %v", len(items), e.signal.name, retried, err)
+	}
+}
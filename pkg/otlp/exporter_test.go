@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+// receiver is an in-process OTLP/gRPC receiver
+type receiver struct {
+	server  *grpc.Server
+	addr    string
+	mutex   sync.Mutex
+	spans   []*Span
+	metrics []*Metric
+	headers []string
+}
+
+func newReceiver(t *testing.T) *receiver {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &receiver{
+		server: grpc.NewServer(),
+		addr:   ln.Addr().String(),
+	}
+	r.server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.trace.v1.TraceService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &ExportTraceServiceRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				r.mutex.Lock()
+				defer r.mutex.Unlock()
+				if md, ok := metadata.FromIncomingContext(ctx); ok {
+					r.headers = append(r.headers, md.Get("x-token")...)
+				}
+				for _, rs := range req.ResourceSpans {
+					for _, ss := range rs.ScopeSpans {
+						r.spans = append(r.spans, ss.Spans...)
+					}
+				}
+				return &ExportTraceServiceResponse{}, nil
+			},
+		}},
+	}, struct{}{})
+	go r.server.Serve(ln)
+	return r
+}
+
+func TestExporterGRPC(t *testing.T) {
+	r := newReceiver(t)
+	defer r.server.Stop()
+	e, err := NewTraceExporter(&v2.OTLPExporterConfig{
+		Endpoint:      r.addr,
+		Headers:       map[string]string{"x-token": "token"},
+		BatchSize:     2,
+		BatchInterval: api.DurationConfig{Duration: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		e.ExportSpan(&Span{Name: "span", TraceId: []byte{1}, SpanId: []byte{byte(i + 1)}})
+	}
+	e.Close()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.spans) != 5 {
+		t.Fatalf("expected 5 spans received, but got %d", len(r.spans))
+	}
+	// 5 spans are exported in 3 batches
+	if len(r.headers) != 3 || r.headers[0] != "token" {
+		t.Fatalf("unexpected headers: %v", r.headers)
+	}
+}
+
+func TestExporterHTTPRetry(t *testing.T) {
+	var calls uint32
+	var received []*Metric
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("unexpected request: %s %v", r.URL.Path, r.Header)
+		}
+		// the first request is failed with a retryable status
+		if atomic.AddUint32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("unmarshal request failed: %v", err)
+		}
+		mutex.Lock()
+		received = append(received, req.ResourceMetrics[0].ScopeMetrics[0].Metrics...)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	e, err := NewMetricsExporter(&v2.OTLPExporterConfig{
+		Protocol:      v2.OTLPProtocolHTTP,
+		Endpoint:      server.URL,
+		BatchInterval: api.DurationConfig{Duration: 10 * time.Millisecond},
+		RetryBackOff:  api.DurationConfig{Duration: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	e.ExportMetric(&Metric{
+		Name: "gauge",
+		Gauge: &Gauge{DataPoints: []*NumberDataPoint{{
+			Attributes: []*KeyValue{StringKeyValue("k", "v")},
+			Value:      &NumberDataPoint_AsInt{AsInt: 0},
+		}}},
+	})
+	for i := 0; i < 100; i++ {
+		mutex.Lock()
+		n := len(received)
+		mutex.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 1 || atomic.LoadUint32(&calls) != 2 {
+		t.Fatalf("expected the metric is received after retry, calls: %d", calls)
+	}
+	point := received[0].Gauge.DataPoints[0]
+	// the zero value of oneof should be kept
+	if _, ok := point.Value.(*NumberDataPoint_AsInt); !ok || point.Attributes[0].Value.GetStringValue() != "v" {
+		t.Fatalf("unexpected data point: %v", point)
+	}
+}
+
+func TestExporterConfig(t *testing.T) {
+	if _, err := NewTraceExporter(&v2.OTLPExporterConfig{}); err != ErrEmptyEndpoint {
+		t.Fatalf("expected empty endpoint error, but got %v", err)
+	}
+	if _, err := NewTraceExporter(&v2.OTLPExporterConfig{Endpoint: "127.0.0.1:4317", Protocol: "unknown"}); err != ErrUnknownProtocol {
+		t.Fatalf("expected unknown protocol error, but got %v", err)
+	}
+}
@@ -28,11 +28,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/valyala/fasthttp"
 	"mosn.io/api"
 	mbuffer "mosn.io/mosn/pkg/buffer"
 	"mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
 	"mosn.io/mosn/pkg/router"
@@ -65,6 +67,9 @@ type downStream struct {
 	upstreamRequest *upstreamRequest
 	perRetryTimer   *utils.Timer
 	responseTimer   *utils.Timer
+	// retryTimer continues the retry after the back off, retryBackOffDone is true when it is scheduled
+	retryTimer       *utils.Timer
+	retryBackOffDone bool
 
 	// ~~~ downstream request buf
 	downstreamReqHeaders  types.HeaderMap
@@ -91,6 +96,10 @@ type downStream struct {
 	directResponse bool
 	// oneway
 	oneway bool
+	// the streaming request body is read for the route matching
+	routeBodyRead bool
+	// the request body exceeds the route body buffer limit
+	routeBodyExceeded bool
 
 	notify chan struct{}
 
@@ -337,34 +346,38 @@ func (s *downStream) OnReceive(ctx context.Context, headers types.HeaderMap, dat
 	id := s.ID
 	// goroutine for proxy
 	pool.ScheduleAuto(func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Proxy.Errorf(s.context, "[proxy] [downstream] OnReceive panic: %v, downstream: %+v, oldId: %d, newId: %d\n%s",
-					r, s, id, s.ID, string(debug.Stack()))
+		s.process(ctx, id, types.InitPhase)
+	})
+}
 
-				if id == s.ID {
-					s.delete()
-				}
-			}
-		}()
-
-		phase := types.InitPhase
-		for i := 0; i < 10; i++ {
-			s.cleanNotify()
-
-			phase = s.receive(ctx, id, phase)
-			switch phase {
-			case types.End:
-				return
-			case types.MatchRoute:
-				log.Proxy.Debugf(s.context, "[proxy] [downstream] redo match route %+v", s)
-			case types.Retry:
-				log.Proxy.Debugf(s.context, "[proxy] [downstream] retry %+v", s)
-			case types.UpFilter:
-				log.Proxy.Debugf(s.context, "[proxy] [downstream] directResponse %+v", s)
+// process runs the proxy phases from the phase, it is also used to continue the retry after the back off
+func (s *downStream) process(ctx context.Context, id uint32, phase types.Phase) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Proxy.Errorf(s.context, "[proxy] [downstream] OnReceive panic: %v, downstream: %+v, oldId: %d, newId: %d\n%s",
+				r, s, id, s.ID, string(debug.Stack()))
+
+			if id == s.ID {
+				s.delete()
 			}
 		}
-	})
+	}()
+
+	for i := 0; i < 10; i++ {
+		s.cleanNotify()
+
+		phase = s.receive(ctx, id, phase)
+		switch phase {
+		case types.End:
+			return
+		case types.MatchRoute:
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] redo match route %+v", s)
+		case types.Retry:
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] retry %+v", s)
+		case types.UpFilter:
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] directResponse %+v", s)
+		}
+	}
 }
 
 func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase) types.Phase {
@@ -501,6 +514,17 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 			}
 
+			// the retry is made after the back off, the worker goroutine is not blocked by the waiting
+			if !s.retryBackOffDone {
+				s.scheduleRetry(ctx, id)
+				return types.End
+			}
+			s.retryBackOffDone = false
+			// the downstream may be reset or cleaned during the back off
+			if p, err := s.processError(id); err != nil && p != types.Retry {
+				return p
+			}
+
 			if s.downstreamReqDataBuf != nil {
 				s.downstreamReqDataBuf.Count(1)
 			}
@@ -615,8 +639,13 @@ func (s *downStream) matchRoute() {
 
 	// get router instance and do routing
 	routers := s.proxy.routersWrapper.GetRouters()
+	// the request body is buffered if the routes can be matched by the body
+	routeHeaders, ok := s.routeHeaders()
+	if !ok {
+		return
+	}
 	// do handler chain
-	handlerChain := router.CallMakeHandlerChain(s.context, headers, routers, s.proxy.clusterManager)
+	handlerChain := router.CallMakeHandlerChain(s.context, routeHeaders, routers, s.proxy.clusterManager)
 	// handlerChain should never be nil
 	if handlerChain == nil {
 		log.Proxy.Alertf(s.context, types.ErrorKeyRouteMatch, "no route to make handler chain, headers = %v", headers)
@@ -625,6 +654,39 @@ func (s *downStream) matchRoute() {
 		return
 	}
 	s.snapshot, s.route = handlerChain.DoNextHandler()
+	// the redirect route is responded directly, no cluster is needed
+	if s.route != nil {
+		if rule, ok := s.route.RouteRule().(types.RedirectRouteRule); ok {
+			if redirect := rule.RedirectRule(); redirect != nil {
+				s.sendRedirect(redirect)
+			}
+		}
+	}
+}
+
+func (s *downStream) sendRedirect(redirect types.RedirectRule) {
+	scheme := "http"
+	if _, ok := s.proxy.readCallbacks.Connection().RawConn().(*mtls.TLSConn); ok {
+		scheme = "https"
+	}
+	location := redirect.RedirectLocation(scheme, s.downstreamReqHeaders)
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] redirect to %s, proxyId = %d", location, s.ID)
+	}
+	// the response carries only the location, the request headers are not echoed
+	headers := newRedirectHeaders(s.downstreamReqHeaders)
+	headers.Set("Location", location)
+	s.sendHijackReply(redirect.RedirectCode(), headers)
+}
+
+// newRedirectHeaders returns an empty response headers in the same protocol as the request headers
+func newRedirectHeaders(requestHeaders types.HeaderMap) types.HeaderMap {
+	if _, ok := requestHeaders.(http.RequestHeader); ok {
+		return http.ResponseHeader{
+			ResponseHeader: &fasthttp.ResponseHeader{},
+		}
+	}
+	return protocol.CommonHeader{}
 }
 
 func (s *downStream) convertProtocol() (dp, up types.ProtocolName) {
@@ -696,14 +758,12 @@ func (s *downStream) chooseHost(endStream bool) {
 		s.sendHijackReply(types.RouterUnavailableCode, s.downstreamReqHeaders)
 		return
 	}
-	// as ClusterName has random factor when choosing weighted cluster,
-	// so need determination at the first time
-	clusterName := s.route.RouteRule().ClusterName()
+	// the cluster is chosen by the route handler, as ClusterName has random factor when choosing weighted cluster,
+	// and the cluster specifier chooses the cluster by the request
+	s.cluster = s.snapshot.ClusterInfo()
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
-		log.Proxy.Debugf(s.context, "[proxy] [downstream] route match result:%+v, clusterName=%v", s.route, clusterName)
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] route match result:%+v, clusterName=%v", s.route, s.cluster.Name())
 	}
-
-	s.cluster = s.snapshot.ClusterInfo()
 	s.requestInfo.SetRouteEntry(s.route.RouteRule())
 
 	pool, err := s.initializeUpstreamConnectionPool(s)
@@ -738,7 +798,11 @@ func (s *downStream) chooseHost(endStream bool) {
 func (s *downStream) receiveHeaders(endStream bool) {
 
 	//Modify request headers
-	s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+	if rule, ok := s.route.RouteRule().(types.VariableHeadersRouteRule); ok {
+		rule.FinalizeRequestHeadersWithContext(s.context, s.downstreamReqHeaders, s.requestInfo)
+	} else {
+		s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+	}
 	//Call upstream's append header method to build upstream's request
 	s.upstreamRequest.appendHeaders(endStream)
 
@@ -812,6 +876,9 @@ func (s *downStream) onUpstreamRequestSent() {
 	s.upstreamRequestSent = true
 	s.requestInfo.SetRequestReceivedDuration(time.Now())
 
+	// the whole request is received, send the mirror requests
+	s.mirrorRequest()
+
 	if s.upstreamRequest != nil && !s.oneway {
 		// setup per req timeout timer
 		s.setupPerReqTimeout()
@@ -1042,6 +1109,7 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 			if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 				s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 				s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+				s.putOutlierResetResult(reason)
 			}
 
 			// setup retry timer and return
@@ -1077,6 +1145,7 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 		if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 			s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			s.putOutlierResetResult(reason)
 		}
 		// clear reset flag
 		log.Proxy.Infof(s.context, "[proxy] [downstream] onUpstreamReset, send hijack, reason %v", reason)
@@ -1096,6 +1165,7 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 			if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 				s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 				s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+				s.putOutlierResult(types.OutlierResultServerError)
 			}
 
 			return
@@ -1112,7 +1182,11 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 
 	// directResponse for no route should be nil
 	if s.route != nil {
-		s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+		if rule, ok := s.route.RouteRule().(types.VariableHeadersRouteRule); ok {
+			rule.FinalizeResponseHeadersWithContext(s.context, headers, s.requestInfo)
+		} else {
+			s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+		}
 	}
 
 	if endStream {
@@ -1129,13 +1203,32 @@ func (s *downStream) handleUpstreamStatusCode() {
 		if s.requestInfo.ResponseCode() >= http.InternalServerError {
 			s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			s.putOutlierResult(types.OutlierResultServerError)
 		} else {
 			s.upstreamRequest.host.HostStats().UpstreamResponseSuccess.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseSuccess.Inc(1)
+			s.putOutlierResult(types.OutlierResultSuccess)
 		}
 	}
 }
 
+// putOutlierResult puts the upstream request result into the cluster's outlier detector if it is configured
+func (s *downStream) putOutlierResult(result types.OutlierResult) {
+	host := s.upstreamRequest.host
+	if od := host.ClusterInfo().OutlierDetector(); od != nil {
+		od.PutResult(host, result)
+	}
+}
+
+// putOutlierResetResult puts the upstream reset into the outlier detector as a connect failure.
+// overflow is a local circuit breaker result, it is not a failure of the upstream host
+func (s *downStream) putOutlierResetResult(reason types.StreamResetReason) {
+	if reason == types.StreamOverflow {
+		return
+	}
+	s.putOutlierResult(types.OutlierResultConnectFailed)
+}
+
 func (s *downStream) onUpstreamData(endStream bool) {
 	if endStream {
 		s.onUpstreamResponseRecvFinished()
@@ -1197,11 +1290,25 @@ func (s *downStream) setupRetry(endStream bool) bool {
 	return true
 }
 
+// scheduleRetry continues the retry phase in a new worker goroutine after the back off interval
+func (s *downStream) scheduleRetry(ctx context.Context, id uint32) {
+	interval := router.DefaultRetryInterval
+	if s.retryState != nil {
+		interval = s.retryState.backOff()
+		if s.upstreamRequest != nil && s.upstreamRequest.connPool != nil {
+			s.retryState.onHostAttempted(s.upstreamRequest.connPool.Host())
+		}
+	}
+	s.retryBackOffDone = true
+	s.retryTimer = utils.NewTimer(interval, func() {
+		pool.ScheduleAuto(func() {
+			s.process(ctx, id, types.Retry)
+		})
+	})
+}
+
 // Note: retry-timer MUST be stopped before active stream got recycled, otherwise resetting stream's properties will cause panic here
 func (s *downStream) doRetry() {
-	// retry interval
-	time.Sleep(10 * time.Millisecond)
-
 	// no reuse buffer
 	atomic.StoreUint32(&s.reuseBuffer, 0)
 
@@ -1301,6 +1408,13 @@ func (s *downStream) cleanUp() {
 		s.perRetryTimer = nil
 	}
 
+	// reset retry back off timer
+	if s.retryTimer != nil {
+		s.retryTimer.Stop()
+		s.retryTimer = nil
+	}
+	s.retryBackOffDone = false
+
 	// reset response timer
 	if s.responseTimer != nil {
 		s.responseTimer.Stop()
@@ -1316,6 +1430,15 @@ func (s *downStream) setBufferLimit(bufferLimit uint32) {
 }
 
 func (s *downStream) AddStreamReceiverFilter(filter api.StreamReceiverFilter, p api.FilterPhase) {
+	s.AddNamedStreamReceiverFilter("", filter, p)
+}
+
+func (s *downStream) AddStreamSenderFilter(filter api.StreamSenderFilter) {
+	s.AddNamedStreamSenderFilter("", filter)
+}
+
+// AddNamedStreamReceiverFilter adds a receiver filter created by the stream filter type, which can be disabled by the route
+func (s *downStream) AddNamedStreamReceiverFilter(filterType string, filter api.StreamReceiverFilter, p api.FilterPhase) {
 	var phase types.Phase
 	switch p {
 	case api.BeforeRoute:
@@ -1328,11 +1451,14 @@ func (s *downStream) AddStreamReceiverFilter(filter api.StreamReceiverFilter, p
 		phase = types.DownFilterAfterRoute
 	}
 	sf := newActiveStreamReceiverFilter(s, filter, phase)
+	sf.filterType = filterType
 	s.receiverFilters = append(s.receiverFilters, sf)
 }
 
-func (s *downStream) AddStreamSenderFilter(filter api.StreamSenderFilter) {
+// AddNamedStreamSenderFilter adds a sender filter created by the stream filter type, which can be disabled by the route
+func (s *downStream) AddNamedStreamSenderFilter(filterType string, filter api.StreamSenderFilter) {
 	sf := newActiveStreamSenderFilter(s, filter)
+	sf.filterType = filterType
 	s.senderFilters = append(s.senderFilters, sf)
 }
 
@@ -1370,6 +1496,33 @@ func (s *downStream) DownstreamCluster() types.ClusterInfo {
 	return s.cluster
 }
 
+func (s *downStream) HashKey() (uint64, bool) {
+	if s.route == nil {
+		return 0, false
+	}
+	if rule, ok := s.route.RouteRule().(types.HashPolicyRouteRule); ok {
+		if policy := rule.HashPolicy(); policy != nil {
+			return policy.GenerateHash(s.context, s.downstreamReqHeaders)
+		}
+	}
+	return 0, false
+}
+
+// types.HostPredicateContext
+func (s *downStream) ShouldSelectAnotherHost(host types.Host) bool {
+	if s.retryState == nil {
+		return false
+	}
+	return s.retryState.shouldSelectAnotherHost(host)
+}
+
+func (s *downStream) HostSelectionRetryMaxAttempts() int {
+	if s.retryState == nil {
+		return 0
+	}
+	return s.retryState.hostSelectionRetryMaxAttempts()
+}
+
 func (s *downStream) giveStream() {
 	if atomic.LoadUint32(&s.reuseBuffer) != 1 {
 		return
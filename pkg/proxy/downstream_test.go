@@ -158,6 +158,51 @@ func TestDirectResponse(t *testing.T) {
 	}
 }
 
+func TestRedirectResponse(t *testing.T) {
+	client := &mockResponseSender{}
+	s := &downStream{
+		proxy: &proxy{
+			config: &v2.Proxy{},
+			routersWrapper: &mockRouterWrapper{
+				routers: &mockRouters{
+					route: &mockRoute{
+						rule: &mockRedirectRouteRule{
+							redirect: &mockRedirectRule{
+								code:     302,
+								location: "redirect.com/new",
+							},
+						},
+					},
+				},
+			},
+			clusterManager:   &mockClusterManager{},
+			readCallbacks:    &mockReadFilterCallbacks{},
+			stats:            globalStats,
+			listenerStats:    newListenerStats("test"),
+			serverStreamConn: &mockServerConn{},
+		},
+		responseSender: client,
+		requestInfo:    &network.RequestInfo{},
+	}
+	reqHeaders := protocol.CommonHeader{
+		"Cookie": "session=secret",
+	}
+	s.OnReceive(context.Background(), reqHeaders, buffer.NewIoBuffer(1), nil)
+	time.Sleep(100 * time.Millisecond)
+	if client.headers == nil {
+		t.Fatal("want to receive a header response")
+	}
+	if _, ok := client.headers.Get("Cookie"); ok {
+		t.Error("the request headers should not be sent in the redirect response")
+	}
+	if code, ok := client.headers.Get(types.HeaderStatus); !ok || code != "302" {
+		t.Errorf("response status code not expected: %s", code)
+	}
+	if location, ok := client.headers.Get("Location"); !ok || location != "http://redirect.com/new" {
+		t.Errorf("response location not expected: %s", location)
+	}
+}
+
 func TestOnewayHijack(t *testing.T) {
 	initGlobalStats()
 	proxy := &proxy{
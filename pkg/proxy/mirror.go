@@ -0,0 +1,300 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	mbuffer "mosn.io/mosn/pkg/buffer"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/utils"
+)
+
+// mirrorRequest sends a copy of the request to the shadow clusters of the route's mirror policies.
+// the mirror requests are sent in other goroutines, and the responses are ignored.
+func (s *downStream) mirrorRequest() {
+	if s.route == nil {
+		return
+	}
+	rule, ok := s.route.RouteRule().(types.MirrorPolicyRouteRule)
+	if !ok || reflect.ValueOf(rule).IsNil() {
+		return
+	}
+	for _, policy := range rule.MirrorPolicies() {
+		if !policy.IsMirror() {
+			continue
+		}
+		m := newMirror(s, policy.ClusterName())
+		utils.GoWithRecover(func() {
+			m.send()
+		}, nil)
+	}
+}
+
+// types.StreamEventListener
+// types.StreamReceiveListener
+// types.PoolEventListener
+// types.LoadBalancerContext
+// mirror is a fire-and-forget copy of the downstream request
+type mirror struct {
+	ctx            context.Context
+	clusterName    string
+	clusterManager types.ClusterManager
+	// the protocol of downstream and upstream, the request is converted if they are different
+	downstreamProtocol types.ProtocolName
+	upstreamProtocol   types.ProtocolName
+	noConvert          bool
+	timeout            time.Duration
+
+	// ~~~ the copy of downstream request
+	headers  types.HeaderMap
+	data     types.IoBuffer
+	trailers types.HeaderMap
+
+	cluster types.ClusterInfo
+	sender  types.StreamSender
+	timer   *utils.Timer
+	done    uint32
+}
+
+// newMirror copies the request of downstream, as the downstream's buffers are reused after the stream is finished
+func newMirror(s *downStream, clusterName string) *mirror {
+	// the mirror request has its own stream buffers and variables
+	ctx := mbuffer.NewBufferPoolContext(mosnctx.Clone(s.context))
+	ctx = variable.NewVariableContext(ctx)
+	m := &mirror{
+		ctx:                ctx,
+		clusterName:        clusterName,
+		clusterManager:     s.proxy.clusterManager,
+		downstreamProtocol: s.getDownstreamProtocol(),
+		upstreamProtocol:   s.getUpstreamProtocol(),
+		noConvert:          s.noConvert,
+		timeout:            s.timeout.GlobalTimeout,
+	}
+	// the mirror request must be finished in a limited time, or the buffers are never released
+	if m.timeout <= 0 {
+		m.timeout = types.GlobalTimeout
+	}
+	if s.downstreamReqHeaders != nil {
+		m.headers = cloneRequestHeaders(ctx, s.downstreamReqHeaders)
+	}
+	if s.downstreamReqDataBuf != nil {
+		m.data = s.downstreamReqDataBuf.Clone()
+	}
+	if s.downstreamReqTrailers != nil {
+		m.trailers = s.downstreamReqTrailers.Clone()
+	}
+	return m
+}
+
+// cloneRequestHeaders returns a copy of the request headers.
+// the clone of a xprotocol frame is only the header part, so the frame is
+// encoded and decoded again to get a new one. the frame is decoded into the
+// buffers of ctx, so ctx must not be the downstream's context.
+func cloneRequestHeaders(ctx context.Context, headers types.HeaderMap) types.HeaderMap {
+	frame, ok := headers.(xprotocol.XFrame)
+	if !ok {
+		return headers.Clone()
+	}
+	subProtocol, _ := mosnctx.Get(ctx, types.ContextSubProtocol).(string)
+	proto := xprotocol.GetProtocol(types.ProtocolName(subProtocol))
+	if proto == nil {
+		return headers.Clone()
+	}
+	buf, err := proto.Encode(ctx, frame)
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[proxy] [mirror] encode request failed: %v", err)
+		return headers.Clone()
+	}
+	// the encoded buffer may be the raw data of the frame
+	data := buffer.NewIoBufferBytes(append([]byte(nil), buf.Bytes()...))
+	buffer.PutIoBuffer(buf)
+	cmd, err := proto.Decode(ctx, data)
+	if clone, ok := cmd.(xprotocol.XFrame); ok && err == nil {
+		return clone.GetHeader()
+	}
+	log.Proxy.Errorf(ctx, "[proxy] [mirror] decode request failed: %v", err)
+	return headers.Clone()
+}
+
+func (m *mirror) send() {
+	snapshot := m.clusterManager.GetClusterSnapshot(m.ctx, m.clusterName)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.Proxy.Errorf(m.ctx, "[proxy] [mirror] cluster %s is not found", m.clusterName)
+		m.release()
+		return
+	}
+	m.cluster = snapshot.ClusterInfo()
+	pool := m.clusterManager.ConnPoolForCluster(m, snapshot, m.upstreamProtocol)
+	if pool == nil {
+		log.Proxy.Errorf(m.ctx, "[proxy] [mirror] no healthy upstream in cluster %s", m.clusterName)
+		if m.finish(false) {
+			m.release()
+		}
+		return
+	}
+	pool.NewStream(m.ctx, m, m)
+}
+
+// finish records the result of mirror request, it is called only once
+func (m *mirror) finish(success bool) bool {
+	if !atomic.CompareAndSwapUint32(&m.done, 0, 1) {
+		return false
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	if success {
+		m.cluster.Stats().UpstreamRequestMirrorSuccess.Inc(1)
+	} else {
+		m.cluster.Stats().UpstreamRequestMirrorFailure.Inc(1)
+	}
+	return true
+}
+
+// release gives back the stream buffers of the mirror request, it is called after the request is finished
+func (m *mirror) release() {
+	if bufferCtx := mbuffer.PoolContext(m.ctx); bufferCtx != nil {
+		bufferCtx.Give()
+	}
+}
+
+func (m *mirror) onTimeout() {
+	if !m.finish(false) {
+		return
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(m.ctx, "[proxy] [mirror] request to cluster %s timeout", m.clusterName)
+	}
+	m.sender.GetStream().RemoveEventListener(m)
+	m.sender.GetStream().ResetStream(types.StreamLocalReset)
+	m.release()
+}
+
+// types.PoolEventListener
+func (m *mirror) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(m.ctx, "[proxy] [mirror] request to host %s failed, reason: %v", host.AddressString(), reason)
+	}
+	if m.finish(false) {
+		m.release()
+	}
+}
+
+func (m *mirror) OnReady(sender types.StreamSender, host types.Host) {
+	m.sender = sender
+	m.sender.GetStream().AddEventListener(m)
+	m.timer = utils.NewTimer(m.timeout, m.onTimeout)
+	endStream := m.data == nil && m.trailers == nil
+	m.sender.AppendHeaders(m.ctx, m.convertHeader(m.headers), endStream)
+	if m.data != nil {
+		m.sender.AppendData(m.ctx, m.convertData(m.data), m.trailers == nil)
+	}
+	if m.trailers != nil {
+		m.sender.AppendTrailers(m.ctx, m.convertTrailer(m.trailers))
+	}
+}
+
+// types.StreamReceiveListener
+func (m *mirror) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+	if m.finish(true) {
+		// the stream buffers are not used any more
+		m.release()
+	}
+}
+
+func (m *mirror) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
+	if m.finish(false) {
+		m.release()
+	}
+}
+
+// types.StreamEventListener
+func (m *mirror) OnResetStream(reason types.StreamResetReason) {
+	if m.finish(false) {
+		m.release()
+	}
+}
+
+func (m *mirror) OnDestroyStream() {}
+
+func (m *mirror) convertHeader(headers types.HeaderMap) types.HeaderMap {
+	if m.noConvert || m.downstreamProtocol == m.upstreamProtocol {
+		return headers
+	}
+	if convHeader, err := protocol.ConvertHeader(m.ctx, m.downstreamProtocol, m.upstreamProtocol, headers); err == nil {
+		return convHeader
+	}
+	return headers
+}
+
+func (m *mirror) convertData(data types.IoBuffer) types.IoBuffer {
+	if m.noConvert || m.downstreamProtocol == m.upstreamProtocol {
+		return data
+	}
+	if convData, err := protocol.ConvertData(m.ctx, m.downstreamProtocol, m.upstreamProtocol, data); err == nil {
+		return convData
+	}
+	return data
+}
+
+func (m *mirror) convertTrailer(trailers types.HeaderMap) types.HeaderMap {
+	if m.noConvert || m.downstreamProtocol == m.upstreamProtocol {
+		return trailers
+	}
+	if convTrailer, err := protocol.ConvertTrailer(m.ctx, m.downstreamProtocol, m.upstreamProtocol, trailers); err == nil {
+		return convTrailer
+	}
+	return trailers
+}
+
+// types.LoadBalancerContext
+func (m *mirror) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return nil
+}
+
+func (m *mirror) DownstreamConnection() net.Conn {
+	return nil
+}
+
+func (m *mirror) DownstreamHeaders() types.HeaderMap {
+	return m.headers
+}
+
+func (m *mirror) DownstreamContext() context.Context {
+	return m.ctx
+}
+
+func (m *mirror) DownstreamCluster() types.ClusterInfo {
+	return m.cluster
+}
+
+func (m *mirror) HashKey() (uint64, bool) {
+	return 0, false
+}
@@ -97,6 +97,28 @@ func (c *mockRouteRule) FinalizeResponseHeaders(headers api.HeaderMap, requestIn
 	return
 }
 
+type mockRedirectRouteRule struct {
+	mockRouteRule
+	redirect types.RedirectRule
+}
+
+func (r *mockRedirectRouteRule) RedirectRule() types.RedirectRule {
+	return r.redirect
+}
+
+type mockRedirectRule struct {
+	code     int
+	location string
+}
+
+func (r *mockRedirectRule) RedirectCode() int {
+	return r.code
+}
+
+func (r *mockRedirectRule) RedirectLocation(scheme string, headers api.HeaderMap) string {
+	return scheme + "://" + r.location
+}
+
 type mockDirectRule struct {
 	status int
 	body   string
@@ -124,6 +146,15 @@ type mockClusterSnapshot struct {
 	types.ClusterSnapshot
 }
 
+type mockHost struct {
+	types.Host
+	addr string
+}
+
+func (h *mockHost) AddressString() string {
+	return h.addr
+}
+
 type mockResponseSender struct {
 	// receive data
 	headers  api.HeaderMap
@@ -174,6 +205,10 @@ func (c *mockConnection) ID() uint64 {
 	return 0
 }
 
+func (c *mockConnection) RawConn() net.Conn {
+	return nil
+}
+
 func (c *mockConnection) LocalAddr() net.Addr {
 	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1")
 	return addr
@@ -19,19 +19,27 @@ package proxy
 
 import (
 	"context"
+	"time"
 
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
 )
 
 type retryState struct {
 	retryPolicy      api.RetryPolicy
-	requestHeaders   types.HeaderMap // TODO: support retry policy by header
+	conditionPolicy  types.RetryConditionPolicy // nil if the retry policy does not support conditions
+	requestHeaders   types.HeaderMap            // TODO: support retry policy by header
 	cluster          types.ClusterInfo
 	retryOn          bool
 	retiesRemaining  uint32
+	retried          uint32
+	triedHosts       []types.Host
 	upstreamProtocol types.ProtocolName
 }
 
@@ -49,6 +57,9 @@ func newRetryState(retryPolicy api.RetryPolicy,
 	if retryPolicy.NumRetries() > rs.retiesRemaining {
 		rs.retiesRemaining = retryPolicy.NumRetries()
 	}
+	if p, ok := retryPolicy.(types.RetryConditionPolicy); ok {
+		rs.conditionPolicy = p
+	}
 
 	return rs
 }
@@ -62,6 +73,7 @@ func (r *retryState) retry(ctx context.Context, headers api.HeaderMap, reason ty
 		return check
 	}
 
+	r.retried++
 	r.cluster.ResourceManager().Retries().Increase()
 	r.cluster.Stats().UpstreamRequestRetry.Inc(1)
 
@@ -93,6 +105,15 @@ func (r *retryState) doRetryCheck(ctx context.Context, headers types.HeaderMap,
 		return false
 	}
 
+	if r.conditionPolicy != nil && len(r.conditionPolicy.RetryConditions()) > 0 {
+		for _, condition := range r.conditionPolicy.RetryConditions() {
+			if r.matchCondition(ctx, condition, headers, reason) {
+				return true
+			}
+		}
+		return false
+	}
+
 	if r.retryOn {
 		// TODO: add retry policy to decide retry or not. use default policy now
 		if headers != nil {
@@ -125,6 +146,98 @@ func (r *retryState) doRetryCheck(ctx context.Context, headers types.HeaderMap,
 	return false
 }
 
+// matchCondition checks the retry condition, headers is nil if the upstream is reset without response
+func (r *retryState) matchCondition(ctx context.Context, condition string, headers types.HeaderMap, reason types.StreamResetReason) bool {
+	switch condition {
+	case v2.RetryOn5xx:
+		if headers == nil {
+			return reason == types.StreamConnectionFailed || isRetriableReset(reason)
+		}
+		code, err := protocol.MappingHeaderStatusCode(ctx, r.upstreamProtocol, headers)
+		return err == nil && code >= http.InternalServerError
+	case v2.RetryOnGatewayError:
+		if headers == nil {
+			return reason == types.StreamConnectionFailed || isRetriableReset(reason)
+		}
+		code, err := protocol.MappingHeaderStatusCode(ctx, r.upstreamProtocol, headers)
+		return err == nil && (code == http.BadGateway || code == http.ServiceUnavailable || code == http.GatewayTimeout)
+	case v2.RetryOnConnectFailure:
+		return headers == nil && reason == types.StreamConnectionFailed
+	case v2.RetryOnReset:
+		return headers == nil && isRetriableReset(reason)
+	case v2.RetryOnRetriableStatusCodes:
+		return headers != nil && r.isRetriableStatusCode(ctx, headers)
+	}
+	return false
+}
+
+// isRetriableStatusCode checks the response status code with the retriable status codes.
+// the status code of xprotocol response is protocol-specific, so the retriable status codes
+// are mapped by the sub protocol's Hijacker.Mapping
+func (r *retryState) isRetriableStatusCode(ctx context.Context, headers types.HeaderMap) bool {
+	codes := r.conditionPolicy.RetriableStatusCodes()
+	if frame, ok := headers.(xprotocol.XRespFrame); ok {
+		subProtocol, _ := mosnctx.Get(ctx, types.ContextSubProtocol).(string)
+		if proto := xprotocol.GetProtocol(types.ProtocolName(subProtocol)); proto != nil {
+			status := frame.GetStatusCode()
+			for _, code := range codes {
+				if proto.Mapping(code) == status {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	status, err := protocol.MappingHeaderStatusCode(ctx, r.upstreamProtocol, headers)
+	if err != nil {
+		return false
+	}
+	for _, code := range codes {
+		if uint32(status) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetriableReset returns true if the upstream does not respond at all
+func isRetriableReset(reason types.StreamResetReason) bool {
+	switch reason {
+	case types.StreamConnectionTermination, types.StreamRemoteReset, types.UpstreamReset, types.UpstreamPerTryTimeout:
+		return true
+	}
+	return false
+}
+
+// backOff returns the interval before the next retry
+func (r *retryState) backOff() time.Duration {
+	if r.conditionPolicy == nil {
+		return router.DefaultRetryInterval
+	}
+	return r.conditionPolicy.BackOff(r.retried)
+}
+
+// onHostAttempted records the host that the request has been sent to
+func (r *retryState) onHostAttempted(host types.Host) {
+	if host != nil {
+		r.triedHosts = append(r.triedHosts, host)
+	}
+}
+
+func (r *retryState) shouldSelectAnotherHost(host types.Host) bool {
+	if r.conditionPolicy == nil {
+		return false
+	}
+	return r.conditionPolicy.ShouldRejectHost(host, r.triedHosts)
+}
+
+func (r *retryState) hostSelectionRetryMaxAttempts() int {
+	if r.conditionPolicy == nil {
+		return 0
+	}
+	return r.conditionPolicy.HostSelectionRetryMaxAttempts()
+}
+
 func (r *retryState) reset() {
 	r.cluster.ResourceManager().Retries().Decrease()
 }
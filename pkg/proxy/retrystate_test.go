@@ -25,7 +25,9 @@ import (
 	metrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
 )
@@ -135,3 +137,109 @@ func TestRetryConnetionFailed(t *testing.T) {
 		}
 	}
 }
+
+func newConditionRetryState(t *testing.T, cfg v2.RetryPolicyConfig, proto types.ProtocolName) *retryState {
+	rcfg := &v2.Router{}
+	rcfg.Route = v2.RouteAction{}
+	rcfg.Route.RetryPolicy = &v2.RetryPolicy{
+		RetryPolicyConfig: cfg,
+	}
+	r, err := router.NewRouteRuleImplBase(nil, rcfg)
+	if err != nil {
+		t.Fatalf("create route rule failed: %v", err)
+	}
+	clusterInfo := &fakeClusterInfo{
+		mgr: &fakeResourceManager{},
+	}
+	return newRetryState(r.Policy().RetryPolicy(), nil, clusterInfo, proto)
+}
+
+func TestRetryConditions(t *testing.T) {
+	header := func(code string) types.HeaderMap {
+		return protocol.CommonHeader{
+			types.HeaderStatus: code,
+		}
+	}
+	testcases := []struct {
+		conditions []string
+		header     types.HeaderMap
+		reason     types.StreamResetReason
+		expected   api.RetryCheckStatus
+	}{
+		{[]string{v2.RetryOn5xx}, header("500"), "", api.ShouldRetry},
+		{[]string{v2.RetryOn5xx}, header("404"), "", api.NoRetry},
+		{[]string{v2.RetryOn5xx}, nil, types.StreamConnectionTermination, api.ShouldRetry},
+		{[]string{v2.RetryOnGatewayError}, header("503"), "", api.ShouldRetry},
+		{[]string{v2.RetryOnGatewayError}, header("500"), "", api.NoRetry},
+		{[]string{v2.RetryOnConnectFailure}, nil, types.StreamConnectionFailed, api.ShouldRetry},
+		{[]string{v2.RetryOnConnectFailure}, nil, types.UpstreamPerTryTimeout, api.NoRetry},
+		{[]string{v2.RetryOnConnectFailure}, header("500"), "", api.NoRetry},
+		{[]string{v2.RetryOnReset}, nil, types.UpstreamPerTryTimeout, api.ShouldRetry},
+		{[]string{v2.RetryOnReset}, nil, types.StreamConnectionFailed, api.NoRetry},
+		{[]string{v2.RetryOnRetriableStatusCodes}, header("409"), "", api.ShouldRetry},
+		{[]string{v2.RetryOnRetriableStatusCodes}, header("500"), "", api.NoRetry},
+		{[]string{v2.RetryOnConnectFailure, v2.RetryOnRetriableStatusCodes}, header("409"), "", api.ShouldRetry},
+		// overflow is never retried
+		{[]string{v2.RetryOnReset}, nil, types.StreamOverflow, api.NoRetry},
+	}
+	for i, tc := range testcases {
+		rs := newConditionRetryState(t, v2.RetryPolicyConfig{
+			NumRetries:           3,
+			RetryConditions:      tc.conditions,
+			RetriableStatusCodes: []uint32{409},
+		}, protocol.HTTP1)
+		if status := rs.retry(context.Background(), tc.header, tc.reason); status != tc.expected {
+			t.Errorf("#%d retry check expected %v, but got %v", i, tc.expected, status)
+		}
+	}
+}
+
+func TestRetryXprotocolStatusCodes(t *testing.T) {
+	rs := newConditionRetryState(t, v2.RetryPolicyConfig{
+		NumRetries:           3,
+		RetryConditions:      []string{v2.RetryOnRetriableStatusCodes},
+		RetriableStatusCodes: []uint32{types.TimeoutExceptionCode},
+	}, protocol.Xprotocol)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextSubProtocol, string(bolt.ProtocolName))
+	newResponse := func(status uint16) types.HeaderMap {
+		return &bolt.Response{
+			ResponseHeader: bolt.ResponseHeader{
+				ResponseStatus: status,
+			},
+		}
+	}
+	// the retriable status codes are mapped by the sub protocol
+	if status := rs.retry(ctx, newResponse(bolt.ResponseStatusTimeout), ""); status != api.ShouldRetry {
+		t.Errorf("bolt timeout response expected retry, but got %v", status)
+	}
+	if status := rs.retry(ctx, newResponse(bolt.ResponseStatusServerThreadpoolBusy), ""); status != api.NoRetry {
+		t.Errorf("bolt threadpool busy response expected no retry, but got %v", status)
+	}
+}
+
+func TestRetryHostPredicate(t *testing.T) {
+	rs := newConditionRetryState(t, v2.RetryPolicyConfig{
+		NumRetries:                    3,
+		RetryHostPredicates:           []string{v2.RetryHostPredicatePreviousHosts},
+		HostSelectionRetryMaxAttempts: 5,
+	}, protocol.HTTP1)
+	tried := &mockHost{addr: "127.0.0.1:8080"}
+	other := &mockHost{addr: "127.0.0.1:8081"}
+	if rs.shouldSelectAnotherHost(tried) {
+		t.Fatal("no hosts are tried, expected not reject")
+	}
+	rs.onHostAttempted(tried)
+	if !rs.shouldSelectAnotherHost(tried) || rs.shouldSelectAnotherHost(other) {
+		t.Fatal("only the tried host should be rejected")
+	}
+	if rs.hostSelectionRetryMaxAttempts() != 5 {
+		t.Fatalf("host selection max attempts expected 5, but got %d", rs.hostSelectionRetryMaxAttempts())
+	}
+	// the back off is not greater than the max interval
+	for i := uint32(1); i < 10; i++ {
+		rs.retried = i
+		if interval := rs.backOff(); interval < 0 || interval >= 250*time.Millisecond {
+			t.Fatalf("unexpected back off interval %v", interval)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"io"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/utils"
+)
+
+const streamBodyChunkSize = 4096
+
+// routeHeaders returns the headers used to match the routes.
+// if the proxy enables the route body buffer, the headers contains the buffered request body,
+// ok is false if the request is responded because the body exceeds the limit.
+func (s *downStream) routeHeaders() (headers types.HeaderMap, ok bool) {
+	headers = s.downstreamReqHeaders
+	if s.proxy.config == nil || s.proxy.config.RouteBodyBuffer == nil {
+		return headers, true
+	}
+	cfg := s.proxy.config.RouteBodyBuffer
+	maxBytes := int(cfg.MaxBytes)
+	if maxBytes == 0 {
+		maxBytes = v2.DefaultRouteBodyBufferBytes
+	}
+	body, exceeded := s.bufferRouteBody(maxBytes)
+	if !exceeded {
+		return router.NewBodyHeaders(headers, body), true
+	}
+	if cfg.OnExceed == v2.RouteBodyExceedHeaderOnly {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] request body exceeds %d bytes, match route by headers only, proxyId = %d", maxBytes, s.ID)
+		}
+		return headers, true
+	}
+	log.Proxy.Warnf(s.context, "[proxy] [downstream] request body exceeds %d bytes, proxyId = %d", maxBytes, s.ID)
+	s.requestInfo.SetResponseFlag(api.ReqEntityTooLarge)
+	s.sendHijackReply(types.PayloadTooLargeCode, headers)
+	return nil, false
+}
+
+// bufferRouteBody returns the request body buffered in the downstream, exceeded is true if the body exceeds maxBytes.
+func (s *downStream) bufferRouteBody(maxBytes int) (body []byte, exceeded bool) {
+	if s.downstreamReqDataBuf == nil {
+		return nil, false
+	}
+	if s.isStreamBody() {
+		// the route may be matched again, the streaming body is read only once
+		if !s.routeBodyRead {
+			s.routeBodyRead = true
+			s.routeBodyExceeded = s.readStreamBody(maxBytes)
+		}
+		if s.routeBodyExceeded {
+			return nil, true
+		}
+	}
+	if s.downstreamReqDataBuf.Len() > maxBytes {
+		return nil, true
+	}
+	return s.downstreamReqDataBuf.Bytes(), false
+}
+
+// isStreamBody returns true if the request body is received in stream, the body is not completed when the route is matched.
+func (s *downStream) isStreamBody() bool {
+	if s.getDownstreamProtocol() != protocol.HTTP2 {
+		return false
+	}
+	useStream, _ := mosnctx.Get(s.context, types.ContextKeyH2Stream).(bool)
+	return useStream
+}
+
+// readStreamBody reads the streaming request body into a buffer until the end of the stream,
+// and replaces the downstream buffer with it. If the body exceeds maxBytes, the read data and
+// the unread stream are piped to a new stream buffer, so the request body can still be proxied.
+func (s *downStream) readStreamBody(maxBytes int) (exceeded bool) {
+	stream := s.downstreamReqDataBuf
+	body := buffer.NewIoBuffer(streamBodyChunkSize)
+	chunk := make([]byte, streamBodyChunkSize)
+	for body.Len() <= maxBytes {
+		n, err := stream.Read(chunk)
+		body.Write(chunk[:n])
+		// io.EOF means the end of the stream, other errors mean the stream is reset
+		if err != nil {
+			s.downstreamReqDataBuf = body
+			return false
+		}
+	}
+	pipe := buffer.NewPipeBuffer(0)
+	pipe.Write(body.Bytes())
+	utils.GoWithRecover(func() {
+		for {
+			n, err := stream.Read(chunk)
+			if n > 0 {
+				pipe.Write(chunk[:n])
+			}
+			if err != nil {
+				pipe.CloseWithError(io.EOF)
+				return
+			}
+		}
+	}, nil)
+	s.downstreamReqDataBuf = pipe
+	return true
+}
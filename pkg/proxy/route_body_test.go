@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+func TestRouteHeaders(t *testing.T) {
+	testCases := []struct {
+		cfg      *v2.RouteBodyBuffer
+		body     types.IoBuffer
+		ok       bool
+		withBody bool
+	}{
+		{nil, buffer.NewIoBufferString("body"), true, false},
+		{&v2.RouteBodyBuffer{}, buffer.NewIoBufferString("body"), true, true},
+		{&v2.RouteBodyBuffer{}, nil, true, true},
+		{&v2.RouteBodyBuffer{MaxBytes: 4}, buffer.NewIoBufferString("body"), true, true},
+		{&v2.RouteBodyBuffer{MaxBytes: 3}, buffer.NewIoBufferString("body"), false, false},
+		{&v2.RouteBodyBuffer{MaxBytes: 3, OnExceed: v2.RouteBodyExceedHeaderOnly}, buffer.NewIoBufferString("body"), true, false},
+	}
+	for i, tc := range testCases {
+		s := &downStream{
+			proxy: &proxy{
+				config: &v2.Proxy{
+					DownstreamProtocol: string(protocol.HTTP1),
+					RouteBodyBuffer:    tc.cfg,
+				},
+			},
+			context:              context.Background(),
+			requestInfo:          &network.RequestInfo{},
+			downstreamReqHeaders: protocol.CommonHeader{},
+			downstreamReqDataBuf: tc.body,
+		}
+		headers, ok := s.routeHeaders()
+		if ok != tc.ok {
+			t.Fatalf("#%d expected ok %v, but got %v", i, tc.ok, ok)
+		}
+		if !ok {
+			if code, _ := s.downstreamRespHeaders.Get(types.HeaderStatus); code != "413" {
+				t.Errorf("#%d expected response 413, but got %s", i, code)
+			}
+			if !s.requestInfo.GetResponseFlag(api.ReqEntityTooLarge) {
+				t.Errorf("#%d expected response flag is set", i)
+			}
+			continue
+		}
+		if _, isCommon := headers.(protocol.CommonHeader); isCommon == tc.withBody {
+			t.Errorf("#%d expected headers with body %v, but got %T", i, tc.withBody, headers)
+		}
+	}
+}
+
+func readAll(t *testing.T, buf types.IoBuffer) []byte {
+	var data []byte
+	chunk := make([]byte, 3)
+	for {
+		n, err := buf.Read(chunk)
+		data = append(data, chunk[:n]...)
+		if err == io.EOF {
+			return data
+		}
+		if err != nil {
+			t.Fatalf("read body failed: %v", err)
+		}
+	}
+}
+
+func TestRouteHeadersStreamBody(t *testing.T) {
+	newStream := func(cfg *v2.RouteBodyBuffer, body types.IoBuffer) *downStream {
+		return &downStream{
+			proxy: &proxy{
+				config: &v2.Proxy{
+					DownstreamProtocol: string(protocol.HTTP2),
+					RouteBodyBuffer:    cfg,
+				},
+			},
+			context:              mosnctx.WithValue(context.Background(), types.ContextKeyH2Stream, true),
+			requestInfo:          &network.RequestInfo{},
+			downstreamReqHeaders: protocol.CommonHeader{},
+			downstreamReqDataBuf: body,
+		}
+	}
+	// the streaming body is read until the end
+	pipe := buffer.NewPipeBuffer(0)
+	go func() {
+		pipe.Write([]byte("hello "))
+		time.Sleep(50 * time.Millisecond)
+		pipe.Write([]byte("world"))
+		pipe.CloseWithError(io.EOF)
+	}()
+	s := newStream(&v2.RouteBodyBuffer{}, pipe)
+	if _, ok := s.routeHeaders(); !ok {
+		t.Fatal("route headers with stream body failed")
+	}
+	if body, exceeded := s.bufferRouteBody(64); exceeded || string(body) != "hello world" {
+		t.Fatalf("buffered body not expected: %s, exceeded: %v", string(body), exceeded)
+	}
+	// route matched again
+	if _, ok := s.routeHeaders(); !ok {
+		t.Fatal("route headers with stream body again failed")
+	}
+	if data := readAll(t, s.downstreamReqDataBuf); string(data) != "hello world" {
+		t.Fatalf("proxied body not expected: %s", string(data))
+	}
+	// the body exceeds the limit, and the body is still streamed
+	large := bytes.Repeat([]byte("m"), streamBodyChunkSize*2)
+	pipe = buffer.NewPipeBuffer(0)
+	go func() {
+		pipe.Write(large)
+		time.Sleep(50 * time.Millisecond)
+		pipe.Write([]byte("end"))
+		pipe.CloseWithError(io.EOF)
+	}()
+	s = newStream(&v2.RouteBodyBuffer{MaxBytes: 16, OnExceed: v2.RouteBodyExceedHeaderOnly}, pipe)
+	headers, ok := s.routeHeaders()
+	if !ok {
+		t.Fatal("route headers with exceeded stream body failed")
+	}
+	if _, isCommon := headers.(protocol.CommonHeader); !isCommon {
+		t.Fatalf("expected match route by headers only, but got %T", headers)
+	}
+	if data := readAll(t, s.downstreamReqDataBuf); !bytes.Equal(data, append(large, "end"...)) {
+		t.Fatalf("proxied body not expected, got %d bytes", len(data))
+	}
+}
@@ -29,6 +29,9 @@ import (
 func (s *downStream) runAppendFilters(p types.Phase, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) bool {
 	for ; s.senderFiltersIndex < len(s.senderFilters); s.senderFiltersIndex++ {
 		f := s.senderFilters[s.senderFiltersIndex]
+		if f.disabled() {
+			continue
+		}
 
 		status := f.filter.Append(s.context, headers, data, trailers)
 		if status == api.StreamFilterStop {
@@ -43,7 +46,7 @@ func (s *downStream) runAppendFilters(p types.Phase, headers types.HeaderMap, da
 func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) bool {
 	for ; s.receiverFiltersIndex < len(s.receiverFilters); s.receiverFiltersIndex++ {
 		f := s.receiverFilters[s.receiverFiltersIndex]
-		if f.p != p {
+		if f.p != p || f.disabled() {
 			continue
 		}
 
@@ -79,6 +82,19 @@ func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, d
 
 type activeStreamFilter struct {
 	activeStream *downStream
+	// filterType is the stream filter type that creates the filter, empty if it is unknown
+	filterType string
+}
+
+// disabled returns true if the filter is disabled by the per-route config of the matched route
+func (f *activeStreamFilter) disabled() bool {
+	if f.filterType == "" || f.activeStream.route == nil {
+		return false
+	}
+	if rule, ok := f.activeStream.route.RouteRule().(types.FilterConfigRouteRule); ok {
+		return rule.FilterDisabled(f.filterType)
+	}
+	return false
 }
 
 func (f *activeStreamFilter) Connection() api.Connection {
@@ -316,6 +316,59 @@ func TestRunSenderFiltersStop(t *testing.T) {
 	}
 }
 
+// mockFilterConfigRouteRule disables the stream filters by type
+type mockFilterConfigRouteRule struct {
+	mockRouteRule
+	disabled map[string]bool
+}
+
+func (r *mockFilterConfigRouteRule) FilterConfig(filterType string) interface{} {
+	return nil
+}
+
+func (r *mockFilterConfigRouteRule) FilterDisabled(filterType string) bool {
+	return r.disabled[filterType]
+}
+
+func TestRunDisabledFilters(t *testing.T) {
+	s := &downStream{
+		proxy: &proxy{
+			routersWrapper: &mockRouterWrapper{},
+			clusterManager: &mockClusterManager{},
+		},
+	}
+	enabled := &mockStreamReceiverFilter{status: api.StreamFilterContinue, phase: api.AfterRoute, s: s}
+	disabled := &mockStreamReceiverFilter{status: api.StreamFilterStop, phase: api.AfterRoute, s: s}
+	unnamed := &mockStreamReceiverFilter{status: api.StreamFilterContinue, phase: api.AfterRoute, s: s}
+	s.AddNamedStreamReceiverFilter("enabled", enabled, enabled.phase)
+	s.AddNamedStreamReceiverFilter("disabled", disabled, disabled.phase)
+	s.AddStreamReceiverFilter(unnamed, unnamed.phase)
+	disabledSender := &mockStreamSenderFilter{status: api.StreamFilterStop, s: s}
+	enabledSender := &mockStreamSenderFilter{status: api.StreamFilterContinue, s: s}
+	s.AddNamedStreamSenderFilter("disabled", disabledSender)
+	s.AddNamedStreamSenderFilter("enabled", enabledSender)
+
+	s.route = &mockRoute{
+		rule: &mockFilterConfigRouteRule{
+			disabled: map[string]bool{"disabled": true},
+		},
+	}
+	s.runReceiveFilters(types.DownFilterAfterRoute, nil, nil, nil)
+	if enabled.on != 1 || disabled.on != 0 || unnamed.on != 1 {
+		t.Errorf("unexpected receiver filters called, enabled: %d, disabled: %d, unnamed: %d", enabled.on, disabled.on, unnamed.on)
+	}
+	s.runAppendFilters(0, nil, nil, nil)
+	if enabledSender.on != 1 || disabledSender.on != 0 {
+		t.Errorf("unexpected sender filters called, enabled: %d, disabled: %d", enabledSender.on, disabledSender.on)
+	}
+	// the filters are not disabled if the route rule has no per-route config
+	s.route = &mockRoute{}
+	s.runReceiveFilters(types.DownFilterAfterRoute, nil, nil, nil)
+	if disabled.on != 1 {
+		t.Errorf("filter should not be disabled, but called %d", disabled.on)
+	}
+}
+
 // Mock stream filters
 type mockStreamReceiverFilter struct {
 	handler api.StreamReceiverFilterHandler
@@ -21,6 +21,8 @@ import (
 	"context"
 	"strconv"
 
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/types"
 
 	"mosn.io/mosn/pkg/variable"
@@ -47,6 +49,7 @@ var (
 		variable.NewBasicVariable(types.VarDownstreamLocalAddress, nil, downstreamLocalAddressGetter, nil, 0),
 		variable.NewBasicVariable(types.VarDownstreamRemoteAddress, nil, downstreamRemoteAddressGetter, nil, 0),
 		variable.NewBasicVariable(types.VarUpstreamHost, nil, upstreamHostGetter, nil, 0),
+		variable.NewBasicVariable(types.VarDownstreamPeerIdentity, nil, downstreamPeerIdentityGetter, nil, 0),
 
 		variable.NewIndexedVariable(types.VarProxyTryTimeout, nil, nil, variable.BasicSetter, 0),
 		variable.NewIndexedVariable(types.VarProxyGlobalTimeout, nil, nil, variable.BasicSetter, 0),
@@ -175,7 +178,7 @@ func downstreamLocalAddressGetter(ctx context.Context, value *variable.IndexedVa
 	info := proxyBuffers.info
 
 	if info.DownstreamLocalAddress() != nil {
-		return info.DownstreamLocalAddress().String(), nil
+		return v2.AddressString(info.DownstreamLocalAddress()), nil
 	}
 
 	return variable.ValueNotFound, nil
@@ -188,7 +191,7 @@ func downstreamRemoteAddressGetter(ctx context.Context, value *variable.IndexedV
 	info := proxyBuffers.info
 
 	if info.DownstreamRemoteAddress() != nil {
-		return info.DownstreamRemoteAddress().String(), nil
+		return v2.AddressString(info.DownstreamRemoteAddress()), nil
 	}
 
 	return variable.ValueNotFound, nil
@@ -207,6 +210,22 @@ func upstreamHostGetter(ctx context.Context, value *variable.IndexedValue, data
 	return variable.ValueNotFound, nil
 }
 
+// downstreamPeerIdentityGetter
+// get the identity of downstream's verified tls certificate, such as a spiffe id
+func downstreamPeerIdentityGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	stream := proxyBuffers.stream
+
+	if stream.proxy == nil || stream.proxy.readCallbacks == nil {
+		return variable.ValueNotFound, nil
+	}
+	if identity := mtls.PeerIdentity(stream.proxy.readCallbacks.Connection().RawConn()); identity != "" {
+		return identity, nil
+	}
+
+	return variable.ValueNotFound, nil
+}
+
 func requestHeaderMapGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
 	proxyBuffers := proxyBuffersByContext(ctx)
 	headers := proxyBuffers.stream.downstreamReqHeaders
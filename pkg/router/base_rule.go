@@ -18,13 +18,16 @@
 package router
 
 import (
+	"context"
 	"math/rand"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/protocol"
 	httpmosn "mosn.io/mosn/pkg/protocol/http"
@@ -36,9 +39,16 @@ type RouteRuleImplBase struct {
 	vHost                 *VirtualHostImpl
 	routerMatch           v2.RouterMatch
 	configHeaders         []*types.HeaderData
-	configQueryParameters []types.QueryParameterMatcher //TODO: not implement yet
+	configQueryParameters []types.QueryParameterMatcher
+	configCookies         []*cookieMatcher
+	configBody            []*bodyMatcher
+	configMethods         []string
+	runtimeFraction       *runtimeFraction
+	caseSensitive         *bool
 	// rewrite
 	prefixRewrite         string
+	regexRewrite          *regexp.Regexp
+	regexSubstitution     string
 	hostRewrite           string
 	autoHostRewrite       bool // TODO: not implement yet
 	requestHeadersParser  *headerParser
@@ -46,50 +56,129 @@ type RouteRuleImplBase struct {
 	// information
 	upstreamProtocol string
 	perFilterConfig  map[string]interface{}
+	filterConfigs    map[string]interface{}
+	disabledFilters  map[string]bool
 	// policy
-	policy *policy
+	policy     *policy
+	hashPolicy types.HashPolicy
+	// request mirror policies
+	mirrorPolicies []types.MirrorPolicy
+	corsPolicy     types.CorsPolicy
 	// direct response
 	directResponseRule *directResponseImpl
+	redirectRule       *redirectImpl
 	// action
 	routerAction       v2.RouteAction
 	defaultCluster     *weightedClusterEntry // cluster name and metadata
 	weightedClusters   map[string]weightedClusterEntry
 	totalClusterWeight uint32
+	clusterSpecifier   types.ClusterSpecifier
 	lock               sync.Mutex
 	randInstance       *rand.Rand
 }
 
 func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleImplBase, error) {
 	base := &RouteRuleImplBase{
-		vHost:                 vHost,
-		routerMatch:           route.Match,
-		configHeaders:         getRouterHeaders(route.Match.Headers),
-		prefixRewrite:         route.Route.PrefixRewrite,
-		hostRewrite:           route.Route.HostRewrite,
-		autoHostRewrite:       route.Route.AutoHostRewrite,
-		requestHeadersParser:  getHeaderParser(route.Route.RequestHeadersToAdd, nil),
-		responseHeadersParser: getHeaderParser(route.Route.ResponseHeadersToAdd, route.Route.ResponseHeadersToRemove),
-		upstreamProtocol:      route.Route.UpstreamProtocol,
-		perFilterConfig:       route.PerFilterConfig,
-		policy:                &policy{},
-		routerAction:          route.Route,
+		vHost:            vHost,
+		routerMatch:      route.Match,
+		configHeaders:    getRouterHeaders(route.Match.Headers),
+		caseSensitive:    route.Match.CaseSensitive,
+		prefixRewrite:    route.Route.PrefixRewrite,
+		hostRewrite:      route.Route.HostRewrite,
+		autoHostRewrite:  route.Route.AutoHostRewrite,
+		upstreamProtocol: route.Route.UpstreamProtocol,
+		perFilterConfig:  route.PerFilterConfig,
+		policy:           &policy{},
+		routerAction:     route.Route,
 		defaultCluster: &weightedClusterEntry{
 			clusterName: route.Route.ClusterName,
 		},
 		lock: sync.Mutex{},
 	}
+	// add match conditions
+	var err error
+	for _, method := range route.Match.Methods {
+		base.configMethods = append(base.configMethods, strings.ToUpper(method))
+	}
+	if base.configQueryParameters, err = getQueryParameterMatchers(route.Match.QueryParameters); err != nil {
+		return nil, err
+	}
+	if base.configCookies, err = getCookieMatchers(route.Match.Cookies); err != nil {
+		return nil, err
+	}
+	if base.configBody, err = getBodyMatchers(route.Match.Body); err != nil {
+		return nil, err
+	}
+	if base.runtimeFraction, err = newRuntimeFraction(route.Match.RuntimeFraction); err != nil {
+		return nil, err
+	}
+	// add stream filters' configs, the virtual host's configs are overridden
+	var vhPerFilterConfig map[string]interface{}
+	if vHost != nil {
+		vhPerFilterConfig = vHost.perFilterConfig
+	}
+	if base.filterConfigs, base.disabledFilters, err = filter.ParsePerFilterConfig(vhPerFilterConfig, route.PerFilterConfig); err != nil {
+		return nil, err
+	}
+	// add headers parser
+	if base.requestHeadersParser, err = getHeaderParser(route.Route.RequestHeadersToAdd, nil); err != nil {
+		return nil, err
+	}
+	if base.responseHeadersParser, err = getHeaderParser(route.Route.ResponseHeadersToAdd, route.Route.ResponseHeadersToRemove); err != nil {
+		return nil, err
+	}
 	// add clusters
 	base.weightedClusters, base.totalClusterWeight = getWeightedClusterEntry(route.Route.WeightedClusters)
 	if len(route.Route.MetadataMatch) > 0 {
 		base.defaultCluster.clusterMetadataMatchCriteria = NewMetadataMatchCriteriaImpl(route.Route.MetadataMatch)
 	}
+	// add cluster specifier, the cluster_header is the name of the cluster
+	switch {
+	case route.Route.ClusterSpecifier != nil:
+		if base.clusterSpecifier, err = newClusterSpecifier(route.Route.ClusterSpecifier); err != nil {
+			return nil, err
+		}
+	case route.Route.ClusterHeader != "":
+		base.clusterSpecifier = &headerLookupSpecifier{
+			key: headerHashKey(route.Route.ClusterHeader),
+		}
+	}
 	// add policy
 	if route.Route.RetryPolicy != nil {
-		base.policy.retryPolicy = &retryPolicyImpl{
-			retryOn:      route.Route.RetryPolicy.RetryOn,
-			retryTimeout: route.Route.RetryPolicy.RetryTimeout,
-			numRetries:   route.Route.RetryPolicy.NumRetries,
+		if base.policy.retryPolicy, err = newRetryPolicy(route.Route.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+	// add hash policy
+	hashPolicy, err := newHashPolicy(route.Route.HashPolicy)
+	if err != nil {
+		return nil, err
+	}
+	base.hashPolicy = hashPolicy
+	// add request mirror policies
+	if base.mirrorPolicies, err = newMirrorPolicies(route.Route.RequestMirrorPolicies); err != nil {
+		return nil, err
+	}
+	// add cors policy
+	if base.corsPolicy, err = newCorsPolicy(route.Route.Cors); err != nil {
+		return nil, err
+	}
+	// add regex rewrite
+	if rewrite := route.Route.RegexRewrite; rewrite != nil {
+		if base.prefixRewrite != "" {
+			return nil, ErrConflictPathRewrite
+		}
+		if base.regexRewrite, err = regexp.Compile(rewrite.Pattern); err != nil {
+			return nil, err
 		}
+		base.regexSubstitution = rewrite.Substitution
+	}
+	// add redirect rule
+	if route.Redirect != nil && route.DirectResponse != nil {
+		return nil, ErrConflictRouteAction
+	}
+	if base.redirectRule, err = newRedirect(route.Redirect); err != nil {
+		return nil, err
 	}
 	// add direct repsonse rule
 	if route.DirectResponse != nil {
@@ -105,6 +194,14 @@ func (rri *RouteRuleImplBase) DirectResponseRule() api.DirectResponseRule {
 	return rri.directResponseRule
 }
 
+// types.RedirectRouteRule
+func (rri *RouteRuleImplBase) RedirectRule() types.RedirectRule {
+	if rri.redirectRule == nil {
+		return nil
+	}
+	return rri.redirectRule
+}
+
 // types.RouteRule
 // Select Cluster for Routing
 // if weighted cluster is nil, return clusterName directly, else
@@ -128,6 +225,16 @@ func (rri *RouteRuleImplBase) ClusterName() string {
 	return rri.defaultCluster.clusterName
 }
 
+// types.ClusterSpecifierRouteRule
+func (rri *RouteRuleImplBase) ClusterNameWithContext(ctx context.Context, headers api.HeaderMap) string {
+	if rri.clusterSpecifier != nil {
+		if clusterName := rri.clusterSpecifier.ClusterName(ctx, headers); clusterName != "" {
+			return clusterName
+		}
+	}
+	return rri.ClusterName()
+}
+
 func (rri *RouteRuleImplBase) UpstreamProtocol() string {
 	return rri.upstreamProtocol
 }
@@ -140,6 +247,23 @@ func (rri *RouteRuleImplBase) Policy() api.Policy {
 	return rri.policy
 }
 
+func (rri *RouteRuleImplBase) HashPolicy() types.HashPolicy {
+	return rri.hashPolicy
+}
+
+// types.MirrorPolicyRouteRule
+func (rri *RouteRuleImplBase) MirrorPolicies() []types.MirrorPolicy {
+	return rri.mirrorPolicies
+}
+
+// types.CorsPolicyRouteRule
+func (rri *RouteRuleImplBase) CorsPolicy() types.CorsPolicy {
+	if rri.corsPolicy == nil && rri.vHost != nil {
+		return rri.vHost.corsPolicy
+	}
+	return rri.corsPolicy
+}
+
 func (rri *RouteRuleImplBase) MetadataMatchCriteria(clusterName string) api.MetadataMatchCriteria {
 	criteria := rri.defaultCluster.clusterMetadataMatchCriteria
 	if len(rri.weightedClusters) != 0 {
@@ -158,33 +282,142 @@ func (rri *RouteRuleImplBase) PerFilterConfig() map[string]interface{} {
 	return rri.perFilterConfig
 }
 
-// matchRoute is a common matched for http
+// FilterConfig returns the parsed per-route config of the stream filter type
+func (rri *RouteRuleImplBase) FilterConfig(filterType string) interface{} {
+	return rri.filterConfigs[filterType]
+}
+
+// FilterDisabled returns true if the stream filter type is disabled in the route
+func (rri *RouteRuleImplBase) FilterDisabled(filterType string) bool {
+	return rri.disabledFilters[filterType]
+}
+
+// MatchRoute matches the common conditions of the route rule, such as methods, headers,
+// query parameters, cookies, body and runtime fraction.
+// the route rules created by the RouterRuleFactory can use it to match the common conditions.
+func (rri *RouteRuleImplBase) MatchRoute(headers api.HeaderMap, randomValue uint64) bool {
+	return rri.matchRoute(headers, randomValue)
+}
+
+// matchRoute is a common matched for all route rules
 func (rri *RouteRuleImplBase) matchRoute(headers api.HeaderMap, randomValue uint64) bool {
-	// 1. match headers' KV
+	// 1. match methods
+	if len(rri.configMethods) > 0 {
+		method, _ := headers.Get(protocol.MosnHeaderMethod)
+		if !matchMethods(strings.ToUpper(method), rri.configMethods) {
+			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match method", method)
+			return false
+		}
+	}
+	// 2. match headers' KV
 	if !ConfigUtilityInst.MatchHeaders(headers, rri.configHeaders) {
 		log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match header", headers)
 		return false
 	}
-	// 2. match query parameters
-	var queryParams types.QueryParams
-	if QueryString, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
-		queryParams = httpmosn.ParseQueryString(QueryString)
-	}
-	if len(queryParams) != 0 {
+	// 3. match query parameters
+	if len(rri.configQueryParameters) > 0 {
+		var queryParams types.QueryParams
+		if QueryString, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
+			queryParams = httpmosn.ParseQueryString(QueryString)
+		}
 		if !ConfigUtilityInst.MatchQueryParams(queryParams, rri.configQueryParameters) {
 			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match query params", queryParams)
 			return false
 		}
 	}
+	// 4. match cookies
+	for _, cookie := range rri.configCookies {
+		if !cookie.Matches(headers) {
+			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match cookie", cookie.name)
+			return false
+		}
+	}
+	// 5. match body
+	for _, body := range rri.configBody {
+		if !body.Matches(headers) {
+			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match body", body.name)
+			return false
+		}
+	}
+	// 6. match runtime fraction
+	if rri.runtimeFraction != nil && !rri.runtimeFraction.match(randomValue) {
+		log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match runtime fraction", randomValue)
+		return false
+	}
 	return true
 }
 
+// isCaseSensitive returns whether the path is matched case sensitive,
+// the defaultValue is used if the route does not configure it.
+func (rri *RouteRuleImplBase) isCaseSensitive(defaultValue bool) bool {
+	if rri.caseSensitive == nil {
+		return defaultValue
+	}
+	return *rri.caseSensitive
+}
+
+func hasPrefix(s, prefix string, caseSensitive bool) bool {
+	if caseSensitive {
+		return strings.HasPrefix(s, prefix)
+	}
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func matchMethods(method string, configMethods []string) bool {
+	for _, m := range configMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeFraction matches numerator/denominator of the requests by the random value
+type runtimeFraction struct {
+	numerator   uint64
+	denominator uint64
+}
+
+func newRuntimeFraction(cfg *v2.RuntimeFraction) (*runtimeFraction, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	f := &runtimeFraction{
+		numerator:   uint64(cfg.Numerator),
+		denominator: uint64(cfg.Denominator),
+	}
+	switch f.denominator {
+	case 0:
+		f.denominator = 100
+	case 100, 10000, 1000000:
+	default:
+		return nil, ErrInvalidRuntimeFraction
+	}
+	return f, nil
+}
+
+func (f *runtimeFraction) match(randomValue uint64) bool {
+	return randomValue%f.denominator < f.numerator
+}
+
 func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedPath string) {
+	if rri.regexRewrite != nil {
+		if path, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
+			if rewritten := rri.regexRewrite.ReplaceAllString(path, rri.regexSubstitution); rewritten != path {
+				headers.Set(protocol.MosnOriginalHeaderPathKey, path)
+				headers.Set(protocol.MosnHeaderPathKey, rewritten)
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "finalizePathHeader", "rewrite path by regex, new path is "+rewritten)
+				}
+			}
+		}
+		return
+	}
 	if len(rri.prefixRewrite) < 1 {
 		return
 	}
 	if path, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-		if strings.HasPrefix(path, matchedPath) {
+		if hasPrefix(path, matchedPath, rri.isCaseSensitive(true)) {
 			headers.Set(protocol.MosnOriginalHeaderPathKey, path)
 			headers.Set(protocol.MosnHeaderPathKey, rri.prefixRewrite+path[len(matchedPath):])
 			log.DefaultLogger.Infof(RouterLogFormat, "routerule", "finalizePathHeader", "add prefix to path, prefix is "+rri.prefixRewrite)
@@ -193,20 +426,35 @@ func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedP
 }
 
 func (rri *RouteRuleImplBase) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.finalizeRequestHeaders(headers, requestInfo)
+	rri.finalizeRequestHeaders(nil, headers, requestInfo)
+}
+
+// types.VariableHeadersRouteRule
+func (rri *RouteRuleImplBase) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.finalizeRequestHeaders(ctx, headers, requestInfo)
 }
 
-func (rri *RouteRuleImplBase) finalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+// finalizeRequestHeaders evaluates the request headers, the variables in headers are not resolved if ctx is nil
+func (rri *RouteRuleImplBase) finalizeRequestHeaders(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 	if len(rri.hostRewrite) > 0 {
 		headers.Set(protocol.IstioHeaderHostKey, rri.hostRewrite)
 	}
 }
 
 func (rri *RouteRuleImplBase) FinalizeResponseHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+	rri.finalizeResponseHeaders(nil, headers, requestInfo)
+}
+
+// types.VariableHeadersRouteRule
+func (rri *RouteRuleImplBase) FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.finalizeResponseHeaders(ctx, headers, requestInfo)
+}
+
+func (rri *RouteRuleImplBase) finalizeResponseHeaders(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 }
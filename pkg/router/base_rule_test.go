@@ -190,6 +190,50 @@ func Test_RouteRuleImplBase_finalizePathHeader(t *testing.T) {
 	}
 }
 
+func Test_RouteRuleImplBase_finalizePathHeaderRegex(t *testing.T) {
+	routeCfg := &v2.Router{}
+	routeCfg.Match.Prefix = "/"
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.RegexRewrite = &v2.RegexRewrite{
+		Pattern:      `^/service/([^/]+)/(.*)$`,
+		Substitution: "/$2/instance/${1}",
+	}
+	rri, err := NewRouteRuleImplBase(nil, routeCfg)
+	if err != nil {
+		t.Fatalf("create route rule failed: %v", err)
+	}
+	tests := []struct {
+		path string
+		want types.HeaderMap
+	}{
+		{
+			path: "/service/foo/v1/list",
+			want: protocol.CommonHeader{protocol.MosnHeaderPathKey: "/v1/list/instance/foo", protocol.MosnOriginalHeaderPathKey: "/service/foo/v1/list"},
+		},
+		{
+			path: "/other/path",
+			want: protocol.CommonHeader{protocol.MosnHeaderPathKey: "/other/path"},
+		},
+	}
+	for _, tt := range tests {
+		headers := protocol.CommonHeader{protocol.MosnHeaderPathKey: tt.path}
+		rri.finalizePathHeader(headers, "/")
+		if !reflect.DeepEqual(headers, tt.want) {
+			t.Errorf("path %s regex rewrite got %v, want %v", tt.path, headers, tt.want)
+		}
+	}
+	// invalid configs
+	routeCfg.Route.RegexRewrite.Pattern = "("
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err == nil {
+		t.Error("invalid regex rewrite pattern should be failed")
+	}
+	routeCfg.Route.RegexRewrite.Pattern = "^/service"
+	routeCfg.Route.PrefixRewrite = "/abc"
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err != ErrConflictPathRewrite {
+		t.Errorf("prefix rewrite and regex rewrite should be conflicted, but got %v", err)
+	}
+}
+
 func Test_RouteRuleImplBase_FinalizeRequestHeaders(t *testing.T) {
 
 	type args struct {
@@ -481,3 +525,86 @@ func Test_RouteRuleImplBase_FinalizeResponseHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteRuleMatchConditions(t *testing.T) {
+	present := true
+	absent := false
+	insensitive := false
+	testCases := []struct {
+		match    v2.RouterMatch
+		headers  map[string]string
+		random   uint64
+		expected bool
+	}{
+		// methods
+		{v2.RouterMatch{Prefix: "/", Methods: []string{"get", "HEAD"}}, map[string]string{protocol.MosnHeaderMethod: "GET"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", Methods: []string{"GET"}}, map[string]string{protocol.MosnHeaderMethod: "POST"}, 1, false},
+		{v2.RouterMatch{Prefix: "/", Methods: []string{"GET"}}, map[string]string{}, 1, false},
+		// header presence, absence and inverted match
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-canary", Present: &present}}}, map[string]string{"x-canary": ""}, 1, true},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-canary", Present: &present}}}, map[string]string{}, 1, false},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-canary", Present: &absent}}}, map[string]string{}, 1, true},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-canary", Present: &absent}}}, map[string]string{"x-canary": "1"}, 1, false},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "prod", InvertMatch: true}}}, map[string]string{"x-env": "test"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "prod", InvertMatch: true}}}, map[string]string{"x-env": "prod"}, 1, false},
+		{v2.RouterMatch{Prefix: "/", Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "^pro", Regex: true, InvertMatch: true}}}, map[string]string{"x-env": "prod"}, 1, false},
+		// query parameters
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "version", Value: "v2"}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "version=v2&k=v"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "version", Value: "v2"}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "version=v1"}, 1, false},
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "version", Value: "v2"}}}, map[string]string{}, 1, false},
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "version", Value: "^v[23]$", Regex: true}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "version=v3"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "debug", Present: &present}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "debug=1"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "debug", Present: &absent}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "debug=1"}, 1, false},
+		// cookies
+		{v2.RouterMatch{Prefix: "/", Cookies: []v2.CookieMatcher{{Name: "user", Value: "alice"}}}, map[string]string{"Cookie": "id=1; user=alice"}, 1, true},
+		{v2.RouterMatch{Prefix: "/", Cookies: []v2.CookieMatcher{{Name: "user", Value: "^a", Regex: true}}}, map[string]string{"cookie": "user=bob"}, 1, false},
+		{v2.RouterMatch{Prefix: "/", Cookies: []v2.CookieMatcher{{Name: "user", Present: &absent}}}, map[string]string{}, 1, true},
+		// runtime fraction
+		{v2.RouterMatch{Prefix: "/", RuntimeFraction: &v2.RuntimeFraction{Numerator: 10}}, map[string]string{}, 109, true},
+		{v2.RouterMatch{Prefix: "/", RuntimeFraction: &v2.RuntimeFraction{Numerator: 10}}, map[string]string{}, 110, false},
+		{v2.RouterMatch{Prefix: "/", RuntimeFraction: &v2.RuntimeFraction{Numerator: 1, Denominator: 10000}}, map[string]string{}, 20000, true},
+		// case sensitive
+		{v2.RouterMatch{Prefix: "/API"}, map[string]string{}, 1, false},
+		{v2.RouterMatch{Prefix: "/API", CaseSensitive: &insensitive}, map[string]string{}, 1, true},
+		{v2.RouterMatch{Regex: "^/API/.*$"}, map[string]string{}, 1, false},
+		{v2.RouterMatch{Regex: "^/API/.*$", CaseSensitive: &insensitive}, map[string]string{}, 1, true},
+		// sofa rule shares the conditions
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: ".*"}}, Methods: []string{"GET"}}, map[string]string{types.SofaRouteMatchKey: "com.test"}, 1, false},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: ".*"}, {Name: "x-env", Value: "prod"}}}, map[string]string{types.SofaRouteMatchKey: "com.test", "x-env": "prod"}, 1, true},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: ".*"}, {Name: "x-env", Value: "prod"}}}, map[string]string{types.SofaRouteMatchKey: "com.test"}, 1, false},
+	}
+	for i, tc := range testCases {
+		vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+			Name: "test",
+			Routers: []v2.Router{
+				{RouterConfig: v2.RouterConfig{Match: tc.match, Route: v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: "test"}}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("#%d create virtual host failed: %v", i, err)
+		}
+		headers := map[string]string{protocol.MosnHeaderPathKey: "/api/test"}
+		for k, v := range tc.headers {
+			headers[k] = v
+		}
+		if matched := vh.GetRouteFromEntries(protocol.CommonHeader(headers), tc.random) != nil; matched != tc.expected {
+			t.Errorf("#%d expected matched %v, but got %v", i, tc.expected, matched)
+		}
+	}
+}
+
+func TestRouteRuleInvalidMatchConditions(t *testing.T) {
+	for i, match := range []v2.RouterMatch{
+		{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Value: "v"}}},
+		{Prefix: "/", QueryParameters: []v2.QueryParameterMatcher{{Name: "v", Value: "(", Regex: true}}},
+		{Prefix: "/", Cookies: []v2.CookieMatcher{{Name: "c", Value: "(", Regex: true}}},
+		{Prefix: "/", RuntimeFraction: &v2.RuntimeFraction{Numerator: 1, Denominator: 1000}},
+		{Prefix: "/", Body: []v2.BodyMatcher{{Type: "xml", Name: "method"}}},
+		{Prefix: "/", Body: []v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Value: "v"}}},
+	} {
+		route := &v2.Router{RouterConfig: v2.RouterConfig{Match: match}}
+		if _, err := NewRouteRuleImplBase(nil, route); err == nil {
+			t.Errorf("#%d invalid match conditions should be failed", i)
+		}
+	}
+}
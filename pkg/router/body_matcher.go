@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"bytes"
+	rawjson "encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+// bodyHeaders wraps the request headers with the buffered request body.
+// the body is parsed at most once in a route matching
+type bodyHeaders struct {
+	api.HeaderMap
+	body       []byte
+	jsonParsed bool
+	jsonBody   interface{}
+	formParsed bool
+	formBody   url.Values
+}
+
+// NewBodyHeaders returns a request header map that contains the buffered request body,
+// the routes with body matchers can be matched only if the headers is created by NewBodyHeaders
+func NewBodyHeaders(headers api.HeaderMap, body []byte) api.HeaderMap {
+	return &bodyHeaders{
+		HeaderMap: headers,
+		body:      body,
+	}
+}
+
+func (h *bodyHeaders) jsonValue() interface{} {
+	if !h.jsonParsed {
+		h.jsonParsed = true
+		decoder := rawjson.NewDecoder(bytes.NewReader(h.body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&h.jsonBody); err != nil {
+			log.DefaultLogger.Debugf(RouterLogFormat, "body headers", "parse json body failed", err)
+			h.jsonBody = nil
+		}
+	}
+	return h.jsonBody
+}
+
+func (h *bodyHeaders) formValue() url.Values {
+	if !h.formParsed {
+		h.formParsed = true
+		form, err := url.ParseQuery(string(h.body))
+		if err != nil {
+			log.DefaultLogger.Debugf(RouterLogFormat, "body headers", "parse form body failed", err)
+		}
+		h.formBody = form
+	}
+	return h.formBody
+}
+
+// bodyMatcher matches a field of the request body as the query parameter
+type bodyMatcher struct {
+	*queryParameterMatcher
+	matcherType string
+	path        []string
+}
+
+func newBodyMatcher(cfg v2.BodyMatcher) (*bodyMatcher, error) {
+	if cfg.Type != v2.BodyMatcherJSON && cfg.Type != v2.BodyMatcherForm {
+		return nil, ErrInvalidBodyMatcherType
+	}
+	matcher, err := newQueryParameterMatcher(cfg.Name, cfg.Value, cfg.Regex, cfg.Present)
+	if err != nil {
+		return nil, err
+	}
+	bm := &bodyMatcher{
+		queryParameterMatcher: matcher,
+		matcherType:           cfg.Type,
+	}
+	if cfg.Type == v2.BodyMatcherJSON {
+		bm.path = strings.Split(cfg.Name, ".")
+	}
+	return bm, nil
+}
+
+func (bm *bodyMatcher) Matches(headers api.HeaderMap) bool {
+	h, ok := headers.(*bodyHeaders)
+	// the body is not buffered
+	if !ok {
+		return false
+	}
+	var value string
+	switch bm.matcherType {
+	case v2.BodyMatcherJSON:
+		value, ok = lookupJSONPath(h.jsonValue(), bm.path)
+	case v2.BodyMatcherForm:
+		var values []string
+		values, ok = h.formValue()[bm.name]
+		if ok && len(values) > 0 {
+			value = values[0]
+		}
+	}
+	return bm.matchValue(value, ok)
+}
+
+// lookupJSONPath returns the string value of the json field in the path,
+// the objects and arrays are returned as the json encoded string
+func lookupJSONPath(v interface{}, path []string) (string, bool) {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			child, ok := node[key]
+			if !ok {
+				return "", false
+			}
+			v = child
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return "", false
+			}
+			v = node[idx]
+		default:
+			return "", false
+		}
+	}
+	switch value := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return value, true
+	case rawjson.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		b, err := rawjson.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func TestBodyMatcher(t *testing.T) {
+	present := true
+	absent := false
+	jsonBody := `{"jsonrpc":"2.0","method":"user.get","params":[{"id":1001,"vip":true}],"meta":{"tags":["a"]},"extra":null}`
+	formBody := "action=query&user=alice&user=bob"
+	testCases := []struct {
+		matchers []v2.BodyMatcher
+		body     string
+		expected bool
+	}{
+		// json
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "method", Value: "user.get"}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "method", Value: "user.set"}}, jsonBody, false},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "method", Value: "^user\\.", Regex: true}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "params.0.id", Value: "1001"}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "params.0.vip", Value: "true"}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "params.1.id", Present: &absent}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "meta.tags", Value: `["a"]`}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "extra", Present: &present}}, jsonBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "method.name", Present: &present}}, jsonBody, false},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherJSON, Name: "method", Present: &present}}, "not json", false},
+		{[]v2.BodyMatcher{
+			{Type: v2.BodyMatcherJSON, Name: "jsonrpc", Value: "2.0"},
+			{Type: v2.BodyMatcherJSON, Name: "params.0.id", Value: "1002"},
+		}, jsonBody, false},
+		// form
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherForm, Name: "action", Value: "query"}}, formBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherForm, Name: "user", Value: "alice"}}, formBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherForm, Name: "user", Value: "bob"}}, formBody, false},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherForm, Name: "debug", Present: &absent}}, formBody, true},
+		{[]v2.BodyMatcher{{Type: v2.BodyMatcherForm, Name: "debug", Present: &absent}}, "", true},
+	}
+	for i, tc := range testCases {
+		vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+			Name: "test",
+			Routers: []v2.Router{
+				{RouterConfig: v2.RouterConfig{
+					Match: v2.RouterMatch{Prefix: "/", Body: tc.matchers},
+					Route: v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: "test"}},
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("#%d create virtual host failed: %v", i, err)
+		}
+		headers := protocol.CommonHeader{protocol.MosnHeaderPathKey: "/rpc"}
+		if matched := vh.GetRouteFromEntries(NewBodyHeaders(headers, []byte(tc.body)), 1) != nil; matched != tc.expected {
+			t.Errorf("#%d expected matched %v, but got %v", i, tc.expected, matched)
+		}
+		// the routes with body matchers are not matched if the body is not buffered
+		if vh.GetRouteFromEntries(headers, 1) != nil {
+			t.Errorf("#%d expected not matched without body", i)
+		}
+	}
+}
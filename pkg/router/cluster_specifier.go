@@ -0,0 +1,293 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	rawjson "encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/plugin"
+	"mosn.io/mosn/pkg/plugin/proto"
+	"mosn.io/mosn/pkg/types"
+)
+
+// Cluster specifier types
+const (
+	// ClusterSpecifierHeaderLookup chooses the cluster by looking up the request key in a table
+	ClusterSpecifierHeaderLookup = "header_lookup"
+	// ClusterSpecifierHashSplit chooses the cluster by the hash of the request key, the same key always
+	// chooses the same cluster, so the requests of a user stick to a cluster
+	ClusterSpecifierHashSplit = "hash_split"
+	// ClusterSpecifierPlugin chooses the cluster by calling a plugin in pkg/plugin
+	ClusterSpecifierPlugin = "plugin"
+)
+
+func init() {
+	RegisterClusterSpecifier(ClusterSpecifierHeaderLookup, newHeaderLookupSpecifier)
+	RegisterClusterSpecifier(ClusterSpecifierHashSplit, newHashSplitSpecifier)
+	RegisterClusterSpecifier(ClusterSpecifierPlugin, newPluginSpecifier)
+}
+
+// ClusterSpecifierFactory creates a cluster specifier by the config
+type ClusterSpecifierFactory func(config map[string]interface{}) (types.ClusterSpecifier, error)
+
+var (
+	clusterSpecifierFactories      = map[string]ClusterSpecifierFactory{}
+	clusterSpecifierFactoriesMutex sync.RWMutex
+)
+
+// RegisterClusterSpecifier registers the cluster specifier factory of the type,
+// the factory registered later overrides the former one
+func RegisterClusterSpecifier(typ string, f ClusterSpecifierFactory) {
+	clusterSpecifierFactoriesMutex.Lock()
+	defer clusterSpecifierFactoriesMutex.Unlock()
+	if _, ok := clusterSpecifierFactories[typ]; ok {
+		log.DefaultLogger.Warnf(RouterLogFormat, "Extend", "RegisterClusterSpecifier", fmt.Sprintf("cluster specifier %s is overridden", typ))
+	}
+	clusterSpecifierFactories[typ] = f
+}
+
+func newClusterSpecifier(cfg *v2.ClusterSpecifierConfig) (types.ClusterSpecifier, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	clusterSpecifierFactoriesMutex.RLock()
+	f, ok := clusterSpecifierFactories[cfg.Type]
+	clusterSpecifierFactoriesMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrUnknownClusterSpecifier, cfg.Type)
+	}
+	return f(cfg.Config)
+}
+
+// parseClusterSpecifierConfig parses the config map into the config struct
+func parseClusterSpecifierConfig(config map[string]interface{}, v interface{}) error {
+	data, err := rawjson.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return rawjson.Unmarshal(data, v)
+}
+
+// clusterSpecifierKeyConfig specifies where the request key comes from, only one of them should be set
+type clusterSpecifierKeyConfig struct {
+	Header   string `json:"header,omitempty"`
+	Cookie   string `json:"cookie,omitempty"`
+	Variable string `json:"variable,omitempty"`
+}
+
+func (cfg *clusterSpecifierKeyConfig) getter() (hashKeyGetter, error) {
+	switch {
+	case cfg.Header != "":
+		return headerHashKey(cfg.Header), nil
+	case cfg.Cookie != "":
+		return cookieHashKey(cfg.Cookie), nil
+	case cfg.Variable != "":
+		return variableHashKey(cfg.Variable), nil
+	default:
+		return nil, ErrEmptyClusterSpecifierKey
+	}
+}
+
+// headerLookupSpecifier looks up the cluster in the table by the request key,
+// the request key is the cluster name if the table is empty
+type headerLookupSpecifier struct {
+	key            hashKeyGetter
+	clusters       map[string]string
+	defaultCluster string
+}
+
+type headerLookupConfig struct {
+	clusterSpecifierKeyConfig
+	Clusters       map[string]string `json:"clusters,omitempty"`
+	DefaultCluster string            `json:"default_cluster,omitempty"`
+}
+
+func newHeaderLookupSpecifier(config map[string]interface{}) (types.ClusterSpecifier, error) {
+	cfg := &headerLookupConfig{}
+	if err := parseClusterSpecifierConfig(config, cfg); err != nil {
+		return nil, err
+	}
+	key, err := cfg.getter()
+	if err != nil {
+		return nil, err
+	}
+	return &headerLookupSpecifier{
+		key:            key,
+		clusters:       cfg.Clusters,
+		defaultCluster: cfg.DefaultCluster,
+	}, nil
+}
+
+func (s *headerLookupSpecifier) ClusterName(ctx context.Context, headers api.HeaderMap) string {
+	value, ok := s.key(ctx, headers)
+	if !ok {
+		return s.defaultCluster
+	}
+	if len(s.clusters) == 0 {
+		return value
+	}
+	if cluster, ok := s.clusters[value]; ok {
+		return cluster
+	}
+	return s.defaultCluster
+}
+
+// hashSplitSpecifier splits the requests into the clusters by the weights,
+// the cluster is chosen by the hash of the request key instead of a random value
+type hashSplitSpecifier struct {
+	key         hashKeyGetter
+	clusters    []hashSplitCluster
+	totalWeight uint64
+}
+
+type hashSplitCluster struct {
+	Name   string `json:"name,omitempty"`
+	Weight uint32 `json:"weight,omitempty"`
+}
+
+type hashSplitConfig struct {
+	clusterSpecifierKeyConfig
+	Clusters []hashSplitCluster `json:"clusters,omitempty"`
+}
+
+func newHashSplitSpecifier(config map[string]interface{}) (types.ClusterSpecifier, error) {
+	cfg := &hashSplitConfig{}
+	if err := parseClusterSpecifierConfig(config, cfg); err != nil {
+		return nil, err
+	}
+	key, err := cfg.getter()
+	if err != nil {
+		return nil, err
+	}
+	s := &hashSplitSpecifier{
+		key: key,
+	}
+	for _, cluster := range cfg.Clusters {
+		if cluster.Name == "" || cluster.Weight == 0 {
+			continue
+		}
+		s.clusters = append(s.clusters, cluster)
+		s.totalWeight += uint64(cluster.Weight)
+	}
+	if s.totalWeight == 0 {
+		return nil, ErrEmptyHashSplitClusters
+	}
+	return s, nil
+}
+
+func (s *hashSplitSpecifier) ClusterName(ctx context.Context, headers api.HeaderMap) string {
+	value, ok := s.key(ctx, headers)
+	if !ok {
+		return ""
+	}
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	bucket := h.Sum64() % s.totalWeight
+	for _, cluster := range s.clusters {
+		if bucket < uint64(cluster.Weight) {
+			return cluster.Name
+		}
+		bucket -= uint64(cluster.Weight)
+	}
+	return ""
+}
+
+const (
+	defaultPluginSpecifierTimeout = 10 * time.Millisecond
+	defaultPluginClusterHeader    = "cluster"
+	// pluginSpecifierRequestType is the type of the request sent to the plugin
+	pluginSpecifierRequestType = "cluster_specifier"
+)
+
+// pluginCaller calls a plugin, it is implemented by *plugin.Client
+type pluginCaller interface {
+	Call(request *proto.Request, timeout time.Duration) (*proto.Response, error)
+}
+
+// pluginSpecifier sends the request headers to a plugin, and gets the cluster name from the response headers
+type pluginSpecifier struct {
+	name          string
+	client        pluginCaller
+	timeout       time.Duration
+	clusterHeader string
+}
+
+type pluginSpecifierConfig struct {
+	Name          string             `json:"name,omitempty"`
+	Args          []string           `json:"args,omitempty"`
+	Timeout       api.DurationConfig `json:"timeout,omitempty"`
+	ClusterHeader string             `json:"cluster_header,omitempty"`
+}
+
+func newPluginSpecifier(config map[string]interface{}) (types.ClusterSpecifier, error) {
+	cfg := &pluginSpecifierConfig{}
+	if err := parseClusterSpecifierConfig(config, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Name == "" {
+		return nil, ErrEmptyPluginName
+	}
+	var pluginConfig *plugin.Config
+	if len(cfg.Args) > 0 {
+		pluginConfig = &plugin.Config{MaxProcs: 1, Args: cfg.Args}
+	}
+	client, err := plugin.Register(cfg.Name, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+	s := &pluginSpecifier{
+		name:          cfg.Name,
+		client:        client,
+		timeout:       cfg.Timeout.Duration,
+		clusterHeader: cfg.ClusterHeader,
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultPluginSpecifierTimeout
+	}
+	if s.clusterHeader == "" {
+		s.clusterHeader = defaultPluginClusterHeader
+	}
+	return s, nil
+}
+
+func (s *pluginSpecifier) ClusterName(ctx context.Context, headers api.HeaderMap) string {
+	h := make(map[string]string)
+	if headers != nil {
+		headers.Range(func(k, v string) bool {
+			h[k] = v
+			return true
+		})
+	}
+	response, err := s.client.Call(&proto.Request{
+		Header: h,
+		Type:   pluginSpecifierRequestType,
+	}, s.timeout)
+	if err != nil {
+		log.Proxy.Errorf(ctx, RouterLogFormat, "pluginSpecifier", "ClusterName", fmt.Sprintf("call plugin %s failed: %v", s.name, err))
+		return ""
+	}
+	return response.GetHeader()[s.clusterHeader]
+}
@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/plugin/proto"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type fixedClusterSpecifier string
+
+func (s fixedClusterSpecifier) ClusterName(ctx context.Context, headers api.HeaderMap) string {
+	return string(s)
+}
+
+func TestClusterSpecifierConfig(t *testing.T) {
+	RegisterClusterSpecifier("test_fixed", func(config map[string]interface{}) (types.ClusterSpecifier, error) {
+		return fixedClusterSpecifier(config["cluster"].(string)), nil
+	})
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "default"
+	routeCfg.Route.ClusterSpecifier = &v2.ClusterSpecifierConfig{
+		Type:   "test_fixed",
+		Config: map[string]interface{}{"cluster": "fixed"},
+	}
+	rule, err := NewRouteRuleImplBase(nil, routeCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := rule.ClusterNameWithContext(context.Background(), protocol.CommonHeader{}); name != "fixed" {
+		t.Fatalf("expected cluster fixed, but got %s", name)
+	}
+	// invalid configs
+	for i, cfg := range []*v2.ClusterSpecifierConfig{
+		{Type: "unknown"},
+		{Type: ClusterSpecifierHeaderLookup},
+		{Type: ClusterSpecifierHashSplit, Config: map[string]interface{}{"header": "user"}},
+		{Type: ClusterSpecifierPlugin},
+	} {
+		routeCfg.Route.ClusterSpecifier = cfg
+		if _, err := NewRouteRuleImplBase(nil, routeCfg); err == nil {
+			t.Errorf("#%d invalid cluster specifier config should be failed", i)
+		}
+	}
+}
+
+func TestClusterSpecifierHeaderLookup(t *testing.T) {
+	s, err := newHeaderLookupSpecifier(map[string]interface{}{
+		"header": "x-version",
+		"clusters": map[string]interface{}{
+			"v1": "cluster_v1",
+			"v2": "cluster_v2",
+		},
+		"default_cluster": "cluster_stable",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, tc := range []struct {
+		headers  api.HeaderMap
+		expected string
+	}{
+		{protocol.CommonHeader{"x-version": "v1"}, "cluster_v1"},
+		{protocol.CommonHeader{"x-version": "v2"}, "cluster_v2"},
+		{protocol.CommonHeader{"x-version": "v3"}, "cluster_stable"},
+		{protocol.CommonHeader{}, "cluster_stable"},
+	} {
+		if name := s.ClusterName(ctx, tc.headers); name != tc.expected {
+			t.Errorf("headers %v expected cluster %s, but got %s", tc.headers, tc.expected, name)
+		}
+	}
+	// cluster_header uses the header value as the cluster name
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "default"
+	routeCfg.Route.ClusterHeader = "x-cluster"
+	rule, err := NewRouteRuleImplBase(nil, routeCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := rule.ClusterNameWithContext(ctx, protocol.CommonHeader{"x-cluster": "c1"}); name != "c1" {
+		t.Fatalf("expected cluster c1, but got %s", name)
+	}
+	if name := rule.ClusterNameWithContext(ctx, protocol.CommonHeader{}); name != "default" {
+		t.Fatalf("expected cluster default, but got %s", name)
+	}
+}
+
+func TestClusterSpecifierHashSplit(t *testing.T) {
+	s, err := newHashSplitSpecifier(map[string]interface{}{
+		"cookie": "uid",
+		"clusters": []interface{}{
+			map[string]interface{}{"name": "stable", "weight": 80},
+			map[string]interface{}{"name": "canary", "weight": 20},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	count := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		headers := protocol.CommonHeader{"Cookie": fmt.Sprintf("uid=user%d", i)}
+		name := s.ClusterName(ctx, headers)
+		// the same user always chooses the same cluster
+		for j := 0; j < 3; j++ {
+			if n := s.ClusterName(ctx, headers); n != name {
+				t.Fatalf("user%d chooses %s and %s", i, name, n)
+			}
+		}
+		count[name]++
+	}
+	if count["stable"]+count["canary"] != 1000 || count["canary"] < 100 || count["canary"] > 300 {
+		t.Fatalf("unexpected split result: %v", count)
+	}
+	if name := s.ClusterName(ctx, protocol.CommonHeader{}); name != "" {
+		t.Fatalf("no cluster should be chosen without key, but got %s", name)
+	}
+	// falls back to the route's cluster
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "default"
+	rule, _ := NewRouteRuleImplBase(nil, routeCfg)
+	rule.clusterSpecifier = s
+	if name := rule.ClusterNameWithContext(ctx, protocol.CommonHeader{}); name != "default" {
+		t.Fatalf("expected cluster default, but got %s", name)
+	}
+}
+
+type mockPluginCaller struct {
+	request *proto.Request
+	err     error
+}
+
+func (c *mockPluginCaller) Call(request *proto.Request, timeout time.Duration) (*proto.Response, error) {
+	c.request = request
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &proto.Response{
+		Header: map[string]string{"cluster": request.Header["x-user"] + "_cluster"},
+	}, nil
+}
+
+func TestClusterSpecifierPlugin(t *testing.T) {
+	caller := &mockPluginCaller{}
+	s := &pluginSpecifier{
+		name:          "test",
+		client:        caller,
+		timeout:       defaultPluginSpecifierTimeout,
+		clusterHeader: defaultPluginClusterHeader,
+	}
+	ctx := context.Background()
+	if name := s.ClusterName(ctx, protocol.CommonHeader{"x-user": "u1"}); name != "u1_cluster" {
+		t.Fatalf("expected cluster u1_cluster, but got %s", name)
+	}
+	if caller.request.Type != pluginSpecifierRequestType {
+		t.Fatalf("unexpected request type: %s", caller.request.Type)
+	}
+	caller.err = errors.New("plugin error")
+	if name := s.ClusterName(ctx, protocol.CommonHeader{"x-user": "u1"}); name != "" {
+		t.Fatalf("no cluster should be chosen if the plugin failed, but got %s", name)
+	}
+	if _, err := newPluginSpecifier(map[string]interface{}{"name": "not_exists_plugin"}); err == nil {
+		t.Fatal("plugin not exists should be failed")
+	}
+}
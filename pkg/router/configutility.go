@@ -40,27 +40,29 @@ func (cu *configUtility) MatchHeaders(requestHeaders api.HeaderMap, configHeader
 		log.DefaultLogger.Debugf(RouterLogFormat, "config utility", "try match header", requestHeaders)
 	}
 	for _, cfgHeaderData := range configHeaders {
-		cfgName := cfgHeaderData.Name.Get()
-		cfgValue := cfgHeaderData.Value
 		// if a condition is not matched, return false
 		// all condition matched, return true
-		value, ok := requestHeaders.Get(cfgName)
-		if !ok {
+		if matchHeader(requestHeaders, cfgHeaderData) == cfgHeaderData.InvertMatch {
 			return false
 		}
-		if cfgHeaderData.IsRegex {
-			if !cfgHeaderData.RegexPattern.MatchString(value) {
-				return false
-			}
-		} else {
-			if cfgValue != value {
-				return false
-			}
-		}
 	}
 	return true
 }
 
+func matchHeader(requestHeaders api.HeaderMap, cfgHeaderData *types.HeaderData) bool {
+	value, ok := requestHeaders.Get(cfgHeaderData.Name.Get())
+	if cfgHeaderData.PresentMatch {
+		return ok == cfgHeaderData.IsPresent
+	}
+	if !ok {
+		return false
+	}
+	if cfgHeaderData.IsRegex {
+		return cfgHeaderData.RegexPattern.MatchString(value)
+	}
+	return cfgHeaderData.Value == value
+}
+
 // types.MatchQueryParams
 func (cu *configUtility) MatchQueryParams(queryParams types.QueryParams, configQueryParams []types.QueryParameterMatcher) bool {
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
@@ -80,29 +82,87 @@ type queryParameterMatcher struct {
 	name         string
 	value        string
 	isRegex      bool
-	regexPattern regexp.Regexp
+	regexPattern *regexp.Regexp
+	presentMatch bool
+	isPresent    bool
+}
+
+func newQueryParameterMatcher(name, value string, isRegex bool, present *bool) (*queryParameterMatcher, error) {
+	if name == "" {
+		return nil, ErrEmptyMatcherName
+	}
+	qpm := &queryParameterMatcher{
+		name:    name,
+		value:   value,
+		isRegex: isRegex,
+	}
+	if present != nil {
+		qpm.presentMatch = true
+		qpm.isPresent = *present
+		return qpm, nil
+	}
+	if isRegex {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		qpm.regexPattern = pattern
+	}
+	return qpm, nil
 }
 
 func (qpm *queryParameterMatcher) Matches(requestQueryParams types.QueryParams) bool {
 	requestQueryValue, ok := requestQueryParams[qpm.name]
+	return qpm.matchValue(requestQueryValue, ok)
+}
+
+func (qpm *queryParameterMatcher) matchValue(value string, ok bool) bool {
+	if qpm.presentMatch {
+		return ok == qpm.isPresent
+	}
 	if !ok {
 		return false
 	}
 	if qpm.isRegex {
-		return qpm.regexPattern.MatchString(requestQueryValue)
+		return qpm.regexPattern.MatchString(value)
 	}
 	if qpm.value == "" {
 		return true
 	}
-	return qpm.value == requestQueryValue
+	return qpm.value == value
+}
+
+// cookieMatcher matches the request's cookie as the query parameter
+type cookieMatcher struct {
+	*queryParameterMatcher
+}
+
+func (cm *cookieMatcher) Matches(headers api.HeaderMap) bool {
+	cookies, ok := headers.Get("Cookie")
+	if !ok {
+		cookies, ok = headers.Get("cookie")
+	}
+	var value string
+	if ok {
+		value, ok = getCookieValue(cookies, cm.name)
+	}
+	return cm.matchValue(value, ok)
 }
 
 // NewConfigImpl return an configImpl instance contains requestHeadersParser and responseHeadersParser
-func NewConfigImpl(routerConfig *v2.RouterConfiguration) *configImpl {
-	return &configImpl{
-		requestHeadersParser:  getHeaderParser(routerConfig.RequestHeadersToAdd, nil),
-		responseHeadersParser: getHeaderParser(routerConfig.ResponseHeadersToAdd, routerConfig.ResponseHeadersToRemove),
+func NewConfigImpl(routerConfig *v2.RouterConfiguration) (*configImpl, error) {
+	requestHeadersParser, err := getHeaderParser(routerConfig.RequestHeadersToAdd, nil)
+	if err != nil {
+		return nil, err
+	}
+	responseHeadersParser, err := getHeaderParser(routerConfig.ResponseHeadersToAdd, routerConfig.ResponseHeadersToRemove)
+	if err != nil {
+		return nil, err
 	}
+	return &configImpl{
+		requestHeadersParser:  requestHeadersParser,
+		responseHeadersParser: responseHeadersParser,
+	}, nil
 }
 
 // Implementation of Config that reads from a proto file.
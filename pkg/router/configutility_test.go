@@ -187,7 +187,7 @@ func Test_NewConfigImpl(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewConfigImpl(tt.args.routerConfig); !reflect.DeepEqual(got, tt.want) {
+			if got, err := NewConfigImpl(tt.args.routerConfig); err != nil || !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewConfigImpl(routerConfig *v2.RouterConfiguration) = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// corsPolicyImpl is an implementation of types.CorsPolicy
+type corsPolicyImpl struct {
+	allowAllOrigins  bool
+	allowOrigins     map[string]struct{}
+	allowOriginRegex []*regexp.Regexp
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+}
+
+func newCorsPolicy(cfg *v2.CorsPolicy) (types.CorsPolicy, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	p := &corsPolicyImpl{
+		allowOrigins:     make(map[string]struct{}, len(cfg.AllowOrigins)),
+		allowMethods:     strings.Join(cfg.AllowMethods, ","),
+		allowHeaders:     strings.Join(cfg.AllowHeaders, ","),
+		exposeHeaders:    strings.Join(cfg.ExposeHeaders, ","),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			p.allowAllOrigins = true
+		}
+		p.allowOrigins[origin] = struct{}{}
+	}
+	for _, pattern := range cfg.AllowOriginRegex {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.allowOriginRegex = append(p.allowOriginRegex, regex)
+	}
+	if cfg.MaxAge.Duration > 0 {
+		p.maxAge = strconv.FormatInt(int64(cfg.MaxAge.Duration.Seconds()), 10)
+	}
+	return p, nil
+}
+
+func (p *corsPolicyImpl) AllowOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.allowAllOrigins {
+		return true
+	}
+	if _, ok := p.allowOrigins[origin]; ok {
+		return true
+	}
+	for _, regex := range p.allowOriginRegex {
+		if regex.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicyImpl) AllowMethods() string {
+	return p.allowMethods
+}
+
+func (p *corsPolicyImpl) AllowHeaders() string {
+	return p.allowHeaders
+}
+
+func (p *corsPolicyImpl) ExposeHeaders() string {
+	return p.exposeHeaders
+}
+
+func (p *corsPolicyImpl) MaxAge() string {
+	return p.maxAge
+}
+
+func (p *corsPolicyImpl) AllowCredentials() bool {
+	return p.allowCredentials
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestCorsPolicy(t *testing.T) {
+	if _, err := newCorsPolicy(&v2.CorsPolicy{AllowOriginRegex: []string{"("}}); err == nil {
+		t.Fatal("invalid origin regex should be failed")
+	}
+	policy, err := newCorsPolicy(&v2.CorsPolicy{
+		AllowOrigins:     []string{"http://example.com"},
+		AllowOriginRegex: []string{`^https://.*\.example\.com$`},
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"content-type", "x-custom"},
+		ExposeHeaders:    []string{"x-expose"},
+		MaxAge:           api.DurationConfig{Duration: time.Hour},
+		AllowCredentials: true,
+	})
+	if err != nil {
+		t.Fatalf("create cors policy failed: %v", err)
+	}
+	for origin, expected := range map[string]bool{
+		"http://example.com":      true,
+		"https://foo.example.com": true,
+		"http://foo.example.com":  false,
+		"http://other.com":        false,
+		"":                        false,
+	} {
+		if policy.AllowOrigin(origin) != expected {
+			t.Errorf("origin %s allowed expected %v", origin, expected)
+		}
+	}
+	if policy.AllowMethods() != "GET,POST" || policy.AllowHeaders() != "content-type,x-custom" ||
+		policy.ExposeHeaders() != "x-expose" || policy.MaxAge() != "3600" || !policy.AllowCredentials() {
+		t.Fatalf("unexpected cors policy: %+v", policy)
+	}
+	all, _ := newCorsPolicy(&v2.CorsPolicy{AllowOrigins: []string{"*"}})
+	if !all.AllowOrigin("http://any.com") {
+		t.Fatal("* should allow all of the origins")
+	}
+}
+
+func TestCorsPolicyOverride(t *testing.T) {
+	vhCfg := &v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Cors: &v2.CorsPolicy{
+			AllowOrigins: []string{"http://vhost.com"},
+		},
+	}
+	routeCfg := v2.Router{}
+	routeCfg.Match.Prefix = "/route"
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.Cors = &v2.CorsPolicy{
+		AllowOrigins: []string{"http://route.com"},
+	}
+	defaultCfg := v2.Router{}
+	defaultCfg.Match.Prefix = "/"
+	defaultCfg.Route.ClusterName = "test"
+	vhCfg.Routers = []v2.Router{routeCfg, defaultCfg}
+	vh, err := NewVirtualHostImpl(vhCfg)
+	if err != nil {
+		t.Fatalf("create virtual host failed: %v", err)
+	}
+	for path, origin := range map[string]string{
+		"/route": "http://route.com",
+		"/":      "http://vhost.com",
+	} {
+		route := vh.GetRouteFromEntries(protocol.CommonHeader{types.HeaderPath: path}, 1)
+		if route == nil {
+			t.Fatalf("path %s no route matched", path)
+		}
+		policy := route.RouteRule().(types.CorsPolicyRouteRule).CorsPolicy()
+		if policy == nil || !policy.AllowOrigin(origin) {
+			t.Errorf("path %s expected allow origin %s", path, origin)
+		}
+	}
+}
@@ -20,6 +20,7 @@ package router
 import (
 	"context"
 	"fmt"
+	"math/rand"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
@@ -48,6 +49,14 @@ func RegisterRouterRule(f RouterRuleFactory, order uint32) {
 func DefaultSofaRouterRuleFactory(base *RouteRuleImplBase, headers []v2.HeaderMatcher) RouteBase {
 	for _, header := range headers {
 		if header.Name == types.SofaRouteMatchKey {
+			// the sofa header is matched by the rule itself, the other conditions are matched by the base
+			var configHeaders []*types.HeaderData
+			for _, h := range base.configHeaders {
+				if h.Name.Get() != types.SofaRouteMatchKey {
+					configHeaders = append(configHeaders, h)
+				}
+			}
+			base.configHeaders = configHeaders
 			return &SofaRouteRuleImpl{
 				RouteRuleImplBase: base,
 				matchValue:        header.Value,
@@ -71,14 +80,20 @@ func RegisterMakeHandlerChain(f MakeHandlerChain, order uint32) {
 }
 
 type simpleHandler struct {
-	route api.Route
+	route   api.Route
+	headers api.HeaderMap
 }
 
 func (h *simpleHandler) IsAvailable(ctx context.Context, manager types.ClusterManager) (types.ClusterSnapshot, types.HandlerStatus) {
 	if h.route == nil {
 		return nil, types.HandlerNotAvailable
 	}
-	clusterName := h.Route().RouteRule().ClusterName()
+	var clusterName string
+	if rule, ok := h.Route().RouteRule().(types.ClusterSpecifierRouteRule); ok {
+		clusterName = rule.ClusterNameWithContext(ctx, h.headers)
+	} else {
+		clusterName = h.Route().RouteRule().ClusterName()
+	}
 	snapshot := manager.GetClusterSnapshot(context.Background(), clusterName)
 	return snapshot, types.HandlerAvailable
 }
@@ -89,11 +104,12 @@ func (h *simpleHandler) Route() api.Route {
 
 func DefaultMakeHandlerChain(ctx context.Context, headers api.HeaderMap, routers types.Routers, clusterManager types.ClusterManager) *RouteHandlerChain {
 	var handlers []types.RouteHandler
-	if r := routers.MatchRoute(headers, 1); r != nil {
+	// the random value is used to match the runtime fraction of routes
+	if r := routers.MatchRoute(headers, rand.Uint64()); r != nil {
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(ctx, RouterLogFormat, "DefaultHandklerChain", "MatchRoute", fmt.Sprintf("matched a route: %v", r))
 		}
-		handlers = append(handlers, &simpleHandler{route: r})
+		handlers = append(handlers, &simpleHandler{route: r, headers: headers})
 	}
 	return NewRouteHandlerChain(ctx, clusterManager, handlers)
 }
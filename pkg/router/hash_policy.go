@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+var errInvalidHashPolicy = errors.New("hash policy should contain one of header, cookie, source_ip and variable")
+
+// hashKeyGetter gets the key of a hash policy from the request
+type hashKeyGetter func(ctx context.Context, headers api.HeaderMap) (string, bool)
+
+// hashPolicyImpl is an implementation of types.HashPolicy
+// the hash keys of all policies are combined into one hash key
+type hashPolicyImpl struct {
+	getters []hashKeyGetter
+}
+
+func newHashPolicy(configs []v2.HashPolicy) (types.HashPolicy, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	hp := &hashPolicyImpl{
+		getters: make([]hashKeyGetter, 0, len(configs)),
+	}
+	for _, cfg := range configs {
+		var getter hashKeyGetter
+		switch {
+		case cfg.Header != nil && cfg.Header.Key != "":
+			getter = headerHashKey(cfg.Header.Key)
+		case cfg.Cookie != nil && cfg.Cookie.Name != "":
+			getter = cookieHashKey(cfg.Cookie.Name)
+		case cfg.SourceIP != nil:
+			getter = sourceIPHashKey
+		case cfg.Variable != nil && cfg.Variable.Name != "":
+			getter = variableHashKey(cfg.Variable.Name)
+		default:
+			return nil, errInvalidHashPolicy
+		}
+		hp.getters = append(hp.getters, getter)
+	}
+	return hp, nil
+}
+
+func (hp *hashPolicyImpl) GenerateHash(ctx context.Context, headers api.HeaderMap) (uint64, bool) {
+	var hash uint64
+	generated := false
+	for _, getter := range hp.getters {
+		key, ok := getter(ctx, headers)
+		if !ok {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		// rotate the combined hash, so the order of policies affects the result
+		hash = (hash<<1 | hash>>63) ^ h.Sum64()
+		generated = true
+	}
+	return hash, generated
+}
+
+func headerHashKey(key string) hashKeyGetter {
+	return func(ctx context.Context, headers api.HeaderMap) (string, bool) {
+		if headers == nil {
+			return "", false
+		}
+		value, ok := headers.Get(key)
+		return value, ok && value != ""
+	}
+}
+
+func cookieHashKey(name string) hashKeyGetter {
+	return func(ctx context.Context, headers api.HeaderMap) (string, bool) {
+		if headers == nil {
+			return "", false
+		}
+		cookies, ok := headers.Get("Cookie")
+		if !ok {
+			if cookies, ok = headers.Get("cookie"); !ok {
+				return "", false
+			}
+		}
+		return getCookieValue(cookies, name)
+	}
+}
+
+// getCookieValue finds the cookie value in the cookie header, such as "k1=v1; k2=v2"
+func getCookieValue(cookies, name string) (string, bool) {
+	for _, cookie := range strings.Split(cookies, ";") {
+		cookie = strings.TrimSpace(cookie)
+		if idx := strings.IndexByte(cookie, '='); idx > 0 && cookie[:idx] == name {
+			value := strings.Trim(cookie[idx+1:], `"`)
+			return value, value != ""
+		}
+	}
+	return "", false
+}
+
+func sourceIPHashKey(ctx context.Context, headers api.HeaderMap) (string, bool) {
+	addr, err := variable.GetVariableValue(ctx, types.VarDownstreamRemoteAddress)
+	if err != nil || addr == "" || addr == variable.ValueNotFound {
+		return "", false
+	}
+	if ip, _, err := net.SplitHostPort(addr); err == nil {
+		return ip, true
+	}
+	return addr, true
+}
+
+func variableHashKey(name string) hashKeyGetter {
+	return func(ctx context.Context, headers api.HeaderMap) (string, bool) {
+		value, err := variable.GetVariableValue(ctx, name)
+		if err != nil || value == "" || value == variable.ValueNotFound {
+			return "", false
+		}
+		return value, true
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+func init() {
+	variable.RegisterVariable(variable.NewIndexedVariable(types.VarDownstreamRemoteAddress, nil, nil, variable.BasicSetter, 0))
+	variable.RegisterVariable(variable.NewIndexedVariable("test_hash_variable", nil, nil, variable.BasicSetter, 0))
+}
+
+func TestHashPolicyConfig(t *testing.T) {
+	if hp, err := newHashPolicy(nil); hp != nil || err != nil {
+		t.Fatal("no hash policy configured should returns nil")
+	}
+	if _, err := newHashPolicy([]v2.HashPolicy{{}}); err == nil {
+		t.Fatal("empty hash policy should be failed")
+	}
+	if _, err := newHashPolicy([]v2.HashPolicy{{Header: &v2.HeaderHashPolicy{}}}); err == nil {
+		t.Fatal("empty header key should be failed")
+	}
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.HashPolicy = []v2.HashPolicy{{Cookie: &v2.CookieHashPolicy{}}}
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err == nil {
+		t.Fatal("route with invalid hash policy should be failed")
+	}
+}
+
+func TestHashPolicyHeader(t *testing.T) {
+	hp, err := newHashPolicy([]v2.HashPolicy{
+		{Header: &v2.HeaderHashPolicy{Key: "user"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	k1, ok := hp.GenerateHash(ctx, protocol.CommonHeader{"user": "u1"})
+	if !ok {
+		t.Fatal("hash key should be generated")
+	}
+	k2, _ := hp.GenerateHash(ctx, protocol.CommonHeader{"user": "u1", "other": "value"})
+	k3, _ := hp.GenerateHash(ctx, protocol.CommonHeader{"user": "u2"})
+	if k1 != k2 || k1 == k3 {
+		t.Fatalf("unexpected hash keys: %d, %d, %d", k1, k2, k3)
+	}
+	if _, ok := hp.GenerateHash(ctx, protocol.CommonHeader{"other": "value"}); ok {
+		t.Fatal("no hash key should be generated without header")
+	}
+}
+
+func TestHashPolicyCookie(t *testing.T) {
+	hp, err := newHashPolicy([]v2.HashPolicy{
+		{Cookie: &v2.CookieHashPolicy{Name: "session"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	k1, ok := hp.GenerateHash(ctx, protocol.CommonHeader{"Cookie": "a=1; session=abc; b=2"})
+	if !ok {
+		t.Fatal("hash key should be generated")
+	}
+	k2, _ := hp.GenerateHash(ctx, protocol.CommonHeader{"cookie": `session="abc"`})
+	if k1 != k2 {
+		t.Fatal("same cookie value should generate the same key")
+	}
+	if _, ok := hp.GenerateHash(ctx, protocol.CommonHeader{"Cookie": "sessionid=abc"}); ok {
+		t.Fatal("no hash key should be generated without cookie")
+	}
+}
+
+func TestHashPolicySourceIPAndVariable(t *testing.T) {
+	hp, err := newHashPolicy([]v2.HashPolicy{
+		{SourceIP: &v2.SourceIPHashPolicy{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx1 := variable.NewVariableContext(context.Background())
+	variable.SetVariableValue(ctx1, types.VarDownstreamRemoteAddress, "127.0.0.1:12345")
+	ctx2 := variable.NewVariableContext(context.Background())
+	variable.SetVariableValue(ctx2, types.VarDownstreamRemoteAddress, "127.0.0.1:23456")
+	k1, ok1 := hp.GenerateHash(ctx1, nil)
+	k2, ok2 := hp.GenerateHash(ctx2, nil)
+	if !ok1 || !ok2 || k1 != k2 {
+		t.Fatal("same source ip should generate the same key")
+	}
+	// combine policies
+	hp, err = newHashPolicy([]v2.HashPolicy{
+		{SourceIP: &v2.SourceIPHashPolicy{}},
+		{Variable: &v2.VariableHashPolicy{Name: "test_hash_variable"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	variable.SetVariableValue(ctx1, "test_hash_variable", "v1")
+	variable.SetVariableValue(ctx2, "test_hash_variable", "v2")
+	k1, ok1 = hp.GenerateHash(ctx1, nil)
+	k2, ok2 = hp.GenerateHash(ctx2, nil)
+	if !ok1 || !ok2 || k1 == k2 {
+		t.Fatal("different variable should generate different keys")
+	}
+}
@@ -18,6 +18,7 @@
 package router
 
 import (
+	"context"
 	"fmt"
 
 	"mosn.io/mosn/pkg/types"
@@ -28,12 +29,13 @@ type headerParser struct {
 	headersToRemove []*lowerCaseString
 }
 
-func (h *headerParser) evaluateHeaders(headers types.HeaderMap, requestInfo types.RequestInfo) {
+// evaluateHeaders adds and removes the headers, the ctx is used to resolve the variables in the header values
+func (h *headerParser) evaluateHeaders(ctx context.Context, headers types.HeaderMap, requestInfo types.RequestInfo) {
 	if h == nil {
 		return
 	}
 	for _, toAdd := range h.headersToAdd {
-		value := toAdd.headerFormatter.format(requestInfo)
+		value := toAdd.headerFormatter.format(ctx, requestInfo)
 		if v, ok := headers.Get(toAdd.headerName.Get()); ok && len(v) > 0 && toAdd.headerFormatter.append() {
 			value = fmt.Sprintf("%s,%s", v, value)
 		}
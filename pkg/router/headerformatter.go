@@ -18,22 +18,31 @@
 package router
 
 import (
+	"context"
 	"strings"
 
-	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/variable"
 )
 
-func getHeaderFormatter(value string, append bool) headerFormatter {
-	// TODO: variable headers would be support very soon
-	if strings.Index(value, "%") != -1 {
-		log.DefaultLogger.Warnf("variable headers not support yet, skip, value: %s", value)
-		return nil
+// getHeaderFormatter returns a plain formatter if the value contains no variables,
+// otherwise the %variable% in the value are resolved by the stream context,
+// the variables must be registered before the formatter is created.
+func getHeaderFormatter(value string, append bool) (headerFormatter, error) {
+	if strings.Index(value, "%") == -1 {
+		return &plainHeaderFormatter{
+			isAppend:    append,
+			staticValue: value,
+		}, nil
 	}
-	return &plainHeaderFormatter{
-		isAppend:    append,
-		staticValue: value,
+	entries, err := parseHeaderFormat(value)
+	if err != nil {
+		return nil, err
 	}
+	return &variableHeaderFormatter{
+		isAppend: append,
+		entries:  entries,
+	}, nil
 }
 
 type plainHeaderFormatter struct {
@@ -45,6 +54,81 @@ func (f *plainHeaderFormatter) append() bool {
 	return f.isAppend
 }
 
-func (f *plainHeaderFormatter) format(requestInfo types.RequestInfo) string {
+func (f *plainHeaderFormatter) format(ctx context.Context, requestInfo api.RequestInfo) string {
 	return f.staticValue
 }
+
+// headerFormatEntry is a text or a variable name in the header value
+type headerFormatEntry struct {
+	text     string
+	variable string
+}
+
+type variableHeaderFormatter struct {
+	isAppend bool
+	entries  []headerFormatEntry
+}
+
+func (f *variableHeaderFormatter) append() bool {
+	return f.isAppend
+}
+
+// format resolves the variables by the context, the value of a variable is "-" if it is not found
+func (f *variableHeaderFormatter) format(ctx context.Context, requestInfo api.RequestInfo) string {
+	var sb strings.Builder
+	for _, entry := range f.entries {
+		if entry.variable == "" {
+			sb.WriteString(entry.text)
+			continue
+		}
+		value := variable.ValueNotFound
+		// the variables can not be resolved without the stream context
+		if ctx != nil {
+			if v, err := variable.GetVariableValue(ctx, entry.variable); err == nil {
+				value = v
+			}
+		}
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
+
+// parseHeaderFormat parses the header value as the access log format does, such as "%downstream_remote_address%",
+// a '%' escaped by '\' is not treated as the variable mark.
+func parseHeaderFormat(value string) ([]headerFormatEntry, error) {
+	var entries []headerFormatEntry
+	varDef := false
+	// last pos of '%' occur
+	lastMark := -1
+	for pos, ch := range value {
+		if ch != '%' || (pos > 0 && value[pos-1] == '\\') {
+			continue
+		}
+		if varDef {
+			name := value[lastMark+1 : pos]
+			if name == "" {
+				return nil, ErrEmptyHeaderVariable
+			}
+			// check the variable is registered
+			if _, err := variable.AddVariable(name); err != nil {
+				return nil, err
+			}
+			entries = append(entries, headerFormatEntry{variable: name})
+		} else if pos > lastMark+1 {
+			entries = append(entries, headerFormatEntry{text: unescapeHeaderFormat(value[lastMark+1 : pos])})
+		}
+		lastMark = pos
+		varDef = !varDef
+	}
+	if varDef {
+		return nil, ErrUnclosedHeaderVariable
+	}
+	if lastMark < len(value)-1 {
+		entries = append(entries, headerFormatEntry{text: unescapeHeaderFormat(value[lastMark+1:])})
+	}
+	return entries, nil
+}
+
+func unescapeHeaderFormat(text string) string {
+	return strings.Replace(text, `\%`, "%", -1)
+}
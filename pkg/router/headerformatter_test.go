@@ -18,19 +18,27 @@
 package router
 
 import (
+	"context"
 	"reflect"
 	"testing"
+
+	"mosn.io/mosn/pkg/variable"
 )
 
+func init() {
+	variable.RegisterVariable(variable.NewIndexedVariable("test_header_variable", nil, nil, variable.BasicSetter, 0))
+}
+
 func Test_getHeaderFormatter(t *testing.T) {
 	type args struct {
 		value  string
 		append bool
 	}
 	tests := []struct {
-		name string
-		args args
-		want headerFormatter
+		name    string
+		args    args
+		want    headerFormatter
+		wantErr bool
 	}{
 		{
 			name: "case1",
@@ -49,19 +57,85 @@ func Test_getHeaderFormatter(t *testing.T) {
 				value:  "%address%",
 				append: false,
 			},
-			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "case3",
+			args: args{
+				value:  "prefix-%test_header_variable%-suffix",
+				append: true,
+			},
+			want: &variableHeaderFormatter{
+				isAppend: true,
+				entries: []headerFormatEntry{
+					{text: "prefix-"},
+					{variable: "test_header_variable"},
+					{text: "-suffix"},
+				},
+			},
+		},
+		{
+			name: "case4",
+			args: args{
+				value: `100\%-%test_header_variable%`,
+			},
+			want: &variableHeaderFormatter{
+				entries: []headerFormatEntry{
+					{text: "100%-"},
+					{variable: "test_header_variable"},
+				},
+			},
+		},
+		{
+			name: "case5",
+			args: args{
+				value: "%test_header_variable",
+			},
+			wantErr: true,
+		},
+		{
+			name: "case6",
+			args: args{
+				value: "%%",
+			},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHeaderFormatter(tt.args.value, tt.args.append); !reflect.DeepEqual(got, tt.want) {
+			got, err := getHeaderFormatter(tt.args.value, tt.args.append)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getHeaderFormatter(value string, append bool) error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getHeaderFormatter(value string, append bool) = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_variableHeaderFormatter_format(t *testing.T) {
+	formatter, err := getHeaderFormatter("%test_header_variable%, %test_header_variable%", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := variable.NewVariableContext(context.Background())
+	variable.SetVariableValue(ctx, "test_header_variable", "value")
+	if got := formatter.format(ctx, nil); got != "value, value" {
+		t.Errorf("format with context = %s, want %s", got, "value, value")
+	}
+	// the variable is not set
+	ctx = variable.NewVariableContext(context.Background())
+	if got := formatter.format(ctx, nil); got != "-, -" {
+		t.Errorf("format with empty variable = %s, want %s", got, "-, -")
+	}
+	// no context
+	if got := formatter.format(nil, nil); got != "-, -" {
+		t.Errorf("format without context = %s, want %s", got, "-, -")
+	}
+}
+
 func Test_plainHeaderFormatter_append(t *testing.T) {
 	formatter := plainHeaderFormatter{
 		isAppend:    false,
@@ -104,7 +178,7 @@ func Test_plainHeaderFormatter_format(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatter.format(nil); !reflect.DeepEqual(got, tt.want) {
+			if got := formatter.format(nil, nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("(f *plainHeaderFormatter) format(requestInfo types.RequestInfo) = %v, want %v", got, tt.want)
 			}
 		})
@@ -18,6 +18,7 @@
 package router
 
 import (
+	"context"
 	"regexp"
 	"strings"
 
@@ -51,16 +52,25 @@ func (prri *PathRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prri *PathRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prri.finalizeRequestHeaders(headers, requestInfo)
+	prri.FinalizeRequestHeadersWithContext(nil, headers, requestInfo)
+}
+
+// types.VariableHeadersRouteRule
+// override Base
+func (prri *PathRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prri.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prri.finalizePathHeader(headers, prri.path)
 }
 
 func (prri *PathRouteRuleImpl) Match(headers api.HeaderMap, randomValue uint64) api.Route {
 	if prri.matchRoute(headers, randomValue) {
 		if headerPathValue, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-			// TODO: config to support case sensitive
-			// case insensitive
-			if strings.EqualFold(headerPathValue, prri.path) {
+			// the path is case insensitive by default
+			if prri.isCaseSensitive(false) {
+				if headerPathValue == prri.path {
+					return prri
+				}
+			} else if strings.EqualFold(headerPathValue, prri.path) {
 				return prri
 			}
 		}
@@ -95,14 +105,20 @@ func (prei *PrefixRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prei *PrefixRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prei.finalizeRequestHeaders(headers, requestInfo)
+	prei.FinalizeRequestHeadersWithContext(nil, headers, requestInfo)
+}
+
+// types.VariableHeadersRouteRule
+// override Base
+func (prei *PrefixRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prei.finalizePathHeader(headers, prei.prefix)
 }
 
 func (prei *PrefixRouteRuleImpl) Match(headers api.HeaderMap, randomValue uint64) api.Route {
 	if prei.matchRoute(headers, randomValue) {
 		if headerPathValue, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-			if strings.HasPrefix(headerPathValue, prei.prefix) {
+			if hasPrefix(headerPathValue, prei.prefix, prei.isCaseSensitive(true)) {
 				return prei
 			}
 		}
@@ -135,7 +151,13 @@ func (rrei *RegexRouteRuleImpl) MatchType() api.PathMatchType {
 }
 
 func (rrei *RegexRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rrei.finalizeRequestHeaders(headers, requestInfo)
+	rrei.FinalizeRequestHeadersWithContext(nil, headers, requestInfo)
+}
+
+// types.VariableHeadersRouteRule
+// override Base
+func (rrei *RegexRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rrei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	rrei.finalizePathHeader(headers, rrei.regexStr)
 }
 
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+var errInvalidMirrorPolicy = errors.New("request mirror policy should contain a cluster")
+
+// mirrorPolicyImpl is an implementation of types.MirrorPolicy
+type mirrorPolicyImpl struct {
+	cluster string
+	percent uint32
+}
+
+func newMirrorPolicies(configs []v2.RequestMirrorPolicy) ([]types.MirrorPolicy, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	policies := make([]types.MirrorPolicy, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Cluster == "" {
+			return nil, errInvalidMirrorPolicy
+		}
+		p := &mirrorPolicyImpl{
+			cluster: cfg.Cluster,
+			percent: 100,
+		}
+		if cfg.Percent != nil {
+			if *cfg.Percent > 100 {
+				return nil, fmt.Errorf("request mirror policy percent should be in [0, 100], but got %d", *cfg.Percent)
+			}
+			p.percent = *cfg.Percent
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func (p *mirrorPolicyImpl) ClusterName() string {
+	return p.cluster
+}
+
+func (p *mirrorPolicyImpl) IsMirror() bool {
+	switch p.percent {
+	case 0:
+		return false
+	case 100:
+		return true
+	}
+	return uint32(rand.Intn(100)) < p.percent
+}
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestMirrorPolicyConfig(t *testing.T) {
+	if policies, err := newMirrorPolicies(nil); policies != nil || err != nil {
+		t.Fatal("no mirror policy configured should returns nil")
+	}
+	if _, err := newMirrorPolicies([]v2.RequestMirrorPolicy{{}}); err == nil {
+		t.Fatal("mirror policy without cluster should be failed")
+	}
+	invalid := uint32(101)
+	if _, err := newMirrorPolicies([]v2.RequestMirrorPolicy{{Cluster: "shadow", Percent: &invalid}}); err == nil {
+		t.Fatal("mirror policy with percent larger than 100 should be failed")
+	}
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.RequestMirrorPolicies = []v2.RequestMirrorPolicy{{Percent: &invalid}}
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err == nil {
+		t.Fatal("route with invalid mirror policy should be failed")
+	}
+}
+
+func TestMirrorPolicySampling(t *testing.T) {
+	zero := uint32(0)
+	half := uint32(50)
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.RequestMirrorPolicies = []v2.RequestMirrorPolicy{
+		{Cluster: "all"},
+		{Cluster: "none", Percent: &zero},
+		{Cluster: "half", Percent: &half},
+	}
+	rule, err := NewRouteRuleImplBase(nil, routeCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mr types.MirrorPolicyRouteRule = rule
+	policies := mr.MirrorPolicies()
+	if len(policies) != 3 {
+		t.Fatalf("expected 3 mirror policies, but got %d", len(policies))
+	}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		for _, p := range policies {
+			if p.IsMirror() {
+				counts[p.ClusterName()]++
+			}
+		}
+	}
+	if counts["all"] != 1000 || counts["none"] != 0 {
+		t.Fatalf("unexpected mirror counts: %v", counts)
+	}
+	// sampled by random, allows some deviation
+	if counts["half"] < 400 || counts["half"] > 600 {
+		t.Fatalf("expected about 500 mirrored requests, but got %d", counts["half"])
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+// redirectImpl is an implementation of types.RedirectRule
+type redirectImpl struct {
+	code       int
+	scheme     string
+	host       string
+	port       string
+	path       string
+	stripQuery bool
+}
+
+func newRedirect(cfg *v2.RedirectAction) (*redirectImpl, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	r := &redirectImpl{
+		code:       http.StatusMovedPermanently,
+		scheme:     cfg.SchemeRedirect,
+		host:       cfg.HostRedirect,
+		path:       cfg.PathRedirect,
+		stripQuery: cfg.StripQuery,
+	}
+	switch cfg.ResponseCode {
+	case 0:
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		r.code = cfg.ResponseCode
+	default:
+		return nil, fmt.Errorf("invalid redirect response code: %d", cfg.ResponseCode)
+	}
+	if cfg.HTTPSRedirect {
+		r.scheme = "https"
+	}
+	if host, port, err := net.SplitHostPort(r.host); err == nil {
+		r.host, r.port = host, port
+	}
+	if cfg.PortRedirect > 0 {
+		r.port = strconv.FormatUint(uint64(cfg.PortRedirect), 10)
+	}
+	return r, nil
+}
+
+func (r *redirectImpl) RedirectCode() int {
+	return r.code
+}
+
+func (r *redirectImpl) RedirectLocation(scheme string, headers api.HeaderMap) string {
+	host, _ := headers.Get(protocol.MosnHeaderHostKey)
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+	// the port of the request is meaningless if the scheme is changed
+	if r.scheme != "" && r.scheme != scheme {
+		scheme = r.scheme
+		port = ""
+	}
+	if r.host != "" {
+		hostname = r.host
+	}
+	if r.port != "" {
+		port = r.port
+	}
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		hostname = net.JoinHostPort(hostname, port)
+	}
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	if r.path != "" {
+		path = r.path
+	}
+	var builder strings.Builder
+	builder.WriteString(scheme)
+	builder.WriteString("://")
+	builder.WriteString(hostname)
+	builder.WriteString(path)
+	if !r.stripQuery {
+		if query, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok && query != "" {
+			builder.WriteString("?")
+			builder.WriteString(query)
+		}
+	}
+	return builder.String()
+}
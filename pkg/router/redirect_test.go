@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRedirectLocation(t *testing.T) {
+	headers := protocol.CommonHeader{
+		protocol.MosnHeaderHostKey:        "example.com:8080",
+		protocol.MosnHeaderPathKey:        "/index",
+		protocol.MosnHeaderQueryStringKey: "a=1&b=2",
+	}
+	testcases := []struct {
+		redirect v2.RedirectAction
+		scheme   string
+		code     int
+		location string
+	}{
+		{v2.RedirectAction{}, "http", 301, "http://example.com:8080/index?a=1&b=2"},
+		{v2.RedirectAction{ResponseCode: 302, HostRedirect: "new.com"}, "http", 302, "http://new.com:8080/index?a=1&b=2"},
+		{v2.RedirectAction{ResponseCode: 307, HostRedirect: "new.com:9090"}, "http", 307, "http://new.com:9090/index?a=1&b=2"},
+		{v2.RedirectAction{ResponseCode: 308, PortRedirect: 80}, "http", 308, "http://example.com/index?a=1&b=2"},
+		{v2.RedirectAction{PathRedirect: "/new", StripQuery: true}, "http", 301, "http://example.com:8080/new"},
+		// the port of request is removed if the scheme is changed
+		{v2.RedirectAction{HTTPSRedirect: true}, "http", 301, "https://example.com/index?a=1&b=2"},
+		{v2.RedirectAction{SchemeRedirect: "https", PortRedirect: 8443}, "http", 301, "https://example.com:8443/index?a=1&b=2"},
+		{v2.RedirectAction{HTTPSRedirect: true}, "https", 301, "https://example.com:8080/index?a=1&b=2"},
+	}
+	for i, tc := range testcases {
+		routeCfg := &v2.Router{}
+		routeCfg.Match.Prefix = "/"
+		routeCfg.Redirect = &tc.redirect
+		rule, err := NewRouteRuleImplBase(nil, routeCfg)
+		if err != nil {
+			t.Fatalf("#%d create route rule failed: %v", i, err)
+		}
+		var redirect types.RedirectRule = rule.RedirectRule()
+		if redirect == nil {
+			t.Fatalf("#%d redirect rule expected", i)
+		}
+		if redirect.RedirectCode() != tc.code {
+			t.Errorf("#%d redirect code expected %d, but got %d", i, tc.code, redirect.RedirectCode())
+		}
+		if location := redirect.RedirectLocation(tc.scheme, headers); location != tc.location {
+			t.Errorf("#%d redirect location expected %s, but got %s", i, tc.location, location)
+		}
+	}
+}
+
+func TestRedirectConfig(t *testing.T) {
+	routeCfg := &v2.Router{}
+	routeCfg.Match.Prefix = "/"
+	routeCfg.Route.ClusterName = "test"
+	rule, _ := NewRouteRuleImplBase(nil, routeCfg)
+	if rule.RedirectRule() != nil {
+		t.Fatal("no redirect configured should returns nil")
+	}
+	routeCfg.Redirect = &v2.RedirectAction{ResponseCode: 200}
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err == nil {
+		t.Fatal("invalid redirect code should be failed")
+	}
+	routeCfg.Redirect = &v2.RedirectAction{}
+	routeCfg.DirectResponse = &v2.DirectResponseAction{StatusCode: 200}
+	if _, err := NewRouteRuleImplBase(nil, routeCfg); err != ErrConflictRouteAction {
+		t.Fatalf("redirect and direct response should be conflicted, but got %v", err)
+	}
+}
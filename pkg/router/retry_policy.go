@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// DefaultRetryInterval is the fixed interval between the retries if the back off is not configured
+// or not supported by the retry policy
+const DefaultRetryInterval = 10 * time.Millisecond
+
+const (
+	defaultRetryBaseInterval             = 25 * time.Millisecond
+	defaultHostSelectionRetryMaxAttempts = 1
+)
+
+// retryPolicyImpl is an implementation of api.RetryPolicy and types.RetryConditionPolicy
+type retryPolicyImpl struct {
+	retryOn                       bool
+	retryTimeout                  time.Duration
+	numRetries                    uint32
+	retryConditions               []string
+	retriableStatusCodes          []uint32
+	backOff                       bool
+	baseInterval                  time.Duration
+	maxInterval                   time.Duration
+	rejectPreviousHosts           bool
+	hostSelectionRetryMaxAttempts int
+}
+
+func newRetryPolicy(cfg *v2.RetryPolicy) (*retryPolicyImpl, error) {
+	p := &retryPolicyImpl{
+		retryOn:                       cfg.RetryOn,
+		retryTimeout:                  cfg.RetryTimeout,
+		numRetries:                    cfg.NumRetries,
+		baseInterval:                  defaultRetryBaseInterval,
+		hostSelectionRetryMaxAttempts: defaultHostSelectionRetryMaxAttempts,
+	}
+	for _, condition := range cfg.RetryConditions {
+		switch condition {
+		case v2.RetryOn5xx, v2.RetryOnGatewayError, v2.RetryOnConnectFailure, v2.RetryOnReset, v2.RetryOnRetriableStatusCodes:
+			p.retryConditions = append(p.retryConditions, condition)
+		default:
+			return nil, fmt.Errorf("unknown retry condition: %s", condition)
+		}
+	}
+	p.retriableStatusCodes = cfg.RetriableStatusCodes
+	if backoff := cfg.RetryBackOff; backoff != nil {
+		p.backOff = true
+		if backoff.BaseInterval != nil {
+			if backoff.BaseInterval.Duration <= 0 {
+				return nil, fmt.Errorf("retry back off base interval should be greater than zero")
+			}
+			p.baseInterval = backoff.BaseInterval.Duration
+		}
+		if backoff.MaxInterval != nil {
+			if backoff.MaxInterval.Duration < p.baseInterval {
+				return nil, fmt.Errorf("retry back off max interval should not be less than base interval")
+			}
+			p.maxInterval = backoff.MaxInterval.Duration
+		}
+	}
+	if p.maxInterval == 0 {
+		p.maxInterval = 10 * p.baseInterval
+	}
+	for _, predicate := range cfg.RetryHostPredicates {
+		switch predicate {
+		case v2.RetryHostPredicatePreviousHosts:
+			p.rejectPreviousHosts = true
+		default:
+			return nil, fmt.Errorf("unknown retry host predicate: %s", predicate)
+		}
+	}
+	if cfg.HostSelectionRetryMaxAttempts > 0 {
+		p.hostSelectionRetryMaxAttempts = int(cfg.HostSelectionRetryMaxAttempts)
+	}
+	return p, nil
+}
+
+func (p *retryPolicyImpl) RetryOn() bool {
+	if p == nil {
+		return false
+	}
+	return p.retryOn
+}
+
+func (p *retryPolicyImpl) TryTimeout() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.retryTimeout
+}
+
+func (p *retryPolicyImpl) NumRetries() uint32 {
+	if p == nil {
+		return 0
+	}
+	return p.numRetries
+}
+
+func (p *retryPolicyImpl) RetryConditions() []string {
+	if p == nil {
+		return nil
+	}
+	return p.retryConditions
+}
+
+func (p *retryPolicyImpl) RetriableStatusCodes() []uint32 {
+	if p == nil {
+		return nil
+	}
+	return p.retriableStatusCodes
+}
+
+// BackOff returns a random interval in [0, (2^retried-1)*baseInterval), which is limited by the maxInterval.
+// if the back off is not configured, the interval is fixed.
+func (p *retryPolicyImpl) BackOff(retried uint32) time.Duration {
+	if p == nil || !p.backOff {
+		return DefaultRetryInterval
+	}
+	base, max := p.baseInterval, p.maxInterval
+	if retried == 0 {
+		retried = 1
+	}
+	ceiling := max
+	// the multiplier is clamped before it multiplies the base, avoid overflow
+	if retried < 63 {
+		if multiplier := time.Duration((uint64(1) << retried) - 1); multiplier <= max/base {
+			ceiling = multiplier * base
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func (p *retryPolicyImpl) ShouldRejectHost(host types.Host, triedHosts []types.Host) bool {
+	if p == nil || !p.rejectPreviousHosts || host == nil {
+		return false
+	}
+	addr := host.AddressString()
+	for _, tried := range triedHosts {
+		if tried.AddressString() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *retryPolicyImpl) HostSelectionRetryMaxAttempts() int {
+	if p == nil {
+		return defaultHostSelectionRetryMaxAttempts
+	}
+	return p.hostSelectionRetryMaxAttempts
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRetryPolicyConfig(t *testing.T) {
+	invalidConfigs := []v2.RetryPolicyConfig{
+		{RetryConditions: []string{"unknown"}},
+		{RetryHostPredicates: []string{"unknown"}},
+		{RetryBackOff: &v2.RetryBackOff{BaseInterval: &api.DurationConfig{}}},
+		{RetryBackOff: &v2.RetryBackOff{
+			BaseInterval: &api.DurationConfig{Duration: time.Second},
+			MaxInterval:  &api.DurationConfig{Duration: time.Millisecond},
+		}},
+	}
+	for i, cfg := range invalidConfigs {
+		if _, err := newRetryPolicy(&v2.RetryPolicy{RetryPolicyConfig: cfg}); err == nil {
+			t.Errorf("#%d invalid retry policy config should be failed", i)
+		}
+	}
+	routeCfg := &v2.Router{}
+	routeCfg.Route.ClusterName = "test"
+	routeCfg.Route.RetryPolicy = &v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			NumRetries:           3,
+			RetryConditions:      []string{v2.RetryOn5xx, v2.RetryOnRetriableStatusCodes},
+			RetriableStatusCodes: []uint32{409},
+			RetryBackOff: &v2.RetryBackOff{
+				BaseInterval: &api.DurationConfig{Duration: 10 * time.Millisecond},
+			},
+			RetryHostPredicates: []string{v2.RetryHostPredicatePreviousHosts},
+		},
+		RetryTimeout: time.Second,
+	}
+	rule, err := NewRouteRuleImplBase(nil, routeCfg)
+	if err != nil {
+		t.Fatalf("create route rule failed: %v", err)
+	}
+	policy, ok := rule.Policy().RetryPolicy().(types.RetryConditionPolicy)
+	if !ok {
+		t.Fatal("retry policy should be a condition policy")
+	}
+	if len(policy.RetryConditions()) != 2 || len(policy.RetriableStatusCodes()) != 1 {
+		t.Fatalf("unexpected retry conditions: %v, %v", policy.RetryConditions(), policy.RetriableStatusCodes())
+	}
+	if rule.Policy().RetryPolicy().TryTimeout() != time.Second {
+		t.Fatalf("unexpected per try timeout: %v", rule.Policy().RetryPolicy().TryTimeout())
+	}
+	// the back off is in [0, (2^N-1)*base), and is limited by the max interval, which is ten times of base
+	for retried := uint32(1); retried < 100; retried++ {
+		ceiling := 100 * time.Millisecond
+		if retried < 4 {
+			ceiling = time.Duration((1<<retried)-1) * 10 * time.Millisecond
+		}
+		for i := 0; i < 100; i++ {
+			if interval := policy.BackOff(retried); interval < 0 || interval >= ceiling {
+				t.Fatalf("retried %d back off interval %v is not in [0, %v)", retried, interval, ceiling)
+			}
+		}
+	}
+	if policy.HostSelectionRetryMaxAttempts() != defaultHostSelectionRetryMaxAttempts {
+		t.Fatalf("unexpected host selection max attempts: %d", policy.HostSelectionRetryMaxAttempts())
+	}
+}
+
+func TestRetryPolicyBackOff(t *testing.T) {
+	// the interval is fixed if the back off is not configured
+	policy, err := newRetryPolicy(&v2.RetryPolicy{})
+	if err != nil {
+		t.Fatalf("create retry policy failed: %v", err)
+	}
+	var nilPolicy *retryPolicyImpl
+	for retried := uint32(0); retried < 5; retried++ {
+		if interval := policy.BackOff(retried); interval != DefaultRetryInterval {
+			t.Fatalf("expected fixed interval %v, but got %v", DefaultRetryInterval, interval)
+		}
+		if interval := nilPolicy.BackOff(retried); interval != DefaultRetryInterval {
+			t.Fatalf("expected fixed interval %v, but got %v", DefaultRetryInterval, interval)
+		}
+	}
+	// a large base interval does not overflow
+	policy, err = newRetryPolicy(&v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			RetryBackOff: &v2.RetryBackOff{
+				BaseInterval: &api.DurationConfig{Duration: 1000 * time.Hour},
+				MaxInterval:  &api.DurationConfig{Duration: 2000 * time.Hour},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create retry policy failed: %v", err)
+	}
+	for _, retried := range []uint32{1, 2, 20, 40, 62, 63, 100} {
+		if interval := policy.BackOff(retried); interval < 0 || interval >= 2000*time.Hour {
+			t.Fatalf("retried %d back off interval %v is not in [0, %v)", retried, interval, 2000*time.Hour)
+		}
+	}
+}
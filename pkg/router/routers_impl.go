@@ -205,7 +205,10 @@ func NewRouters(routerConfig *v2.RouterConfiguration) (types.Routers, error) {
 		greaterSortedWildcardVirtualHostSuffixes: []int{},
 		virtualHosts:                             []types.VirtualHost{},
 	}
-	configImpl := NewConfigImpl(routerConfig)
+	configImpl, err := NewConfigImpl(routerConfig)
+	if err != nil {
+		return nil, err
+	}
 	for index, vhConfig := range routerConfig.VirtualHosts {
 		vh, err := NewVirtualHostImpl(vhConfig)
 		if err != nil {
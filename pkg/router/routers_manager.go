@@ -50,6 +50,10 @@ func (rw *RoutersWrapper) GetRoutersConfig() v2.RouterConfiguration {
 // RoutersManager implementation
 type routersManagerImpl struct {
 	routersWrapperMap sync.Map
+	// dynamicScopes is the scopes discovered by the xDS, keyed by the scoped routes name.
+	// the scopes are kept even if no scoped routers uses them, so the scoped routers added later can use them.
+	scopesMux     sync.Mutex
+	dynamicScopes map[string][]*v2.RouteScope
 }
 
 // AddOrUpdateRouters used to add or update router
@@ -58,13 +62,18 @@ func (rm *routersManagerImpl) AddOrUpdateRouters(routerConfig *v2.RouterConfigur
 		log.DefaultLogger.Errorf(RouterLogFormat, "routers_manager", "AddOrUpdateRouters", "error: %v", ErrNilRouterConfig)
 		return ErrNilRouterConfig
 	}
+	// the dynamic scoped routers should not miss the scopes updated during it is added
+	if scoped := routerConfig.ScopedRoutes; scoped != nil && scoped.ScopedRDS {
+		rm.scopesMux.Lock()
+		defer rm.scopesMux.Unlock()
+	}
 	if v, ok := rm.routersWrapperMap.Load(routerConfig.RouterConfigName); ok {
 		rw, ok := v.(*RoutersWrapper)
 		if !ok {
 			log.DefaultLogger.Errorf(RouterLogFormat, "routers_manager", "AddOrUpdateRouters", "unexpected object in routers map")
 			return ErrUnexpected
 		}
-		routers, err := NewRouters(routerConfig)
+		routers, err := rm.newRouters(routerConfig)
 		if err != nil {
 			// TODO: the rds maybe call this function with a invalid routers(nil) just like Add
 			// so we should ignore the alert
@@ -80,7 +89,7 @@ func (rm *routersManagerImpl) AddOrUpdateRouters(routerConfig *v2.RouterConfigur
 		// if a routerConfig with no routes, it is a valid config
 		// we ignore the error when we addsd a new router
 		// becasue we may stored a nil routers, which is used in istio "RDS" mode
-		routers, _ := NewRouters(routerConfig)
+		routers, _ := rm.newRouters(routerConfig)
 		rm.routersWrapperMap.Store(routerConfig.RouterConfigName, &RoutersWrapper{
 			routers:       routers,
 			routersConfig: routerConfig,
@@ -92,6 +101,90 @@ func (rm *routersManagerImpl) AddOrUpdateRouters(routerConfig *v2.RouterConfigur
 	return nil
 }
 
+// RemoveRouters removes the routers by the config name.
+// the wrapper is kept with nil routers, because it is referenced by the listeners, and the router may be added again
+func (rm *routersManagerImpl) RemoveRouters(routerConfigName string) error {
+	v, ok := rm.routersWrapperMap.Load(routerConfigName)
+	if !ok {
+		return nil
+	}
+	rw, ok := v.(*RoutersWrapper)
+	if !ok {
+		log.DefaultLogger.Errorf(RouterLogFormat, "routers_manager", "RemoveRouters", "unexpected object in routers map")
+		return ErrUnexpected
+	}
+	rw.mux.Lock()
+	rw.routers = nil
+	rw.routersConfig = &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: routerConfigName,
+		},
+	}
+	rw.mux.Unlock()
+	store.RemoveRouter(routerConfigName)
+	log.DefaultLogger.Infof(RouterLogFormat, "routers_manager", "RemoveRouters", "remove router: "+routerConfigName)
+	return nil
+}
+
+// newRouters creates the routers by the config, the scoped routers are created if the scoped routes is configured.
+// the dynamic scoped routers use the discovered scopes of its name, the caller should hold the scopesMux.
+func (rm *routersManagerImpl) newRouters(routerConfig *v2.RouterConfiguration) (types.Routers, error) {
+	if routerConfig.ScopedRoutes != nil {
+		routers, err := newScopedRouters(rm, routerConfig.ScopedRoutes)
+		if err != nil {
+			// avoid a typed nil routers
+			return nil, err
+		}
+		if scopes, ok := rm.dynamicScopes[routers.name]; ok && routers.dynamic {
+			if err := routers.UpdateScopes(scopes); err != nil {
+				log.DefaultLogger.Warnf(RouterLogFormat, "routers_manager", "newRouters", "the discovered scopes are not used: "+err.Error())
+			}
+		}
+		return routers, nil
+	}
+	return NewRouters(routerConfig)
+}
+
+// UpdateDynamicRouteScopes replaces all of the scopes discovered by the xDS, the scopes are keyed by the scoped routes name.
+// the scoped routers are changed only if the scopes are valid for all of them.
+func (rm *routersManagerImpl) UpdateDynamicRouteScopes(scopes map[string][]*v2.RouteScope) error {
+	rm.scopesMux.Lock()
+	defer rm.scopesMux.Unlock()
+	type scopesUpdate struct {
+		routers   *scopedRouters
+		scopesMap map[string]string
+	}
+	var updates []scopesUpdate
+	var errGlobal error
+	rm.routersWrapperMap.Range(func(key, value interface{}) bool {
+		rw, ok := value.(*RoutersWrapper)
+		if !ok {
+			return true
+		}
+		sr, ok := rw.GetRouters().(*scopedRouters)
+		if !ok || !sr.dynamic {
+			return true
+		}
+		scopesMap, err := sr.buildScopes(scopes[sr.name])
+		if err != nil {
+			log.DefaultLogger.Errorf(RouterLogFormat, "routers_manager", "UpdateDynamicRouteScopes", fmt.Sprintf("update scopes of %v failed: %v", key, err))
+			errGlobal = err
+			return false
+		}
+		updates = append(updates, scopesUpdate{sr, scopesMap})
+		return true
+	})
+	if errGlobal != nil {
+		return errGlobal
+	}
+	for _, u := range updates {
+		u.routers.setScopes(u.scopesMap, scopes[u.routers.name])
+	}
+	rm.dynamicScopes = scopes
+	log.DefaultLogger.Infof(RouterLogFormat, "routers_manager", "UpdateDynamicRouteScopes", fmt.Sprintf("update scopes of %d scoped routes, %d scoped routers are changed", len(scopes), len(updates)))
+	return nil
+}
+
 // GetRouterWrapperByName returns a router wrapper from manager
 func (rm *routersManagerImpl) GetRouterWrapperByName(routerConfigName string) types.RouterWrapper {
 	if v, ok := rm.routersWrapperMap.Load(routerConfigName); ok {
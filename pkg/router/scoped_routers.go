@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"strings"
+	"sync"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// scopeKeyFragmentBuilder extracts a fragment of the scope key from the request headers
+type scopeKeyFragmentBuilder struct {
+	headerName        string
+	elementSeparator  string
+	index             int
+	elementKey        string
+	keyValueSeparator string
+}
+
+func newScopeKeyFragmentBuilder(cfg v2.ScopeKeyFragment) (*scopeKeyFragmentBuilder, error) {
+	if cfg.HeaderName == "" {
+		return nil, ErrEmptyScopeKeyHeader
+	}
+	builder := &scopeKeyFragmentBuilder{
+		headerName:        cfg.HeaderName,
+		elementSeparator:  cfg.ElementSeparator,
+		index:             int(cfg.Index),
+		elementKey:        cfg.ElementKey,
+		keyValueSeparator: cfg.KeyValueSeparator,
+	}
+	if builder.keyValueSeparator == "" {
+		builder.keyValueSeparator = "="
+	}
+	return builder, nil
+}
+
+func (b *scopeKeyFragmentBuilder) build(headers api.HeaderMap) (string, bool) {
+	value, ok := headers.Get(b.headerName)
+	if !ok {
+		return "", false
+	}
+	if b.elementSeparator == "" {
+		return value, true
+	}
+	elements := strings.Split(value, b.elementSeparator)
+	if b.elementKey == "" {
+		if b.index >= len(elements) {
+			return "", false
+		}
+		return strings.TrimSpace(elements[b.index]), true
+	}
+	for _, element := range elements {
+		kv := strings.SplitN(strings.TrimSpace(element), b.keyValueSeparator, 2)
+		if len(kv) == 2 && kv[0] == b.elementKey {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
+// scopeKey joins the fragments as the key of the scopes
+func scopeKey(fragments []string) string {
+	return strings.Join(fragments, "\x00")
+}
+
+// scopedRouters is an implementation of types.Routers, it selects a route table by the scope key
+// built from the request headers. The route tables are the routers managed by the routers manager,
+// they are found by name when the requests are matched, so the route tables can be updated independently.
+type scopedRouters struct {
+	manager          *routersManagerImpl
+	fragmentBuilders []*scopeKeyFragmentBuilder
+	// the scopes are discovered by the xDS, and they are keyed by the name
+	name    string
+	dynamic bool
+	mux     sync.RWMutex
+	// scopes is the route table name of each scope key
+	scopes       map[string]string
+	scopeConfigs []*v2.RouteScope
+}
+
+func newScopedRouters(manager *routersManagerImpl, cfg *v2.ScopedRoutes) (*scopedRouters, error) {
+	if len(cfg.ScopeKeyBuilder.Fragments) == 0 {
+		return nil, ErrEmptyScopeKeyBuilder
+	}
+	sr := &scopedRouters{
+		manager: manager,
+		name:    cfg.Name,
+		dynamic: cfg.ScopedRDS,
+		scopes:  map[string]string{},
+	}
+	for _, fragment := range cfg.ScopeKeyBuilder.Fragments {
+		builder, err := newScopeKeyFragmentBuilder(fragment)
+		if err != nil {
+			return nil, err
+		}
+		sr.fragmentBuilders = append(sr.fragmentBuilders, builder)
+	}
+	if !sr.dynamic {
+		if err := sr.UpdateScopes(cfg.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	return sr, nil
+}
+
+// UpdateScopes replaces all of the scopes
+func (sr *scopedRouters) UpdateScopes(scopes []*v2.RouteScope) error {
+	scopesMap, err := sr.buildScopes(scopes)
+	if err != nil {
+		return err
+	}
+	sr.setScopes(scopesMap, scopes)
+	return nil
+}
+
+// buildScopes returns the route table name of each scope key, and checks the scopes are valid
+func (sr *scopedRouters) buildScopes(scopes []*v2.RouteScope) (map[string]string, error) {
+	scopesMap := make(map[string]string, len(scopes))
+	for _, scope := range scopes {
+		if len(scope.Key) != len(sr.fragmentBuilders) {
+			log.DefaultLogger.Errorf(RouterLogFormat, "scoped routers", "UpdateScopes", "invalid key of scope: "+scope.Name)
+			return nil, ErrInvalidScopeKey
+		}
+		key := scopeKey(scope.Key)
+		if _, ok := scopesMap[key]; ok {
+			log.DefaultLogger.Errorf(RouterLogFormat, "scoped routers", "UpdateScopes", "duplicate key of scope: "+scope.Name)
+			return nil, ErrDuplicateRouteScope
+		}
+		scopesMap[key] = scope.RouterConfigName
+	}
+	return scopesMap, nil
+}
+
+func (sr *scopedRouters) setScopes(scopesMap map[string]string, scopes []*v2.RouteScope) {
+	sr.mux.Lock()
+	sr.scopes = scopesMap
+	sr.scopeConfigs = scopes
+	sr.mux.Unlock()
+}
+
+// findRouters returns the route table of the request's scope, nil if no scope found
+func (sr *scopedRouters) findRouters(headers api.HeaderMap) types.Routers {
+	fragments := make([]string, 0, len(sr.fragmentBuilders))
+	for _, builder := range sr.fragmentBuilders {
+		fragment, ok := builder.build(headers)
+		if !ok {
+			if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+				log.DefaultLogger.Debugf(RouterLogFormat, "scoped routers", "findRouters", "no scope key fragment found in header: "+builder.headerName)
+			}
+			return nil
+		}
+		fragments = append(fragments, fragment)
+	}
+	sr.mux.RLock()
+	name, ok := sr.scopes[scopeKey(fragments)]
+	sr.mux.RUnlock()
+	if !ok {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf(RouterLogFormat, "scoped routers", "findRouters", "no scope found")
+		}
+		return nil
+	}
+	wrapper := sr.manager.GetRouterWrapperByName(name)
+	if wrapper == nil {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf(RouterLogFormat, "scoped routers", "findRouters", "no route table found: "+name)
+		}
+		return nil
+	}
+	return wrapper.GetRouters()
+}
+
+func (sr *scopedRouters) MatchRoute(headers api.HeaderMap, randomValue uint64) api.Route {
+	if routers := sr.findRouters(headers); routers != nil {
+		return routers.MatchRoute(headers, randomValue)
+	}
+	return nil
+}
+
+func (sr *scopedRouters) MatchAllRoutes(headers api.HeaderMap, randomValue uint64) []api.Route {
+	if routers := sr.findRouters(headers); routers != nil {
+		return routers.MatchAllRoutes(headers, randomValue)
+	}
+	return nil
+}
+
+func (sr *scopedRouters) MatchRouteFromHeaderKV(headers api.HeaderMap, key, value string) api.Route {
+	if routers := sr.findRouters(headers); routers != nil {
+		return routers.MatchRouteFromHeaderKV(headers, key, value)
+	}
+	return nil
+}
+
+// AddRoute is not supported, the route should be added into the route table
+func (sr *scopedRouters) AddRoute(domain string, route *v2.Router) int {
+	log.DefaultLogger.Errorf(RouterLogFormat, "scoped routers", "AddRoute", "scoped routers can not add route, add it into the route table")
+	return -1
+}
+
+// RemoveAllRoutes is not supported, the routes should be removed from the route table
+func (sr *scopedRouters) RemoveAllRoutes(domain string) int {
+	log.DefaultLogger.Errorf(RouterLogFormat, "scoped routers", "RemoveAllRoutes", "scoped routers can not remove routes, remove them from the route table")
+	return -1
+}
@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func newRouteTable(name, cluster string) *v2.RouterConfiguration {
+	return &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: name,
+		},
+		VirtualHosts: []*v2.VirtualHost{
+			{
+				Name:    name,
+				Domains: []string{"*"},
+				Routers: []v2.Router{
+					{RouterConfig: v2.RouterConfig{
+						Match: v2.RouterMatch{Prefix: "/"},
+						Route: v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: cluster}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func newScopedRouterConfig(name string, scoped *v2.ScopedRoutes) *v2.RouterConfiguration {
+	return &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: name,
+			ScopedRoutes:     scoped,
+		},
+	}
+}
+
+func matchedCluster(rm *routersManagerImpl, name string, headers map[string]string) string {
+	headers[protocol.MosnHeaderPathKey] = "/"
+	route := rm.GetRouterWrapperByName(name).GetRouters().MatchRoute(protocol.CommonHeader(headers), 1)
+	if route == nil {
+		return ""
+	}
+	return route.RouteRule().ClusterName()
+}
+
+func TestScopeKeyFragmentBuilder(t *testing.T) {
+	testCases := []struct {
+		cfg      v2.ScopeKeyFragment
+		value    string
+		fragment string
+		ok       bool
+	}{
+		{v2.ScopeKeyFragment{HeaderName: "x-tenant"}, "tenant-a", "tenant-a", true},
+		{v2.ScopeKeyFragment{HeaderName: "x-route", ElementSeparator: ",", Index: 1}, "a, b,c", "b", true},
+		{v2.ScopeKeyFragment{HeaderName: "x-route", ElementSeparator: ",", Index: 3}, "a,b,c", "", false},
+		{v2.ScopeKeyFragment{HeaderName: "cookie", ElementSeparator: ";", ElementKey: "tenant"}, "id=1; tenant=b", "b", true},
+		{v2.ScopeKeyFragment{HeaderName: "x-route", ElementSeparator: ";", ElementKey: "tenant", KeyValueSeparator: ":"}, "tenant:c;id:1", "c", true},
+		{v2.ScopeKeyFragment{HeaderName: "cookie", ElementSeparator: ";", ElementKey: "tenant"}, "id=1", "", false},
+	}
+	for i, tc := range testCases {
+		builder, err := newScopeKeyFragmentBuilder(tc.cfg)
+		if err != nil {
+			t.Fatalf("#%d create builder failed: %v", i, err)
+		}
+		fragment, ok := builder.build(protocol.CommonHeader{tc.cfg.HeaderName: tc.value})
+		if fragment != tc.fragment || ok != tc.ok {
+			t.Errorf("#%d expected fragment %s, %v, but got %s, %v", i, tc.fragment, tc.ok, fragment, ok)
+		}
+		if _, ok := builder.build(protocol.CommonHeader{}); ok {
+			t.Errorf("#%d expected no fragment without the header", i)
+		}
+	}
+}
+
+func TestScopedRouters(t *testing.T) {
+	rm := &routersManagerImpl{}
+	scoped := &v2.ScopedRoutes{
+		ScopeKeyBuilder: v2.ScopeKeyBuilder{
+			Fragments: []v2.ScopeKeyFragment{
+				{HeaderName: "x-tenant"},
+				{HeaderName: "x-env", ElementSeparator: ",", Index: 0},
+			},
+		},
+		Scopes: []*v2.RouteScope{
+			{Name: "a-prod", RouterConfigName: "table_a", Key: []string{"a", "prod"}},
+			{Name: "b-prod", RouterConfigName: "table_b", Key: []string{"b", "prod"}},
+		},
+	}
+	if err := rm.AddOrUpdateRouters(newScopedRouterConfig("scoped", scoped)); err != nil {
+		t.Fatalf("add scoped routers failed: %v", err)
+	}
+	if err := rm.AddOrUpdateRouters(newRouteTable("table_a", "cluster_a")); err != nil {
+		t.Fatalf("add route table failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a", "x-env": "prod,gray"}); c != "cluster_a" {
+		t.Errorf("expected matched cluster_a, but got %s", c)
+	}
+	// the route table is not added yet
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "b", "x-env": "prod"}); c != "" {
+		t.Errorf("expected no route matched, but got %s", c)
+	}
+	// no scope matched
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a", "x-env": "test"}); c != "" {
+		t.Errorf("expected no route matched, but got %s", c)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a"}); c != "" {
+		t.Errorf("expected no route matched, but got %s", c)
+	}
+	// the route tables are updated independently
+	if err := rm.AddOrUpdateRouters(newRouteTable("table_b", "cluster_b")); err != nil {
+		t.Fatalf("add route table failed: %v", err)
+	}
+	if err := rm.AddOrUpdateRouters(newRouteTable("table_a", "cluster_a_v2")); err != nil {
+		t.Fatalf("update route table failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "b", "x-env": "prod"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a", "x-env": "prod"}); c != "cluster_a_v2" {
+		t.Errorf("expected matched cluster_a_v2, but got %s", c)
+	}
+	// the scoped routers can not add routes
+	if err := rm.AddRoute("scoped", "*", &v2.Router{}); err == nil {
+		t.Error("expected add route into scoped routers failed")
+	}
+}
+
+func TestDynamicRouteScopes(t *testing.T) {
+	rm := &routersManagerImpl{}
+	scoped := &v2.ScopedRoutes{
+		ScopeKeyBuilder: v2.ScopeKeyBuilder{
+			Fragments: []v2.ScopeKeyFragment{{HeaderName: "x-tenant"}},
+		},
+		// ignored
+		Scopes:    []*v2.RouteScope{{Name: "a", RouterConfigName: "table_a", Key: []string{"a"}}},
+		ScopedRDS: true,
+	}
+	for _, cfg := range []*v2.RouterConfiguration{
+		newScopedRouterConfig("scoped", scoped),
+		newRouteTable("table_a", "cluster_a"),
+		newRouteTable("table_b", "cluster_b"),
+	} {
+		if err := rm.AddOrUpdateRouters(cfg); err != nil {
+			t.Fatalf("add routers failed: %v", err)
+		}
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a"}); c != "" {
+		t.Errorf("expected no route matched, but got %s", c)
+	}
+	if err := rm.UpdateDynamicRouteScopes(map[string][]*v2.RouteScope{
+		"": {{Name: "a", RouterConfigName: "table_b", Key: []string{"a"}}},
+	}); err != nil {
+		t.Fatalf("update scopes failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+	// the discovered scopes are kept when the scoped routers is updated
+	if err := rm.AddOrUpdateRouters(newScopedRouterConfig("scoped", scoped)); err != nil {
+		t.Fatalf("update scoped routers failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+	// invalid scopes are rejected, and the scopes are not changed
+	for i, scopes := range [][]*v2.RouteScope{
+		{{Name: "a", RouterConfigName: "table_a", Key: []string{"a", "b"}}},
+		{{Name: "a", RouterConfigName: "table_a", Key: []string{"a"}}, {Name: "b", RouterConfigName: "table_b", Key: []string{"a"}}},
+	} {
+		if err := rm.UpdateDynamicRouteScopes(map[string][]*v2.RouteScope{"": scopes}); err == nil {
+			t.Errorf("#%d expected update invalid scopes failed", i)
+		}
+	}
+	if c := matchedCluster(rm, "scoped", map[string]string{"x-tenant": "a"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+}
+
+func TestDynamicRouteScopesByName(t *testing.T) {
+	rm := &routersManagerImpl{}
+	newScoped := func(name string) *v2.ScopedRoutes {
+		return &v2.ScopedRoutes{
+			Name: name,
+			ScopeKeyBuilder: v2.ScopeKeyBuilder{
+				Fragments: []v2.ScopeKeyFragment{{HeaderName: "x-tenant"}},
+			},
+			ScopedRDS: true,
+		}
+	}
+	for _, cfg := range []*v2.RouterConfiguration{
+		newRouteTable("table_a", "cluster_a"),
+		newRouteTable("table_b", "cluster_b"),
+		newScopedRouterConfig("scoped_foo", newScoped("foo")),
+	} {
+		if err := rm.AddOrUpdateRouters(cfg); err != nil {
+			t.Fatalf("add routers failed: %v", err)
+		}
+	}
+	// the scopes of bar arrive before the scoped routers of bar is added
+	if err := rm.UpdateDynamicRouteScopes(map[string][]*v2.RouteScope{
+		"foo": {{Name: "foo/a", RouterConfigName: "table_a", Key: []string{"a"}}},
+		"bar": {{Name: "bar/a", RouterConfigName: "table_b", Key: []string{"a"}}},
+	}); err != nil {
+		t.Fatalf("update scopes failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped_foo", map[string]string{"x-tenant": "a"}); c != "cluster_a" {
+		t.Errorf("expected matched cluster_a, but got %s", c)
+	}
+	if err := rm.AddOrUpdateRouters(newScopedRouterConfig("scoped_bar", newScoped("bar"))); err != nil {
+		t.Fatalf("add routers failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped_bar", map[string]string{"x-tenant": "a"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+	// the scopes of foo are removed
+	if err := rm.UpdateDynamicRouteScopes(map[string][]*v2.RouteScope{
+		"bar": {{Name: "bar/a", RouterConfigName: "table_b", Key: []string{"a"}}},
+	}); err != nil {
+		t.Fatalf("update scopes failed: %v", err)
+	}
+	if c := matchedCluster(rm, "scoped_foo", map[string]string{"x-tenant": "a"}); c != "" {
+		t.Errorf("expected no route matched, but got %s", c)
+	}
+	if c := matchedCluster(rm, "scoped_bar", map[string]string{"x-tenant": "a"}); c != "cluster_b" {
+		t.Errorf("expected matched cluster_b, but got %s", c)
+	}
+}
+
+func TestInvalidScopedRoutes(t *testing.T) {
+	rm := &routersManagerImpl{}
+	for i, scoped := range []*v2.ScopedRoutes{
+		{},
+		{ScopeKeyBuilder: v2.ScopeKeyBuilder{Fragments: []v2.ScopeKeyFragment{{ElementSeparator: ","}}}},
+		{
+			ScopeKeyBuilder: v2.ScopeKeyBuilder{Fragments: []v2.ScopeKeyFragment{{HeaderName: "x-tenant"}}},
+			Scopes:          []*v2.RouteScope{{Name: "a", RouterConfigName: "table_a"}},
+		},
+	} {
+		if _, err := rm.newRouters(newScopedRouterConfig("scoped", scoped)); err == nil {
+			t.Errorf("#%d expected create scoped routers failed", i)
+		}
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"context"
 	"errors"
 	"strings"
-	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
@@ -38,10 +37,31 @@ var (
 	ErrDuplicateVirtualHost = errors.New("duplicate domain virtual host")
 	ErrUnexpected           = errors.New("an unexpected error occurs")
 	ErrRouterFactory        = errors.New("default router factory create router failed")
+	// header variables errors
+	ErrEmptyHeaderVariable    = errors.New("header format error: empty variable definition")
+	ErrUnclosedHeaderVariable = errors.New("header format error: unclosed variable definition")
+	// route match errors
+	ErrEmptyMatcherName       = errors.New("route match error: empty query parameter or cookie name")
+	ErrInvalidRuntimeFraction = errors.New("route match error: runtime fraction denominator should be one of 100, 10000 and 1000000")
+	ErrInvalidBodyMatcherType = errors.New("route match error: body matcher type should be one of json and form")
+	// route action errors
+	ErrConflictPathRewrite = errors.New("route action error: prefix rewrite and regex rewrite cannot be configured at the same time")
+	ErrConflictRouteAction = errors.New("route action error: redirect and direct response cannot be configured at the same time")
+	// scoped routes errors
+	ErrEmptyScopeKeyBuilder = errors.New("scoped routes error: scope key builder has no fragment")
+	ErrEmptyScopeKeyHeader  = errors.New("scoped routes error: empty header name of scope key fragment")
+	ErrInvalidScopeKey      = errors.New("scoped routes error: scope key does not match the scope key builder")
+	ErrDuplicateRouteScope  = errors.New("scoped routes error: duplicate scope key")
+	// cluster specifier errors
+	ErrUnknownClusterSpecifier  = errors.New("cluster specifier error: unknown cluster specifier type")
+	ErrEmptyClusterSpecifierKey = errors.New("cluster specifier error: key should contain one of header, cookie and variable")
+	ErrEmptyHashSplitClusters   = errors.New("cluster specifier error: hash split has no cluster with weight")
+	ErrEmptyPluginName          = errors.New("cluster specifier error: empty plugin name")
 )
 
 type headerFormatter interface {
-	format(requestInfo api.RequestInfo) string
+	// format returns the header value, the ctx can be nil if the stream context is not available
+	format(ctx context.Context, requestInfo api.RequestInfo) string
 	append() bool
 }
 
@@ -96,33 +116,6 @@ func (p *policy) ShadowPolicy() api.ShadowPolicy {
 	return p.shadowPolicy
 }
 
-type retryPolicyImpl struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
-}
-
-func (p *retryPolicyImpl) RetryOn() bool {
-	if p == nil {
-		return false
-	}
-	return p.retryOn
-}
-
-func (p *retryPolicyImpl) TryTimeout() time.Duration {
-	if p == nil {
-		return 0
-	}
-	return p.retryTimeout
-}
-
-func (p *retryPolicyImpl) NumRetries() uint32 {
-	if p == nil {
-		return 0
-	}
-	return p.numRetries
-}
-
 type shadowPolicyImpl struct {
 	cluster    string
 	runtimeKey string
@@ -18,6 +18,7 @@
 package router
 
 import (
+	"fmt"
 	"regexp"
 
 	"mosn.io/mosn/pkg/config/v2"
@@ -52,11 +53,16 @@ func getRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 			Name: &lowerCaseString{
 				header.Name,
 			},
-			Value:   header.Value,
-			IsRegex: header.Regex,
+			Value:       header.Value,
+			IsRegex:     header.Regex,
+			InvertMatch: header.InvertMatch,
+		}
+		if header.Present != nil {
+			headerData.PresentMatch = true
+			headerData.IsPresent = *header.Present
 		}
 
-		if header.Regex {
+		if header.Regex && !headerData.PresentMatch {
 			pattern, err := regexp.Compile(header.Value)
 			if err != nil {
 				log.DefaultLogger.Errorf("getRouterHeaders compile error")
@@ -72,20 +78,61 @@ func getRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 	return headerDatas
 }
 
-func getHeaderParser(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) *headerParser {
-	if headersToAdd == nil && headersToRemove == nil {
-		return nil
+func getQueryParameterMatchers(params []v2.QueryParameterMatcher) ([]types.QueryParameterMatcher, error) {
+	var matchers []types.QueryParameterMatcher
+	for _, param := range params {
+		matcher, err := newQueryParameterMatcher(param.Name, param.Value, param.Regex, param.Present)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func getCookieMatchers(cookies []v2.CookieMatcher) ([]*cookieMatcher, error) {
+	var matchers []*cookieMatcher
+	for _, cookie := range cookies {
+		matcher, err := newQueryParameterMatcher(cookie.Name, cookie.Value, cookie.Regex, cookie.Present)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, &cookieMatcher{
+			queryParameterMatcher: matcher,
+		})
 	}
+	return matchers, nil
+}
 
+func getBodyMatchers(bodies []v2.BodyMatcher) ([]*bodyMatcher, error) {
+	var matchers []*bodyMatcher
+	for _, body := range bodies {
+		matcher, err := newBodyMatcher(body)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func getHeaderParser(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) (*headerParser, error) {
+	if headersToAdd == nil && headersToRemove == nil {
+		return nil, nil
+	}
+	pairs, err := getHeaderPair(headersToAdd)
+	if err != nil {
+		return nil, err
+	}
 	return &headerParser{
-		headersToAdd:    getHeaderPair(headersToAdd),
+		headersToAdd:    pairs,
 		headersToRemove: getHeadersToRemove(headersToRemove),
-	}
+	}, nil
 }
 
-func getHeaderPair(headersToAdd []*v2.HeaderValueOption) []*headerPair {
+func getHeaderPair(headersToAdd []*v2.HeaderValueOption) ([]*headerPair, error) {
 	if headersToAdd == nil {
-		return nil
+		return nil, nil
 	}
 	headerPairs := make([]*headerPair, 0, len(headersToAdd))
 	for _, option := range headersToAdd {
@@ -99,16 +146,16 @@ func getHeaderPair(headersToAdd []*v2.HeaderValueOption) []*headerPair {
 		if option.Append != nil {
 			isAppend = *option.Append
 		}
-		value := getHeaderFormatter(option.Header.Value, isAppend)
-		if value == nil {
-			continue
+		value, err := getHeaderFormatter(option.Header.Value, isAppend)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of header %s: %v", option.Header.Key, err)
 		}
 		headerPairs = append(headerPairs, &headerPair{
 			headerName:      key,
 			headerFormatter: value,
 		})
 	}
-	return headerPairs
+	return headerPairs, nil
 }
 
 func getHeadersToRemove(headersToRemove []string) []*lowerCaseString {
@@ -135,7 +135,7 @@ func Test_getHeaderParser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHeaderParser(tt.args.headersToAdd, tt.args.headersToRemove); !reflect.DeepEqual(got, tt.want) {
+			if got, err := getHeaderParser(tt.args.headersToAdd, tt.args.headersToRemove); err != nil || !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getHeaderParser(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) = %v, want %v", got, tt.want)
 			}
 		})
@@ -189,7 +189,7 @@ func Test_getHeaderPair(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHeaderPair(tt.args.headersToAdd); !reflect.DeepEqual(got, tt.want) {
+			if got, err := getHeaderPair(tt.args.headersToAdd); err != nil || !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getHeaderPair(headersToAdd []*v2.HeaderValueOption) = %v, want %v", got, tt.want)
 			}
 		})
@@ -24,6 +24,7 @@ import (
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
 )
 
 type VirtualHostImpl struct {
@@ -34,6 +35,8 @@ type VirtualHostImpl struct {
 	globalRouteConfig     *configImpl
 	requestHeadersParser  *headerParser
 	responseHeadersParser *headerParser
+	corsPolicy            types.CorsPolicy
+	perFilterConfig       map[string]interface{}
 }
 
 func (vh *VirtualHostImpl) Name() string {
@@ -58,7 +61,11 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 			path:              route.Match.Path,
 		}
 	} else if route.Match.Regex != "" {
-		regPattern, err := regexp.Compile(route.Match.Regex)
+		regex := route.Match.Regex
+		if !base.isCaseSensitive(true) {
+			regex = "(?i)" + regex
+		}
+		regPattern, err := regexp.Compile(regex)
 		if err != nil {
 			log.DefaultLogger.Errorf(RouterLogFormat, "virtualhost", "addRouteBase", err)
 			return err
@@ -79,7 +86,7 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 		vh.routes = append(vh.routes, router)
 		// make fast index, used in certain scenarios
 		// TODO: rule can be extended
-		if len(route.Match.Headers) == 1 && !route.Match.Headers[0].Regex {
+		if isFastIndexRoute(&route.Match) {
 			key := route.Match.Headers[0].Name
 			value := route.Match.Headers[0].Value
 			valueMap, ok := vh.fastIndex[key]
@@ -98,6 +105,16 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 
 }
 
+// isFastIndexRoute returns true if the route matches one header's value only
+func isFastIndexRoute(match *v2.RouterMatch) bool {
+	if len(match.Headers) != 1 || len(match.Methods) != 0 || len(match.QueryParameters) != 0 ||
+		len(match.Cookies) != 0 || match.RuntimeFraction != nil {
+		return false
+	}
+	header := match.Headers[0]
+	return !header.Regex && header.Present == nil && !header.InvertMatch
+}
+
 func (vh *VirtualHostImpl) GetRouteFromEntries(headers api.HeaderMap, randomValue uint64) api.Route {
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
@@ -148,10 +165,19 @@ func (vh *VirtualHostImpl) RemoveAllRoutes() {
 
 func NewVirtualHostImpl(virtualHost *v2.VirtualHost) (*VirtualHostImpl, error) {
 	vhImpl := &VirtualHostImpl{
-		virtualHostName:       virtualHost.Name,
-		fastIndex:             make(map[string]map[string]api.Route),
-		requestHeadersParser:  getHeaderParser(virtualHost.RequestHeadersToAdd, nil),
-		responseHeadersParser: getHeaderParser(virtualHost.ResponseHeadersToAdd, virtualHost.ResponseHeadersToRemove),
+		virtualHostName: virtualHost.Name,
+		fastIndex:       make(map[string]map[string]api.Route),
+		perFilterConfig: virtualHost.PerFilterConfig,
+	}
+	var err error
+	if vhImpl.requestHeadersParser, err = getHeaderParser(virtualHost.RequestHeadersToAdd, nil); err != nil {
+		return nil, err
+	}
+	if vhImpl.responseHeadersParser, err = getHeaderParser(virtualHost.ResponseHeadersToAdd, virtualHost.ResponseHeadersToRemove); err != nil {
+		return nil, err
+	}
+	if vhImpl.corsPolicy, err = newCorsPolicy(virtualHost.Cors); err != nil {
+		return nil, err
 	}
 	for _, route := range virtualHost.Routers {
 		if err := vhImpl.addRouteBase(&route); err != nil {
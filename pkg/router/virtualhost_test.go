@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
 )
 
 // Prefix > Path > Regex
@@ -195,3 +197,66 @@ func TestAllRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestVirtualHostPerFilterConfig(t *testing.T) {
+	filter.RegisterPerRouteConfigParser("test_router_filter", func(config map[string]interface{}) (interface{}, error) {
+		return config["value"], nil
+	})
+	newRouter := func(prefix string, perFilterConfig map[string]interface{}) v2.Router {
+		r := v2.Router{}
+		r.Match = v2.RouterMatch{Prefix: prefix}
+		r.Route = v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: "test"}}
+		r.PerFilterConfig = perFilterConfig
+		return r
+	}
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{
+			newRouter("/override", map[string]interface{}{
+				"test_router_filter": map[string]interface{}{"value": "route"},
+				"test_disabled":      map[string]interface{}{filter.PerRouteConfigDisabled: true},
+			}),
+			newRouter("/", nil),
+		},
+		PerFilterConfig: map[string]interface{}{
+			"test_router_filter": map[string]interface{}{"value": "virtual_host"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create virtual host failed: %v", err)
+	}
+	testCases := []struct {
+		path     string
+		value    interface{}
+		disabled bool
+	}{
+		{"/override", "route", true},
+		{"/default", "virtual_host", false},
+	}
+	for _, tc := range testCases {
+		headers := protocol.CommonHeader{protocol.MosnHeaderPathKey: tc.path}
+		route := vh.GetRouteFromEntries(headers, 1)
+		if route == nil {
+			t.Fatalf("%s no route matched", tc.path)
+		}
+		rule, ok := route.RouteRule().(types.FilterConfigRouteRule)
+		if !ok {
+			t.Fatalf("%s route rule is not a FilterConfigRouteRule", tc.path)
+		}
+		if v := rule.FilterConfig("test_router_filter"); v != tc.value {
+			t.Errorf("%s expected filter config %v, but got %v", tc.path, tc.value, v)
+		}
+		if d := rule.FilterDisabled("test_disabled"); d != tc.disabled {
+			t.Errorf("%s expected filter disabled %v, but got %v", tc.path, tc.disabled, d)
+		}
+	}
+	// invalid per filter config
+	if _, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{newRouter("/", map[string]interface{}{"test_router_filter": "invalid"})},
+	}); err == nil {
+		t.Error("invalid per filter config expected an error")
+	}
+}
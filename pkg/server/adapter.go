@@ -142,16 +142,19 @@ func (adapter *ListenerAdapter) UpdateListenerTLS(serverName string, listenerNam
 	if ln := connHandler.FindListenerByName(listenerName); ln != nil {
 		cfg := *ln.Config() // should clone a config
 		cfg.Inspector = inspector
-		cfg.FilterChains = []v2.FilterChain{
-			{
-				FilterChainConfig: v2.FilterChainConfig{
-					FilterChainMatch: cfg.FilterChains[0].FilterChainMatch,
-					Filters:          cfg.FilterChains[0].Filters,
-					TLSConfigs:       tlsConfigs,
-				},
-				TLSContexts: tlsConfigs,
+		// only the first filter chain's tls config is updated
+		filterChains := make([]v2.FilterChain, len(cfg.FilterChains))
+		copy(filterChains, cfg.FilterChains)
+		filterChains[0] = v2.FilterChain{
+			FilterChainConfig: v2.FilterChainConfig{
+				FilterChainMatch: cfg.FilterChains[0].FilterChainMatch,
+				Match:            cfg.FilterChains[0].Match,
+				Filters:          cfg.FilterChains[0].Filters,
+				TLSConfigs:       tlsConfigs,
 			},
+			TLSContexts: tlsConfigs,
 		}
+		cfg.FilterChains = filterChains
 
 		if _, err := connHandler.AddOrUpdateListener(&cfg); err != nil {
 			return fmt.Errorf("connHandler.UpdateListenerTLS called error, server:%s, error: %s", serverName, err.Error())
@@ -8,6 +8,7 @@ import (
 	"net"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -152,7 +153,9 @@ func TestLDS(t *testing.T) {
 		ListenerConfig: v2.ListenerConfig{
 			Name: name, // name should same as the exists listener
 			AccessLogs: []v2.AccessLog{
-				{},
+				{
+					Format: "access log", // access log is updated, the variables are not registered in test
+				},
 			},
 			FilterChains: []v2.FilterChain{
 				{
@@ -450,3 +453,78 @@ func TestListenerMetrics(t *testing.T) {
 		t.Fatalf("mosn listener metrics is not expected, got %d", lnCount)
 	}
 }
+
+// mockClosableSink counts the closed access log sinks
+type mockClosableSink struct {
+	closed *int32
+}
+
+func (s *mockClosableSink) Write(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo, buf buffer.IoBuffer) {
+	buffer.PutIoBuffer(buf)
+}
+
+func (s *mockClosableSink) Toggle(disable bool) {}
+
+func (s *mockClosableSink) Disable() bool {
+	return false
+}
+
+func (s *mockClosableSink) Close() error {
+	atomic.AddInt32(s.closed, 1)
+	return nil
+}
+
+func TestListenerAccessLogsClosed(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var closed int32
+	log.RegisterAccessLogSink("mock_closable", func(config map[string]interface{}) (log.AccessLogSink, error) {
+		return &mockClosableSink{closed: &closed}, nil
+	})
+	accessLogs := []v2.AccessLog{
+		{
+			Format: "access log",
+			Sink: &v2.AccessLogSinkConfig{
+				Type: "mock_closable",
+			},
+		},
+	}
+	name := "access_log_listener"
+	// the listener is not bound to port, so it is never started
+	cfg := baseListenerConfig("127.0.0.1:8085", name)
+	cfg.BindToPort = false
+	cfg.AccessLogs = accessLogs
+	if err := GetListenerAdapterInstance().AddOrUpdateListener(testServerName, cfg); err != nil {
+		t.Fatalf("add listener failed: %v", err)
+	}
+	handler := listenerAdapterInstance.defaultConnHandler.(*connHandler)
+	al := handler.findActiveListenerByName(name)
+	if al == nil || len(al.accessLogs()) != 1 {
+		t.Fatal("access logs of the listener are not created")
+	}
+	oldLogs := al.accessLogs()
+	// the old access logs are closed when they are replaced
+	newCfg := baseListenerConfig("127.0.0.1:8085", name)
+	newCfg.BindToPort = false
+	newCfg.AccessLogs = accessLogs
+	if err := GetListenerAdapterInstance().AddOrUpdateListener(testServerName, newCfg); err != nil {
+		t.Fatalf("update listener failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != 1 {
+		t.Fatalf("expected 1 access log closed after updated, but got %d", n)
+	}
+	if logs := al.accessLogs(); len(logs) != 1 || logs[0] == oldLogs[0] {
+		t.Fatalf("access logs are not replaced: %v", logs)
+	}
+	// the access logs are closed when the listener is removed
+	if err := GetListenerAdapterInstance().DeleteListener(testServerName, name); err != nil {
+		t.Fatalf("delete listener failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != 2 {
+		t.Fatalf("expected 2 access logs closed after removed, but got %d", n)
+	}
+	if len(al.accessLogs()) != 0 {
+		t.Fatal("access logs are not removed from the listener")
+	}
+}
@@ -0,0 +1,334 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/types"
+)
+
+// activeFilterChain is a filter chain of listener with the parsed match criteria
+type activeFilterChain struct {
+	destinationPort         uint32
+	prefixRanges            []*net.IPNet
+	serverNames             []string
+	transportProtocol       string
+	applicationProtocols    []string
+	sourcePrefixRanges      []*net.IPNet
+	networkFiltersFactories []api.NetworkFilterChainFactory
+	// streamFiltersFactoriesStore is nil if the filter chain uses the listener's stream filters
+	streamFiltersFactoriesStore *atomic.Value
+	tlsMng                      types.TLSContextManager
+	// the filters of udp listener are created at the first datagram
+	udpMutex   sync.Mutex
+	udpInited  bool
+	udpClosed  bool
+	udpFilters []types.UDPListenerFilter
+}
+
+// filterChainManager chooses a filter chain for the new connections
+type filterChainManager struct {
+	chains []*activeFilterChain
+	// inspect is true if the tls client hello is needed to choose the filter chain
+	inspect bool
+}
+
+// connectionInfo is the information used to match the filter chain
+type connectionInfo struct {
+	destinationIP        net.IP
+	destinationPort      uint32
+	sourceIP             net.IP
+	serverName           string
+	transportProtocol    string
+	applicationProtocols []string
+}
+
+func newFilterChainManager(lc *v2.Listener) (*filterChainManager, error) {
+	if len(lc.FilterChains) == 0 {
+		return nil, fmt.Errorf("listener %s has no filter chains", lc.Name)
+	}
+	mng := &filterChainManager{
+		chains: make([]*activeFilterChain, 0, len(lc.FilterChains)),
+	}
+	for i := range lc.FilterChains {
+		fc := &lc.FilterChains[i]
+		chain, err := newActiveFilterChain(lc, fc)
+		if err != nil {
+			mng.releaseTLSContextManagers()
+			return nil, err
+		}
+		if len(chain.serverNames) > 0 || len(chain.applicationProtocols) > 0 || chain.transportProtocol != "" {
+			mng.inspect = true
+		}
+		mng.chains = append(mng.chains, chain)
+	}
+	return mng, nil
+}
+
+func newActiveFilterChain(lc *v2.Listener, fc *v2.FilterChain) (*activeFilterChain, error) {
+	chain := &activeFilterChain{}
+	if m := fc.Match; m != nil {
+		var err error
+		chain.destinationPort = m.DestinationPort
+		if chain.prefixRanges, err = parseCIDRs(m.PrefixRanges); err != nil {
+			return nil, err
+		}
+		if chain.sourcePrefixRanges, err = parseCIDRs(m.SourcePrefixRanges); err != nil {
+			return nil, err
+		}
+		for _, name := range m.ServerNames {
+			chain.serverNames = append(chain.serverNames, strings.ToLower(name))
+		}
+		switch m.TransportProtocol {
+		case "", v2.TransportProtocolTLS, v2.TransportProtocolRawBuffer:
+			chain.transportProtocol = m.TransportProtocol
+		default:
+			return nil, fmt.Errorf("unsupported transport protocol: %s", m.TransportProtocol)
+		}
+		chain.applicationProtocols = m.ApplicationProtocols
+	}
+	// each filter chain has its own certificates
+	tlsMng, err := mtls.NewTLSServerContextManager(&v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:         lc.Name,
+			Inspector:    lc.Inspector,
+			FilterChains: []v2.FilterChain{*fc},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	chain.tlsMng = tlsMng
+	chain.networkFiltersFactories = configmanager.GetNetworkFilters(fc)
+	if len(fc.StreamFilters) > 0 {
+		chain.streamFiltersFactoriesStore = &atomic.Value{}
+		chain.streamFiltersFactoriesStore.Store(configmanager.GetStreamFilters(fc.StreamFilters))
+	}
+	return chain, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		// a single ip is allowed
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid prefix range: %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			cidr = fmt.Sprintf("%s/%d", cidr, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prefix range: %s", cidr)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// match returns the most specific filter chain for the connection, nil means no filter chain matched.
+// the criteria are checked in order: destination port, destination ip, server name,
+// transport protocol, application protocols and source ip. in each step, only the most
+// specific filter chains are kept, an empty criteria is the least specific one.
+// if more than one filter chains are left finally, the first one in config is used.
+func (mng *filterChainManager) match(info *connectionInfo) *activeFilterChain {
+	candidates := mng.chains
+	steps := []func(*activeFilterChain, *connectionInfo) int{
+		matchDestinationPort,
+		matchDestinationIP,
+		matchServerName,
+		matchTransportProtocol,
+		matchApplicationProtocols,
+		matchSourceIP,
+	}
+	for _, step := range steps {
+		candidates = filterMostSpecific(candidates, info, step)
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+	return candidates[0]
+}
+
+// filterMostSpecific keeps the filter chains with the highest score,
+// the score is negative if the filter chain is not matched, and zero if the criteria is empty.
+func filterMostSpecific(chains []*activeFilterChain, info *connectionInfo, score func(*activeFilterChain, *connectionInfo) int) []*activeFilterChain {
+	max := -1
+	var result []*activeFilterChain
+	for _, chain := range chains {
+		s := score(chain, info)
+		switch {
+		case s < 0 || s < max:
+			continue
+		case s > max:
+			max = s
+			result = result[:0]
+		}
+		result = append(result, chain)
+	}
+	return result
+}
+
+func matchDestinationPort(chain *activeFilterChain, info *connectionInfo) int {
+	if chain.destinationPort == 0 {
+		return 0
+	}
+	if chain.destinationPort == info.destinationPort {
+		return 1
+	}
+	return -1
+}
+
+func matchDestinationIP(chain *activeFilterChain, info *connectionInfo) int {
+	return matchPrefixRanges(chain.prefixRanges, info.destinationIP)
+}
+
+func matchSourceIP(chain *activeFilterChain, info *connectionInfo) int {
+	return matchPrefixRanges(chain.sourcePrefixRanges, info.sourceIP)
+}
+
+// matchPrefixRanges returns the longest matched prefix length plus one
+func matchPrefixRanges(nets []*net.IPNet, ip net.IP) int {
+	if len(nets) == 0 {
+		return 0
+	}
+	longest := -1
+	for _, n := range nets {
+		if ip != nil && n.Contains(ip) {
+			if ones, _ := n.Mask.Size(); ones+1 > longest {
+				longest = ones + 1
+			}
+		}
+	}
+	return longest
+}
+
+// matchServerName prefers the exact server name to the wildcard ones,
+// and the wildcard with the longer suffix is preferred.
+func matchServerName(chain *activeFilterChain, info *connectionInfo) int {
+	if len(chain.serverNames) == 0 {
+		return 0
+	}
+	if info.serverName == "" {
+		return -1
+	}
+	score := -1
+	for _, name := range chain.serverNames {
+		if name == info.serverName {
+			// longer than any wildcard
+			return len(name) + 1
+		}
+		if strings.HasPrefix(name, "*.") {
+			suffix := name[1:]
+			if strings.HasSuffix(info.serverName, suffix) && len(suffix) > score {
+				score = len(suffix)
+			}
+		}
+	}
+	return score
+}
+
+func matchTransportProtocol(chain *activeFilterChain, info *connectionInfo) int {
+	if chain.transportProtocol == "" {
+		return 0
+	}
+	if chain.transportProtocol == info.transportProtocol {
+		return 1
+	}
+	return -1
+}
+
+func matchApplicationProtocols(chain *activeFilterChain, info *connectionInfo) int {
+	if len(chain.applicationProtocols) == 0 {
+		return 0
+	}
+	for _, proto := range chain.applicationProtocols {
+		for _, p := range info.applicationProtocols {
+			if proto == p {
+				return 1
+			}
+		}
+	}
+	return -1
+}
+
+// udpListenerFilters returns the udp listener filters created by the network filter factories,
+// no filters are returned after the filter chain is closed.
+func (chain *activeFilterChain) udpListenerFilters(ctx context.Context, cb types.UDPListenerFilterCallbacks) []types.UDPListenerFilter {
+	chain.udpMutex.Lock()
+	defer chain.udpMutex.Unlock()
+	if chain.udpClosed {
+		return nil
+	}
+	if !chain.udpInited {
+		chain.udpInited = true
+		for _, factory := range chain.networkFiltersFactories {
+			if udpFactory, ok := factory.(types.UDPListenerFilterFactory); ok {
+				chain.udpFilters = append(chain.udpFilters, udpFactory.CreateUDPListenerFilter(ctx, cb))
+			}
+		}
+	}
+	return chain.udpFilters
+}
+
+// closeUDPListenerFilters closes the created udp listener filters, the filter chains cannot create them any more
+func (mng *filterChainManager) closeUDPListenerFilters() {
+	for _, chain := range mng.chains {
+		chain.udpMutex.Lock()
+		filters := chain.udpFilters
+		chain.udpFilters = nil
+		chain.udpClosed = true
+		chain.udpMutex.Unlock()
+		for _, f := range filters {
+			f.OnClose()
+		}
+	}
+}
+
+// releaseTLSContextManagers releases the tls context managers when the filter chains are not used any more
+func (mng *filterChainManager) releaseTLSContextManagers() {
+	for _, chain := range mng.chains {
+		mtls.ReleaseTLSContextManager(chain.tlsMng)
+	}
+}
+
+// addrIPPort returns the ip and port of a tcp address
+func addrIPPort(addr net.Addr) (net.IP, uint32) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP, uint32(a.Port)
+	case *net.UDPAddr:
+		return a.IP, uint32(a.Port)
+	}
+	return nil, 0
+}
@@ -0,0 +1,282 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func filterChainWithMatch(match *v2.FilterChainMatch) v2.FilterChain {
+	return v2.FilterChain{
+		FilterChainConfig: v2.FilterChainConfig{
+			Match: match,
+			Filters: []v2.Filter{
+				{
+					Type: "mock_network",
+				},
+			},
+		},
+	}
+}
+
+func TestFilterChainMatch(t *testing.T) {
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name: "test_filter_chain_match",
+			FilterChains: []v2.FilterChain{
+				filterChainWithMatch(nil), // 0: default
+				filterChainWithMatch(&v2.FilterChainMatch{ // 1
+					DestinationPort: 8080,
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 2
+					DestinationPort: 8080,
+					PrefixRanges:    []string{"10.0.0.0/8"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 3
+					DestinationPort: 8080,
+					PrefixRanges:    []string{"10.1.0.0/16"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 4
+					ServerNames: []string{"*.mosn.io"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 5
+					ServerNames: []string{"api.mosn.io"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 6
+					ServerNames:       []string{"*.test.mosn.io"},
+					TransportProtocol: v2.TransportProtocolTLS,
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 7
+					ServerNames:          []string{"*.test.mosn.io"},
+					TransportProtocol:    v2.TransportProtocolTLS,
+					ApplicationProtocols: []string{"h2"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 8
+					SourcePrefixRanges: []string{"192.168.1.1"},
+				}),
+				filterChainWithMatch(&v2.FilterChainMatch{ // 9
+					DestinationPort:    9090,
+					SourcePrefixRanges: []string{"192.168.0.0/16"},
+				}),
+			},
+		},
+	}
+	mng, err := newFilterChainManager(lc)
+	if err != nil {
+		t.Fatalf("create filter chains failed: %v", err)
+	}
+	if !mng.inspect {
+		t.Fatal("filter chains with server names should inspect the connection")
+	}
+	for idx, tc := range []struct {
+		info     *connectionInfo
+		expected int
+	}{
+		{&connectionInfo{destinationPort: 80}, 0},
+		{&connectionInfo{destinationPort: 8080, destinationIP: net.ParseIP("127.0.0.1")}, 1},
+		{&connectionInfo{destinationPort: 8080, destinationIP: net.ParseIP("10.2.0.1")}, 2},
+		{&connectionInfo{destinationPort: 8080, destinationIP: net.ParseIP("10.1.0.1")}, 3},
+		// destination is checked first
+		{&connectionInfo{destinationPort: 8080, serverName: "api.mosn.io"}, 1},
+		{&connectionInfo{destinationPort: 80, serverName: "www.mosn.io"}, 4},
+		{&connectionInfo{destinationPort: 80, serverName: "api.mosn.io"}, 5},
+		{&connectionInfo{destinationPort: 80, serverName: "a.test.mosn.io", transportProtocol: v2.TransportProtocolTLS}, 6},
+		{&connectionInfo{destinationPort: 80, serverName: "a.test.mosn.io", transportProtocol: v2.TransportProtocolTLS, applicationProtocols: []string{"http/1.1", "h2"}}, 7},
+		{&connectionInfo{destinationPort: 80, sourceIP: net.ParseIP("192.168.1.1")}, 8},
+		{&connectionInfo{destinationPort: 80, sourceIP: net.ParseIP("192.168.1.2")}, 0},
+		{&connectionInfo{destinationPort: 9090, sourceIP: net.ParseIP("192.168.1.2")}, 9},
+	} {
+		chain := mng.match(tc.info)
+		if chain != mng.chains[tc.expected] {
+			t.Errorf("case %d expected filter chain %d matched", idx, tc.expected)
+		}
+	}
+	// the more specific filter chain is not matched in the following criteria
+	if chain := mng.match(&connectionInfo{
+		destinationPort: 80,
+		serverName:      "a.test.mosn.io",
+	}); chain != nil {
+		t.Error("expected no filter chain matched")
+	}
+	if chain := mng.match(&connectionInfo{
+		destinationPort: 9090,
+		sourceIP:        net.ParseIP("10.0.0.1"),
+	}); chain != nil {
+		t.Error("expected no filter chain matched")
+	}
+}
+
+func TestFilterChainInvalidConfig(t *testing.T) {
+	for _, match := range []*v2.FilterChainMatch{
+		{PrefixRanges: []string{"10.0.0.0/33"}},
+		{SourcePrefixRanges: []string{"invalid"}},
+		{TransportProtocol: "quic"},
+	} {
+		lc := &v2.Listener{
+			ListenerConfig: v2.ListenerConfig{
+				FilterChains: []v2.FilterChain{filterChainWithMatch(match)},
+			},
+		}
+		if _, err := newFilterChainManager(lc); err == nil {
+			t.Errorf("filter chain match %+v expected an error", match)
+		}
+	}
+	if _, err := newFilterChainManager(&v2.Listener{}); err == nil {
+		t.Error("listener without filter chains expected an error")
+	}
+}
+
+func TestFilterChainStreamFilters(t *testing.T) {
+	withStreamFilters := filterChainWithMatch(&v2.FilterChainMatch{
+		ServerNames: []string{"www.mosn.io"},
+	})
+	withStreamFilters.StreamFilters = []v2.Filter{
+		{
+			Type: "mock_stream",
+		},
+	}
+	mng, err := newFilterChainManager(&v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			FilterChains: []v2.FilterChain{withStreamFilters, filterChainWithMatch(nil)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mng.releaseTLSContextManagers()
+	store := mng.chains[0].streamFiltersFactoriesStore
+	if store == nil {
+		t.Fatal("the filter chain with stream filters should have its own stream filters")
+	}
+	if factories, ok := store.Load().([]api.StreamFilterChainFactory); !ok || len(factories) != 1 {
+		t.Fatalf("unexpected stream filters of the filter chain: %v", store.Load())
+	}
+	// uses the listener's stream filters
+	if mng.chains[1].streamFiltersFactoriesStore != nil {
+		t.Fatal("the filter chain without stream filters should use the listener's")
+	}
+}
+
+func TestListenerFilterChainsByServerName(t *testing.T) {
+	setup()
+	defer tearDown()
+	addrStr := "127.0.0.1:8082"
+	name := "listener_server_names"
+	listenerConfig := baseListenerConfig(addrStr, name)
+	listenerConfig.FilterChains[0].Match = &v2.FilterChainMatch{
+		ServerNames: []string{"*.mosn.io"},
+	}
+	// a non tls filter chain
+	listenerConfig.FilterChains = append(listenerConfig.FilterChains, filterChainWithMatch(&v2.FilterChainMatch{
+		TransportProtocol: v2.TransportProtocolRawBuffer,
+	}))
+	if err := GetListenerAdapterInstance().AddOrUpdateListener(testServerName, listenerConfig); err != nil {
+		t.Fatalf("add a new listener failed %v", err)
+	}
+	time.Sleep(time.Second) // wait listener start
+	dialer := &net.Dialer{
+		Timeout: time.Second,
+	}
+	if conn, err := tls.DialWithDialer(dialer, "tcp", addrStr, &tls.Config{
+		ServerName:         "www.mosn.io",
+		InsecureSkipVerify: true,
+	}); err != nil {
+		t.Fatalf("dial tls failed: %v", err)
+	} else {
+		conn.Close()
+	}
+	// no filter chain matched, the connection is closed
+	if conn, err := tls.DialWithDialer(dialer, "tcp", addrStr, &tls.Config{
+		ServerName:         "www.example.com",
+		InsecureSkipVerify: true,
+	}); err == nil {
+		conn.Close()
+		t.Fatal("dial tls with unmatched server name should be failed")
+	}
+	// the plain connection is accepted by the raw buffer filter chain
+	conn, err := net.DialTimeout("tcp", addrStr, time.Second)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("plain"))
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	// the mock network filter does not response, the connection should be kept
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("unexpected response")
+	} else if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("the plain connection should not be closed: %v", err)
+	}
+}
+
+type mockUDPFilterFactory struct {
+	created int
+}
+
+func (f *mockUDPFilterFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+}
+
+func (f *mockUDPFilterFactory) CreateUDPListenerFilter(ctx context.Context, cb types.UDPListenerFilterCallbacks) types.UDPListenerFilter {
+	f.created++
+	return &mockUDPFilter{}
+}
+
+type mockUDPFilter struct {
+	closed bool
+}
+
+func (f *mockUDPFilter) OnData(data []byte, remoteAddr net.Addr) {}
+
+func (f *mockUDPFilter) OnClose() {
+	f.closed = true
+}
+
+func TestCloseUDPListenerFilters(t *testing.T) {
+	factory := &mockUDPFilterFactory{}
+	chain := &activeFilterChain{
+		networkFiltersFactories: []api.NetworkFilterChainFactory{factory},
+	}
+	mng := &filterChainManager{
+		chains: []*activeFilterChain{chain},
+	}
+	filters := chain.udpListenerFilters(context.Background(), nil)
+	if len(filters) != 1 || factory.created != 1 {
+		t.Fatalf("expected 1 udp listener filter, but got %d", len(filters))
+	}
+	// the filters are created once
+	chain.udpListenerFilters(context.Background(), nil)
+	if factory.created != 1 {
+		t.Fatalf("udp listener filters should be created once, but created %d", factory.created)
+	}
+	mng.closeUDPListenerFilters()
+	if !filters[0].(*mockUDPFilter).closed {
+		t.Fatal("udp listener filter should be closed")
+	}
+	// the closed filter chain cannot create the filters again
+	if filters := chain.udpListenerFilters(context.Background(), nil); len(filters) != 0 || factory.created != 1 {
+		t.Fatalf("closed filter chain should not create udp listener filters, created %d", factory.created)
+	}
+}
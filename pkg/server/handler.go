@@ -105,17 +105,22 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener) (types.ListenerEvent
 	} else {
 		listenerName = lc.Name
 	}
-	// currently, we just support one filter chain
-	if len(lc.FilterChains) != 1 {
-		return nil, errors.New("error updating listener, listener have filter chains count is not 1")
-	}
-	// set listener filter , network filter and stream filter
+	// set listener filter , filter chains and stream filter
 	var listenerFiltersFactories []api.ListenerFilterChainFactory
-	var networkFiltersFactories []api.NetworkFilterChainFactory
 	var streamFiltersFactories []api.StreamFilterChainFactory
 	listenerFiltersFactories = configmanager.GetListenerFilters(lc.ListenerFilters)
-	networkFiltersFactories = configmanager.GetNetworkFilters(&lc.FilterChains[0])
 	streamFiltersFactories = configmanager.GetStreamFilters(lc.StreamFilters)
+	// filter chains contains the network filters and tls context
+	filterChains, err := newFilterChainManager(lc)
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] [conn handler] create filter chains failed, %v", err)
+		return nil, err
+	}
+	als, err := newListenerAccessLogs(lc)
+	if err != nil {
+		filterChains.releaseTLSContextManagers()
+		return nil, err
+	}
 
 	var al *activeListener
 	if al = ch.findActiveListenerByName(listenerName); al != nil {
@@ -124,6 +129,10 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener) (types.ListenerEvent
 		// a listener with the same name must have the same configured address
 		if al.listener.Addr().String() != lc.Addr.String() ||
 			al.listener.Addr().Network() != lc.Addr.Network() {
+			filterChains.releaseTLSContextManagers()
+			for _, accessLog := range als {
+				log.CloseAccessLog(accessLog)
+			}
 			return nil, errors.New("error updating listener, listen address and listen name doesn't match")
 		}
 
@@ -132,26 +141,22 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener) (types.ListenerEvent
 
 		al.listenerFiltersFactories = listenerFiltersFactories
 		rawConfig.ListenerFilters = lc.ListenerFilters
-		al.networkFiltersFactories = networkFiltersFactories
-		rawConfig.FilterChains[0].FilterChainMatch = lc.FilterChains[0].FilterChainMatch
-		rawConfig.FilterChains[0].Filters = lc.FilterChains[0].Filters
 
 		al.streamFiltersFactoriesStore.Store(streamFiltersFactories)
 		rawConfig.StreamFilters = lc.StreamFilters
 
-		// tls update only take effects on new connections
+		// filter chains and tls update only take effects on new connections
 		// config changed
-		rawConfig.FilterChains[0].TLSContexts = lc.FilterChains[0].TLSContexts
-		rawConfig.FilterChains[0].TLSConfig = lc.FilterChains[0].TLSConfig
-		rawConfig.FilterChains[0].TLSConfigs = lc.FilterChains[0].TLSConfigs
+		rawConfig.FilterChains = lc.FilterChains
 		rawConfig.Inspector = lc.Inspector
-		mgr, err := mtls.NewTLSServerContextManager(rawConfig)
-		if err != nil {
-			log.DefaultLogger.Errorf("[server] [conn handler] [update listener] create tls context manager failed, %v", err)
-			return nil, err
-		}
 		// object changed
-		al.tlsMng = mgr
+		oldFilterChains := al.filterChainsStore.Load().(*filterChainManager)
+		al.filterChainsStore.Store(filterChains)
+		oldFilterChains.closeUDPListenerFilters()
+		oldFilterChains.releaseTLSContextManagers()
+		// the access logs are replaced, the old access logs are closed
+		rawConfig.AccessLogs = lc.AccessLogs
+		al.setAccessLogs(als)
 		// some simle config update
 		rawConfig.PerConnBufferLimitBytes = lc.PerConnBufferLimitBytes
 		al.listener.SetPerConnBufferLimitBytes(lc.PerConnBufferLimitBytes)
@@ -172,30 +177,9 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener) (types.ListenerEvent
 		//TODO: connection level stop-chan usage confirm
 		listenerStopChan := make(chan struct{})
 
-		//initialize access log
-		var als []api.AccessLog
-
-		for _, alConfig := range lc.AccessLogs {
-
-			//use default listener access log path
-			if alConfig.Path == "" {
-				alConfig.Path = types.MosnLogBasePath + string(os.PathSeparator) + lc.Name + "_access.log"
-			}
-
-			if al, err := log.NewAccessLog(alConfig.Path, alConfig.Format); err == nil {
-				als = append(als, al)
-			} else {
-				return nil, fmt.Errorf("initialize listener access logger %s failed: %v", alConfig.Path, err.Error())
-			}
-		}
-
 		l := network.NewListener(lc)
 
-		var err error
-		al, err = newActiveListener(l, lc, als, listenerFiltersFactories, networkFiltersFactories, streamFiltersFactories, ch, listenerStopChan)
-		if err != nil {
-			return al, err
-		}
+		al = newActiveListener(l, lc, als, listenerFiltersFactories, filterChains, streamFiltersFactories, ch, listenerStopChan)
 		l.SetListenerCallbacks(al)
 		ch.listeners = append(ch.listeners, al)
 		log.DefaultLogger.Infof("[server] [conn handler] [add listener] add listener: %s", lc.Addr.String())
@@ -205,6 +189,28 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener) (types.ListenerEvent
 	return al, nil
 }
 
+// newListenerAccessLogs creates the access logs of the listener,
+// the created access logs are closed if any of them is failed
+func newListenerAccessLogs(lc *v2.Listener) ([]api.AccessLog, error) {
+	var als []api.AccessLog
+	for _, alConfig := range lc.AccessLogs {
+		//use default listener access log path
+		if alConfig.Path == "" {
+			alConfig.Path = types.MosnLogBasePath + string(os.PathSeparator) + lc.Name + "_access.log"
+		}
+
+		if al, err := log.NewAccessLogWithConfig(&alConfig); err == nil {
+			als = append(als, al)
+		} else {
+			for _, accessLog := range als {
+				log.CloseAccessLog(accessLog)
+			}
+			return nil, fmt.Errorf("initialize listener access logger %s failed: %v", alConfig.Path, err.Error())
+		}
+	}
+	return als, nil
+}
+
 func (ch *connHandler) StartListener(lctx context.Context, listenerTag uint64) {
 	for _, l := range ch.listeners {
 		if l.listener.ListenerTag() == listenerTag {
@@ -246,6 +252,9 @@ func (ch *connHandler) RemoveListeners(name string) {
 		if l.listener.Name() == name {
 			log.DefaultLogger.Infof("[server] [conn handler] remove listener name: %s", name)
 			ch.listeners = append(ch.listeners[:i], ch.listeners[i+1:]...)
+			// the access logs are closed even if the listener is never started
+			l.setAccessLogs(nil)
+			break
 		}
 	}
 }
@@ -332,7 +341,7 @@ func (ch *connHandler) StopConnection() {
 type activeListener struct {
 	listener                    types.Listener
 	listenerFiltersFactories    []api.ListenerFilterChainFactory
-	networkFiltersFactories     []api.NetworkFilterChainFactory
+	filterChainsStore           atomic.Value // store *filterChainManager
 	streamFiltersFactoriesStore atomic.Value // store []api.StreamFilterChainFactory
 	listenIP                    string
 	listenPort                  int
@@ -341,28 +350,28 @@ type activeListener struct {
 	handler                     *connHandler
 	stopChan                    chan struct{}
 	stats                       *listenerStats
-	accessLogs                  []api.AccessLog
+	accessLogsStore             atomic.Value // store []api.AccessLog
+	accessLogsMux               sync.Mutex
 	updatedLabel                bool
 	idleTimeout                 *api.DurationConfig
-	tlsMng                      types.TLSContextManager
 }
 
 func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers []api.AccessLog,
 	listenerFiltersFactories []api.ListenerFilterChainFactory,
-	networkFiltersFactories []api.NetworkFilterChainFactory, streamFiltersFactories []api.StreamFilterChainFactory,
-	handler *connHandler, stopChan chan struct{}) (*activeListener, error) {
+	filterChains *filterChainManager, streamFiltersFactories []api.StreamFilterChainFactory,
+	handler *connHandler, stopChan chan struct{}) *activeListener {
 	al := &activeListener{
 		listener:                 listener,
 		conns:                    list.New(),
 		handler:                  handler,
 		stopChan:                 stopChan,
-		accessLogs:               accessLoggers,
 		updatedLabel:             false,
 		idleTimeout:              lc.ConnectionIdleTimeout,
-		networkFiltersFactories:  networkFiltersFactories,
 		listenerFiltersFactories: listenerFiltersFactories,
 	}
+	al.filterChainsStore.Store(filterChains)
 	al.streamFiltersFactoriesStore.Store(streamFiltersFactories)
+	al.accessLogsStore.Store(accessLoggers)
 
 	listenPort := 0
 	var listenIP string
@@ -377,14 +386,7 @@ func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers [
 	al.listenPort = listenPort
 	al.stats = newListenerStats(al.listener.Name())
 
-	mgr, err := mtls.NewTLSServerContextManager(lc)
-	if err != nil {
-		log.DefaultLogger.Errorf("[server] [new listener] create tls context manager failed, %v", err)
-		return nil, err
-	}
-	al.tlsMng = mgr
-
-	return al, nil
+	return al
 }
 
 func (al *activeListener) GoStart(lctx context.Context) {
@@ -400,25 +402,17 @@ func (al *activeListener) GoStart(lctx context.Context) {
 func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemoteAddr net.Addr, ch chan api.Connection, buf []byte) {
 	var rawf *os.File
 
-	// only store fd and tls conn handshake in final working listener
+	// only store fd in final working listener
+	// the tls conn handshake is setted when the filter chain is chosen
 	if !useOriginalDst {
 		if network.UseNetpollMode {
 			// store fd for further usage
-			if tc, ok := rawc.(*net.TCPConn); ok {
-				rawf, _ = tc.File()
-			}
-		}
-		// if ch is not nil, the conn has been initialized in func transferNewConn
-		if al.tlsMng != nil && ch == nil {
-			conn, err := al.tlsMng.Conn(rawc)
-			if err != nil {
-				if log.DefaultLogger.GetLogLevel() >= log.INFO {
-					log.DefaultLogger.Infof("[server] [listener] accept connection failed, error: %v", err)
-				}
-				rawc.Close()
-				return
+			switch c := rawc.(type) {
+			case *net.TCPConn:
+				rawf, _ = c.File()
+			case *net.UnixConn:
+				rawf, _ = c.File()
 			}
-			rawc = conn
 		}
 	}
 
@@ -438,9 +432,8 @@ func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemote
 	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyListenerPort, al.listenPort)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerType, al.listener.Config().Type)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerName, al.listener.Name())
-	ctx = mosnctx.WithValue(ctx, types.ContextKeyNetworkFilterChainFactories, al.networkFiltersFactories)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyStreamFilterChainFactories, &al.streamFiltersFactoriesStore)
-	ctx = mosnctx.WithValue(ctx, types.ContextKeyAccessLogs, al.accessLogs)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyAccessLogs, al.accessLogs())
 	if rawf != nil {
 		ctx = mosnctx.WithValue(ctx, types.ContextKeyConnectionFd, rawf)
 	}
@@ -460,8 +453,10 @@ func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemote
 func (al *activeListener) OnNewConnection(ctx context.Context, conn api.Connection) {
 	//Register Proxy's Filter
 	filterManager := conn.FilterManager()
-	for _, nfcf := range al.networkFiltersFactories {
-		nfcf.CreateFilterChain(ctx, filterManager)
+	if factories, ok := mosnctx.Get(ctx, types.ContextKeyNetworkFilterChainFactories).([]api.NetworkFilterChainFactory); ok {
+		for _, nfcf := range factories {
+			nfcf.CreateFilterChain(ctx, filterManager)
+		}
 	}
 	filterManager.InitializeReadFilters()
 
@@ -488,7 +483,62 @@ func (al *activeListener) OnNewConnection(ctx context.Context, conn api.Connecti
 	conn.Start(ctx)
 }
 
-func (al *activeListener) OnClose() {}
+func (al *activeListener) OnClose() {
+	filterChains := al.filterChainsStore.Load().(*filterChainManager)
+	filterChains.closeUDPListenerFilters()
+	filterChains.releaseTLSContextManagers()
+	al.setAccessLogs(nil)
+}
+
+func (al *activeListener) accessLogs() []api.AccessLog {
+	als, _ := al.accessLogsStore.Load().([]api.AccessLog)
+	return als
+}
+
+// setAccessLogs replaces the access logs of the new connections, and closes the old access logs
+func (al *activeListener) setAccessLogs(als []api.AccessLog) {
+	al.accessLogsMux.Lock()
+	old := al.accessLogs()
+	al.accessLogsStore.Store(als)
+	al.accessLogsMux.Unlock()
+	for _, accessLog := range old {
+		log.CloseAccessLog(accessLog)
+	}
+}
+
+// OnDatagram handles the datagrams received by udp listener
+func (al *activeListener) OnDatagram(data []byte, remoteAddr net.Addr) {
+	cb, ok := al.listener.(types.UDPListenerFilterCallbacks)
+	if !ok {
+		return
+	}
+	filterChains := al.filterChainsStore.Load().(*filterChainManager)
+	info := &connectionInfo{
+		transportProtocol: v2.TransportProtocolRawBuffer,
+	}
+	info.destinationIP, info.destinationPort = addrIPPort(al.listener.Addr())
+	info.sourceIP, _ = addrIPPort(remoteAddr)
+	chain := filterChains.match(info)
+	if chain == nil {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[server] [listener] no filter chain matched, drop datagram from %s", remoteAddr)
+		}
+		return
+	}
+	filters := chain.udpListenerFilters(al.listenerContext(), cb)
+	for _, f := range filters {
+		f.OnData(data, remoteAddr)
+	}
+}
+
+// listenerContext returns the context with listener's information
+func (al *activeListener) listenerContext() context.Context {
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyListenerPort, al.listenPort)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerType, al.listener.Config().Type)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerName, al.listener.Name())
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyAccessLogs, al.accessLogs())
+	return ctx
+}
 
 func (al *activeListener) removeConnection(ac *activeConnection) {
 	al.connsMux.Lock()
@@ -504,6 +554,19 @@ func (al *activeListener) removeConnection(ac *activeConnection) {
 var defaultIdleTimeout = types.DefaultIdleTimeout
 
 func (al *activeListener) newConnection(ctx context.Context, rawc net.Conn) {
+	rawc, chain, err := al.chooseFilterChain(ctx, rawc)
+	if err != nil {
+		if log.DefaultLogger.GetLogLevel() >= log.INFO {
+			log.DefaultLogger.Infof("[server] [listener] accept connection failed, error: %v", err)
+		}
+		rawc.Close()
+		return
+	}
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyNetworkFilterChainFactories, chain.networkFiltersFactories)
+	if chain.streamFiltersFactoriesStore != nil {
+		ctx = mosnctx.WithValue(ctx, types.ContextKeyStreamFilterChainFactories, chain.streamFiltersFactoriesStore)
+	}
+
 	conn := network.NewServerConnection(ctx, rawc, al.stopChan)
 	if al.idleTimeout != nil {
 		conn.SetIdleTimeout(al.idleTimeout.Duration)
@@ -523,6 +586,59 @@ func (al *activeListener) newConnection(ctx context.Context, rawc net.Conn) {
 	al.OnNewConnection(newCtx, conn)
 }
 
+// chooseFilterChain returns the filter chain matched the connection, and the connection is wrapped
+// by the tls context of the filter chain. the tls client hello is peeked only if it is required by
+// the filter chains, notice that the server-first protocols will wait the peek until timeout.
+func (al *activeListener) chooseFilterChain(ctx context.Context, rawc net.Conn) (net.Conn, *activeFilterChain, error) {
+	filterChains := al.filterChainsStore.Load().(*filterChainManager)
+	info := &connectionInfo{}
+	if oriRemoteAddr, ok := mosnctx.Get(ctx, types.ContextOriRemoteAddr).(net.Addr); ok {
+		info.destinationIP, info.destinationPort = addrIPPort(oriRemoteAddr)
+	} else {
+		info.destinationIP, info.destinationPort = addrIPPort(rawc.LocalAddr())
+	}
+	info.sourceIP, _ = addrIPPort(rawc.RemoteAddr())
+	// if the conn is transferred, the conn has been initialized in func transferNewConn
+	transferred := mosnctx.Get(ctx, types.ContextKeyAcceptChan) != nil
+	switch c := rawc.(type) {
+	case *mtls.TLSConn:
+		state := c.ConnectionState()
+		info.transportProtocol = v2.TransportProtocolTLS
+		info.serverName = state.ServerName
+		if state.NegotiatedProtocol != "" {
+			info.applicationProtocols = []string{state.NegotiatedProtocol}
+		}
+	case *net.TCPConn, *net.UnixConn:
+		if filterChains.inspect && !transferred {
+			conn := &mtls.Conn{Conn: c}
+			hello, err := conn.PeekClientHello()
+			if err != nil {
+				return rawc, nil, err
+			}
+			rawc = conn
+			if hello != nil {
+				info.transportProtocol = v2.TransportProtocolTLS
+				info.serverName = hello.ServerName
+				info.applicationProtocols = hello.ALPN
+			} else {
+				info.transportProtocol = v2.TransportProtocolRawBuffer
+			}
+		}
+	}
+	chain := filterChains.match(info)
+	if chain == nil {
+		return rawc, nil, fmt.Errorf("no filter chain matched, remote addr: %s", rawc.RemoteAddr())
+	}
+	if chain.tlsMng != nil && !transferred {
+		conn, err := chain.tlsMng.Conn(rawc)
+		if err != nil {
+			return rawc, nil, err
+		}
+		rawc = conn
+	}
+	return rawc, chain, nil
+}
+
 type activeRawConn struct {
 	rawc                net.Conn
 	rawf                *os.File
@@ -733,7 +849,8 @@ func sendInheritListeners() (net.Conn, error) {
 	return uc, nil
 }
 
-func GetInheritListeners() ([]net.Listener, net.Conn, error) {
+// GetInheritListeners returns the tcp or unix listeners and the udp listeners inherited from the old process
+func GetInheritListeners() ([]net.Listener, []net.PacketConn, net.Conn, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.StartLogger.Errorf("[server] getInheritListeners panic %v", r)
@@ -741,7 +858,7 @@ func GetInheritListeners() ([]net.Listener, net.Conn, error) {
 	}()
 
 	if !isReconfigure() {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 
 	syscall.Unlink(types.TransferListenDomainSocket)
@@ -749,7 +866,7 @@ func GetInheritListeners() ([]net.Listener, net.Conn, error) {
 	l, err := net.Listen("unix", types.TransferListenDomainSocket)
 	if err != nil {
 		log.StartLogger.Errorf("[server] InheritListeners net listen error: %v", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer l.Close()
 
@@ -760,7 +877,7 @@ func GetInheritListeners() ([]net.Listener, net.Conn, error) {
 	uc, err := ul.AcceptUnix()
 	if err != nil {
 		log.StartLogger.Errorf("[server] InheritListeners Accept error :%v", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	log.StartLogger.Infof("[server] Get InheritListeners Accept")
 
@@ -768,45 +885,55 @@ func GetInheritListeners() ([]net.Listener, net.Conn, error) {
 	oob := make([]byte, 1024)
 	_, oobn, _, _, err := uc.ReadMsgUnix(buf, oob)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	scms, err := unix.ParseSocketControlMessage(oob[0:oobn])
 	if err != nil {
 		log.StartLogger.Errorf("[server] ParseSocketControlMessage: %v", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if len(scms) != 1 {
 		log.StartLogger.Errorf("[server] expected 1 SocketControlMessage; got scms = %#v", scms)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	gotFds, err := unix.ParseUnixRights(&scms[0])
 	if err != nil {
 		log.StartLogger.Errorf("[server] unix.ParseUnixRights: %v", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	listeners := make([]net.Listener, len(gotFds))
+	var listeners []net.Listener
+	var packetConns []net.PacketConn
 	for i := 0; i < len(gotFds); i++ {
 		fd := uintptr(gotFds[i])
 		file := os.NewFile(fd, "")
 		if file == nil {
 			log.StartLogger.Errorf("[server] create new file from fd %d failed", fd)
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		defer file.Close()
 
+		// the udp listener's fd is a packet conn
+		if packetConn, err := net.FilePacketConn(file); err == nil {
+			if _, ok := packetConn.(*net.UDPConn); ok {
+				packetConns = append(packetConns, packetConn)
+				continue
+			}
+			packetConn.Close()
+		}
 		fileListener, err := net.FileListener(file)
 		if err != nil {
 			log.StartLogger.Errorf("[server] recover listener from fd %d failed: %s", fd, err)
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
-		if listener, ok := fileListener.(*net.TCPListener); ok {
-			listeners[i] = listener
-		} else {
-			log.StartLogger.Errorf("[server] listener recovered from fd %d is not a tcp listener", fd)
-			return nil, nil, errors.New("not a tcp listener")
+		switch listener := fileListener.(type) {
+		case *net.TCPListener, *net.UnixListener:
+			listeners = append(listeners, listener)
+		default:
+			log.StartLogger.Errorf("[server] listener recovered from fd %d is not a tcp or unix listener", fd)
+			return nil, nil, nil, errors.New("not a tcp or unix listener")
 		}
 	}
 
-	return listeners, uc, nil
+	return listeners, packetConns, uc, nil
 }
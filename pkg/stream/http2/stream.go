@@ -27,6 +27,7 @@ import (
 	"net/url"
 	"strconv"
 	"sync"
+	"time"
 
 	"mosn.io/api"
 	mbuffer "mosn.io/mosn/pkg/buffer"
@@ -37,6 +38,7 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	mhttp2 "mosn.io/mosn/pkg/protocol/http2"
 	str "mosn.io/mosn/pkg/stream"
+	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 )
@@ -277,11 +279,6 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 	var stream *serverStream
 	// header
 	if h2s != nil {
-		stream, err = conn.onNewStreamDetect(mosnctx.Clone(ctx), h2s, endStream)
-		if err != nil {
-			conn.handleError(ctx, f, err)
-			return
-		}
 		header := mhttp2.NewReqHeader(h2s.Request)
 
 		scheme := "http"
@@ -305,6 +302,12 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 			header.Set(protocol.MosnHeaderQueryStringKey, h2s.Request.URL.RawQuery)
 		}
 
+		stream, err = conn.onNewStreamDetect(mosnctx.Clone(ctx), h2s, header, endStream)
+		if err != nil {
+			conn.handleError(ctx, f, err)
+			return
+		}
+
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(stream.ctx, "http2 server header: %d, %+v", id, h2s.Request.Header)
 		}
@@ -394,7 +397,7 @@ func (conn *serverStreamConnection) handleError(ctx context.Context, f http2.Fra
 	}
 }
 
-func (conn *serverStreamConnection) onNewStreamDetect(ctx context.Context, h2s *http2.MStream, endStream bool) (*serverStream, error) {
+func (conn *serverStreamConnection) onNewStreamDetect(ctx context.Context, h2s *http2.MStream, header *mhttp2.ReqHeader, endStream bool) (*serverStream, error) {
 	stream := &serverStream{}
 	stream.id = h2s.ID()
 	stream.ctx = mosnctx.WithValue(ctx, types.ContextKeyStreamID, stream.id)
@@ -407,7 +410,16 @@ func (conn *serverStreamConnection) onNewStreamDetect(ctx context.Context, h2s *
 	conn.streams[stream.id] = stream
 	conn.mutex.Unlock()
 
-	stream.receiver = conn.serverCallbacks.NewStreamDetect(stream.ctx, stream, nil)
+	var span types.Span
+	if trace.IsEnabled() {
+		tracer := trace.Tracer(protocol.HTTP2)
+		if tracer != nil {
+			span = tracer.Start(ctx, header, time.Now())
+		}
+		stream.ctx = conn.cm.InjectTrace(stream.ctx, span)
+	}
+
+	stream.receiver = conn.serverCallbacks.NewStreamDetect(stream.ctx, stream, span)
 
 	return stream, nil
 }
@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sync
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/pkg/utils"
+)
+
+// Batcher collects the items into batches in background, a batch is handled when it reaches the batch size
+// or the flush interval is passed. The items are put into a bounded queue, the new items are dropped if it is full.
+type Batcher struct {
+	queue     chan interface{}
+	batchSize int
+	interval  time.Duration
+	handler   func(batch []interface{})
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBatcher creates a batcher and starts it, the handler is called in the batcher's goroutine,
+// and the batch is not reused after the handler returns.
+func NewBatcher(queueSize, batchSize int, interval time.Duration, handler func(batch []interface{})) *Batcher {
+	b := &Batcher{
+		queue:     make(chan interface{}, queueSize),
+		batchSize: batchSize,
+		interval:  interval,
+		handler:   handler,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	utils.GoWithRecover(b.run, nil)
+	return b
+}
+
+// Add puts the item into the queue, returns false if the queue is full or the batcher is closed
+func (b *Batcher) Add(item interface{}) bool {
+	select {
+	case <-b.quit:
+		return false
+	default:
+	}
+	select {
+	case b.queue <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close handles the items in the queue and stops the batcher, it returns after the last batch is handled
+func (b *Batcher) Close() {
+	b.closeOnce.Do(func() {
+		close(b.quit)
+		<-b.done
+	})
+}
+
+// Retry calls fn until it succeeds, it is retried at most maxRetries times if the error is retryable.
+// the back off is doubled for each retry, and the waiting is stopped if the batcher is closing,
+// so the remaining items are not blocked by the retries. it returns the last error and the retried times.
+func (b *Batcher) Retry(maxRetries int, backOff time.Duration, fn func() (retryable bool, err error)) (int, error) {
+	for retried := 0; ; retried++ {
+		retryable, err := fn()
+		if err == nil || !retryable || retried >= maxRetries {
+			return retried, err
+		}
+		timer := time.NewTimer(backOff)
+		select {
+		case <-timer.C:
+		case <-b.quit:
+			timer.Stop()
+			return retried, err
+		}
+		backOff *= 2
+	}
+}
+
+func (b *Batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	batch := make([]interface{}, 0, b.batchSize)
+	add := func(item interface{}) {
+		batch = append(batch, item)
+		if len(batch) >= b.batchSize {
+			b.handler(batch)
+			batch = make([]interface{}, 0, b.batchSize)
+		}
+	}
+	flush := func() {
+		if len(batch) > 0 {
+			b.handler(batch)
+			batch = make([]interface{}, 0, b.batchSize)
+		}
+	}
+	for {
+		select {
+		case item := <-b.queue:
+			add(item)
+		case <-ticker.C:
+			flush()
+		case <-b.quit:
+			for {
+				select {
+				case item := <-b.queue:
+					add(item)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
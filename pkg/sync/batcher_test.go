@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sync
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchRecorder struct {
+	mutex   sync.Mutex
+	batches [][]interface{}
+}
+
+func (r *batchRecorder) handle(batch []interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.batches = append(r.batches, batch)
+}
+
+func (r *batchRecorder) sizes() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sizes := make([]int, 0, len(r.batches))
+	for _, batch := range r.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestBatcherBatchSize(t *testing.T) {
+	r := &batchRecorder{}
+	b := NewBatcher(10, 3, time.Hour, r.handle)
+	for i := 0; i < 7; i++ {
+		if !b.Add(i) {
+			t.Fatalf("add item %d failed", i)
+		}
+	}
+	// the remaining items are handled when the batcher is closed
+	b.Close()
+	sizes := r.sizes()
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Fatalf("unexpected batches: %v", sizes)
+	}
+	if b.Add(8) {
+		t.Fatal("add item into a closed batcher should be failed")
+	}
+	// close again is ok
+	b.Close()
+}
+
+func TestBatcherInterval(t *testing.T) {
+	r := &batchRecorder{}
+	b := NewBatcher(10, 100, 50*time.Millisecond, r.handle)
+	defer b.Close()
+	b.Add(1)
+	b.Add(2)
+	for i := 0; i < 20; i++ {
+		if sizes := r.sizes(); len(sizes) == 1 && sizes[0] == 2 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("unexpected batches: %v", r.sizes())
+}
+
+func TestBatcherQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	b := NewBatcher(1, 1, time.Hour, func(batch []interface{}) {
+		<-block
+	})
+	// the first item is handled and blocks the batcher, the second is in the queue
+	b.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	b.Add(2)
+	if b.Add(3) {
+		t.Fatal("add item into a full queue should be failed")
+	}
+	close(block)
+	b.Close()
+}
+
+func TestBatcherRetry(t *testing.T) {
+	b := NewBatcher(1, 1, time.Hour, func(batch []interface{}) {})
+	errRetry := errors.New("retry")
+	calls := 0
+	retried, err := b.Retry(3, time.Millisecond, func() (bool, error) {
+		calls++
+		if calls < 3 {
+			return true, errRetry
+		}
+		return true, nil
+	})
+	if err != nil || retried != 2 || calls != 3 {
+		t.Fatalf("unexpected retry result: %d, %v, calls %d", retried, err, calls)
+	}
+	// the error is not retryable
+	calls = 0
+	if retried, err := b.Retry(3, time.Millisecond, func() (bool, error) {
+		calls++
+		return false, errRetry
+	}); err != errRetry || retried != 0 || calls != 1 {
+		t.Fatalf("unexpected retry result: %d, %v, calls %d", retried, err, calls)
+	}
+	// the max retries is reached
+	calls = 0
+	if retried, err := b.Retry(2, time.Millisecond, func() (bool, error) {
+		calls++
+		return true, errRetry
+	}); err != errRetry || retried != 2 || calls != 3 {
+		t.Fatalf("unexpected retry result: %d, %v, calls %d", retried, err, calls)
+	}
+	// the retries are stopped when the batcher is closing
+	b.Close()
+	start := time.Now()
+	if _, err := b.Retry(3, time.Hour, func() (bool, error) {
+		return true, errRetry
+	}); err != errRetry || time.Since(start) > time.Second {
+		t.Fatalf("the retries should be stopped when the batcher is closed, error: %v", err)
+	}
+}
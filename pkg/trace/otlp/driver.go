@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/trace"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	OTLPDriverName = "OpenTelemetry"
+)
+
+var (
+	SampleRateCfgErr = errors.New("OpenTelemetry tracer sample_rate should be in the range [0, 1]")
+)
+
+func init() {
+	trace.RegisterDriver(OTLPDriverName, NewOTLPDriverImpl())
+	trace.RegisterTracerBuilder(OTLPDriverName, protocol.HTTP1, NewTracerBuilder(protocol.HTTP1))
+	trace.RegisterTracerBuilder(OTLPDriverName, protocol.HTTP2, NewTracerBuilder(protocol.HTTP2))
+	trace.RegisterTracerBuilder(OTLPDriverName, protocol.Xprotocol, NewTracerBuilder(protocol.Xprotocol))
+}
+
+type holder struct {
+	types.Tracer
+	types.TracerBuilder
+}
+
+// tracerInitializer is implemented by the tracers that need the shared config and exporter
+type tracerInitializer interface {
+	initialize(cfg *v2.OTLPTraceConfig, exporter *mosnotlp.Exporter)
+}
+
+type otlpDriver struct {
+	tracers  map[types.ProtocolName]*holder
+	exporter *mosnotlp.Exporter
+}
+
+func (d *otlpDriver) Init(config map[string]interface{}) error {
+	cfg, err := parseAndVerifyOTLPTracerConfig(config)
+	if err != nil {
+		return err
+	}
+	exporter, err := mosnotlp.NewTraceExporter(&cfg.OTLPExporterConfig)
+	if err != nil {
+		return err
+	}
+	for proto, holder := range d.tracers {
+		tracer, err := holder.TracerBuilder(config)
+		if err != nil {
+			exporter.Close()
+			return fmt.Errorf("build tracer for %v error, %s", proto, err)
+		}
+		if initializer, ok := tracer.(tracerInitializer); ok {
+			initializer.initialize(cfg, exporter)
+		}
+		holder.Tracer = tracer
+	}
+	// the spans of the former tracers are exported before the new exporter takes effect
+	if d.exporter != nil {
+		d.exporter.Close()
+	}
+	d.exporter = exporter
+	return nil
+}
+
+func (d *otlpDriver) Register(proto types.ProtocolName, builder types.TracerBuilder) {
+	d.tracers[proto] = &holder{
+		TracerBuilder: builder,
+	}
+}
+
+func (d *otlpDriver) Get(proto types.ProtocolName) types.Tracer {
+	if holder, ok := d.tracers[proto]; ok {
+		return holder.Tracer
+	}
+	return nil
+}
+
+func NewOTLPDriverImpl() types.Driver {
+	return &otlpDriver{
+		tracers: make(map[types.ProtocolName]*holder),
+	}
+}
+
+func parseAndVerifyOTLPTracerConfig(cfg map[string]interface{}) (*v2.OTLPTraceConfig, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.DefaultLogger.Debugf("[OpenTelemetry] [tracer] tracer config: %v", string(data))
+
+	config := &v2.OTLPTraceConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	// set default value
+	if config.SampleRate == nil {
+		rate := 1.0
+		config.SampleRate = &rate
+	} else if *config.SampleRate < 0 || *config.SampleRate > 1 {
+		return nil, SampleRateCfgErr
+	}
+	return config, nil
+}
@@ -0,0 +1,268 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/http2"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+// collector is a local otlp/http receiver
+type collector struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	resource *mosnotlp.Resource
+	spans    []*mosnotlp.Span
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &mosnotlp.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("unmarshal spans failed: %v", err)
+		}
+		c.mutex.Lock()
+		for _, rs := range req.ResourceSpans {
+			c.resource = rs.Resource
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		c.mutex.Unlock()
+	}))
+	return c
+}
+
+func (c *collector) Spans() []*mosnotlp.Span {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]*mosnotlp.Span{}, c.spans...)
+}
+
+func initTestDriver(t *testing.T, config map[string]interface{}) *otlpDriver {
+	driver := NewOTLPDriverImpl().(*otlpDriver)
+	for _, proto := range []types.ProtocolName{protocol.HTTP1, protocol.HTTP2, protocol.Xprotocol} {
+		driver.Register(proto, NewTracerBuilder(proto))
+	}
+	if err := driver.Init(config); err != nil {
+		t.Fatal(err)
+	}
+	return driver
+}
+
+func attribute(span *mosnotlp.Span, key string) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+func TestOTLPConfig(t *testing.T) {
+	for i, config := range []map[string]interface{}{
+		nil,
+		{"endpoint": "127.0.0.1:4317", "sample_rate": -1},
+		{"endpoint": "127.0.0.1:4317", "protocol": "unknown"},
+	} {
+		driver := NewOTLPDriverImpl()
+		if err := driver.Init(config); err == nil {
+			t.Errorf("#%d invalid config should be failed", i)
+		}
+	}
+	cfg, err := parseAndVerifyOTLPTracerConfig(map[string]interface{}{
+		"endpoint": "127.0.0.1:4317",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cfg.SampleRate != 1 || cfg.Endpoint != "127.0.0.1:4317" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestExtractSpanContext(t *testing.T) {
+	for i, tc := range []struct {
+		traceparent string
+		valid       bool
+	}{
+		{"00-" + testTraceID + "-" + testSpanID + "-01", true},
+		{"01-" + testTraceID + "-" + testSpanID + "-01-future", true},
+		{"00-" + testTraceID + "-" + testSpanID + "-01-future", false},
+		{"ff-" + testTraceID + "-" + testSpanID + "-01", false},
+		{"00-00000000000000000000000000000000-" + testSpanID + "-01", false},
+		{"00-" + testTraceID + "-0000000000000000-01", false},
+		{"00-" + testTraceID + "-" + testSpanID + "-0x", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01", false},
+		{"", false},
+	} {
+		header := protocol.CommonHeader{traceParentHeader: tc.traceparent}
+		sc := extractSpanContext(header)
+		if (sc.traceID != "") != tc.valid {
+			t.Errorf("#%d traceparent %s valid expected %v", i, tc.traceparent, tc.valid)
+		}
+	}
+}
+
+func TestOTLPTraceHTTP1(t *testing.T) {
+	c := newCollector(t)
+	defer c.Close()
+	driver := initTestDriver(t, map[string]interface{}{
+		"protocol":       "http",
+		"endpoint":       c.URL,
+		"service_name":   "test",
+		"batch_interval": "10ms",
+	})
+	header := mosnhttp.RequestHeader{RequestHeader: &fasthttp.RequestHeader{}}
+	header.SetRequestURI("/test")
+	header.SetMethod("GET")
+	header.Set(traceParentHeader, "00-"+testTraceID+"-"+testSpanID+"-01")
+	header.Set(traceStateHeader, "vendor=value")
+
+	span := driver.Get(protocol.HTTP1).Start(context.Background(), header, time.Now())
+	if span.TraceId() != testTraceID || span.ParentSpanId() != testSpanID {
+		t.Fatalf("trace context is not extracted: %s, %s", span.TraceId(), span.ParentSpanId())
+	}
+	requestInfo := network.NewRequestInfo()
+	requestInfo.SetUpstreamLocalAddress("127.0.0.1:8080")
+	span.InjectContext(header, requestInfo)
+	clientSpanID := span.(*otlpSpan).client.SpanId()
+	if v, _ := header.Get(traceParentHeader); v != "00-"+testTraceID+"-"+clientSpanID+"-01" {
+		t.Fatalf("unexpected traceparent: %s", v)
+	}
+	if v, _ := header.Get(traceStateHeader); v != "vendor=value" {
+		t.Fatalf("unexpected tracestate: %s", v)
+	}
+	requestInfo.SetResponseCode(503)
+	span.SetRequestInfo(requestInfo)
+	span.FinishSpan()
+
+	var spans []*mosnotlp.Span
+	for i := 0; i < 100; i++ {
+		if spans = c.Spans(); len(spans) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans exported, but got %d", len(spans))
+	}
+	client, server := spans[0], spans[1]
+	if client.Kind != mosnotlp.SpanKindClient || server.Kind != mosnotlp.SpanKindServer {
+		t.Fatalf("unexpected span kinds: %d, %d", client.Kind, server.Kind)
+	}
+	if hex.EncodeToString(client.SpanId) != clientSpanID || hex.EncodeToString(client.ParentSpanId) != span.SpanId() ||
+		hex.EncodeToString(server.ParentSpanId) != testSpanID || hex.EncodeToString(server.TraceId) != testTraceID {
+		t.Fatalf("unexpected span ids: client %v, server %v", client, server)
+	}
+	if server.Name != "GET" || attribute(server, attrHTTPTarget) != "/test" || attribute(server, attrHTTPStatusCode) != "503" ||
+		server.Status == nil || server.Status.Code != mosnotlp.StatusCodeError {
+		t.Fatalf("unexpected server span: %v", server)
+	}
+	if attribute(client, attrPeerAddress) != "127.0.0.1:8080" || server.TraceState != "vendor=value" {
+		t.Fatalf("unexpected client span: %v", client)
+	}
+	c.mutex.Lock()
+	if c.resource == nil || c.resource.Attributes[0].Value.GetStringValue() != "test" {
+		t.Errorf("unexpected resource: %v", c.resource)
+	}
+	c.mutex.Unlock()
+	driver.exporter.Close()
+}
+
+func TestOTLPTraceSampling(t *testing.T) {
+	c := newCollector(t)
+	defer c.Close()
+	driver := initTestDriver(t, map[string]interface{}{
+		"protocol":    "http",
+		"endpoint":    c.URL,
+		"sample_rate": 0,
+	})
+	tracer := driver.Get(protocol.HTTP2)
+	// not sampled by the sample rate
+	header := http2.NewReqHeader(&http.Request{Method: "POST", URL: &url.URL{Path: "/p"}, Header: http.Header{}})
+	span := tracer.Start(context.Background(), header, time.Now())
+	span.InjectContext(header, nil)
+	if v, _ := header.Get(traceParentHeader); v != "00-"+span.TraceId()+"-"+span.(*otlpSpan).client.SpanId()+"-00" {
+		t.Fatalf("unexpected traceparent: %s", v)
+	}
+	span.FinishSpan()
+	// the sampling decision of downstream is used
+	header = http2.NewReqHeader(&http.Request{Method: "POST", URL: &url.URL{Path: "/p"}, Header: http.Header{}})
+	header.Set(traceParentHeader, "00-"+testTraceID+"-"+testSpanID+"-01")
+	sampled := tracer.Start(context.Background(), header, time.Now())
+	sampled.FinishSpan()
+	// all of the spans are exported when closed
+	driver.exporter.Close()
+	spans := c.Spans()
+	if len(spans) != 1 || hex.EncodeToString(spans[0].SpanId) != sampled.SpanId() || attribute(spans[0], attrHTTPTarget) != "/p" {
+		t.Fatalf("only the sampled span should be exported, but got %v", spans)
+	}
+}
+
+func TestOTLPTraceXprotocol(t *testing.T) {
+	driver := initTestDriver(t, map[string]interface{}{
+		"endpoint": "127.0.0.1:4317",
+	})
+	defer driver.exporter.Close()
+	tracer := driver.Get(protocol.Xprotocol)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextSubProtocol, string(bolt.ProtocolName))
+	request := bolt.NewRpcRequest(1, protocol.CommonHeader{traceParentHeader: "00-" + testTraceID + "-" + testSpanID + "-00"}, nil)
+	span := tracer.Start(ctx, request, time.Now())
+	if span.TraceId() != testTraceID || span.ParentSpanId() != testSpanID {
+		t.Fatalf("trace context is not extracted: %s, %s", span.TraceId(), span.ParentSpanId())
+	}
+	if attr := span.(*otlpSpan).attributes[attrRPCSystem]; attr != string(bolt.ProtocolName) {
+		t.Fatalf("unexpected rpc system: %s", attr)
+	}
+	span.InjectContext(request.GetHeader(), nil)
+	if v, _ := request.Get(traceParentHeader); v != "00-"+testTraceID+"-"+span.(*otlpSpan).client.SpanId()+"-00" {
+		t.Fatalf("unexpected traceparent: %s", v)
+	}
+	// heartbeat is ignored
+	heartbeat := bolt.NewRpcRequest(2, nil, nil)
+	heartbeat.CmdCode = bolt.CmdCodeHeartbeat
+	if span := tracer.Start(ctx, heartbeat, time.Now()); span != nil {
+		t.Fatal("heartbeat should not be traced")
+	}
+}
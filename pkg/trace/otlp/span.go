@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"time"
+
+	"mosn.io/api"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+	"mosn.io/mosn/pkg/types"
+)
+
+// otlpSpan implements types.Span, the server span is created when the request is received,
+// and a client span is created when the request is sent to the upstream
+type otlpSpan struct {
+	tracer     *otlpTracer
+	traceID    string
+	spanID     string
+	parentID   string
+	sampled    bool
+	traceState string
+	name       string
+	kind       int32
+	startTime  time.Time
+	attributes map[string]string
+	rawTags    map[uint64]string
+	statusCode int32
+	// client is the span of the upstream request
+	client   *otlpSpan
+	finished bool
+}
+
+func (s *otlpSpan) TraceId() string {
+	return s.traceID
+}
+
+func (s *otlpSpan) SpanId() string {
+	return s.spanID
+}
+
+func (s *otlpSpan) ParentSpanId() string {
+	return s.parentID
+}
+
+func (s *otlpSpan) SetOperation(operation string) {
+	s.name = operation
+}
+
+func (s *otlpSpan) SetTag(key uint64, value string) {
+	if s.rawTags == nil {
+		s.rawTags = make(map[uint64]string)
+	}
+	s.rawTags[key] = value
+}
+
+func (s *otlpSpan) Tag(key uint64) string {
+	return s.rawTags[key]
+}
+
+func (s *otlpSpan) setAttribute(key, value string) {
+	if value == "" {
+		return
+	}
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+	s.attributes[key] = value
+}
+
+func (s *otlpSpan) SetRequestInfo(requestInfo api.RequestInfo) {
+	if requestInfo == nil {
+		return
+	}
+	code := requestInfo.ResponseCode()
+	if client := s.client; client != nil {
+		client.setResponseCode(code)
+		client.FinishSpan()
+	}
+	s.setResponseCode(code)
+}
+
+func (s *otlpSpan) setResponseCode(code int) {
+	if code == 0 {
+		return
+	}
+	s.setAttribute(s.tracer.statusCodeAttribute(), strconv.Itoa(code))
+	if code >= 500 {
+		s.statusCode = mosnotlp.StatusCodeError
+	}
+}
+
+func (s *otlpSpan) FinishSpan() {
+	if s.finished {
+		return
+	}
+	s.finished = true
+	// the client span is finished with the server span if no response is received
+	if s.client != nil {
+		s.client.FinishSpan()
+	}
+	if !s.sampled {
+		return
+	}
+	s.tracer.export(s.model(time.Now()))
+}
+
+func (s *otlpSpan) model(finishTime time.Time) *mosnotlp.Span {
+	span := &mosnotlp.Span{
+		Name:              s.name,
+		Kind:              s.kind,
+		TraceState:        s.traceState,
+		StartTimeUnixNano: uint64(s.startTime.UnixNano()),
+		EndTimeUnixNano:   uint64(finishTime.UnixNano()),
+	}
+	span.TraceId, _ = hex.DecodeString(s.traceID)
+	span.SpanId, _ = hex.DecodeString(s.spanID)
+	if s.parentID != "" {
+		span.ParentSpanId, _ = hex.DecodeString(s.parentID)
+	}
+	keys := make([]string, 0, len(s.attributes))
+	for k := range s.attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		span.Attributes = append(span.Attributes, mosnotlp.StringKeyValue(k, s.attributes[k]))
+	}
+	if s.statusCode != mosnotlp.StatusCodeUnset {
+		span.Status = &mosnotlp.Status{Code: s.statusCode}
+	}
+	return span
+}
+
+// InjectContext creates a client span for the upstream request, and injects its context into the request headers.
+// the former client span is finished if the request is retried
+func (s *otlpSpan) InjectContext(requestHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	if s.client != nil {
+		s.client.FinishSpan()
+	}
+	parent := spanContext{
+		traceID:    s.traceID,
+		spanID:     s.spanID,
+		sampled:    &s.sampled,
+		traceState: s.traceState,
+	}
+	client := s.tracer.newSpan(parent, s.name, mosnotlp.SpanKindClient, time.Now())
+	for k, v := range s.attributes {
+		if k != s.tracer.statusCodeAttribute() {
+			client.setAttribute(k, v)
+		}
+	}
+	if requestInfo != nil {
+		client.setAttribute(attrPeerAddress, requestInfo.UpstreamLocalAddress())
+	}
+	s.client = client
+	if requestHeaders != nil {
+		injectSpanContext(requestHeaders, client)
+	}
+}
+
+func (s *otlpSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
+	parent := spanContext{
+		traceID:    s.traceID,
+		spanID:     s.spanID,
+		sampled:    &s.sampled,
+		traceState: s.traceState,
+	}
+	return s.tracer.newSpan(parent, operationName, mosnotlp.SpanKindInternal, startTime)
+}
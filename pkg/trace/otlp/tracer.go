@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	mosnotlp "mosn.io/mosn/pkg/otlp"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/http2"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// W3C trace context headers, the lower case names are used as xprotocol headers are case sensitive
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// span attributes, which follow the OpenTelemetry semantic conventions
+const (
+	attrHTTPMethod     = "http.method"
+	attrHTTPTarget     = "http.target"
+	attrHTTPStatusCode = "http.status_code"
+	attrRPCSystem      = "rpc.system"
+	attrRPCService     = "rpc.service"
+	attrRPCMethod      = "rpc.method"
+	attrStatusCode     = "status_code"
+	attrPeerAddress    = "net.peer.name"
+)
+
+// otlpTracer implements types.Tracer for a protocol,
+// it is initialized by the driver with the config and the exporter shared by all protocols
+type otlpTracer struct {
+	protocol   types.ProtocolName
+	sampleRate float64
+	exporter   *mosnotlp.Exporter
+}
+
+// NewTracerBuilder returns a builder that creates the OpenTelemetry tracer of the protocol,
+// the supported protocols are http1, http2 and xprotocol
+func NewTracerBuilder(proto types.ProtocolName) types.TracerBuilder {
+	return func(_ map[string]interface{}) (types.Tracer, error) {
+		return &otlpTracer{
+			protocol: proto,
+		}, nil
+	}
+}
+
+func (t *otlpTracer) initialize(cfg *v2.OTLPTraceConfig, exporter *mosnotlp.Exporter) {
+	t.sampleRate = *cfg.SampleRate
+	t.exporter = exporter
+}
+
+func (t *otlpTracer) Start(ctx context.Context, request interface{}, startTime time.Time) types.Span {
+	var headers api.HeaderMap
+	attributes := map[string]string{}
+	name := string(t.protocol)
+	switch req := request.(type) {
+	case http.RequestHeader:
+		if req.RequestHeader == nil {
+			return nil
+		}
+		headers = req
+		name = string(req.Method())
+		attributes[attrHTTPMethod] = name
+		attributes[attrHTTPTarget] = string(req.RequestURI())
+	case *http2.ReqHeader:
+		if req.Req == nil {
+			return nil
+		}
+		headers = req
+		name = req.Req.Method
+		attributes[attrHTTPMethod] = name
+		if req.Req.URL != nil {
+			attributes[attrHTTPTarget] = req.Req.URL.RequestURI()
+		}
+	case xprotocol.XFrame:
+		if req.IsHeartbeatFrame() {
+			return nil
+		}
+		headers = req.GetHeader()
+		if subProtocol, ok := mosnctx.Get(ctx, types.ContextSubProtocol).(string); ok {
+			name = subProtocol
+			attributes[attrRPCSystem] = subProtocol
+		}
+		if aware, ok := req.(xprotocol.ServiceAware); ok {
+			attributes[attrRPCService] = aware.GetServiceName()
+			attributes[attrRPCMethod] = aware.GetMethodName()
+			if method := aware.GetMethodName(); method != "" {
+				name = method
+			}
+		}
+	default:
+		log.DefaultLogger.Debugf("[OpenTelemetry] [tracer] [%s] unable to get request header, downstream trace ignored", t.protocol)
+		return nil
+	}
+	parent := extractSpanContext(headers)
+	span := t.newSpan(parent, name, mosnotlp.SpanKindServer, startTime)
+	span.attributes = attributes
+	return span
+}
+
+// spanContext is the W3C trace context
+type spanContext struct {
+	traceID    string
+	spanID     string
+	sampled    *bool
+	traceState string
+}
+
+// extractSpanContext parses traceparent: {version}-{trace-id}-{parent-id}-{trace-flags},
+// the trace context is ignored if it is invalid
+func extractSpanContext(headers api.HeaderMap) (sc spanContext) {
+	value, _ := headers.Get(traceParentHeader)
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return sc
+	}
+	// the future versions may append more fields
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc
+	}
+	if !isHexID(parts[1], 32) || !isHexID(parts[2], 16) {
+		return sc
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return sc
+	}
+	sampled := flags&0x01 == 0x01
+	sc.traceID = parts[1]
+	sc.spanID = parts[2]
+	sc.sampled = &sampled
+	sc.traceState, _ = headers.Get(traceStateHeader)
+	return sc
+}
+
+func isHexID(id string, length int) bool {
+	if len(id) != length {
+		return false
+	}
+	zero := true
+	for _, c := range id {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+	// all zero id is invalid
+	return !zero
+}
+
+func injectSpanContext(headers api.HeaderMap, s *otlpSpan) {
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	headers.Set(traceParentHeader, "00-"+s.traceID+"-"+s.spanID+"-"+flags)
+	if s.traceState != "" {
+		headers.Set(traceStateHeader, s.traceState)
+	} else if _, ok := headers.Get(traceStateHeader); ok {
+		headers.Del(traceStateHeader)
+	}
+}
+
+func newTraceID() string {
+	return fmt.Sprintf("%016x%016x", rand.Uint64(), rand.Uint64())
+}
+
+func newSpanID() string {
+	id := rand.Uint64()
+	for id == 0 {
+		id = rand.Uint64()
+	}
+	return fmt.Sprintf("%016x", id)
+}
+
+// newSpan creates a child span of the parent, a new trace is started if the parent has no trace id
+func (t *otlpTracer) newSpan(parent spanContext, name string, kind int32, startTime time.Time) *otlpSpan {
+	s := &otlpSpan{
+		tracer:     t,
+		traceID:    parent.traceID,
+		spanID:     newSpanID(),
+		parentID:   parent.spanID,
+		traceState: parent.traceState,
+		name:       name,
+		kind:       kind,
+		startTime:  startTime,
+	}
+	if s.traceID == "" {
+		s.traceID = newTraceID()
+	}
+	if parent.sampled != nil {
+		s.sampled = *parent.sampled
+	} else {
+		s.sampled = t.sampleRate > 0 && rand.Float64() < t.sampleRate
+	}
+	return s
+}
+
+func (t *otlpTracer) statusCodeAttribute() string {
+	if t.protocol == protocol.HTTP1 || t.protocol == protocol.HTTP2 {
+		return attrHTTPStatusCode
+	}
+	return attrStatusCode
+}
+
+func (t *otlpTracer) export(span *mosnotlp.Span) {
+	if t.exporter != nil {
+		t.exporter.ExportSpan(span)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/trace"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	ZipkinDriverName = "Zipkin"
+
+	defaultBatchSize     = 100
+	defaultBatchInterval = time.Second
+	defaultQueueSize     = 1000
+	defaultReportTimeout = 5 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryBackOff  = time.Second
+)
+
+var (
+	ReporterEndpointCfgErr = errors.New("Zipkin tracer must configure the reporter_endpoint")
+	SampleRateCfgErr       = errors.New("Zipkin tracer sample_rate should be in the range [0, 1]")
+	PropagationCfgErr      = errors.New("Zipkin tracer propagation should be one of b3, b3_single and w3c")
+)
+
+func init() {
+	trace.RegisterDriver(ZipkinDriverName, NewZipkinDriverImpl())
+	trace.RegisterTracerBuilder(ZipkinDriverName, protocol.HTTP1, NewTracerBuilder(protocol.HTTP1))
+	trace.RegisterTracerBuilder(ZipkinDriverName, protocol.HTTP2, NewTracerBuilder(protocol.HTTP2))
+	trace.RegisterTracerBuilder(ZipkinDriverName, protocol.Xprotocol, NewTracerBuilder(protocol.Xprotocol))
+}
+
+type holder struct {
+	types.Tracer
+	types.TracerBuilder
+}
+
+// tracerInitializer is implemented by the tracers that need the shared config and reporter
+type tracerInitializer interface {
+	initialize(cfg *v2.ZipkinTraceConfig, r reporter)
+}
+
+type zipkinDriver struct {
+	tracers  map[types.ProtocolName]*holder
+	reporter reporter
+}
+
+func (d *zipkinDriver) Init(config map[string]interface{}) error {
+	cfg, err := parseAndVerifyZipkinTracerConfig(config)
+	if err != nil {
+		return err
+	}
+	r := newHTTPReporter(cfg)
+	for proto, holder := range d.tracers {
+		tracer, err := holder.TracerBuilder(config)
+		if err != nil {
+			r.Close()
+			return fmt.Errorf("build tracer for %v error, %s", proto, err)
+		}
+		if initializer, ok := tracer.(tracerInitializer); ok {
+			initializer.initialize(cfg, r)
+		}
+		holder.Tracer = tracer
+	}
+	// the spans of the former tracers are reported before the new reporter takes effect
+	if d.reporter != nil {
+		d.reporter.Close()
+	}
+	d.reporter = r
+	return nil
+}
+
+func (d *zipkinDriver) Register(proto types.ProtocolName, builder types.TracerBuilder) {
+	d.tracers[proto] = &holder{
+		TracerBuilder: builder,
+	}
+}
+
+func (d *zipkinDriver) Get(proto types.ProtocolName) types.Tracer {
+	if holder, ok := d.tracers[proto]; ok {
+		return holder.Tracer
+	}
+	return nil
+}
+
+func NewZipkinDriverImpl() types.Driver {
+	return &zipkinDriver{
+		tracers: make(map[types.ProtocolName]*holder),
+	}
+}
+
+func parseAndVerifyZipkinTracerConfig(cfg map[string]interface{}) (*v2.ZipkinTraceConfig, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.DefaultLogger.Debugf("[Zipkin] [tracer] tracer config: %v", string(data))
+
+	config := &v2.ZipkinTraceConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	if config.ReporterEndpoint == "" {
+		return nil, ReporterEndpointCfgErr
+	}
+	// set default value
+	if config.ServiceName == "" {
+		config.ServiceName = v2.DefaultServiceName
+	}
+	if config.SampleRate == nil {
+		rate := 1.0
+		config.SampleRate = &rate
+	} else if *config.SampleRate < 0 || *config.SampleRate > 1 {
+		return nil, SampleRateCfgErr
+	}
+	if len(config.Propagation) == 0 {
+		config.Propagation = []string{v2.ZipkinPropagationB3}
+	}
+	for _, format := range config.Propagation {
+		switch format {
+		case v2.ZipkinPropagationB3, v2.ZipkinPropagationB3Single, v2.ZipkinPropagationW3C:
+		default:
+			return nil, PropagationCfgErr
+		}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.BatchInterval.Duration <= 0 {
+		config.BatchInterval.Duration = defaultBatchInterval
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.Timeout.Duration <= 0 {
+		config.Timeout.Duration = defaultReportTimeout
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.RetryBackOff.Duration <= 0 {
+		config.RetryBackOff.Duration = defaultRetryBackOff
+	}
+	return config, nil
+}
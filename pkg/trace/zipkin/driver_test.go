@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/http2"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+)
+
+// collector is a local zipkin collector
+type collector struct {
+	*httptest.Server
+	mutex sync.Mutex
+	spans []*spanModel
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var spans []*spanModel
+		if err := json.Unmarshal(body, &spans); err != nil {
+			t.Errorf("unmarshal spans failed: %v", err)
+		}
+		c.mutex.Lock()
+		c.spans = append(c.spans, spans...)
+		c.mutex.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return c
+}
+
+func (c *collector) Spans() []*spanModel {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]*spanModel{}, c.spans...)
+}
+
+func initTestDriver(t *testing.T, config map[string]interface{}) *zipkinDriver {
+	driver := NewZipkinDriverImpl().(*zipkinDriver)
+	for _, proto := range []types.ProtocolName{protocol.HTTP1, protocol.HTTP2, protocol.Xprotocol} {
+		driver.Register(proto, NewTracerBuilder(proto))
+	}
+	if err := driver.Init(config); err != nil {
+		t.Fatal(err)
+	}
+	return driver
+}
+
+func TestZipkinConfig(t *testing.T) {
+	for i, config := range []map[string]interface{}{
+		nil,
+		{"reporter_endpoint": "http://127.0.0.1:9411/api/v2/spans", "sample_rate": 1.5},
+		{"reporter_endpoint": "http://127.0.0.1:9411/api/v2/spans", "propagation": []string{"unknown"}},
+	} {
+		if _, err := parseAndVerifyZipkinTracerConfig(config); err == nil {
+			t.Errorf("#%d invalid config should be failed", i)
+		}
+	}
+	cfg, err := parseAndVerifyZipkinTracerConfig(map[string]interface{}{
+		"reporter_endpoint": "http://127.0.0.1:9411/api/v2/spans",
+		"batch_interval":    "100ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServiceName != "mosn" || *cfg.SampleRate != 1 || len(cfg.Propagation) != 1 ||
+		cfg.BatchSize != defaultBatchSize || cfg.BatchInterval.Duration != 100*time.Millisecond {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestZipkinTraceHTTP1(t *testing.T) {
+	c := newCollector(t)
+	defer c.Close()
+	driver := initTestDriver(t, map[string]interface{}{
+		"service_name":      "test",
+		"reporter_endpoint": c.URL,
+		"propagation":       []string{"b3", "w3c"},
+		"batch_interval":    "10ms",
+	})
+	header := mosnhttp.RequestHeader{RequestHeader: &fasthttp.RequestHeader{}}
+	header.SetRequestURI("/test")
+	header.SetMethod("GET")
+	header.Set(traceParentHeader, "00-"+testTraceID+"-"+testSpanID+"-01")
+
+	span := driver.Get(protocol.HTTP1).Start(context.Background(), header, time.Now())
+	if span.TraceId() != testTraceID || span.ParentSpanId() != testSpanID {
+		t.Fatalf("trace context is not extracted: %s, %s", span.TraceId(), span.ParentSpanId())
+	}
+	if _, ok := header.Get(traceParentHeader); ok {
+		t.Fatal("the propagation headers of downstream should be removed")
+	}
+	requestInfo := network.NewRequestInfo()
+	requestInfo.SetUpstreamLocalAddress("127.0.0.1:8080")
+	requestInfo.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 12345})
+	span.InjectContext(header, requestInfo)
+	if v, _ := header.Get(b3TraceIDHeader); v != testTraceID {
+		t.Fatalf("b3 trace id expected %s, but got %s", testTraceID, v)
+	}
+	clientSpanID, _ := header.Get(b3SpanIDHeader)
+	if v, _ := header.Get(traceParentHeader); v != "00-"+testTraceID+"-"+clientSpanID+"-01" {
+		t.Fatalf("unexpected traceparent: %s", v)
+	}
+	requestInfo.SetResponseCode(503)
+	span.SetRequestInfo(requestInfo)
+	span.FinishSpan()
+
+	var spans []*spanModel
+	for i := 0; i < 100; i++ {
+		if spans = c.Spans(); len(spans) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans reported, but got %d", len(spans))
+	}
+	client, server := spans[0], spans[1]
+	if client.Kind != spanKindClient || server.Kind != spanKindServer {
+		t.Fatalf("unexpected span kinds: %s, %s", client.Kind, server.Kind)
+	}
+	if client.ID != clientSpanID || client.ParentID != server.ID || server.ParentID != testSpanID {
+		t.Fatalf("unexpected span ids: client %+v, server %+v", client, server)
+	}
+	if server.Name != "GET" || server.Tags[tagHTTPPath] != "/test" || server.Tags[tagHTTPStatusCode] != "503" || server.Tags[tagError] == "" {
+		t.Fatalf("unexpected server span: %+v", server)
+	}
+	if server.LocalEndpoint.ServiceName != "test" || server.RemoteEndpoint.IPv4 != "10.0.0.1" || client.RemoteEndpoint.Port != 8080 {
+		t.Fatalf("unexpected endpoints: %+v, %+v", server, client)
+	}
+	driver.reporter.Close()
+}
+
+func TestZipkinTraceSampling(t *testing.T) {
+	c := newCollector(t)
+	defer c.Close()
+	driver := initTestDriver(t, map[string]interface{}{
+		"reporter_endpoint": c.URL,
+		"sample_rate":       0,
+		"propagation":       []string{"b3_single"},
+	})
+	tracer := driver.Get(protocol.HTTP2)
+	// not sampled by the sample rate
+	header := http2.NewReqHeader(&http.Request{Method: "POST", URL: &url.URL{Path: "/p"}, Header: http.Header{}})
+	span := tracer.Start(context.Background(), header, time.Now())
+	span.InjectContext(header, nil)
+	if v, _ := header.Get(b3SingleHeader); v != span.TraceId()+"-"+span.(*zipkinSpan).client.SpanId()+"-0-"+span.SpanId() {
+		t.Fatalf("unexpected b3 header: %s", v)
+	}
+	span.FinishSpan()
+	// the sampling decision of downstream is used
+	header = http2.NewReqHeader(&http.Request{Method: "POST", URL: &url.URL{Path: "/p"}, Header: http.Header{}})
+	header.Set(b3SingleHeader, "1")
+	sampled := tracer.Start(context.Background(), header, time.Now())
+	sampled.FinishSpan()
+	// all of the spans are reported when closed
+	driver.reporter.Close()
+	spans := c.Spans()
+	if len(spans) != 1 || spans[0].ID != sampled.SpanId() || spans[0].Tags[tagHTTPPath] != "/p" {
+		t.Fatalf("only the sampled span should be reported, but got %v", spans)
+	}
+}
+
+func TestZipkinTraceXprotocol(t *testing.T) {
+	driver := initTestDriver(t, map[string]interface{}{
+		"reporter_endpoint": "http://127.0.0.1:9411/api/v2/spans",
+	})
+	defer driver.reporter.Close()
+	tracer := driver.Get(protocol.Xprotocol)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextSubProtocol, string(bolt.ProtocolName))
+	request := bolt.NewRpcRequest(1, protocol.CommonHeader{b3SingleHeader: testTraceID + "-" + testSpanID}, nil)
+	span := tracer.Start(ctx, request, time.Now())
+	if span.TraceId() != testTraceID || span.ParentSpanId() != testSpanID {
+		t.Fatalf("trace context is not extracted: %s, %s", span.TraceId(), span.ParentSpanId())
+	}
+	span.InjectContext(request.GetHeader(), nil)
+	if v, _ := request.Get(b3TraceIDHeader); v != testTraceID {
+		t.Fatalf("b3 trace id expected %s, but got %s", testTraceID, v)
+	}
+	// heartbeat is ignored
+	heartbeat := bolt.NewRpcRequest(2, nil, nil)
+	heartbeat.CmdCode = bolt.CmdCodeHeartbeat
+	if span := tracer.Start(ctx, heartbeat, time.Now()); span != nil {
+		t.Fatal("heartbeat should not be traced")
+	}
+}
+
+func TestZipkinReporterRetry(t *testing.T) {
+	var calls uint32
+	c := newCollector(t)
+	defer c.Close()
+	handler := c.Config.Handler
+	// the first request is failed with a retryable status
+	c.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddUint32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	cfg, err := parseAndVerifyZipkinTracerConfig(map[string]interface{}{
+		"reporter_endpoint": c.URL,
+		"batch_interval":    "10ms",
+		"retry_back_off":    "10ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != defaultMaxRetries {
+		t.Fatalf("unexpected max retries: %d", cfg.MaxRetries)
+	}
+	r := newHTTPReporter(cfg)
+	defer r.Close()
+	r.Report(&spanModel{TraceID: testTraceID, ID: testSpanID})
+	for i := 0; i < 100 && len(c.Spans()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if spans := c.Spans(); len(spans) != 1 || spans[0].ID != testSpanID {
+		t.Fatalf("the span should be reported after retry: %+v", spans)
+	}
+	if n := atomic.LoadUint32(&calls); n != 2 {
+		t.Fatalf("expected 2 report requests, but got %d", n)
+	}
+}
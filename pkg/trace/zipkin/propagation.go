@@ -0,0 +1,267 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+// propagation headers, the lower case names are used as xprotocol headers are case sensitive
+const (
+	b3TraceIDHeader      = "x-b3-traceid"
+	b3SpanIDHeader       = "x-b3-spanid"
+	b3ParentSpanIDHeader = "x-b3-parentspanid"
+	b3SampledHeader      = "x-b3-sampled"
+	b3FlagsHeader        = "x-b3-flags"
+	b3SingleHeader       = "b3"
+	traceParentHeader    = "traceparent"
+	traceStateHeader     = "tracestate"
+)
+
+var propagationHeaders = []string{
+	b3TraceIDHeader, b3SpanIDHeader, b3ParentSpanIDHeader, b3SampledHeader, b3FlagsHeader,
+	b3SingleHeader, traceParentHeader, traceStateHeader,
+}
+
+// spanContext is the trace context propagated between the services
+type spanContext struct {
+	traceID  string
+	spanID   string
+	parentID string
+	// sampled is nil if the sampling decision is not made
+	sampled *bool
+	debug   bool
+	// traceState is the W3C tracestate, which is propagated as it is
+	traceState string
+}
+
+func newTraceID() string {
+	return fmt.Sprintf("%016x%016x", rand.Uint64(), rand.Uint64())
+}
+
+func newSpanID() string {
+	id := rand.Uint64()
+	for id == 0 {
+		id = rand.Uint64()
+	}
+	return fmt.Sprintf("%016x", id)
+}
+
+func getHeader(headers api.HeaderMap, key string) string {
+	// some header maps return ok even if the header does not exist, so the empty value is ignored
+	value, _ := headers.Get(key)
+	return strings.TrimSpace(value)
+}
+
+func isHexID(id string, lengths ...int) bool {
+	valid := false
+	for _, l := range lengths {
+		if len(id) == l {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return false
+	}
+	zero := true
+	for _, c := range id {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+	// all zero id is invalid
+	return !zero
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// extractSpanContext extracts the trace context from the headers, the W3C trace context is preferred,
+// and then the B3 single header and the B3 multiple headers.
+// ok is false if neither a valid trace context nor a sampling decision is found
+func extractSpanContext(headers api.HeaderMap) (sc spanContext, ok bool) {
+	if headers == nil {
+		return sc, false
+	}
+	if sc, ok = extractW3C(headers); ok {
+		return sc, true
+	}
+	if sc, ok = extractB3Single(headers); ok {
+		return sc, true
+	}
+	return extractB3(headers)
+}
+
+// extractW3C parses traceparent: {version}-{trace-id}-{parent-id}-{trace-flags}
+func extractW3C(headers api.HeaderMap) (sc spanContext, ok bool) {
+	parts := strings.Split(getHeader(headers, traceParentHeader), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return sc, false
+	}
+	// the future versions may append more fields
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if !isHexID(parts[1], 32) || !isHexID(parts[2], 16) {
+		return sc, false
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return sc, false
+	}
+	sc.traceID = parts[1]
+	sc.spanID = parts[2]
+	sc.sampled = boolPtr(flags&0x01 == 0x01)
+	sc.traceState = getHeader(headers, traceStateHeader)
+	return sc, true
+}
+
+// extractB3Single parses b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, or only the sampling state
+func extractB3Single(headers api.HeaderMap) (sc spanContext, ok bool) {
+	value := getHeader(headers, b3SingleHeader)
+	if value == "" {
+		return sc, false
+	}
+	parts := strings.Split(value, "-")
+	if len(parts) == 1 {
+		return sc, parseB3Sampled(&sc, parts[0])
+	}
+	if len(parts) > 4 || !isHexID(parts[0], 16, 32) || !isHexID(parts[1], 16) {
+		return sc, false
+	}
+	sc.traceID = parts[0]
+	sc.spanID = parts[1]
+	if len(parts) > 2 && !parseB3Sampled(&sc, parts[2]) {
+		return sc, false
+	}
+	if len(parts) > 3 {
+		if !isHexID(parts[3], 16) {
+			return sc, false
+		}
+		sc.parentID = parts[3]
+	}
+	return sc, true
+}
+
+// extractB3 parses the B3 multiple headers
+func extractB3(headers api.HeaderMap) (sc spanContext, ok bool) {
+	if getHeader(headers, b3FlagsHeader) == "1" {
+		parseB3Sampled(&sc, "d")
+		ok = true
+	} else if sampled := getHeader(headers, b3SampledHeader); sampled != "" {
+		ok = parseB3Sampled(&sc, sampled)
+	}
+	traceID := getHeader(headers, b3TraceIDHeader)
+	spanID := getHeader(headers, b3SpanIDHeader)
+	if traceID == "" && spanID == "" {
+		return sc, ok
+	}
+	if !isHexID(traceID, 16, 32) || !isHexID(spanID, 16) {
+		return spanContext{}, false
+	}
+	sc.traceID = traceID
+	sc.spanID = spanID
+	if parentID := getHeader(headers, b3ParentSpanIDHeader); isHexID(parentID, 16) {
+		sc.parentID = parentID
+	}
+	return sc, true
+}
+
+func parseB3Sampled(sc *spanContext, state string) bool {
+	switch strings.ToLower(state) {
+	case "1", "true":
+		sc.sampled = boolPtr(true)
+	case "0", "false":
+		sc.sampled = boolPtr(false)
+	case "d":
+		sc.debug = true
+		sc.sampled = boolPtr(true)
+	default:
+		return false
+	}
+	return true
+}
+
+func removePropagationHeaders(headers api.HeaderMap) {
+	for _, key := range propagationHeaders {
+		if _, ok := headers.Get(key); ok {
+			headers.Del(key)
+		}
+	}
+}
+
+// injectSpanContext injects the trace context into the headers with the formats
+func injectSpanContext(headers api.HeaderMap, sc spanContext, formats []string) {
+	sampled := sc.sampled != nil && *sc.sampled
+	for _, format := range formats {
+		switch format {
+		case v2.ZipkinPropagationB3:
+			headers.Set(b3TraceIDHeader, sc.traceID)
+			headers.Set(b3SpanIDHeader, sc.spanID)
+			if sc.parentID != "" {
+				headers.Set(b3ParentSpanIDHeader, sc.parentID)
+			}
+			// debug implies an accept decision, so the sampled header should not be sent
+			if sc.debug {
+				headers.Set(b3FlagsHeader, "1")
+			} else if sampled {
+				headers.Set(b3SampledHeader, "1")
+			} else {
+				headers.Set(b3SampledHeader, "0")
+			}
+		case v2.ZipkinPropagationB3Single:
+			state := "0"
+			if sc.debug {
+				state = "d"
+			} else if sampled {
+				state = "1"
+			}
+			value := sc.traceID + "-" + sc.spanID + "-" + state
+			if sc.parentID != "" {
+				value += "-" + sc.parentID
+			}
+			headers.Set(b3SingleHeader, value)
+		case v2.ZipkinPropagationW3C:
+			flags := "00"
+			if sampled {
+				flags = "01"
+			}
+			// the 64-bit trace id of B3 is left padded to 128-bit
+			traceID := sc.traceID
+			if len(traceID) == 16 {
+				traceID = strings.Repeat("0", 16) + traceID
+			}
+			headers.Set(traceParentHeader, "00-"+traceID+"-"+sc.spanID+"-"+flags)
+			if sc.traceState != "" {
+				headers.Set(traceStateHeader, sc.traceState)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+const (
+	testTraceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID   = "00f067aa0ba902b7"
+	testParentID = "a3ce929d0e0e4736"
+)
+
+func TestExtractSpanContext(t *testing.T) {
+	for i, tc := range []struct {
+		headers  protocol.CommonHeader
+		ok       bool
+		traceID  string
+		spanID   string
+		parentID string
+		sampled  *bool
+		debug    bool
+	}{
+		{
+			headers: protocol.CommonHeader{traceParentHeader: "00-" + testTraceID + "-" + testSpanID + "-01", traceStateHeader: "k=v"},
+			ok:      true, traceID: testTraceID, spanID: testSpanID, sampled: boolPtr(true),
+		},
+		{
+			headers: protocol.CommonHeader{traceParentHeader: "00-" + testTraceID + "-" + testSpanID + "-00"},
+			ok:      true, traceID: testTraceID, spanID: testSpanID, sampled: boolPtr(false),
+		},
+		{
+			// invalid traceparent falls back to b3
+			headers: protocol.CommonHeader{traceParentHeader: "00-" + testTraceID + "-0000000000000000-01", b3SingleHeader: "d"},
+			ok:      true, sampled: boolPtr(true), debug: true,
+		},
+		{
+			headers: protocol.CommonHeader{b3SingleHeader: testTraceID + "-" + testSpanID + "-1-" + testParentID},
+			ok:      true, traceID: testTraceID, spanID: testSpanID, parentID: testParentID, sampled: boolPtr(true),
+		},
+		{
+			headers: protocol.CommonHeader{b3SingleHeader: testParentID + "-" + testSpanID},
+			ok:      true, traceID: testParentID, spanID: testSpanID,
+		},
+		{
+			headers: protocol.CommonHeader{b3SingleHeader: "0"},
+			ok:      true, sampled: boolPtr(false),
+		},
+		{
+			headers: protocol.CommonHeader{
+				b3TraceIDHeader:      testTraceID,
+				b3SpanIDHeader:       testSpanID,
+				b3ParentSpanIDHeader: testParentID,
+				b3SampledHeader:      "0",
+			},
+			ok: true, traceID: testTraceID, spanID: testSpanID, parentID: testParentID, sampled: boolPtr(false),
+		},
+		{
+			headers: protocol.CommonHeader{b3TraceIDHeader: testTraceID, b3SpanIDHeader: testSpanID, b3FlagsHeader: "1"},
+			ok:      true, traceID: testTraceID, spanID: testSpanID, sampled: boolPtr(true), debug: true,
+		},
+		{
+			headers: protocol.CommonHeader{b3TraceIDHeader: "not-hex", b3SpanIDHeader: testSpanID},
+		},
+		{
+			headers: protocol.CommonHeader{},
+		},
+	} {
+		sc, ok := extractSpanContext(tc.headers)
+		if ok != tc.ok {
+			t.Errorf("#%d expected ok %v, but got %v", i, tc.ok, ok)
+			continue
+		}
+		if sc.traceID != tc.traceID || sc.spanID != tc.spanID || sc.parentID != tc.parentID || sc.debug != tc.debug {
+			t.Errorf("#%d unexpected span context: %+v", i, sc)
+		}
+		if (sc.sampled == nil) != (tc.sampled == nil) || (sc.sampled != nil && *sc.sampled != *tc.sampled) {
+			t.Errorf("#%d unexpected sampling decision: %v", i, sc.sampled)
+		}
+	}
+}
+
+func TestInjectSpanContext(t *testing.T) {
+	sc := spanContext{
+		traceID:    testParentID,
+		spanID:     testSpanID,
+		parentID:   testParentID,
+		sampled:    boolPtr(true),
+		traceState: "k=v",
+	}
+	headers := protocol.CommonHeader{}
+	injectSpanContext(headers, sc, []string{v2.ZipkinPropagationB3, v2.ZipkinPropagationB3Single, v2.ZipkinPropagationW3C})
+	expected := map[string]string{
+		b3TraceIDHeader:      testParentID,
+		b3SpanIDHeader:       testSpanID,
+		b3ParentSpanIDHeader: testParentID,
+		b3SampledHeader:      "1",
+		b3SingleHeader:       testParentID + "-" + testSpanID + "-1-" + testParentID,
+		traceParentHeader:    "00-0000000000000000" + testParentID + "-" + testSpanID + "-01",
+		traceStateHeader:     "k=v",
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			t.Errorf("header %s expected %s, but got %s", k, v, headers[k])
+		}
+	}
+	// the injected headers can be extracted
+	for _, format := range []string{v2.ZipkinPropagationB3, v2.ZipkinPropagationB3Single, v2.ZipkinPropagationW3C} {
+		headers := protocol.CommonHeader{}
+		injectSpanContext(headers, spanContext{traceID: testTraceID, spanID: testSpanID, sampled: boolPtr(false)}, []string{format})
+		got, ok := extractSpanContext(headers)
+		if !ok || got.traceID != testTraceID || got.spanID != testSpanID || got.sampled == nil || *got.sampled {
+			t.Errorf("format %s extract injected context failed: %+v", format, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnsync "mosn.io/mosn/pkg/sync"
+)
+
+// reporter reports the finished spans to the collector
+type reporter interface {
+	// Report adds the span into the queue, the span is dropped if the queue is full
+	Report(span *spanModel)
+	// Close reports the spans in the queue and stops the reporter
+	Close()
+}
+
+// httpReporter reports the spans in batches to the endpoint over http
+type httpReporter struct {
+	endpoint     string
+	client       *http.Client
+	maxRetries   int
+	retryBackOff time.Duration
+	batcher      *mosnsync.Batcher
+}
+
+func newHTTPReporter(cfg *v2.ZipkinTraceConfig) *httpReporter {
+	r := &httpReporter{
+		endpoint: cfg.ReporterEndpoint,
+		client: &http.Client{
+			Timeout: cfg.Timeout.Duration,
+		},
+		maxRetries:   cfg.MaxRetries,
+		retryBackOff: cfg.RetryBackOff.Duration,
+	}
+	r.batcher = mosnsync.NewBatcher(cfg.QueueSize, cfg.BatchSize, cfg.BatchInterval.Duration, r.send)
+	return r
+}
+
+func (r *httpReporter) Report(span *spanModel) {
+	if !r.batcher.Add(span) {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[Zipkin] [reporter] queue is full, span %s of trace %s is dropped", span.ID, span.TraceID)
+		}
+	}
+}
+
+func (r *httpReporter) Close() {
+	r.batcher.Close()
+	r.client.CloseIdleConnections()
+}
+
+func (r *httpReporter) send(items []interface{}) {
+	batch := make([]*spanModel, 0, len(items))
+	for _, item := range items {
+		batch = append(batch, item.(*spanModel))
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		log.DefaultLogger.Errorf("[Zipkin] [reporter] marshal %d spans failed: %v", len(batch), err)
+		return
+	}
+	retried, err := r.batcher.Retry(r.maxRetries, r.retryBackOff, func() (bool, error) {
+		retryable, err := r.post(body)
+		if err != nil && retryable {
+			log.DefaultLogger.Warnf("[Zipkin] [reporter] report %d spans to %s failed: %v", len(batch), r.endpoint, err)
+		}
+		return retryable, err
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("[Zipkin] [reporter] report %d spans to %s failed after %d retries: %v", len(batch), r.endpoint, retried, err)
+	}
+}
+
+// post sends the spans to the endpoint, returns whether the error can be retried
+func (r *httpReporter) post(body []byte) (bool, error) {
+	resp, err := r.client.Post(r.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the network errors can be retried
+		return true, err
+	}
+	defer resp.Body.Close()
+	// drain the body, so the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// span kinds
+const (
+	spanKindServer = "SERVER"
+	spanKindClient = "CLIENT"
+)
+
+// span tags
+const (
+	tagHTTPMethod     = "http.method"
+	tagHTTPPath       = "http.path"
+	tagHTTPStatusCode = "http.status_code"
+	tagRPCService     = "rpc.service"
+	tagRPCMethod      = "rpc.method"
+	tagStatusCode     = "status_code"
+	tagProtocol       = "protocol"
+	tagError          = "error"
+)
+
+// endpoint is the network context of a node in the service graph
+type endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+func newEndpoint(address string) *endpoint {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	ep := &endpoint{}
+	if ip4 := ip.To4(); ip4 != nil {
+		ep.IPv4 = ip4.String()
+	} else {
+		ep.IPv6 = ip.String()
+	}
+	ep.Port, _ = strconv.Atoi(port)
+	return ep
+}
+
+// spanModel is the span in Zipkin v2 json format, the time is in microseconds
+type spanModel struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Kind           string            `json:"kind,omitempty"`
+	Timestamp      int64             `json:"timestamp"`
+	Duration       int64             `json:"duration"`
+	Debug          bool              `json:"debug,omitempty"`
+	LocalEndpoint  *endpoint         `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *endpoint         `json:"remoteEndpoint,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// zipkinSpan implements types.Span, the server span is created when the request is received,
+// and a client span is created when the request is sent to the upstream
+type zipkinSpan struct {
+	tracer         *zipkinTracer
+	context        spanContext
+	name           string
+	kind           string
+	startTime      time.Time
+	remoteEndpoint *endpoint
+	tags           map[string]string
+	rawTags        map[uint64]string
+	// client is the span of the upstream request
+	client   *zipkinSpan
+	finished bool
+}
+
+func (s *zipkinSpan) TraceId() string {
+	return s.context.traceID
+}
+
+func (s *zipkinSpan) SpanId() string {
+	return s.context.spanID
+}
+
+func (s *zipkinSpan) ParentSpanId() string {
+	return s.context.parentID
+}
+
+func (s *zipkinSpan) SetOperation(operation string) {
+	s.name = operation
+}
+
+func (s *zipkinSpan) SetTag(key uint64, value string) {
+	if s.rawTags == nil {
+		s.rawTags = make(map[uint64]string)
+	}
+	s.rawTags[key] = value
+}
+
+func (s *zipkinSpan) Tag(key uint64) string {
+	return s.rawTags[key]
+}
+
+func (s *zipkinSpan) setTag(key, value string) {
+	if value == "" {
+		return
+	}
+	if s.tags == nil {
+		s.tags = make(map[string]string)
+	}
+	s.tags[key] = value
+}
+
+func (s *zipkinSpan) SetRequestInfo(requestInfo api.RequestInfo) {
+	if requestInfo == nil {
+		return
+	}
+	code := requestInfo.ResponseCode()
+	if client := s.client; client != nil {
+		client.setResponseCode(code)
+		client.FinishSpan()
+	}
+	s.setResponseCode(code)
+	if addr := requestInfo.DownstreamRemoteAddress(); addr != nil && s.remoteEndpoint == nil {
+		s.remoteEndpoint = newEndpoint(addr.String())
+	}
+}
+
+func (s *zipkinSpan) setResponseCode(code int) {
+	if code == 0 {
+		return
+	}
+	value := strconv.Itoa(code)
+	s.setTag(s.tracer.statusCodeTag(), value)
+	if code >= 500 {
+		s.setTag(tagError, value)
+	}
+}
+
+func (s *zipkinSpan) FinishSpan() {
+	if s.finished {
+		return
+	}
+	s.finished = true
+	// the client span is finished with the server span if no response is received
+	if s.client != nil {
+		s.client.FinishSpan()
+	}
+	if s.context.sampled == nil || !*s.context.sampled {
+		return
+	}
+	s.tracer.report(s.model(time.Now()))
+}
+
+func (s *zipkinSpan) model(finishTime time.Time) *spanModel {
+	duration := finishTime.Sub(s.startTime).Nanoseconds() / int64(time.Microsecond)
+	if duration <= 0 {
+		duration = 1
+	}
+	return &spanModel{
+		TraceID:        s.context.traceID,
+		ID:             s.context.spanID,
+		ParentID:       s.context.parentID,
+		Name:           s.name,
+		Kind:           s.kind,
+		Timestamp:      s.startTime.UnixNano() / int64(time.Microsecond),
+		Duration:       duration,
+		Debug:          s.context.debug,
+		LocalEndpoint:  s.tracer.localEndpoint,
+		RemoteEndpoint: s.remoteEndpoint,
+		Tags:           s.tags,
+	}
+}
+
+// InjectContext creates a client span for the upstream request, and injects its context into the request headers.
+// the former client span is finished if the request is retried
+func (s *zipkinSpan) InjectContext(requestHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	if s.client != nil {
+		s.client.FinishSpan()
+	}
+	client := s.tracer.newSpan(s.context, s.name, spanKindClient, time.Now())
+	for k, v := range s.tags {
+		if k != tagError && k != s.tracer.statusCodeTag() {
+			client.setTag(k, v)
+		}
+	}
+	if requestInfo != nil {
+		client.remoteEndpoint = newEndpoint(requestInfo.UpstreamLocalAddress())
+	}
+	s.client = client
+	if requestHeaders != nil {
+		injectSpanContext(requestHeaders, client.context, s.tracer.propagation)
+	}
+}
+
+func (s *zipkinSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
+	return s.tracer.newSpan(s.context, operationName, "", startTime)
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/http2"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// zipkinTracer implements types.Tracer for a protocol,
+// it is initialized by the driver with the config and the reporter shared by all protocols
+type zipkinTracer struct {
+	protocol      types.ProtocolName
+	localEndpoint *endpoint
+	sampleRate    float64
+	propagation   []string
+	reporter      reporter
+}
+
+// NewTracerBuilder returns a builder that creates the Zipkin tracer of the protocol,
+// the supported protocols are http1, http2 and xprotocol
+func NewTracerBuilder(proto types.ProtocolName) types.TracerBuilder {
+	return func(_ map[string]interface{}) (types.Tracer, error) {
+		return &zipkinTracer{
+			protocol: proto,
+		}, nil
+	}
+}
+
+func (t *zipkinTracer) initialize(cfg *v2.ZipkinTraceConfig, r reporter) {
+	t.localEndpoint = &endpoint{ServiceName: cfg.ServiceName}
+	t.sampleRate = *cfg.SampleRate
+	t.propagation = cfg.Propagation
+	t.reporter = r
+}
+
+func (t *zipkinTracer) Start(ctx context.Context, request interface{}, startTime time.Time) types.Span {
+	var headers api.HeaderMap
+	tags := map[string]string{
+		tagProtocol: string(t.protocol),
+	}
+	name := string(t.protocol)
+	switch req := request.(type) {
+	case http.RequestHeader:
+		if req.RequestHeader == nil {
+			return nil
+		}
+		headers = req
+		name = string(req.Method())
+		tags[tagHTTPMethod] = name
+		tags[tagHTTPPath] = string(req.RequestURI())
+	case *http2.ReqHeader:
+		if req.Req == nil {
+			return nil
+		}
+		headers = req
+		name = req.Req.Method
+		tags[tagHTTPMethod] = name
+		if req.Req.URL != nil {
+			tags[tagHTTPPath] = req.Req.URL.Path
+		}
+	case xprotocol.XFrame:
+		if req.IsHeartbeatFrame() {
+			return nil
+		}
+		headers = req.GetHeader()
+		if subProtocol, ok := mosnctx.Get(ctx, types.ContextSubProtocol).(string); ok {
+			name = subProtocol
+			tags[tagProtocol] = subProtocol
+		}
+		if aware, ok := req.(xprotocol.ServiceAware); ok {
+			tags[tagRPCService] = aware.GetServiceName()
+			tags[tagRPCMethod] = aware.GetMethodName()
+			if method := aware.GetMethodName(); method != "" {
+				name = method
+			}
+		}
+	default:
+		log.DefaultLogger.Debugf("[Zipkin] [tracer] [%s] unable to get request header, downstream trace ignored", t.protocol)
+		return nil
+	}
+	parent, _ := extractSpanContext(headers)
+	// the headers are injected again by the client span, the stale ones should not be sent to the upstream
+	removePropagationHeaders(headers)
+	span := t.newSpan(parent, name, spanKindServer, startTime)
+	for k, v := range tags {
+		span.setTag(k, v)
+	}
+	return span
+}
+
+// newSpan creates a child span of the parent, a new trace is started if the parent has no trace id
+func (t *zipkinTracer) newSpan(parent spanContext, name, kind string, startTime time.Time) *zipkinSpan {
+	sc := spanContext{
+		traceID:    parent.traceID,
+		spanID:     newSpanID(),
+		parentID:   parent.spanID,
+		sampled:    parent.sampled,
+		debug:      parent.debug,
+		traceState: parent.traceState,
+	}
+	if sc.traceID == "" {
+		sc.traceID = newTraceID()
+		sc.parentID = ""
+	}
+	if sc.sampled == nil {
+		sc.sampled = boolPtr(t.sampleRate > 0 && rand.Float64() < t.sampleRate)
+	}
+	return &zipkinSpan{
+		tracer:    t,
+		context:   sc,
+		name:      name,
+		kind:      kind,
+		startTime: startTime,
+	}
+}
+
+func (t *zipkinTracer) statusCodeTag() string {
+	if t.protocol == protocol.HTTP1 || t.protocol == protocol.HTTP2 {
+		return tagHTTPStatusCode
+	}
+	return tagStatusCode
+}
+
+func (t *zipkinTracer) report(span *spanModel) {
+	if t.reporter != nil {
+		t.reporter.Report(span)
+	}
+}
@@ -34,6 +34,8 @@ const (
 	WeightedRoundRobin LoadBalancerType = "LB_WEIGHTED_ROUNDROBIN"
 	ORIGINAL_DST       LoadBalancerType = "LB_ORIGINAL_DST"
 	LeastActiveRequest LoadBalancerType = "LB_LEAST_REQUEST"
+	RingHash           LoadBalancerType = "LB_RING_HASH"
+	Maglev             LoadBalancerType = "LB_MAGLEV"
 )
 
 // LoadBalancer is a upstream load balancer.
@@ -65,6 +67,21 @@ type LoadBalancerContext interface {
 
 	// Downstream cluster info
 	DownstreamCluster() ClusterInfo
+
+	// HashKey returns the hash key used by the consistent hash load balancers,
+	// ok is false if there is no hash key for the request
+	HashKey() (key uint64, ok bool)
+}
+
+// HostPredicateContext is a load balancer context that can reject the chosen host,
+// such as rejecting the hosts that have been tried by the retries.
+// LoadBalancerContext can be asserted as HostPredicateContext
+type HostPredicateContext interface {
+	// ShouldSelectAnotherHost returns true if the host is rejected
+	ShouldSelectAnotherHost(host Host) bool
+	// HostSelectionRetryMaxAttempts returns the max attempts to choose a host,
+	// the last chosen host is used if all of the attempts are rejected
+	HostSelectionRetryMaxAttempts() int
 }
 
 // LBSubsetEntry is a entry that stored in the subset hierarchy.
@@ -44,7 +44,8 @@ type Metrics interface {
 	Gauge(key string) metrics.Gauge
 
 	// Histogram creates or returns a go-metrics histogram by key
-	// if the key is registered by other interface, it will be panic
+	// if the key is registered by other interface, it will be panic.
+	// the histogram implements BucketHistogram if the buckets of the metrics type are configured
 	Histogram(key string) metrics.Histogram
 
 	// Each call the given function for each registered metric.
@@ -54,6 +55,19 @@ type Metrics interface {
 	UnregisterAll()
 }
 
+// BucketHistogram is a histogram that counts the values in cumulative buckets,
+// which can be aggregated across instances. The percentiles are estimated by the buckets.
+type BucketHistogram interface {
+	metrics.Histogram
+
+	// Buckets returns the upper bounds of the buckets in increasing order, +Inf is not included
+	Buckets() []float64
+
+	// BucketCounts returns the cumulative counts of the buckets, that is the counts of the values
+	// less than or equal to the upper bounds. The count of +Inf is Count()
+	BucketCounts() []uint64
+}
+
 // MetricsSink flush metrics to backend storage
 type MetricsSink interface {
 	// Flush flush given metrics
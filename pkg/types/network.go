@@ -77,7 +77,7 @@ import (
 //    --------------------------------------------------
 //
 
-// Listener is a wrapper of tcp listener
+// Listener is a wrapper of tcp or udp listener
 type Listener interface {
 	// Return config which initialize this listener
 	Config() *v2.Listener
@@ -141,6 +141,38 @@ type ListenerEventListener interface {
 	OnClose()
 }
 
+// UDPListenerEventListener is a Callback invoked by a udp listener.
+type UDPListenerEventListener interface {
+	ListenerEventListener
+
+	// OnDatagram is called on each datagram received by the listener,
+	// the data is reused by the listener after the call returns.
+	OnDatagram(data []byte, remoteAddr net.Addr)
+}
+
+// UDPListenerFilterFactory is implemented by the network filter factories which can be used in the udp listeners.
+type UDPListenerFilterFactory interface {
+	CreateUDPListenerFilter(ctx context.Context, cb UDPListenerFilterCallbacks) UDPListenerFilter
+}
+
+// UDPListenerFilter handles the datagrams received by a udp listener
+type UDPListenerFilter interface {
+	// OnData is called on each datagram received, the data should be copied if it is used after the call returns.
+	OnData(data []byte, remoteAddr net.Addr)
+
+	// OnClose is called when the listener is closed or the filter chain is updated
+	OnClose()
+}
+
+// UDPListenerFilterCallbacks is a callback handler called by udp listener filter to talk to listener
+type UDPListenerFilterCallbacks interface {
+	// WriteTo sends a datagram to the remote address by the listener's socket
+	WriteTo(data []byte, remoteAddr net.Addr) (int, error)
+
+	// Addr returns the listener's network address.
+	Addr() net.Addr
+}
+
 type ListenerFilter interface {
 	// OnAccept is called when a raw connection is accepted, but before a Connection is created.
 	OnAccept(cb ListenerFilterCallbacks) api.FilterStatus
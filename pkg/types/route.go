@@ -65,6 +65,13 @@ type RouterManager interface {
 	AddRoute(routerConfigName, domain string, route *v2.Router) error
 
 	RemoveAllRoutes(routerConfigName, domain string) error
+
+	// RemoveRouters removes the routes of the router config, the requests referenced it are not routed
+	RemoveRouters(routerConfigName string) error
+
+	// UpdateDynamicRouteScopes replaces the scopes of the scoped routers that discover the scopes dynamically,
+	// the scopes are keyed by the scoped routes name
+	UpdateDynamicRouteScopes(scopes map[string][]*v2.RouteScope) error
 }
 
 // HandlerStatus returns the Handler's available status
@@ -106,6 +113,123 @@ type VirtualHost interface {
 	RemoveAllRoutes()
 }
 
+// HashPolicy generates the hash key for the consistent hash load balancers
+type HashPolicy interface {
+	// GenerateHash returns the hash key of the request, ok is false if no key is generated
+	GenerateHash(ctx context.Context, headers api.HeaderMap) (key uint64, ok bool)
+}
+
+// HashPolicyRouteRule is a route rule that contains hash policy.
+// api.RouteRule can be asserted as HashPolicyRouteRule to get the hash policy
+type HashPolicyRouteRule interface {
+	// HashPolicy returns the route's hash policy, nil if it is not configured
+	HashPolicy() HashPolicy
+}
+
+// ClusterSpecifier chooses the upstream cluster of a route by the request
+type ClusterSpecifier interface {
+	// ClusterName returns the cluster chosen by the request headers and the variables in ctx,
+	// empty means the route's own cluster is used
+	ClusterName(ctx context.Context, headers api.HeaderMap) string
+}
+
+// ClusterSpecifierRouteRule is a route rule that chooses the cluster by the request.
+// api.RouteRule can be asserted as ClusterSpecifierRouteRule
+type ClusterSpecifierRouteRule interface {
+	// ClusterNameWithContext is same as api.RouteRule's ClusterName, and the cluster specifier is used if it is configured
+	ClusterNameWithContext(ctx context.Context, headers api.HeaderMap) string
+}
+
+// MirrorPolicy mirrors the requests to a shadow cluster
+type MirrorPolicy interface {
+	// ClusterName returns the shadow cluster name
+	ClusterName() string
+	// IsMirror returns true if the request is sampled to be mirrored
+	IsMirror() bool
+}
+
+// MirrorPolicyRouteRule is a route rule that contains request mirror policies.
+// api.RouteRule can be asserted as MirrorPolicyRouteRule to get the mirror policies
+type MirrorPolicyRouteRule interface {
+	// MirrorPolicies returns the route's mirror policies, nil if it is not configured
+	MirrorPolicies() []MirrorPolicy
+}
+
+// RetryConditionPolicy is a retry policy that contains the retry conditions, back off and host predicates.
+// api.RetryPolicy can be asserted as RetryConditionPolicy
+type RetryConditionPolicy interface {
+	// RetryConditions returns the conditions under which retry takes place, empty means the default conditions
+	RetryConditions() []string
+	// RetriableStatusCodes returns the http status codes used by the retriable-status-codes condition
+	RetriableStatusCodes() []uint32
+	// BackOff returns the interval before the retry, retried is the times of the retries that have been made
+	BackOff(retried uint32) time.Duration
+	// ShouldRejectHost returns true if the host should not be chosen for the retry, triedHosts is the hosts that have been tried
+	ShouldRejectHost(host Host, triedHosts []Host) bool
+	// HostSelectionRetryMaxAttempts returns the max attempts to choose a host that is not rejected
+	HostSelectionRetryMaxAttempts() int
+}
+
+// CorsPolicy is the cross origin resource sharing policy
+type CorsPolicy interface {
+	// AllowOrigin returns true if the origin is allowed
+	AllowOrigin(origin string) bool
+	// AllowMethods returns the value of Access-Control-Allow-Methods, empty if it is not configured
+	AllowMethods() string
+	// AllowHeaders returns the value of Access-Control-Allow-Headers, empty if it is not configured
+	AllowHeaders() string
+	// ExposeHeaders returns the value of Access-Control-Expose-Headers, empty if it is not configured
+	ExposeHeaders() string
+	// MaxAge returns the value of Access-Control-Max-Age, empty if it is not configured
+	MaxAge() string
+	// AllowCredentials returns true if the Access-Control-Allow-Credentials is true
+	AllowCredentials() bool
+}
+
+// CorsPolicyRouteRule is a route rule that contains the cors policy.
+// api.RouteRule can be asserted as CorsPolicyRouteRule to get the cors policy
+type CorsPolicyRouteRule interface {
+	// CorsPolicy returns the route's cors policy, the virtual host's cors policy is returned
+	// if the route does not configure it, nil if both of them are not configured
+	CorsPolicy() CorsPolicy
+}
+
+// RedirectRule is the redirect action of a route, the request is responded with a redirect response
+type RedirectRule interface {
+	// RedirectCode returns the status code of the redirect response
+	RedirectCode() int
+	// RedirectLocation returns the location of the redirect response, which is rebuilt from the request headers.
+	// scheme is the scheme of the request
+	RedirectLocation(scheme string, headers api.HeaderMap) string
+}
+
+// RedirectRouteRule is a route rule that contains a redirect action.
+// api.RouteRule can be asserted as RedirectRouteRule to get the redirect rule
+type RedirectRouteRule interface {
+	// RedirectRule returns the route's redirect rule, nil if it is not configured
+	RedirectRule() RedirectRule
+}
+
+// VariableHeadersRouteRule is a route rule that finalizes the headers with the stream context,
+// so the %variable% in the values of headers to add can be resolved.
+// api.RouteRule can be asserted as VariableHeadersRouteRule
+type VariableHeadersRouteRule interface {
+	// FinalizeRequestHeadersWithContext is same as api.RouteRule's FinalizeRequestHeaders, and resolves the variables by the ctx
+	FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+	// FinalizeResponseHeadersWithContext is same as api.RouteRule's FinalizeResponseHeaders, and resolves the variables by the ctx
+	FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+}
+
+// FilterConfigRouteRule is a route rule that contains the per-route configurations of the stream filters,
+// which are merged from the per_filter_config of the virtual host and the route.
+// api.RouteRule can be asserted as FilterConfigRouteRule
+type FilterConfigRouteRule interface {
+	// FilterConfig returns the config parsed by the per-route config parser of the stream filter type, nil if it is not configured
+	FilterConfig(filterType string) interface{}
+	// FilterDisabled returns true if the stream filter type is disabled in the route
+	FilterDisabled(filterType string) bool
+}
+
 type HeaderFormat interface {
 	Format(info api.RequestInfo) string
 	Append() bool
@@ -124,11 +248,16 @@ type QueryParameterMatcher interface {
 // An empty header value allows for matching to be only based on header presence.
 // Regex is an opt-in. Unless explicitly mentioned, the header values will be used for
 // exact string matching.
+// If PresentMatch is true, only the presence (or absence if IsPresent is false) of the header is matched.
+// InvertMatch inverts the match result.
 type HeaderData struct {
 	Name         LowerCaseString
 	Value        string
 	IsRegex      bool
 	RegexPattern *regexp.Regexp
+	PresentMatch bool
+	IsPresent    bool
+	InvertMatch  bool
 }
 
 // ConfigUtility is utility routines for loading route configuration and matching runtime request headers.
@@ -106,6 +106,23 @@ type Cluster interface {
 	StopHealthChecking()
 }
 
+// TargetsCluster is a cluster whose hosts are resolved from the configured targets, such as the dns cluster.
+// the hosts of it cannot be appended or removed directly.
+// Cluster can be asserted as TargetsCluster
+type TargetsCluster interface {
+	// SetTargets sets the host configs to be resolved, the hosts are updated after they are resolved
+	SetTargets(hostConfigs []v2.Host)
+	// Stop stops resolving the targets
+	Stop()
+}
+
+// DNSResolver resolves the domain names of dns cluster's hosts
+type DNSResolver interface {
+	// Resolve returns the ip addresses of the domain name, and the min ttl of the dns records.
+	// a negative ttl means the ttl is unknown, zero ttl means the records should not be cached.
+	Resolve(ctx context.Context, name string) ([]net.IP, time.Duration, error)
+}
+
 // HostPredicate checks wether the host is matched the metadata
 type HostPredicate func(Host) bool
 
@@ -168,6 +185,35 @@ type ClusterInfo interface {
 
 	// Optional configuration for the load balancing algorithm selected by
 	LbConfig() v2.IsCluster_LbConfig
+
+	// OutlierDetector returns the cluster's passive outlier detector, nil if it is not configured
+	OutlierDetector() OutlierDetector
+}
+
+// OutlierResult is the result of an upstream request that is put into the outlier detector
+type OutlierResult int
+
+// Group of outlier results
+const (
+	// OutlierResultSuccess means the upstream responses a non-5xx response
+	OutlierResultSuccess OutlierResult = iota
+	// OutlierResultServerError means the upstream responses a 5xx response
+	OutlierResultServerError
+	// OutlierResultConnectFailed means the connection to the upstream is failed, or the request is reset/timeout
+	OutlierResultConnectFailed
+)
+
+// OutlierDetector watches the upstream request results of a cluster's hosts,
+// and ejects the outlier hosts from the load balancing pool for a while
+type OutlierDetector interface {
+	// PutResult records an upstream request result of the host
+	PutResult(host Host, result OutlierResult)
+
+	// SetHosts resets the hosts that detector watches
+	SetHosts(hosts []Host)
+
+	// Stop stops the detector, all ejected hosts will be released
+	Stop()
 }
 
 // ResourceManager manages different types of Resource
@@ -217,6 +263,7 @@ type HostStats struct {
 	UpstreamRequestDurationTotal                   metrics.Counter
 	UpstreamResponseSuccess                        metrics.Counter
 	UpstreamResponseFailed                         metrics.Counter
+	UpstreamOutlierEjected                         metrics.Counter
 }
 
 // ClusterStats defines a cluster's statistics information
@@ -239,6 +286,8 @@ type ClusterStats struct {
 	UpstreamRequestRemoteReset                     metrics.Counter
 	UpstreamRequestRetry                           metrics.Counter
 	UpstreamRequestRetryOverflow                   metrics.Counter
+	UpstreamRequestMirrorSuccess                   metrics.Counter
+	UpstreamRequestMirrorFailure                   metrics.Counter
 	UpstreamRequestTimeout                         metrics.Counter
 	UpstreamRequestFailureEject                    metrics.Counter
 	UpstreamRequestPendingOverflow                 metrics.Counter
@@ -248,6 +297,12 @@ type ClusterStats struct {
 	UpstreamResponseFailed                         metrics.Counter
 	LBSubSetsFallBack                              metrics.Counter
 	LBSubsetsCreated                               metrics.Gauge
+	OutlierEjectTotal                              metrics.Counter
+	OutlierEjectActive                             metrics.Gauge
+	OutlierEjectConsecutive5xx                     metrics.Counter
+	OutlierEjectConsecutiveConnectFailure          metrics.Counter
+	OutlierEjectSuccessRate                        metrics.Counter
+	OutlierEjectOverflow                           metrics.Counter
 }
 
 type CreateConnectionData struct {
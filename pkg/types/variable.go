@@ -33,6 +33,7 @@ const (
 	VarDownstreamLocalAddress   string = "downstream_local_address"
 	VarDownstreamRemoteAddress  string = "downstream_remote_address"
 	VarUpstreamHost             string = "upstream_host"
+	VarDownstreamPeerIdentity   string = "downstream_peer_identity"
 
 	// ReqHeaderPrefix is the prefix of request header's formatter
 	VarPrefixReqHeader string = "request_header_"
@@ -33,6 +33,10 @@ import (
 
 func NewCluster(clusterConfig v2.Cluster) types.Cluster {
 	// TODO: support cluster type registered
+	switch clusterConfig.ClusterType {
+	case v2.STRICT_DNS_CLUSTER, v2.LOGICAL_DNS_CLUSTER:
+		return newDNSCluster(clusterConfig)
+	}
 	return newSimpleCluster(clusterConfig)
 }
 
@@ -56,6 +60,14 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		lbOriDstInfo:         NewLBOriDstInfo(&clusterConfig.LBOriDstConfig), // new oridst load balancer info
 		lbType:               types.LoadBalancerType(clusterConfig.LbType),
 		resourceManager:      NewResourceManager(clusterConfig.CirBreThresholds),
+		lbConfig:             clusterConfig.LbConfig,
+	}
+	// the consistent hash load balancers are configured by their own configs
+	switch {
+	case clusterConfig.LbType == v2.LB_RING_HASH && clusterConfig.RingHashLbConfig != nil:
+		info.lbConfig = clusterConfig.RingHashLbConfig
+	case clusterConfig.LbType == v2.LB_MAGLEV && clusterConfig.MaglevLbConfig != nil:
+		info.lbConfig = clusterConfig.MaglevLbConfig
 	}
 
 	// set ConnectTimeout
@@ -71,6 +83,10 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		log.DefaultLogger.Alertf("cluster.config", "[upstream] [cluster] [new cluster] create tls context manager failed, %v", err)
 	}
 	info.tlsMng = mgr
+	// passive outlier detection
+	if clusterConfig.OutlierDetection != nil {
+		info.outlierDetector = newOutlierDetector(clusterConfig.OutlierDetection, info.stats)
+	}
 	cluster := &simpleCluster{
 		info: info,
 	}
@@ -101,6 +117,9 @@ func (sc *simpleCluster) UpdateHosts(newHosts []types.Host) {
 	}
 	sc.lbInstance = lb
 	sc.hostSet = hostSet
+	if info.outlierDetector != nil {
+		info.outlierDetector.SetHosts(hostSet.Hosts())
+	}
 	sc.snapshot.Store(&clusterSnapshot{
 		lb:      lb,
 		hostSet: hostSet,
@@ -147,6 +166,7 @@ type clusterInfo struct {
 	tlsMng               types.TLSContextManager
 	connectTimeout       time.Duration
 	lbConfig             v2.IsCluster_LbConfig
+	outlierDetector      types.OutlierDetector
 }
 
 func updateClusterResourceManager(ci types.ClusterInfo, rm types.ResourceManager) {
@@ -203,6 +223,10 @@ func (ci *clusterInfo) LbConfig() v2.IsCluster_LbConfig {
 	return ci.lbConfig
 }
 
+func (ci *clusterInfo) OutlierDetector() types.OutlierDetector {
+	return ci.outlierDetector
+}
+
 type clusterSnapshot struct {
 	info    types.ClusterInfo
 	hostSet types.HostSet
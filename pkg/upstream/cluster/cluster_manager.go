@@ -29,11 +29,15 @@ import (
 	"mosn.io/mosn/pkg/admin/store"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
 )
 
-var errNilCluster = errors.New("cannot update nil cluster")
+var (
+	errNilCluster     = errors.New("cannot update nil cluster")
+	errTargetsCluster = errors.New("the hosts are resolved from the targets, update the targets instead")
+)
 
 // refreshHostsConfig refresh the stored config for admin api
 func refreshHostsConfig(c types.Cluster) {
@@ -129,9 +133,21 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 		// sync newResourceManager value to oldResourceManager value
 		updateResourceValue(oldResourceManager, newResourceManager)
 
+		// the old cluster's outlier detector should be stopped, the ejected hosts are released
+		if od := c.Snapshot().ClusterInfo().OutlierDetector(); od != nil {
+			od.Stop()
+		}
+		if tc, ok := c.(types.TargetsCluster); ok {
+			tc.Stop()
+		}
+		mtls.ReleaseTLSContextManager(c.Snapshot().ClusterInfo().TLSMng())
+
 		// update hosts, refresh
 		newCluster.UpdateHosts(hosts)
-		refreshHostsConfig(c)
+		// the hosts config of targets cluster is the targets, keeps it in the store
+		if _, ok := newCluster.(types.TargetsCluster); !ok {
+			refreshHostsConfig(c)
+		}
 	}
 	cm.clustersMap.Store(clusterName, newCluster)
 	log.DefaultLogger.Infof("[cluster] [cluster manager] [AddOrUpdatePrimaryCluster] cluster %s updated", clusterName)
@@ -173,6 +189,13 @@ func (cm *clusterManager) RemovePrimaryCluster(clusterNames ...string) error {
 		}
 		c := v.(types.Cluster)
 		c.StopHealthChecking()
+		if od := c.Snapshot().ClusterInfo().OutlierDetector(); od != nil {
+			od.Stop()
+		}
+		if tc, ok := c.(types.TargetsCluster); ok {
+			tc.Stop()
+		}
+		mtls.ReleaseTLSContextManager(c.Snapshot().ClusterInfo().TLSMng())
 
 		cm.clustersMap.Delete(clusterName)
 		store.RemoveClusterConfig(clusterName)
@@ -191,6 +214,12 @@ func (cm *clusterManager) UpdateClusterHosts(clusterName string, hostConfigs []v
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	// the hosts of targets cluster are the targets to be resolved, such as the domain names of dns cluster
+	if tc, ok := c.(types.TargetsCluster); ok {
+		tc.SetTargets(hostConfigs)
+		store.SetHosts(clusterName, hostConfigs)
+		return nil
+	}
 	snap := c.Snapshot()
 	hosts := make([]types.Host, 0, len(hostConfigs))
 	for _, hc := range hostConfigs {
@@ -209,6 +238,10 @@ func (cm *clusterManager) AppendClusterHosts(clusterName string, hostConfigs []v
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	if _, ok := c.(types.TargetsCluster); ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] AppendClusterHosts cluster %s failed: %v", clusterName, errTargetsCluster)
+		return fmt.Errorf("cluster %s: %v", clusterName, errTargetsCluster)
+	}
 	snap := c.Snapshot()
 	hosts := make([]types.Host, 0, len(hostConfigs))
 	for _, hc := range hostConfigs {
@@ -228,6 +261,10 @@ func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string)
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	if _, ok := c.(types.TargetsCluster); ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] RemoveClusterHosts cluster %s failed: %v", clusterName, errTargetsCluster)
+		return fmt.Errorf("cluster %s: %v", clusterName, errTargetsCluster)
+	}
 	snap := c.Snapshot()
 	hosts := snap.HostSet().Hosts()
 	newHosts := make([]types.Host, len(hosts))
@@ -295,6 +332,19 @@ var (
 	errNoHealthyHost   = errors.New("no health hosts")
 )
 
+// chooseHost chooses a host by the load balancer, and chooses again if the host is rejected by the context
+func chooseHost(lb types.LoadBalancer, balancerContext types.LoadBalancerContext) types.Host {
+	host := lb.ChooseHost(balancerContext)
+	predicate, ok := balancerContext.(types.HostPredicateContext)
+	if !ok {
+		return host
+	}
+	for attempts := predicate.HostSelectionRetryMaxAttempts(); attempts > 0 && host != nil && predicate.ShouldSelectAnotherHost(host); attempts-- {
+		host = lb.ChooseHost(balancerContext)
+	}
+	return host
+}
+
 func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBalancerContext, clusterSnapshot types.ClusterSnapshot, protocol types.ProtocolName) (types.ConnectionPool, error) {
 	factory, ok := network.ConnNewPoolFactories[protocol]
 	if !ok {
@@ -311,7 +361,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		try = maxHostsCounts
 	}
 	for i := 0; i < try; i++ {
-		host := clusterSnapshot.LoadBalancer().ChooseHost(balancerContext)
+		host := chooseHost(clusterSnapshot.LoadBalancer(), balancerContext)
 		if host == nil {
 			return nil, errNilHostChoose
 		}
@@ -0,0 +1,336 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	DefaultMinimumRingSize uint64 = 1024
+	DefaultMaximumRingSize uint64 = 8 * 1024 * 1024
+	DefaultMaglevTableSize uint64 = 65537
+)
+
+func init() {
+	RegisterLBType(types.RingHash, newRingHashLoadBalancer)
+	RegisterLBType(types.Maglev, newMaglevLoadBalancer)
+}
+
+// hashString returns a well distributed 64 bit hash of the string
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix64(h.Sum64())
+}
+
+// mix64 is the finalizer of murmur3, which makes the fnv hash avalanche
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// consistentHashBase contains the common parts of consistent hash load balancers
+type consistentHashBase struct {
+	hosts types.HostSet
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+// hashKey returns the request's hash key, a random key is used if no hash key found.
+// the key is mixed, so the keys generated by a weak hash function can be distributed on the ring
+func (lb *consistentHashBase) hashKey(context types.LoadBalancerContext) uint64 {
+	if context != nil {
+		if key, ok := context.HashKey(); ok {
+			return mix64(key)
+		}
+	}
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	return lb.rand.Uint64()
+}
+
+func (lb *consistentHashBase) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *consistentHashBase) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
+
+// normalizeWeights returns the hosts' weights normalized to 1, and the min normalized weight
+// a host with zero weight is treated as weight 1
+func normalizeWeights(hosts []types.Host) ([]float64, float64) {
+	var total float64
+	for _, h := range hosts {
+		total += float64(hostWeight(h))
+	}
+	weights := make([]float64, len(hosts))
+	min := 1.0
+	for i, h := range hosts {
+		weights[i] = float64(hostWeight(h)) / total
+		if weights[i] < min {
+			min = weights[i]
+		}
+	}
+	return weights, min
+}
+
+func hostWeight(h types.Host) uint32 {
+	if w := h.Weight(); w > 0 {
+		return w
+	}
+	return 1
+}
+
+// ringHashEntry is a point on the hash ring
+type ringHashEntry struct {
+	hash uint64
+	host types.Host
+}
+
+// ringHashLoadBalancer is the ketama style consistent hash load balancer,
+// each host is mapped to some points on the hash ring according to its weight,
+// and the request is routed to the first point that is not less than the hash key.
+type ringHashLoadBalancer struct {
+	consistentHashBase
+	ring []ringHashEntry
+}
+
+func newRingHashLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	minSize, maxSize := DefaultMinimumRingSize, DefaultMaximumRingSize
+	if info != nil {
+		if cfg, ok := info.LbConfig().(*v2.RingHashLbConfig); ok {
+			if cfg.MinimumRingSize > 0 {
+				minSize = cfg.MinimumRingSize
+			}
+			if cfg.MaximumRingSize > 0 {
+				maxSize = cfg.MaximumRingSize
+			}
+		}
+	}
+	if minSize > maxSize {
+		minSize = maxSize
+	}
+	lb := &ringHashLoadBalancer{
+		consistentHashBase: consistentHashBase{
+			hosts: hosts,
+			rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		},
+	}
+	lb.ring = buildHashRing(hosts.Hosts(), minSize, maxSize)
+	return lb
+}
+
+// buildHashRing creates the ring, the host with min weight has at least one point,
+// and the ring size is limited by the min and max size
+func buildHashRing(hosts []types.Host, minSize, maxSize uint64) []ringHashEntry {
+	if len(hosts) == 0 {
+		return nil
+	}
+	weights, minWeight := normalizeWeights(hosts)
+	scale := math.Min(math.Ceil(minWeight*float64(minSize))/minWeight, float64(maxSize))
+	ring := make([]ringHashEntry, 0, uint64(scale)+uint64(len(hosts)))
+	var target, current float64
+	for i, h := range hosts {
+		// keeps the ring size close to the scale
+		target += weights[i] * scale
+		addr := h.AddressString()
+		for n := 0; current < target; n++ {
+			ring = append(ring, ringHashEntry{
+				hash: hashString(addr + "_" + strconv.Itoa(n)),
+				host: h,
+			})
+			current++
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	return ring
+}
+
+func (lb *ringHashLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	total := len(lb.ring)
+	if total == 0 {
+		return nil
+	}
+	key := lb.hashKey(context)
+	idx := sort.Search(total, func(i int) bool {
+		return lb.ring[i].hash >= key
+	})
+	// find the next healthy host on the ring
+	for i := 0; i < total; i++ {
+		host := lb.ring[(idx+i)%total].host
+		if host.Health() {
+			return host
+		}
+	}
+	return nil
+}
+
+// maglevLoadBalancer is the consistent hash load balancer described in the paper
+// Maglev: A Fast and Reliable Software Network Load Balancer.
+// a lookup table is filled with hosts by the hosts' permutations, and the request
+// is routed by the table index that calculated by the hash key.
+type maglevLoadBalancer struct {
+	consistentHashBase
+	table []types.Host
+}
+
+func newMaglevLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	size := DefaultMaglevTableSize
+	if info != nil {
+		if cfg, ok := info.LbConfig().(*v2.MaglevLbConfig); ok && cfg.TableSize > 1 {
+			size = nextPrime(cfg.TableSize)
+			if size != cfg.TableSize {
+				log.DefaultLogger.Warnf("[upstream] [maglev] table size %d is not a prime, use %d instead", cfg.TableSize, size)
+			}
+		}
+	}
+	lb := &maglevLoadBalancer{
+		consistentHashBase: consistentHashBase{
+			hosts: hosts,
+			rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		},
+	}
+	lb.table = buildMaglevTable(hosts.Hosts(), size)
+	return lb
+}
+
+// nextPrime returns the smallest prime number that is not less than n.
+// the maglev table size should be a prime, otherwise the permutation of a host may not cover all the slots
+func nextPrime(n uint64) uint64 {
+	if n <= 2 {
+		return 2
+	}
+	if n%2 == 0 {
+		n++
+	}
+	for ; ; n += 2 {
+		isPrime := true
+		for i := uint64(3); i*i <= n; i += 2 {
+			if n%i == 0 {
+				isPrime = false
+				break
+			}
+		}
+		if isPrime {
+			return n
+		}
+	}
+}
+
+// maglevEntry is used to fill the maglev table
+type maglevEntry struct {
+	host   types.Host
+	offset uint64
+	skip   uint64
+	weight float64
+	target float64
+	next   uint64
+}
+
+// buildMaglevTable fills the table with the weighted maglev algorithm,
+// a host with larger weight fills the table more frequently.
+func buildMaglevTable(hosts []types.Host, size uint64) []types.Host {
+	if len(hosts) == 0 {
+		return nil
+	}
+	// the permutation should not depend on the hosts order
+	sorted := make([]types.Host, len(hosts))
+	copy(sorted, hosts)
+	sort.Sort(types.SortedHosts(sorted))
+	weights, _ := normalizeWeights(sorted)
+	entries := make([]*maglevEntry, len(sorted))
+	var maxWeight float64
+	for i, h := range sorted {
+		addr := h.AddressString()
+		entries[i] = &maglevEntry{
+			host:   h,
+			offset: hashString(addr) % size,
+			skip:   hashString(addr+"_skip")%(size-1) + 1,
+			weight: weights[i],
+			target: weights[i],
+		}
+		if weights[i] > maxWeight {
+			maxWeight = weights[i]
+		}
+	}
+	table := make([]types.Host, size)
+	var filled uint64
+	// the permutation of a host has size slots at most, a host is exhausted if all of them are tried
+	exhausted := 0
+	for iteration := 1; filled < size && exhausted < len(entries); iteration++ {
+		for _, e := range entries {
+			if filled >= size {
+				break
+			}
+			// the host has filled enough slots in this iteration, or no free slot is left in its permutation
+			if e.next >= size || float64(iteration)*e.weight < e.target {
+				continue
+			}
+			e.target += maxWeight
+			// find the next free slot in the host's permutation
+			for e.next < size {
+				c := (e.offset + e.skip*e.next) % size
+				e.next++
+				if table[c] == nil {
+					table[c] = e.host
+					filled++
+					break
+				}
+			}
+			if e.next >= size {
+				exhausted++
+			}
+		}
+	}
+	return table
+}
+
+func (lb *maglevLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	total := uint64(len(lb.table))
+	if total == 0 {
+		return nil
+	}
+	key := lb.hashKey(context)
+	// find the next healthy host in the table
+	for i := uint64(0); i < total; i++ {
+		host := lb.table[(key+i)%total]
+		if host.Health() {
+			return host
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockHashLbContext struct {
+	types.LoadBalancerContext
+	key uint64
+}
+
+func (ctx *mockHashLbContext) HashKey() (uint64, bool) {
+	return ctx.key, true
+}
+
+func makeHashHosts(prefix string, weights []uint32) []types.Host {
+	hosts := make([]types.Host, len(weights))
+	for i, w := range weights {
+		hosts[i] = &mockHost{
+			addr: fmt.Sprintf("%s.%d:80", prefix, i),
+			w:    w,
+		}
+	}
+	return hosts
+}
+
+func newTestHashLb(lbType types.LoadBalancerType, hosts []types.Host) types.LoadBalancer {
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	return NewLoadBalancer(&clusterInfo{lbType: lbType}, hs)
+}
+
+func testHashDistribution(t *testing.T, lbType types.LoadBalancerType, prefix string) {
+	weights := []uint32{1, 1, 2, 4}
+	hosts := makeHashHosts(prefix, weights)
+	lb := newTestHashLb(lbType, hosts)
+	results := map[string]int{}
+	total := 100000
+	for i := 0; i < total; i++ {
+		h := lb.ChooseHost(&mockHashLbContext{key: uint64(i)})
+		results[h.AddressString()]++
+	}
+	for i, h := range hosts {
+		rate := float64(results[h.AddressString()]) / float64(total)
+		expected := float64(weights[i]) / 8
+		if math.Abs(rate-expected) > 0.05 {
+			t.Errorf("%s %s request rate is %f, expected %f", lbType, h.AddressString(), rate, expected)
+		}
+	}
+}
+
+func testHashConsistency(t *testing.T, lbType types.LoadBalancerType, prefix string) {
+	hosts := makeHashHosts(prefix, []uint32{1, 1, 1, 1, 1})
+	lb := newTestHashLb(lbType, hosts)
+	// same key routes to the same host
+	for i := 0; i < 100; i++ {
+		ctx := &mockHashLbContext{key: uint64(i)}
+		if lb.ChooseHost(ctx) != lb.ChooseHost(ctx) {
+			t.Fatalf("%s same key should choose the same host", lbType)
+		}
+	}
+	// removes a host, the keys on the removed host should be moved mostly.
+	// the points of remaining hosts are rescaled, so a few other keys may be moved too.
+	removed := hosts[2]
+	newLb := newTestHashLb(lbType, append(append([]types.Host{}, hosts[:2]...), hosts[3:]...))
+	moved, total := 0, 10000
+	for i := 0; i < total; i++ {
+		ctx := &mockHashLbContext{key: uint64(i)}
+		old := lb.ChooseHost(ctx)
+		if old == removed {
+			continue
+		}
+		if newLb.ChooseHost(ctx) != old {
+			moved++
+		}
+	}
+	if rate := float64(moved) / float64(total); rate > 0.15 {
+		t.Errorf("%s too many keys moved after host removed: %f", lbType, rate)
+	}
+	// unhealthy host is skipped
+	removed.SetHealthFlag(api.FAILED_ACTIVE_HC)
+	defer removed.ClearHealthFlag(api.FAILED_ACTIVE_HC)
+	for i := 0; i < 1000; i++ {
+		if h := lb.ChooseHost(&mockHashLbContext{key: uint64(i)}); h == nil || h == removed {
+			t.Fatalf("%s unhealthy host should not be chosen", lbType)
+		}
+	}
+}
+
+func TestRingHashLoadBalancer(t *testing.T) {
+	testHashDistribution(t, types.RingHash, "10.12.1")
+	testHashConsistency(t, types.RingHash, "10.12.2")
+}
+
+func TestMaglevLoadBalancer(t *testing.T) {
+	testHashDistribution(t, types.Maglev, "10.12.3")
+	testHashConsistency(t, types.Maglev, "10.12.4")
+}
+
+func TestConsistentHashNoHosts(t *testing.T) {
+	for _, lbType := range []types.LoadBalancerType{types.RingHash, types.Maglev} {
+		lb := newTestHashLb(lbType, nil)
+		if lb.IsExistsHosts(nil) || lb.ChooseHost(&mockHashLbContext{}) != nil {
+			t.Fatalf("%s should choose no host", lbType)
+		}
+	}
+}
+
+func TestNextPrime(t *testing.T) {
+	for n, expected := range map[uint64]uint64{0: 2, 2: 2, 3: 3, 4: 5, 9: 11, 307: 307, 65536: 65537} {
+		if p := nextPrime(n); p != expected {
+			t.Errorf("next prime of %d expected %d, but got %d", n, expected, p)
+		}
+	}
+}
+
+func TestConsistentHashConfig(t *testing.T) {
+	hosts := makeHashHosts("10.12.5", []uint32{1, 1, 1})
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	ring := newRingHashLoadBalancer(&clusterInfo{
+		lbConfig: &v2.RingHashLbConfig{MinimumRingSize: 30, MaximumRingSize: 60},
+	}, hs).(*ringHashLoadBalancer)
+	if len(ring.ring) != 30 {
+		t.Fatalf("expected ring size 30, but got %d", len(ring.ring))
+	}
+	maglev := newMaglevLoadBalancer(&clusterInfo{
+		lbConfig: &v2.MaglevLbConfig{TableSize: 307},
+	}, hs).(*maglevLoadBalancer)
+	if len(maglev.table) != 307 {
+		t.Fatalf("expected table size 307, but got %d", len(maglev.table))
+	}
+	// the table size is rounded up to a prime, so all the slots can be filled
+	maglev3 := newMaglevLoadBalancer(&clusterInfo{
+		lbConfig: &v2.MaglevLbConfig{TableSize: 4},
+	}, hs).(*maglevLoadBalancer)
+	if len(maglev3.table) != 5 {
+		t.Fatalf("expected table size 5, but got %d", len(maglev3.table))
+	}
+	for i, h := range maglev3.table {
+		if h == nil {
+			t.Fatalf("slot %d of maglev table is not filled", i)
+		}
+	}
+	// the table does not depend on the hosts order
+	hs2 := &hostSet{}
+	hs2.setFinalHost([]types.Host{hosts[2], hosts[0], hosts[1]})
+	maglev2 := newMaglevLoadBalancer(&clusterInfo{
+		lbConfig: &v2.MaglevLbConfig{TableSize: 307},
+	}, hs2).(*maglevLoadBalancer)
+	for i := range maglev.table {
+		if maglev.table[i] != maglev2.table[i] {
+			t.Fatal("maglev table should not depend on the hosts order")
+		}
+	}
+}
+
+func TestConsistentHashConfigJSON(t *testing.T) {
+	for _, tc := range []struct {
+		config string
+		check  func(lb types.LoadBalancer) bool
+	}{
+		{
+			config: `{"name":"ring_hash","lb_type":"LB_RING_HASH","ring_hash_lb_config":{"minimum_ring_size":30,"maximum_ring_size":60}}`,
+			check: func(lb types.LoadBalancer) bool {
+				ring, ok := lb.(*ringHashLoadBalancer)
+				return ok && len(ring.ring) == 30
+			},
+		},
+		{
+			config: `{"name":"maglev","lb_type":"LB_MAGLEV","maglev_lb_config":{"table_size":307}}`,
+			check: func(lb types.LoadBalancer) bool {
+				maglev, ok := lb.(*maglevLoadBalancer)
+				return ok && len(maglev.table) == 307
+			},
+		},
+	} {
+		cfg := v2.Cluster{}
+		if err := json.Unmarshal([]byte(tc.config), &cfg); err != nil {
+			t.Fatal(err)
+		}
+		// the config is kept after marshal
+		b, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg = v2.Cluster{}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		cluster := NewCluster(cfg)
+		cluster.UpdateHosts(makeHashHosts("10.12.6", []uint32{1, 1, 1}))
+		if lb := cluster.Snapshot().LoadBalancer(); !tc.check(lb) {
+			t.Errorf("%s: unexpected load balancer: %T", cfg.Name, lb)
+		}
+	}
+}
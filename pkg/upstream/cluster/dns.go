@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	dnsDefaultPort = "53"
+	// dnsUnknownTTL is returned if the resolver cannot get the ttl of records
+	dnsUnknownTTL time.Duration = -1
+)
+
+// DNSResolverFactory creates the resolver of a dns cluster
+type DNSResolverFactory func(cluster v2.Cluster) types.DNSResolver
+
+var (
+	dnsResolverFactory DNSResolverFactory = newDefaultDNSResolver
+	dnsFactoryMutex    sync.RWMutex
+)
+
+// RegisterDNSResolverFactory replaces the default dns resolver of the dns clusters
+func RegisterDNSResolverFactory(f DNSResolverFactory) {
+	dnsFactoryMutex.Lock()
+	defer dnsFactoryMutex.Unlock()
+	dnsResolverFactory = f
+}
+
+func newDNSResolver(cluster v2.Cluster) types.DNSResolver {
+	dnsFactoryMutex.RLock()
+	defer dnsFactoryMutex.RUnlock()
+	return dnsResolverFactory(cluster)
+}
+
+// dnsResolver is the default implementation of types.DNSResolver based on net.Resolver.
+// the ttl of records is unknown, so the dns clusters are resolved by the refresh rate,
+// registers a resolver that returns the ttl if respect_dns_ttl is needed.
+type dnsResolver struct {
+	resolver    *net.Resolver
+	nameservers []string
+	next        uint32
+}
+
+func newDefaultDNSResolver(cluster v2.Cluster) types.DNSResolver {
+	r := &dnsResolver{
+		resolver: net.DefaultResolver,
+	}
+	if len(cluster.DNSResolvers) == 0 {
+		return r
+	}
+	for _, ns := range cluster.DNSResolvers {
+		if _, _, err := net.SplitHostPort(ns); err != nil {
+			ns = net.JoinHostPort(ns, dnsDefaultPort)
+		}
+		r.nameservers = append(r.nameservers, ns)
+	}
+	r.resolver = &net.Resolver{
+		PreferGo: true,
+		Dial:     r.dial,
+	}
+	return r
+}
+
+// dial connects to the configured nameservers in turn instead of the ones in resolv.conf
+func (r *dnsResolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	ns := r.nameservers[int(atomic.AddUint32(&r.next, 1)-1)%len(r.nameservers)]
+	var d net.Dialer
+	return d.DialContext(ctx, network, ns)
+}
+
+// Resolve returns the ipv4 addresses first, and the ipv6 addresses if no ipv4 address found
+func (r *dnsResolver) Resolve(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+	if ip := net.ParseIP(name); ip != nil {
+		return []net.IP{ip}, dnsUnknownTTL, nil
+	}
+	addrs, err := r.resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, dnsUnknownTTL, err
+	}
+	var ipv4s, ipv6s []net.IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ipv4s = append(ipv4s, addr.IP)
+		} else {
+			ipv6s = append(ipv6s, addr.IP)
+		}
+	}
+	if len(ipv4s) > 0 {
+		return ipv4s, dnsUnknownTTL, nil
+	}
+	return ipv6s, dnsUnknownTTL, nil
+}
@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	DefaultDNSRefreshRate = 5 * time.Second
+	dnsResolveTimeout     = 5 * time.Second
+	// dnsMinTTLRefreshRate avoids resolving the records with zero ttl continuously
+	dnsMinTTLRefreshRate = time.Second
+)
+
+// dnsTarget is a configured host whose address is a domain name
+type dnsTarget struct {
+	config  v2.Host
+	name    string
+	port    string
+	addrs   []string // resolved addresses with port
+	timer   *time.Timer
+	stopped bool
+}
+
+// dnsCluster is a cluster whose hosts are resolved by dns periodically.
+// the configured hosts are the resolve targets, the host set is updated
+// only if the resolved addresses are changed.
+type dnsCluster struct {
+	*simpleCluster
+	resolver    types.DNSResolver
+	refreshRate time.Duration
+	respectTTL  bool
+	logical     bool
+	mutex       sync.Mutex
+	targets     []*dnsTarget
+	resolved    bool
+	stopped     bool
+}
+
+func newDNSCluster(clusterConfig v2.Cluster) *dnsCluster {
+	dc := &dnsCluster{
+		simpleCluster: newSimpleCluster(clusterConfig),
+		resolver:      newDNSResolver(clusterConfig),
+		refreshRate:   DefaultDNSRefreshRate,
+		respectTTL:    clusterConfig.RespectDNSTTL,
+		logical:       clusterConfig.ClusterType == v2.LOGICAL_DNS_CLUSTER,
+	}
+	if clusterConfig.DNSRefreshRate != nil && clusterConfig.DNSRefreshRate.Duration > 0 {
+		dc.refreshRate = clusterConfig.DNSRefreshRate.Duration
+	}
+	// the cluster without targets is not resolved, so it can keep the hosts of the old one
+	if len(clusterConfig.Hosts) > 0 {
+		dc.SetTargets(clusterConfig.Hosts)
+	}
+	return dc
+}
+
+// UpdateHosts sets the hosts directly before the targets are resolved,
+// so the cluster can keep the hosts of the old one when it is updated.
+// after the targets are resolved, the hosts are only updated by dns, the update is rejected.
+func (dc *dnsCluster) UpdateHosts(hosts []types.Host) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if dc.resolved {
+		log.DefaultLogger.Errorf("[upstream] [dns cluster] cluster %s update hosts failed: %v", dc.info.name, errTargetsCluster)
+		return
+	}
+	dc.simpleCluster.UpdateHosts(hosts)
+}
+
+// SetTargets sets the hosts to be resolved, the resolved addresses of unchanged targets are kept
+func (dc *dnsCluster) SetTargets(hostConfigs []v2.Host) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if dc.stopped {
+		return
+	}
+	old := make(map[string]*dnsTarget, len(dc.targets))
+	for _, t := range dc.targets {
+		t.stop()
+		old[t.config.Address] = t
+	}
+	targets := make([]*dnsTarget, 0, len(hostConfigs))
+	for _, hc := range hostConfigs {
+		name, port, err := net.SplitHostPort(hc.Address)
+		if err != nil {
+			log.DefaultLogger.Errorf("[upstream] [dns cluster] cluster %s invalid host address %s: %v", dc.info.name, hc.Address, err)
+			continue
+		}
+		t := &dnsTarget{
+			config: hc,
+			name:   name,
+			port:   port,
+		}
+		if ot, ok := old[hc.Address]; ok {
+			t.addrs = ot.addrs
+		}
+		targets = append(targets, t)
+	}
+	dc.targets = targets
+	if dc.resolved || len(targets) == 0 {
+		dc.resolved = true
+		dc.updateHosts()
+	}
+	for _, t := range targets {
+		target := t
+		utils.GoWithRecover(func() {
+			dc.resolveTarget(target)
+		}, nil)
+	}
+}
+
+// Stop stops resolving the targets
+func (dc *dnsCluster) Stop() {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	dc.stopped = true
+	for _, t := range dc.targets {
+		t.stop()
+	}
+}
+
+// stop should be called with lock
+func (t *dnsTarget) stop() {
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func (dc *dnsCluster) resolveTarget(t *dnsTarget) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsResolveTimeout)
+	ips, ttl, err := dc.resolver.Resolve(ctx, t.name)
+	cancel()
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if t.stopped {
+		return
+	}
+	next := dc.refreshRate
+	if err != nil {
+		// keeps the last resolved addresses
+		log.DefaultLogger.Errorf("[upstream] [dns cluster] cluster %s resolve %s failed: %v", dc.info.name, t.name, err)
+	} else {
+		if dc.respectTTL && ttl >= 0 {
+			next = ttl
+			if next < dnsMinTTLRefreshRate {
+				next = dnsMinTTLRefreshRate
+			}
+		}
+		addrs := dc.resolvedAddrs(t, ips)
+		if !dc.resolved || !equalAddrs(t.addrs, addrs) {
+			t.addrs = addrs
+			dc.resolved = true
+			dc.updateHosts()
+		}
+	}
+	t.timer = time.AfterFunc(next, func() {
+		dc.resolveTarget(t)
+	})
+}
+
+// resolvedAddrs returns the addresses that should be used as hosts.
+// logical dns cluster uses only one address, the current address is kept if it is still resolved.
+func (dc *dnsCluster) resolvedAddrs(t *dnsTarget, ips []net.IP) []string {
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), t.port))
+	}
+	if !dc.logical {
+		// the order of records may be changed by the nameserver
+		sort.Strings(addrs)
+		return addrs
+	}
+	if len(addrs) <= 1 {
+		return addrs
+	}
+	if len(t.addrs) == 1 {
+		for _, addr := range addrs {
+			if addr == t.addrs[0] {
+				return t.addrs
+			}
+		}
+	}
+	return addrs[:1]
+}
+
+// updateHosts creates the hosts as the cluster manager's UpdateClusterHosts does,
+// the stats and health flags are shared by the hosts with the same address.
+// updateHosts should be called with lock
+func (dc *dnsCluster) updateHosts() {
+	var hosts []types.Host
+	added := map[string]bool{}
+	for _, t := range dc.targets {
+		for _, addr := range t.addrs {
+			if added[addr] {
+				continue
+			}
+			added[addr] = true
+			hc := t.config
+			hc.Address = addr
+			if hc.Hostname == "" {
+				hc.Hostname = t.name
+			}
+			hosts = append(hosts, NewSimpleHost(hc, dc.info))
+		}
+	}
+	dc.simpleCluster.UpdateHosts(hosts)
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [dns cluster] cluster %s update hosts: %d", dc.info.name, len(hosts))
+	}
+}
+
+func equalAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,420 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	dnsTypeA     uint16 = 1
+	dnsTypeAAAA  uint16 = 28
+	dnsClassINET uint16 = 1
+	dnsHeaderLen        = 12
+)
+
+// fakeDNSServer is an in-process dns server that answers A and AAAA queries
+type fakeDNSServer struct {
+	conn    net.PacketConn
+	mutex   sync.Mutex
+	records map[string][]net.IP
+	ttls    map[string]uint32
+	queries int
+}
+
+func newFakeDNSServer(t *testing.T) *fakeDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeDNSServer{
+		conn:    conn,
+		records: map[string][]net.IP{},
+		ttls:    map[string]uint32{},
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeDNSServer) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *fakeDNSServer) Close() {
+	s.conn.Close()
+}
+
+func (s *fakeDNSServer) Set(name string, ttl uint32, ips ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ttls[name] = ttl
+	s.records[name] = nil
+	for _, ip := range ips {
+		s.records[name] = append(s.records[name], net.ParseIP(ip))
+	}
+}
+
+func (s *fakeDNSServer) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, name)
+}
+
+func (s *fakeDNSServer) Queries() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.queries
+}
+
+func (s *fakeDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *fakeDNSServer) answer(query []byte) []byte {
+	var labels []string
+	off := dnsHeaderLen
+	for off < len(query) && query[off] != 0 {
+		l := int(query[off])
+		labels = append(labels, string(query[off+1:off+1+l]))
+		off += l + 1
+	}
+	off++
+	qtype := binary.BigEndian.Uint16(query[off:])
+	question := query[dnsHeaderLen : off+4]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queries++
+	name := strings.Join(labels, ".")
+	ips, ok := s.records[name]
+	resp := make([]byte, dnsHeaderLen)
+	copy(resp, query[:2])
+	flags := uint16(0x8180)
+	if !ok {
+		flags |= 3 // NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	resp = append(resp, question...)
+	var ancount uint16
+	for _, ip := range ips {
+		rdata := []byte(ip.To4())
+		rtype := dnsTypeA
+		if rdata == nil {
+			rdata = []byte(ip.To16())
+			rtype = dnsTypeAAAA
+		}
+		if rtype != qtype {
+			continue
+		}
+		ancount++
+		rr := make([]byte, 12)
+		binary.BigEndian.PutUint16(rr[0:], 0xc00c) // pointer to the question name
+		binary.BigEndian.PutUint16(rr[2:], rtype)
+		binary.BigEndian.PutUint16(rr[4:], dnsClassINET)
+		binary.BigEndian.PutUint32(rr[6:], s.ttls[name])
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(rdata)))
+		resp = append(resp, rr...)
+		resp = append(resp, rdata...)
+	}
+	binary.BigEndian.PutUint16(resp[6:], ancount)
+	return resp
+}
+
+func TestDNSResolver(t *testing.T) {
+	server := newFakeDNSServer(t)
+	defer server.Close()
+	server.Set("a.mosn.test", 30, "10.13.1.1", "10.13.1.2")
+	server.Set("v6.mosn.test", 10, "fd00::1")
+	resolver := newDefaultDNSResolver(v2.Cluster{DNSResolvers: []string{server.Addr()}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ips, ttl, err := resolver.Resolve(ctx, "a.mosn.test")
+	if err != nil || len(ips) != 2 || ttl >= 0 {
+		t.Fatalf("resolve failed, ips: %v, ttl: %s, error: %v", ips, ttl, err)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		return ips[i].String() < ips[j].String()
+	})
+	if !ips[0].Equal(net.ParseIP("10.13.1.1")) || !ips[1].Equal(net.ParseIP("10.13.1.2")) {
+		t.Fatalf("unexpected ips: %v", ips)
+	}
+	// no ipv4 address, ipv6 address is used
+	ips, _, err = resolver.Resolve(ctx, "v6.mosn.test")
+	if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("fd00::1")) {
+		t.Fatalf("resolve failed, ips: %v, error: %v", ips, err)
+	}
+	// ip address is not resolved
+	queries := server.Queries()
+	ips, _, err = resolver.Resolve(ctx, "10.13.1.3")
+	if err != nil || len(ips) != 1 || server.Queries() != queries {
+		t.Fatalf("ip address should not be resolved, queries: %d", server.Queries())
+	}
+	if _, _, err := resolver.Resolve(ctx, "none.mosn.test"); err == nil {
+		t.Fatal("resolve unknown name should be failed")
+	}
+	// the system resolver is used if no nameserver configured
+	if _, _, err := newDefaultDNSResolver(v2.Cluster{}).Resolve(ctx, "localhost"); err != nil {
+		t.Fatalf("resolve localhost failed: %v", err)
+	}
+}
+
+func dnsClusterAddrs(c types.Cluster) string {
+	var addrs []string
+	for _, h := range c.Snapshot().HostSet().Hosts() {
+		addrs = append(addrs, h.AddressString())
+	}
+	sort.Strings(addrs)
+	return strings.Join(addrs, ",")
+}
+
+func waitDNSClusterAddrs(t *testing.T, c types.Cluster, expected string) {
+	for i := 0; i < 100; i++ {
+		if dnsClusterAddrs(c) == expected {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expected hosts %s, but got %s", expected, dnsClusterAddrs(c))
+}
+
+func TestStrictDNSCluster(t *testing.T) {
+	server := newFakeDNSServer(t)
+	defer server.Close()
+	server.Set("strict.mosn.test", 0, "10.13.3.1", "10.13.3.2")
+	server.Set("other.mosn.test", 0, "10.13.3.2", "10.13.3.3")
+	c := NewCluster(v2.Cluster{
+		Name:           "strict_dns",
+		ClusterType:    v2.STRICT_DNS_CLUSTER,
+		LbType:         v2.LB_ROUNDROBIN,
+		DNSResolvers:   []string{server.Addr()},
+		DNSRefreshRate: &api.DurationConfig{Duration: 50 * time.Millisecond},
+		Hosts: []v2.Host{
+			{HostConfig: v2.HostConfig{Address: "strict.mosn.test:8080", Weight: 10}},
+			{HostConfig: v2.HostConfig{Address: "other.mosn.test:8080"}},
+		},
+	})
+	defer c.(*dnsCluster).Stop()
+	// the same address is added once
+	waitDNSClusterAddrs(t, c, "10.13.3.1:8080,10.13.3.2:8080,10.13.3.3:8080")
+	for _, h := range c.Snapshot().HostSet().Hosts() {
+		if h.AddressString() == "10.13.3.1:8080" && (h.Weight() != 10 || h.Hostname() != "strict.mosn.test") {
+			t.Fatalf("host config is not expected, weight: %d, hostname: %s", h.Weight(), h.Hostname())
+		}
+	}
+	// re-resolved periodically
+	server.Set("strict.mosn.test", 0, "10.13.3.4")
+	waitDNSClusterAddrs(t, c, "10.13.3.2:8080,10.13.3.3:8080,10.13.3.4:8080")
+	// resolve failed, keeps the hosts
+	server.Remove("other.mosn.test")
+	time.Sleep(200 * time.Millisecond)
+	if addrs := dnsClusterAddrs(c); addrs != "10.13.3.2:8080,10.13.3.3:8080,10.13.3.4:8080" {
+		t.Fatalf("hosts should be kept when resolve failed, but got %s", addrs)
+	}
+	// stopped cluster is not resolved any more
+	c.(*dnsCluster).Stop()
+	time.Sleep(100 * time.Millisecond)
+	queries := server.Queries()
+	time.Sleep(200 * time.Millisecond)
+	if server.Queries() != queries {
+		t.Fatal("stopped dns cluster should not resolve")
+	}
+}
+
+func TestLogicalDNSCluster(t *testing.T) {
+	server := newFakeDNSServer(t)
+	defer server.Close()
+	server.Set("logical.mosn.test", 0, "10.13.4.1", "10.13.4.2")
+	c := NewCluster(v2.Cluster{
+		Name:           "logical_dns",
+		ClusterType:    v2.LOGICAL_DNS_CLUSTER,
+		LbType:         v2.LB_ROUNDROBIN,
+		DNSResolvers:   []string{server.Addr()},
+		DNSRefreshRate: &api.DurationConfig{Duration: 50 * time.Millisecond},
+		Hosts: []v2.Host{
+			{HostConfig: v2.HostConfig{Address: "logical.mosn.test:8080"}},
+		},
+	})
+	defer c.(*dnsCluster).Stop()
+	waitDNSClusterAddrs(t, c, "10.13.4.1:8080")
+	// the current address is kept if it is still resolved
+	server.Set("logical.mosn.test", 0, "10.13.4.3", "10.13.4.1")
+	time.Sleep(200 * time.Millisecond)
+	if addrs := dnsClusterAddrs(c); addrs != "10.13.4.1:8080" {
+		t.Fatalf("current address should be kept, but got %s", addrs)
+	}
+	server.Set("logical.mosn.test", 0, "10.13.4.3", "10.13.4.4")
+	waitDNSClusterAddrs(t, c, "10.13.4.3:8080")
+}
+
+type mockDNSResolver struct {
+	mutex   sync.Mutex
+	ips     map[string][]net.IP
+	ttl     time.Duration
+	queries int
+}
+
+func (r *mockDNSResolver) Resolve(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.queries++
+	return r.ips[name], r.ttl, nil
+}
+
+func (r *mockDNSResolver) Queries() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.queries
+}
+
+func TestDNSClusterRespectTTL(t *testing.T) {
+	resolver := &mockDNSResolver{
+		ips: map[string][]net.IP{
+			"ttl.mosn.test": {net.ParseIP("10.13.5.1")},
+		},
+		ttl: time.Hour,
+	}
+	RegisterDNSResolverFactory(func(cluster v2.Cluster) types.DNSResolver {
+		return resolver
+	})
+	defer RegisterDNSResolverFactory(newDefaultDNSResolver)
+	cfg := v2.Cluster{
+		Name:           "ttl_dns",
+		ClusterType:    v2.STRICT_DNS_CLUSTER,
+		DNSRefreshRate: &api.DurationConfig{Duration: 20 * time.Millisecond},
+		RespectDNSTTL:  true,
+		Hosts: []v2.Host{
+			{HostConfig: v2.HostConfig{Address: "ttl.mosn.test:8080"}},
+		},
+	}
+	c := NewCluster(cfg)
+	waitDNSClusterAddrs(t, c, "10.13.5.1:8080")
+	queries := resolver.Queries()
+	time.Sleep(200 * time.Millisecond)
+	if resolver.Queries() != queries {
+		t.Fatal("dns should not be resolved before ttl expired")
+	}
+	c.(*dnsCluster).Stop()
+	// zero ttl is not treated as unknown
+	resolver.mutex.Lock()
+	resolver.ttl = 0
+	resolver.mutex.Unlock()
+	c = NewCluster(cfg)
+	waitDNSClusterAddrs(t, c, "10.13.5.1:8080")
+	queries = resolver.Queries()
+	time.Sleep(200 * time.Millisecond)
+	if resolver.Queries() != queries {
+		t.Fatal("dns records with zero ttl should not be resolved continuously")
+	}
+	c.(*dnsCluster).Stop()
+	// unknown ttl, resolved by the refresh rate
+	resolver.mutex.Lock()
+	resolver.ttl = dnsUnknownTTL
+	resolver.mutex.Unlock()
+	c = NewCluster(cfg)
+	defer c.(*dnsCluster).Stop()
+	waitDNSClusterAddrs(t, c, "10.13.5.1:8080")
+	queries = resolver.Queries()
+	time.Sleep(200 * time.Millisecond)
+	if resolver.Queries() <= queries {
+		t.Fatal("dns should be resolved by the refresh rate if the ttl is unknown")
+	}
+}
+
+func TestDNSClusterInClusterManager(t *testing.T) {
+	resolver := &mockDNSResolver{
+		ips: map[string][]net.IP{
+			"a.mosn.test": {net.ParseIP("10.13.6.1")},
+			"b.mosn.test": {net.ParseIP("10.13.6.2")},
+		},
+	}
+	RegisterDNSResolverFactory(func(cluster v2.Cluster) types.DNSResolver {
+		return resolver
+	})
+	defer RegisterDNSResolverFactory(newDefaultDNSResolver)
+	cm := &clusterManager{}
+	cfg := v2.Cluster{
+		Name:        "dns_cluster_manager",
+		ClusterType: v2.STRICT_DNS_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}
+	if err := cm.AddOrUpdatePrimaryCluster(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.UpdateClusterHosts(cfg.Name, []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "a.mosn.test:80"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := cm.clustersMap.Load(cfg.Name)
+	waitDNSClusterAddrs(t, c.(types.Cluster), "10.13.6.1:80")
+	// update cluster, the resolved hosts are kept before the new cluster resolved
+	cfg.Hosts = []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "b.mosn.test:80"}},
+	}
+	if err := cm.AddOrUpdatePrimaryCluster(cfg); err != nil {
+		t.Fatal(err)
+	}
+	nc, _ := cm.clustersMap.Load(cfg.Name)
+	waitDNSClusterAddrs(t, nc.(types.Cluster), "10.13.6.2:80")
+	// the resolved hosts cannot be appended or removed
+	if err := cm.AppendClusterHosts(cfg.Name, []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "10.13.6.3:80"}},
+	}); err == nil {
+		t.Fatal("append hosts to dns cluster should be failed")
+	}
+	if err := cm.RemoveClusterHosts(cfg.Name, []string{"10.13.6.2:80"}); err == nil {
+		t.Fatal("remove hosts from dns cluster should be failed")
+	}
+	waitDNSClusterAddrs(t, nc.(types.Cluster), "10.13.6.2:80")
+	// the resolved hosts cannot be updated directly
+	nc.(types.Cluster).UpdateHosts([]types.Host{
+		NewSimpleHost(v2.Host{HostConfig: v2.HostConfig{Address: "10.13.6.3:80"}}, nc.(types.Cluster).Snapshot().ClusterInfo()),
+	})
+	if addrs := dnsClusterAddrs(nc.(types.Cluster)); addrs != "10.13.6.2:80" {
+		t.Fatalf("resolved hosts should not be updated, but got %s", addrs)
+	}
+	if !c.(*dnsCluster).stopped {
+		t.Fatal("old dns cluster should be stopped")
+	}
+	if err := cm.RemovePrimaryCluster(cfg.Name); err != nil {
+		t.Fatal(err)
+	}
+	if !nc.(*dnsCluster).stopped {
+		t.Fatal("removed dns cluster should be stopped")
+	}
+}
@@ -24,6 +24,10 @@ import (
 	"mosn.io/api"
 )
 
+// clusterHealthFlagsMask are the health flags that are kept in the host of a cluster, instead of being shared
+// by the hosts of the same address. the outlier detection ejects a host in its own cluster only
+const clusterHealthFlagsMask = api.FAILED_OUTLIER_CHECK
+
 // health flag resue for same address
 // TODO: use one map for all reuse data
 var healthStore = sync.Map{}
@@ -41,6 +41,8 @@ type simpleHost struct {
 	tlsDisable    bool
 	weight        uint32
 	healthFlags   *uint64
+	// clusterHealthFlags are the health flags of the host in its cluster, see clusterHealthFlagsMask
+	clusterHealthFlags uint64
 }
 
 func NewSimpleHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
@@ -120,30 +122,40 @@ func (sh *simpleHost) CreateConnection(context context.Context) types.CreateConn
 }
 
 func (sh *simpleHost) ClearHealthFlag(flag api.HealthFlag) {
-	ClearHealthFlag(sh.healthFlags, flag)
+	if f := flag & clusterHealthFlagsMask; f != 0 {
+		ClearHealthFlag(&sh.clusterHealthFlags, f)
+	}
+	if f := flag &^ clusterHealthFlagsMask; f != 0 {
+		ClearHealthFlag(sh.healthFlags, f)
+	}
 }
 
 func (sh *simpleHost) ContainHealthFlag(flag api.HealthFlag) bool {
-	return atomic.LoadUint64(sh.healthFlags)&uint64(flag) > 0
+	return uint64(sh.HealthFlag())&uint64(flag) > 0
 }
 
 func (sh *simpleHost) SetHealthFlag(flag api.HealthFlag) {
-	SetHealthFlag(sh.healthFlags, flag)
+	if f := flag & clusterHealthFlagsMask; f != 0 {
+		SetHealthFlag(&sh.clusterHealthFlags, f)
+	}
+	if f := flag &^ clusterHealthFlagsMask; f != 0 {
+		SetHealthFlag(sh.healthFlags, f)
+	}
 }
 
 func (sh *simpleHost) HealthFlag() api.HealthFlag {
-	return api.HealthFlag(atomic.LoadUint64(sh.healthFlags))
+	return api.HealthFlag(atomic.LoadUint64(sh.healthFlags) | atomic.LoadUint64(&sh.clusterHealthFlags))
 }
 
 func (sh *simpleHost) Health() bool {
-	return atomic.LoadUint64(sh.healthFlags) == 0
+	return sh.HealthFlag() == 0
 }
 
 // net.Addr reuse for same address, valid in simple type
 // Update DNS cache using asynchronous mode
 var AddrStore *utils.ExpiredMap = utils.NewExpiredMap(
 	func(key interface{}) (interface{}, bool) {
-		addr, err := net.ResolveTCPAddr("tcp", key.(string))
+		addr, err := v2.ResolveAddress(key.(string))
 		if err == nil {
 			return addr, true
 		}
@@ -156,13 +168,14 @@ func GetOrCreateAddr(addrstr string) net.Addr {
 		return addr.(net.Addr)
 	}
 
-	addr, err := net.ResolveTCPAddr("tcp", addrstr)
+	// the address with unix: prefix is a unix domain socket
+	addr, err := v2.ResolveAddress(addrstr)
 	if err != nil {
 		log.DefaultLogger.Errorf("[upstream] resolve addr %s failed: %v", addrstr, err)
 		return nil
 	}
 
-	if addr.String() != addrstr {
+	if v2.AddressString(addr) != addrstr {
 		// TODO support config or depends on DNS TTL for expire time
 		// now set default expire time == 100 s, Means that after 100 seconds, the new request will trigger domain resolve.
 		AddrStore.Set(addrstr, addr, 100*time.Second)
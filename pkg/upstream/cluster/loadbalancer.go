@@ -236,10 +236,11 @@ type leastActiveRequestLoadBalancer struct {
 
 func newleastActiveRequestLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
 	lb := &leastActiveRequestLoadBalancer{}
-	if info != nil && info.LbConfig() != nil {
-		lb.choice = info.LbConfig().(*v2.LeastRequestLbConfig).ChoiceCount
-	} else {
-		lb.choice = default_choice
+	lb.choice = default_choice
+	if info != nil {
+		if cfg, ok := info.LbConfig().(*v2.LeastRequestLbConfig); ok && cfg.ChoiceCount > 0 {
+			lb.choice = cfg.ChoiceCount
+		}
 	}
 	lb.EdfLoadBalancer = newEdfLoadBalancerLoadBalancer(hosts, lb.unweightChooseHost, lb.hostWeight)
 	return lb
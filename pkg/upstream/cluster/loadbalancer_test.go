@@ -300,3 +300,52 @@ func mockRequest(host types.Host, active bool, times int) {
 		}
 	}
 }
+
+type predicateLbContext struct {
+	types.LoadBalancerContext
+	rejected    map[string]bool
+	maxAttempts int
+}
+
+func (ctx *predicateLbContext) ShouldSelectAnotherHost(host types.Host) bool {
+	return ctx.rejected[host.AddressString()]
+}
+
+func (ctx *predicateLbContext) HostSelectionRetryMaxAttempts() int {
+	return ctx.maxAttempts
+}
+
+func TestChooseHostWithPredicate(t *testing.T) {
+	pool := makePool(3)
+	var hosts []types.Host
+	for i := 0; i < 3; i++ {
+		hosts = append(hosts, &mockHost{
+			addr: pool.Get(),
+		})
+	}
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	lb := rrFactory.newRoundRobinLoadBalancer(nil, hs)
+	ctx := &predicateLbContext{
+		LoadBalancerContext: newMockLbContext(nil),
+		rejected: map[string]bool{
+			hosts[0].AddressString(): true,
+			hosts[1].AddressString(): true,
+		},
+		maxAttempts: 3,
+	}
+	for i := 0; i < 10; i++ {
+		if host := chooseHost(lb, ctx); host.AddressString() != hosts[2].AddressString() {
+			t.Fatalf("expected choose host %s, but got %s", hosts[2].AddressString(), host.AddressString())
+		}
+	}
+	// the last chosen host is used if all of the attempts are rejected
+	ctx.maxAttempts = 0
+	rejected := 0
+	for i := 0; i < 3; i++ {
+		if ctx.rejected[chooseHost(lb, ctx).AddressString()] {
+			rejected++
+		}
+	}
+	assert.Equal(t, 2, rejected)
+}
@@ -55,6 +55,10 @@ func (c *LbCtx) DownstreamCluster() types.ClusterInfo {
 	return c.cluster
 }
 
+func (c *LbCtx) HashKey() (uint64, bool) {
+	return 0, false
+}
+
 type Header struct {
 	v map[string]string
 }
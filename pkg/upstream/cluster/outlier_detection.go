@@ -0,0 +1,306 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// default outlier detection configs
+const (
+	DefaultOutlierInterval                 = 10 * time.Second
+	DefaultOutlierBaseEjectionTime         = 30 * time.Second
+	DefaultOutlierMaxEjectionTime          = 300 * time.Second
+	DefaultOutlierMaxEjectionPercent       = 10
+	DefaultOutlierSuccessRateRequestVolume = 100
+	DefaultOutlierSuccessRateStdevFactor   = 1900
+)
+
+// ejectReason is the reason why a host is ejected
+type ejectReason int
+
+const (
+	ejectConsecutive5xx ejectReason = iota
+	ejectConsecutiveConnectFailure
+	ejectSuccessRate
+)
+
+// outlierHostState records a host's results in the outlier detector
+type outlierHostState struct {
+	host types.Host
+	// updated by PutResult, atomic
+	consecutive5xx            uint32
+	consecutiveConnectFailure uint32
+	success                   uint64
+	total                     uint64
+	// protected by the detector's mutex
+	ejected    bool
+	ejectTime  time.Time
+	ejectTimes uint32
+}
+
+// outlierDetector is an implementation of types.OutlierDetector
+type outlierDetector struct {
+	// config
+	consecutive5xx            uint32
+	consecutiveConnectFailure uint32
+	interval                  time.Duration
+	baseEjectionTime          time.Duration
+	maxEjectionTime           time.Duration
+	maxEjectionPercent        uint32
+	successRateMinimumHosts   uint32
+	successRateRequestVolume  uint64
+	successRateStdevFactor    float64
+	// runtime
+	stats   types.ClusterStats
+	mutex   sync.RWMutex
+	hosts   map[string]*outlierHostState
+	ejected int
+	ticker  *utils.Ticker
+}
+
+func newOutlierDetector(cfg *v2.OutlierDetection, stats types.ClusterStats) *outlierDetector {
+	od := &outlierDetector{
+		consecutive5xx:            cfg.Consecutive5xx,
+		consecutiveConnectFailure: cfg.ConsecutiveConnectionFailure,
+		interval:                  DefaultOutlierInterval,
+		baseEjectionTime:          DefaultOutlierBaseEjectionTime,
+		maxEjectionTime:           DefaultOutlierMaxEjectionTime,
+		maxEjectionPercent:        DefaultOutlierMaxEjectionPercent,
+		successRateMinimumHosts:   cfg.SuccessRateMinimumHosts,
+		successRateRequestVolume:  DefaultOutlierSuccessRateRequestVolume,
+		successRateStdevFactor:    float64(DefaultOutlierSuccessRateStdevFactor) / 1000,
+		stats:                     stats,
+		hosts:                     map[string]*outlierHostState{},
+	}
+	if cfg.Interval.Duration > 0 {
+		od.interval = cfg.Interval.Duration
+	}
+	if cfg.BaseEjectionTime.Duration > 0 {
+		od.baseEjectionTime = cfg.BaseEjectionTime.Duration
+	}
+	if cfg.MaxEjectionTime.Duration > 0 {
+		od.maxEjectionTime = cfg.MaxEjectionTime.Duration
+	}
+	if cfg.MaxEjectionPercent > 0 {
+		od.maxEjectionPercent = cfg.MaxEjectionPercent
+	}
+	if cfg.SuccessRateRequestVolume > 0 {
+		od.successRateRequestVolume = uint64(cfg.SuccessRateRequestVolume)
+	}
+	if cfg.SuccessRateStdevFactor > 0 {
+		od.successRateStdevFactor = float64(cfg.SuccessRateStdevFactor) / 1000
+	}
+	od.ticker = utils.NewTicker(od.onInterval)
+	od.ticker.Start(od.interval)
+	return od
+}
+
+func (od *outlierDetector) getState(host types.Host) *outlierHostState {
+	od.mutex.RLock()
+	defer od.mutex.RUnlock()
+	return od.hosts[host.AddressString()]
+}
+
+func (od *outlierDetector) PutResult(host types.Host, result types.OutlierResult) {
+	state := od.getState(host)
+	if state == nil {
+		return
+	}
+	atomic.AddUint64(&state.total, 1)
+	switch result {
+	case types.OutlierResultSuccess:
+		atomic.AddUint64(&state.success, 1)
+		atomic.StoreUint32(&state.consecutive5xx, 0)
+		atomic.StoreUint32(&state.consecutiveConnectFailure, 0)
+	case types.OutlierResultServerError:
+		atomic.StoreUint32(&state.consecutiveConnectFailure, 0)
+		if od.consecutive5xx > 0 && atomic.AddUint32(&state.consecutive5xx, 1) >= od.consecutive5xx {
+			od.eject(state, ejectConsecutive5xx, time.Now())
+		}
+	case types.OutlierResultConnectFailed:
+		if od.consecutiveConnectFailure > 0 && atomic.AddUint32(&state.consecutiveConnectFailure, 1) >= od.consecutiveConnectFailure {
+			od.eject(state, ejectConsecutiveConnectFailure, time.Now())
+		}
+	}
+}
+
+func (od *outlierDetector) SetHosts(hosts []types.Host) {
+	od.mutex.Lock()
+	defer od.mutex.Unlock()
+	states := make(map[string]*outlierHostState, len(hosts))
+	for _, h := range hosts {
+		addr := h.AddressString()
+		if state, ok := od.hosts[addr]; ok {
+			// the ejection is kept in the host, so it is moved to the new host of the same address
+			if state.ejected && state.host != h {
+				state.host.ClearHealthFlag(api.FAILED_OUTLIER_CHECK)
+				h.SetHealthFlag(api.FAILED_OUTLIER_CHECK)
+			}
+			state.host = h
+			states[addr] = state
+			delete(od.hosts, addr)
+			continue
+		}
+		states[addr] = &outlierHostState{
+			host: h,
+		}
+	}
+	// the removed hosts should be released
+	for _, state := range od.hosts {
+		if state.ejected {
+			od.uneject(state)
+		}
+	}
+	od.hosts = states
+}
+
+func (od *outlierDetector) Stop() {
+	od.ticker.Stop()
+	od.mutex.Lock()
+	defer od.mutex.Unlock()
+	for _, state := range od.hosts {
+		if state.ejected {
+			od.uneject(state)
+		}
+	}
+}
+
+// ejectionDuration grows exponentially with the host's ejected times, and it is limited by max ejection time
+func (od *outlierDetector) ejectionDuration(ejectTimes uint32) time.Duration {
+	d := od.baseEjectionTime
+	for i := uint32(1); i < ejectTimes; i++ {
+		d *= 2
+		if d >= od.maxEjectionTime {
+			return od.maxEjectionTime
+		}
+	}
+	if d > od.maxEjectionTime {
+		return od.maxEjectionTime
+	}
+	return d
+}
+
+func (od *outlierDetector) eject(state *outlierHostState, reason ejectReason, now time.Time) {
+	od.mutex.Lock()
+	defer od.mutex.Unlock()
+	addr := state.host.AddressString()
+	// the host may be removed or ejected already
+	if od.hosts[addr] != state || state.ejected {
+		return
+	}
+	atomic.StoreUint32(&state.consecutive5xx, 0)
+	atomic.StoreUint32(&state.consecutiveConnectFailure, 0)
+	if uint32(od.ejected*100/len(od.hosts)) >= od.maxEjectionPercent {
+		od.stats.OutlierEjectOverflow.Inc(1)
+		log.DefaultLogger.Warnf("[upstream] [outlier detection] host %s should be ejected, but ejected hosts reach the max percent %d", addr, od.maxEjectionPercent)
+		return
+	}
+	state.ejected = true
+	state.ejectTime = now
+	state.ejectTimes++
+	od.ejected++
+	state.host.SetHealthFlag(api.FAILED_OUTLIER_CHECK)
+	state.host.HostStats().UpstreamOutlierEjected.Inc(1)
+	od.stats.OutlierEjectTotal.Inc(1)
+	od.stats.OutlierEjectActive.Update(int64(od.ejected))
+	switch reason {
+	case ejectConsecutive5xx:
+		od.stats.OutlierEjectConsecutive5xx.Inc(1)
+	case ejectConsecutiveConnectFailure:
+		od.stats.OutlierEjectConsecutiveConnectFailure.Inc(1)
+	case ejectSuccessRate:
+		od.stats.OutlierEjectSuccessRate.Inc(1)
+	}
+	log.DefaultLogger.Infof("[upstream] [outlier detection] host %s is ejected for %s, reason: %d", addr, od.ejectionDuration(state.ejectTimes), reason)
+}
+
+// uneject should be called with lock
+func (od *outlierDetector) uneject(state *outlierHostState) {
+	state.ejected = false
+	od.ejected--
+	state.host.ClearHealthFlag(api.FAILED_OUTLIER_CHECK)
+	od.stats.OutlierEjectActive.Update(int64(od.ejected))
+	log.DefaultLogger.Infof("[upstream] [outlier detection] host %s is released", state.host.AddressString())
+}
+
+func (od *outlierDetector) onInterval() {
+	od.evaluate(time.Now())
+}
+
+// evaluate releases the hosts whose ejection time is up, and ejects the hosts
+// whose success rate is lower than the threshold
+func (od *outlierDetector) evaluate(now time.Time) {
+	var candidates []*outlierHostState
+	var rates []float64
+	func() {
+		od.mutex.Lock()
+		defer od.mutex.Unlock()
+		for _, state := range od.hosts {
+			success := atomic.SwapUint64(&state.success, 0)
+			total := atomic.SwapUint64(&state.total, 0)
+			if state.ejected {
+				if !now.Before(state.ejectTime.Add(od.ejectionDuration(state.ejectTimes))) {
+					od.uneject(state)
+				}
+				continue
+			}
+			// a host that keeps healthy for an interval decreases the ejection duration
+			if state.ejectTimes > 0 && !now.Before(state.ejectTime.Add(od.ejectionDuration(state.ejectTimes)+od.interval)) {
+				state.ejectTimes--
+			}
+			if total > 0 && total >= od.successRateRequestVolume {
+				candidates = append(candidates, state)
+				rates = append(rates, float64(success)*100/float64(total))
+			}
+		}
+	}()
+	if od.successRateMinimumHosts == 0 || len(candidates) < int(od.successRateMinimumHosts) {
+		return
+	}
+	threshold := successRateThreshold(rates, od.successRateStdevFactor)
+	for i, state := range candidates {
+		if rates[i] < threshold {
+			od.eject(state, ejectSuccessRate, now)
+		}
+	}
+}
+
+// successRateThreshold returns mean - stdev * factor
+func successRateThreshold(rates []float64, factor float64) float64 {
+	var sum float64
+	for _, r := range rates {
+		sum += r
+	}
+	mean := sum / float64(len(rates))
+	var variance float64
+	for _, r := range rates {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(rates))
+	return mean - math.Sqrt(variance)*factor
+}
@@ -0,0 +1,314 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func makeOutlierHosts(prefix string, size int) []types.Host {
+	hosts := make([]types.Host, size)
+	for i := 0; i < size; i++ {
+		addr := fmt.Sprintf("%s.%d:80", prefix, i)
+		hosts[i] = &mockHost{
+			addr:  addr,
+			stats: newHostStats(fmt.Sprintf("outlier_%d", time.Now().UnixNano()), addr),
+		}
+	}
+	return hosts
+}
+
+func newTestOutlierDetector(cfg *v2.OutlierDetection, hosts []types.Host) *outlierDetector {
+	od := newOutlierDetector(cfg, newClusterStats(fmt.Sprintf("outlier_%d", time.Now().UnixNano())))
+	// evaluate is called manually in test
+	od.ticker.Stop()
+	od.SetHosts(hosts)
+	return od
+}
+
+func TestOutlierConsecutive5xx(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.1", 10)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		Consecutive5xx:   3,
+		BaseEjectionTime: api.DurationConfig{Duration: time.Second},
+	}, hosts)
+	defer od.Stop()
+	h := hosts[0]
+	od.PutResult(h, types.OutlierResultServerError)
+	od.PutResult(h, types.OutlierResultServerError)
+	// success resets the consecutive count
+	od.PutResult(h, types.OutlierResultSuccess)
+	od.PutResult(h, types.OutlierResultServerError)
+	od.PutResult(h, types.OutlierResultServerError)
+	if !h.Health() {
+		t.Fatal("host should not be ejected")
+	}
+	od.PutResult(h, types.OutlierResultServerError)
+	if h.Health() || h.HealthFlag() != api.FAILED_OUTLIER_CHECK {
+		t.Fatal("host should be ejected")
+	}
+	if h.HostStats().UpstreamOutlierEjected.Count() != 1 ||
+		od.stats.OutlierEjectConsecutive5xx.Count() != 1 ||
+		od.stats.OutlierEjectActive.Value() != 1 {
+		t.Fatal("ejection stats is not expected")
+	}
+	// not released yet
+	od.evaluate(time.Now().Add(500 * time.Millisecond))
+	if h.Health() {
+		t.Fatal("host should be still ejected")
+	}
+	od.evaluate(time.Now().Add(time.Second))
+	if !h.Health() {
+		t.Fatal("host should be released")
+	}
+	if od.stats.OutlierEjectActive.Value() != 0 {
+		t.Fatal("ejection active stats is not expected")
+	}
+}
+
+func TestOutlierConsecutiveConnectFailure(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.2", 10)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		ConsecutiveConnectionFailure: 2,
+	}, hosts)
+	defer od.Stop()
+	h := hosts[1]
+	od.PutResult(h, types.OutlierResultConnectFailed)
+	od.PutResult(h, types.OutlierResultConnectFailed)
+	if h.Health() {
+		t.Fatal("host should be ejected")
+	}
+	if od.stats.OutlierEjectConsecutiveConnectFailure.Count() != 1 {
+		t.Fatal("ejection stats is not expected")
+	}
+	// 5xx is not configured, never ejected
+	h2 := hosts[2]
+	for i := 0; i < 100; i++ {
+		od.PutResult(h2, types.OutlierResultServerError)
+	}
+	if !h2.Health() {
+		t.Fatal("host should not be ejected")
+	}
+}
+
+func TestOutlierEjectionDuration(t *testing.T) {
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		BaseEjectionTime: api.DurationConfig{Duration: time.Second},
+		MaxEjectionTime:  api.DurationConfig{Duration: 5 * time.Second},
+	}, nil)
+	defer od.Stop()
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range expected {
+		if got := od.ejectionDuration(uint32(i + 1)); got != d {
+			t.Fatalf("ejection times %d expected duration %s, but got %s", i+1, d, got)
+		}
+	}
+}
+
+func TestOutlierExponentialEjection(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.3", 10)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		Consecutive5xx:   1,
+		Interval:         api.DurationConfig{Duration: time.Second},
+		BaseEjectionTime: api.DurationConfig{Duration: time.Second},
+	}, hosts)
+	defer od.Stop()
+	h := hosts[0]
+	now := time.Now()
+	od.eject(od.getState(h), ejectConsecutive5xx, now)
+	od.evaluate(now.Add(time.Second))
+	if !h.Health() {
+		t.Fatal("host should be released")
+	}
+	// ejected again, duration is doubled
+	now = now.Add(time.Second)
+	od.eject(od.getState(h), ejectConsecutive5xx, now)
+	od.evaluate(now.Add(time.Second))
+	if h.Health() {
+		t.Fatal("host should be still ejected")
+	}
+	od.evaluate(now.Add(2 * time.Second))
+	if !h.Health() {
+		t.Fatal("host should be released")
+	}
+	// keeps healthy, ejection times decreased
+	od.evaluate(now.Add(3 * time.Second))
+	if state := od.getState(h); state.ejectTimes != 1 {
+		t.Fatalf("expected ejection times decreased, but got %d", state.ejectTimes)
+	}
+}
+
+func TestOutlierMaxEjectionPercent(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.4", 10)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		Consecutive5xx:     1,
+		MaxEjectionPercent: 20,
+	}, hosts)
+	defer od.Stop()
+	for _, h := range hosts {
+		od.PutResult(h, types.OutlierResultServerError)
+	}
+	unhealthy := 0
+	for _, h := range hosts {
+		if !h.Health() {
+			unhealthy++
+		}
+	}
+	if unhealthy != 2 {
+		t.Fatalf("expected 2 hosts ejected, but got %d", unhealthy)
+	}
+	if od.stats.OutlierEjectOverflow.Count() != 8 {
+		t.Fatalf("expected 8 ejection overflow, but got %d", od.stats.OutlierEjectOverflow.Count())
+	}
+	// stop releases all hosts
+	od.Stop()
+	for _, h := range hosts {
+		if !h.Health() {
+			t.Fatal("host should be released after detector stopped")
+		}
+	}
+}
+
+func TestOutlierSuccessRate(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.5", 5)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		SuccessRateMinimumHosts:  5,
+		SuccessRateRequestVolume: 10,
+		MaxEjectionPercent:       100,
+	}, hosts)
+	defer od.Stop()
+	for i, h := range hosts {
+		for j := 0; j < 100; j++ {
+			if i == 0 && j%2 == 0 {
+				od.PutResult(h, types.OutlierResultServerError)
+				continue
+			}
+			od.PutResult(h, types.OutlierResultSuccess)
+		}
+	}
+	od.evaluate(time.Now())
+	if hosts[0].Health() {
+		t.Fatal("host with low success rate should be ejected")
+	}
+	for _, h := range hosts[1:] {
+		if !h.Health() {
+			t.Fatal("host should not be ejected")
+		}
+	}
+	if od.stats.OutlierEjectSuccessRate.Count() != 1 {
+		t.Fatal("ejection stats is not expected")
+	}
+}
+
+func TestOutlierSetHosts(t *testing.T) {
+	hosts := makeOutlierHosts("10.11.6", 10)
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		Consecutive5xx: 1,
+	}, hosts)
+	defer od.Stop()
+	od.PutResult(hosts[0], types.OutlierResultServerError)
+	od.PutResult(hosts[0], types.OutlierResultServerError) // ignore, already ejected
+	if hosts[0].Health() {
+		t.Fatal("host should be ejected")
+	}
+	// keeps the ejected state
+	od.SetHosts(hosts[:5])
+	if hosts[0].Health() {
+		t.Fatal("host should be still ejected")
+	}
+	// removed host is released
+	od.SetHosts(hosts[1:5])
+	if !hosts[0].Health() {
+		t.Fatal("removed host should be released")
+	}
+	// not watched host is ignored
+	od.PutResult(hosts[0], types.OutlierResultServerError)
+	if !hosts[0].Health() {
+		t.Fatal("not watched host should not be ejected")
+	}
+}
+
+func TestClusterWithOutlierDetection(t *testing.T) {
+	cluster := NewCluster(v2.Cluster{
+		Name:   "outlier_cluster",
+		LbType: v2.LB_ROUNDROBIN,
+		OutlierDetection: &v2.OutlierDetection{
+			Consecutive5xx: 1,
+		},
+	})
+	defer cluster.Snapshot().ClusterInfo().OutlierDetector().Stop()
+	hosts := makeOutlierHosts("10.11.7", 2)
+	cluster.UpdateHosts(hosts)
+	snap := cluster.Snapshot()
+	od := snap.ClusterInfo().OutlierDetector()
+	if od == nil {
+		t.Fatal("cluster should have an outlier detector")
+	}
+	od.PutResult(hosts[0], types.OutlierResultServerError)
+	for i := 0; i < 10; i++ {
+		if h := snap.LoadBalancer().ChooseHost(nil); h != hosts[1] {
+			t.Fatalf("ejected host should not be chosen, but got %s", h.AddressString())
+		}
+	}
+}
+
+func TestOutlierEjectionInCluster(t *testing.T) {
+	newHost := func(clusterName string) types.Host {
+		cluster := NewCluster(v2.Cluster{
+			Name:   clusterName,
+			LbType: v2.LB_ROUNDROBIN,
+		})
+		return NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: "10.11.8.1:80",
+			},
+		}, cluster.Snapshot().ClusterInfo())
+	}
+	h1, h2 := newHost("outlier_cluster_1"), newHost("outlier_cluster_2")
+	od := newTestOutlierDetector(&v2.OutlierDetection{
+		Consecutive5xx:     1,
+		MaxEjectionPercent: 100,
+	}, []types.Host{h1})
+	defer od.Stop()
+	od.PutResult(h1, types.OutlierResultServerError)
+	if h1.Health() || !h1.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should be ejected")
+	}
+	// the host of the same address in another cluster is not ejected
+	if !h2.Health() {
+		t.Fatalf("host in another cluster should not be ejected, flag: %d", h2.HealthFlag())
+	}
+	// the active health check flag is still shared by the address
+	h2.SetHealthFlag(api.FAILED_ACTIVE_HC)
+	if !h1.ContainHealthFlag(api.FAILED_ACTIVE_HC) {
+		t.Fatal("active health check flag should be shared")
+	}
+	h2.ClearHealthFlag(api.FAILED_ACTIVE_HC)
+	// the ejection is kept in the new host of the same address
+	h3 := newHost("outlier_cluster_1")
+	od.SetHosts([]types.Host{h3})
+	if h3.Health() || !h1.Health() {
+		t.Fatal("ejection should be moved to the new host")
+	}
+}
@@ -46,6 +46,7 @@ func newHostStats(clustername string, addr string) types.HostStats {
 		UpstreamRequestDurationTotal:                   s.Counter(metrics.UpstreamRequestDurationTotal),
 		UpstreamResponseSuccess:                        s.Counter(metrics.UpstreamResponseSuccess),
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
+		UpstreamOutlierEjected:                         s.Counter(metrics.UpstreamOutlierEjected),
 	}
 }
 
@@ -70,6 +71,8 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamRequestRemoteReset:                     s.Counter(metrics.UpstreamRequestRemoteReset),
 		UpstreamRequestRetry:                           s.Counter(metrics.UpstreamRequestRetry),
 		UpstreamRequestRetryOverflow:                   s.Counter(metrics.UpstreamRequestRetryOverflow),
+		UpstreamRequestMirrorSuccess:                   s.Counter(metrics.UpstreamRequestMirrorSuccess),
+		UpstreamRequestMirrorFailure:                   s.Counter(metrics.UpstreamRequestMirrorFailure),
 		UpstreamRequestTimeout:                         s.Counter(metrics.UpstreamRequestTimeout),
 		UpstreamRequestFailureEject:                    s.Counter(metrics.UpstreamRequestFailureEject),
 		UpstreamRequestPendingOverflow:                 s.Counter(metrics.UpstreamRequestPendingOverflow),
@@ -79,5 +82,11 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
 		LBSubSetsFallBack:                              s.Counter(metrics.UpstreamLBSubSetsFallBack),
 		LBSubsetsCreated:                               s.Gauge(metrics.UpstreamLBSubsetsCreated),
+		OutlierEjectTotal:                              s.Counter(metrics.UpstreamOutlierEjectTotal),
+		OutlierEjectActive:                             s.Gauge(metrics.UpstreamOutlierEjectActive),
+		OutlierEjectConsecutive5xx:                     s.Counter(metrics.UpstreamOutlierEjectConsecutive5xx),
+		OutlierEjectConsecutiveConnectFailure:          s.Counter(metrics.UpstreamOutlierEjectConsecutiveConnectFailure),
+		OutlierEjectSuccessRate:                        s.Counter(metrics.UpstreamOutlierEjectSuccessRate),
+		OutlierEjectOverflow:                           s.Counter(metrics.UpstreamOutlierEjectOverflow),
 	}
 }
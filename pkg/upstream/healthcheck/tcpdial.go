@@ -19,8 +19,10 @@ package healthcheck
 
 import (
 	"net"
+	"strings"
 	"time"
 
+	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 )
@@ -28,18 +30,28 @@ import (
 type TCPDialSessionFactory struct{}
 
 func (f *TCPDialSessionFactory) NewSession(cfg map[string]interface{}, host types.Host) types.HealthCheckSession {
+	addr := host.AddressString()
+	// the unix domain socket host is checked by dialing the socket file
+	if v2.IsUnixAddress(addr) {
+		return &TCPDialSession{
+			network: "unix",
+			addr:    strings.TrimPrefix(addr, v2.UnixAddressPrefix),
+		}
+	}
 	return &TCPDialSession{
-		addr: host.AddressString(),
+		network: "tcp",
+		addr:    addr,
 	}
 }
 
 type TCPDialSession struct {
-	addr string
+	network string
+	addr    string
 }
 
 func (s *TCPDialSession) CheckHealth() bool {
 	// default dial timeout, maybe already timeout by checker
-	conn, err := net.DialTimeout("tcp", s.addr, 30*time.Second)
+	conn, err := net.DialTimeout(s.network, s.addr, 30*time.Second)
 	if err != nil {
 		log.DefaultLogger.Infof("[upstream] [health check] [tcpdial session] dial tcp for host %s error: %v", s.addr, err)
 		return false
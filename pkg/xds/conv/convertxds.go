@@ -20,6 +20,8 @@ package conv
 import (
 	"fmt"
 	"net"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,6 +51,7 @@ import (
 	"mosn.io/mosn/pkg/router"
 	payloadlimit "mosn.io/mosn/pkg/xds/model/filter/http/payloadlimit/v2"
 	xdsxproxy "mosn.io/mosn/pkg/xds/model/filter/network/x_proxy/v2"
+	srds "mosn.io/mosn/pkg/xds/model/srds/v2"
 	"mosn.io/mosn/pkg/xds/v2/rds"
 )
 
@@ -101,10 +104,9 @@ func ConvertListenerConfig(xdsListener *xdsapi.Listener) *v2.Listener {
 
 	listenerConfig.FilterChains = convertFilterChains(xdsListener.GetFilterChains())
 
-	if listenerConfig.FilterChains != nil &&
-		len(listenerConfig.FilterChains) == 1 &&
-		listenerConfig.FilterChains[0].Filters != nil {
-		listenerConfig.StreamFilters = convertStreamFilters(&xdsListener.FilterChains[0].Filters[0])
+	// each filter chain has its own stream filters, the listener's stream filters are kept for the single filter chain
+	if len(listenerConfig.FilterChains) == 1 {
+		listenerConfig.StreamFilters = listenerConfig.FilterChains[0].StreamFilters
 	}
 
 	return listenerConfig
@@ -125,11 +127,15 @@ func ConvertClustersConfig(xdsClusters []*xdsapi.Cluster) []*v2.Cluster {
 			ConnBufferLimitBytes: xdsCluster.GetPerConnectionBufferLimitBytes().GetValue(),
 			HealthCheck:          convertHealthChecks(xdsCluster.GetHealthChecks()),
 			CirBreThresholds:     convertCircuitBreakers(xdsCluster.GetCircuitBreakers()),
-			//OutlierDetection:     convertOutlierDetection(xdsCluster.GetOutlierDetection()),
+			OutlierDetection:     convertOutlierDetection(xdsCluster.GetOutlierDetection()),
 			Hosts: convertClusterHosts(xdsCluster.GetHosts()),
 			Spec:  convertSpec(xdsCluster),
 			TLS:   convertTLS(xdsCluster.GetTlsContext()),
 			LbConfig: convertLbConfig(xdsCluster.LbConfig),
+			RingHashLbConfig: convertRingHashLbConfig(xdsCluster.GetRingHashLbConfig()),
+		}
+		if cluster.ClusterType == v2.STRICT_DNS_CLUSTER || cluster.ClusterType == v2.LOGICAL_DNS_CLUSTER {
+			convertDNSCluster(xdsCluster, cluster)
 		}
 
 		clusters = append(clusters, cluster)
@@ -148,6 +154,16 @@ func convertLbConfig(config interface{}) v2.IsCluster_LbConfig {
 	}
 }
 
+func convertRingHashLbConfig(config *xdsapi.Cluster_RingHashLbConfig) *v2.RingHashLbConfig {
+	if config == nil {
+		return nil
+	}
+	return &v2.RingHashLbConfig{
+		MinimumRingSize: config.GetMinimumRingSize().GetValue(),
+		MaximumRingSize: config.GetMaximumRingSize().GetValue(),
+	}
+}
+
 func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.Host {
 	if xdsEndpoint == nil {
 		return nil
@@ -166,7 +182,7 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 			}
 
 		} else if xdsAddress, ok := xdsHost.GetEndpoint().GetAddress().GetAddress().(*xdscore.Address_Pipe); ok {
-			address = xdsAddress.Pipe.GetPath()
+			address = v2.UnixAddressPrefix + xdsAddress.Pipe.GetPath()
 		} else {
 			log.DefaultLogger.Warnf("unsupported address type")
 			continue
@@ -333,6 +349,15 @@ func convertStreamFilters(networkFilter *xdslistener.Filter) []v2.Filter {
 	return filters
 }
 
+// convertFilterChainStreamFilters converts the stream filters in all network filters of a filter chain
+func convertFilterChainStreamFilters(xdsFilters []xdslistener.Filter) []v2.Filter {
+	var filters []v2.Filter
+	for i := range xdsFilters {
+		filters = append(filters, convertStreamFilters(&xdsFilters[i])...)
+	}
+	return filters
+}
+
 func convertStreamFilter(name string, s *types.Struct) v2.Filter {
 	filter := v2.Filter{}
 	var err error
@@ -488,8 +513,10 @@ func convertFilterChains(xdsFilterChains []xdslistener.FilterChain) []v2.FilterC
 		filterChain := v2.FilterChain{
 			FilterChainConfig: v2.FilterChainConfig{
 				FilterChainMatch: xdsFilterChain.GetFilterChainMatch().String(),
+				Match:            convertFilterChainMatch(xdsFilterChain.GetFilterChainMatch()),
 				Filters:          convertFilters(xdsFilterChain.GetFilters()),
 				TLSConfig:        &tlsConfig,
+				StreamFilters:    convertFilterChainStreamFilters(xdsFilterChain.GetFilters()),
 			},
 			TLSContexts: []v2.TLSConfig{
 				tlsConfig,
@@ -500,6 +527,35 @@ func convertFilterChains(xdsFilterChains []xdslistener.FilterChain) []v2.FilterC
 	return filterChains
 }
 
+func convertFilterChainMatch(xdsMatch *xdslistener.FilterChainMatch) *v2.FilterChainMatch {
+	if xdsMatch == nil {
+		return nil
+	}
+	return &v2.FilterChainMatch{
+		DestinationPort:      xdsMatch.GetDestinationPort().GetValue(),
+		PrefixRanges:         convertCidrRanges(xdsMatch.GetPrefixRanges()),
+		ServerNames:          xdsMatch.GetServerNames(),
+		TransportProtocol:    xdsMatch.GetTransportProtocol(),
+		ApplicationProtocols: xdsMatch.GetApplicationProtocols(),
+		SourcePrefixRanges:   convertCidrRanges(xdsMatch.GetSourcePrefixRanges()),
+	}
+}
+
+func convertCidrRanges(xdsRanges []*xdscore.CidrRange) []string {
+	if len(xdsRanges) == 0 {
+		return nil
+	}
+	ranges := make([]string, 0, len(xdsRanges))
+	for _, r := range xdsRanges {
+		if r.GetPrefixLen() == nil {
+			ranges = append(ranges, r.GetAddressPrefix())
+			continue
+		}
+		ranges = append(ranges, r.GetAddressPrefix()+"/"+strconv.Itoa(int(r.GetPrefixLen().GetValue())))
+	}
+	return ranges
+}
+
 func convertFilters(xdsFilters []xdslistener.Filter) []v2.Filter {
 	if xdsFilters == nil {
 		return nil
@@ -655,6 +711,27 @@ func convertXProxyExtendConfig(cfg *xdsxproxy.XProxy) map[string]interface{} {
 	return toMap(extendConfig)
 }
 
+// ConvertRouteScopes converts the scoped route configurations discovered by SRDS to route scopes
+func ConvertRouteScopes(xdsScopes []*srds.ScopedRouteConfiguration) []*v2.RouteScope {
+	scopes := make([]*v2.RouteScope, 0, len(xdsScopes))
+	for _, xdsScope := range xdsScopes {
+		if xdsScope == nil {
+			continue
+		}
+		fragments := xdsScope.GetKey().GetFragments()
+		key := make([]string, 0, len(fragments))
+		for _, fragment := range fragments {
+			key = append(key, fragment.GetStringKey())
+		}
+		scopes = append(scopes, &v2.RouteScope{
+			Name:             xdsScope.GetName(),
+			RouterConfigName: xdsScope.GetRouteConfigurationName(),
+			Key:              key,
+		})
+	}
+	return scopes
+}
+
 func ConvertRouterConf(routeConfigName string, xdsRouteConfig *xdsapi.RouteConfiguration) (*v2.RouterConfiguration, bool) {
 	if routeConfigName != "" {
 		return &v2.RouterConfiguration{
@@ -681,6 +758,9 @@ func ConvertRouterConf(routeConfigName string, xdsRouteConfig *xdsapi.RouteConfi
 			ResponseHeadersToAdd:    convertHeadersToAdd(xdsVirtualHost.GetResponseHeadersToAdd()),
 			ResponseHeadersToRemove: xdsVirtualHost.GetResponseHeadersToRemove(),
 		}
+		if len(xdsVirtualHost.GetPerFilterConfig()) > 0 {
+			virtualHost.PerFilterConfig = convertPerRouteConfig(xdsVirtualHost.GetPerFilterConfig())
+		}
 		virtualHosts = append(virtualHosts, virtualHost)
 	}
 
@@ -716,7 +796,7 @@ func convertRoutes(xdsRoutes []xdsroute.Route) []v2.Router {
 			route := v2.Router{
 				RouterConfig: v2.RouterConfig{
 					Match: convertRouteMatch(xdsRoute.GetMatch()),
-					//Redirect:  convertRedirectAction(xdsRouteAction),
+					Redirect: convertRedirectAction(xdsRouteAction),
 					//Decorator: v2.Decorator(xdsRoute.GetDecorator().String()),
 				},
 				Metadata: convertMeta(xdsRoute.GetMetadata()),
@@ -772,14 +852,53 @@ func convertPerRouteConfig(xdsPerRouteConfig map[string]*types.Struct) map[strin
 }
 
 func convertRouteMatch(xdsRouteMatch xdsroute.RouteMatch) v2.RouterMatch {
-	return v2.RouterMatch{
-		Prefix: xdsRouteMatch.GetPrefix(),
-		Path:   xdsRouteMatch.GetPath(),
-		Regex:  xdsRouteMatch.GetRegex(),
-		//CaseSensitive: xdsRouteMatch.GetCaseSensitive().GetValue(),
-		//Runtime:       convertRuntime(xdsRouteMatch.GetRuntime()),
-		Headers: convertHeaders(xdsRouteMatch.GetHeaders()),
+	match := v2.RouterMatch{
+		Prefix:          xdsRouteMatch.GetPrefix(),
+		Path:            xdsRouteMatch.GetPath(),
+		Regex:           xdsRouteMatch.GetRegex(),
+		Headers:         convertHeaders(xdsRouteMatch.GetHeaders()),
+		QueryParameters: convertQueryParameters(xdsRouteMatch.GetQueryParameters()),
+		RuntimeFraction: convertRuntimeFraction(xdsRouteMatch.GetRuntimeFraction()),
+	}
+	if caseSensitive := xdsRouteMatch.GetCaseSensitive(); caseSensitive != nil {
+		value := caseSensitive.GetValue()
+		match.CaseSensitive = &value
+	}
+	return match
+}
+
+func convertQueryParameters(xdsParams []*xdsroute.QueryParameterMatcher) []v2.QueryParameterMatcher {
+	if xdsParams == nil {
+		return nil
+	}
+	params := make([]v2.QueryParameterMatcher, 0, len(xdsParams))
+	for _, xdsParam := range xdsParams {
+		params = append(params, v2.QueryParameterMatcher{
+			Name:  xdsParam.GetName(),
+			Value: xdsParam.GetValue(),
+			Regex: xdsParam.GetRegex().GetValue(),
+		})
+	}
+	return params
+}
+
+func convertRuntimeFraction(xdsFraction *xdscore.RuntimeFractionalPercent) *v2.RuntimeFraction {
+	percent := xdsFraction.GetDefaultValue()
+	if percent == nil {
+		return nil
+	}
+	fraction := &v2.RuntimeFraction{
+		Numerator: percent.GetNumerator(),
+	}
+	switch percent.GetDenominator() {
+	case xdstype.FractionalPercent_MILLION:
+		fraction.Denominator = 1000000
+	case xdstype.FractionalPercent_TEN_THOUSAND:
+		fraction.Denominator = 10000
+	default:
+		fraction.Denominator = 100
 	}
+	return fraction
 }
 
 /*
@@ -811,6 +930,12 @@ func convertHeaders(xdsHeaders []*xdsroute.HeaderMatcher) []v2.HeaderMatcher {
 			headerMatcher.Regex = false
 		}
 
+		if _, ok := xdsHeader.GetHeaderMatchSpecifier().(*xdsroute.HeaderMatcher_PresentMatch); ok {
+			present := xdsHeader.GetPresentMatch()
+			headerMatcher.Present = &present
+		}
+		headerMatcher.InvertMatch = xdsHeader.GetInvertMatch()
+
 		// as pseudo headers not support when Http1.x upgrade to Http2, change pseudo headers to normal headers
 		// this would be fix soon
 		if strings.HasPrefix(headerMatcher.Name, ":") {
@@ -848,12 +973,41 @@ func convertRouteAction(xdsRouteAction *xdsroute.RouteAction) v2.RouteAction {
 			RequestHeadersToAdd:     convertHeadersToAdd(xdsRouteAction.GetRequestHeadersToAdd()),
 			ResponseHeadersToAdd:    convertHeadersToAdd(xdsRouteAction.GetResponseHeadersToAdd()),
 			ResponseHeadersToRemove: xdsRouteAction.GetResponseHeadersToRemove(),
+			HashPolicy:              convertHashPolicy(xdsRouteAction.GetHashPolicy()),
 		},
 		MetadataMatch: convertMeta(xdsRouteAction.GetMetadataMatch()),
 		Timeout:       convertTimeDurPoint2TimeDur(xdsRouteAction.GetTimeout()),
 	}
 }
 
+// convertHashPolicy converts the hash policies, the hash keys of all policies are combined, so the terminal is ignored.
+// the cookie is not generated if it is not present, and the connection properties are supported for source ip only
+func convertHashPolicy(xdsHashPolicies []*xdsroute.RouteAction_HashPolicy) []v2.HashPolicy {
+	if len(xdsHashPolicies) == 0 {
+		return nil
+	}
+	policies := make([]v2.HashPolicy, 0, len(xdsHashPolicies))
+	for _, xdsPolicy := range xdsHashPolicies {
+		switch {
+		case xdsPolicy.GetHeader() != nil:
+			policies = append(policies, v2.HashPolicy{
+				Header: &v2.HeaderHashPolicy{Key: xdsPolicy.GetHeader().GetHeaderName()},
+			})
+		case xdsPolicy.GetCookie() != nil:
+			policies = append(policies, v2.HashPolicy{
+				Cookie: &v2.CookieHashPolicy{Name: xdsPolicy.GetCookie().GetName()},
+			})
+		case xdsPolicy.GetConnectionProperties().GetSourceIp():
+			policies = append(policies, v2.HashPolicy{
+				SourceIP: &v2.SourceIPHashPolicy{},
+			})
+		default:
+			log.DefaultLogger.Warnf("[xds] [convert] unsupported hash policy: %v", xdsPolicy)
+		}
+	}
+	return policies
+}
+
 func convertHeadersToAdd(headerValueOption []*xdscore.HeaderValueOption) []*v2.HeaderValueOption {
 	if len(headerValueOption) < 1 {
 		return nil
@@ -915,27 +1069,56 @@ func convertRetryPolicy(xdsRetryPolicy *xdsroute.RetryPolicy) *v2.RetryPolicy {
 	if xdsRetryPolicy == nil {
 		return &v2.RetryPolicy{}
 	}
-	return &v2.RetryPolicy{
+	policy := &v2.RetryPolicy{
 		RetryPolicyConfig: v2.RetryPolicyConfig{
-			RetryOn:    len(xdsRetryPolicy.GetRetryOn()) > 0,
-			NumRetries: xdsRetryPolicy.GetNumRetries().GetValue(),
+			RetryOn:                       len(xdsRetryPolicy.GetRetryOn()) > 0,
+			NumRetries:                    xdsRetryPolicy.GetNumRetries().GetValue(),
+			RetriableStatusCodes:          xdsRetryPolicy.GetRetriableStatusCodes(),
+			HostSelectionRetryMaxAttempts: uint32(xdsRetryPolicy.GetHostSelectionRetryMaxAttempts()),
 		},
 		RetryTimeout: convertTimeDurPoint2TimeDur(xdsRetryPolicy.GetPerTryTimeout()),
 	}
+	for _, condition := range strings.Split(xdsRetryPolicy.GetRetryOn(), ",") {
+		switch condition = strings.TrimSpace(condition); condition {
+		case "":
+		case v2.RetryOn5xx, v2.RetryOnGatewayError, v2.RetryOnConnectFailure, v2.RetryOnReset, v2.RetryOnRetriableStatusCodes:
+			policy.RetryConditions = append(policy.RetryConditions, condition)
+		default:
+			log.DefaultLogger.Warnf("unsupported retry condition: %s", condition)
+		}
+	}
+	for _, predicate := range xdsRetryPolicy.GetRetryHostPredicate() {
+		if predicate.GetName() == "envoy.retry_host_predicates.previous_hosts" {
+			policy.RetryHostPredicates = append(policy.RetryHostPredicates, v2.RetryHostPredicatePreviousHosts)
+		} else {
+			log.DefaultLogger.Warnf("unsupported retry host predicate: %s", predicate.GetName())
+		}
+	}
+	return policy
 }
 
-/*
-func convertRedirectAction(xdsRedirectAction *xdsroute.RedirectAction) v2.RedirectAction {
+var redirectResponseCodes = map[xdsroute.RedirectAction_RedirectResponseCode]int{
+	xdsroute.RedirectAction_MOVED_PERMANENTLY:  http.StatusMovedPermanently,
+	xdsroute.RedirectAction_FOUND:              http.StatusFound,
+	xdsroute.RedirectAction_SEE_OTHER:          http.StatusSeeOther,
+	xdsroute.RedirectAction_TEMPORARY_REDIRECT: http.StatusTemporaryRedirect,
+	xdsroute.RedirectAction_PERMANENT_REDIRECT: http.StatusPermanentRedirect,
+}
+
+func convertRedirectAction(xdsRedirectAction *xdsroute.RedirectAction) *v2.RedirectAction {
 	if xdsRedirectAction == nil {
-		return v2.RedirectAction{}
+		return nil
 	}
-	return v2.RedirectAction{
-		HostRedirect: xdsRedirectAction.GetHostRedirect(),
-		PathRedirect: xdsRedirectAction.GetPathRedirect(),
-		ResponseCode: uint32(xdsRedirectAction.GetResponseCode()),
+	return &v2.RedirectAction{
+		ResponseCode:   redirectResponseCodes[xdsRedirectAction.GetResponseCode()],
+		SchemeRedirect: xdsRedirectAction.GetSchemeRedirect(),
+		HostRedirect:   xdsRedirectAction.GetHostRedirect(),
+		PortRedirect:   xdsRedirectAction.GetPortRedirect(),
+		PathRedirect:   xdsRedirectAction.GetPathRedirect(),
+		HTTPSRedirect:  xdsRedirectAction.GetHttpsRedirect(),
+		StripQuery:     xdsRedirectAction.GetStripQuery(),
 	}
 }
-*/
 
 /*
 func convertVirtualClusters(xdsVirtualClusters []*xdsroute.VirtualCluster) []v2.VirtualCluster {
@@ -967,8 +1150,13 @@ func convertAddress(xdsAddress *xdscore.Address) net.Addr {
 			log.DefaultLogger.Warnf("only port value supported")
 			return nil
 		}
+	} else if addr, ok := xdsAddress.GetAddress().(*xdscore.Address_Pipe); ok {
+		return &net.UnixAddr{
+			Name: addr.Pipe.GetPath(),
+			Net:  "unix",
+		}
 	} else {
-		log.DefaultLogger.Errorf("only SocketAddress supported")
+		log.DefaultLogger.Errorf("only SocketAddress and Pipe supported")
 		return nil
 	}
 
@@ -985,7 +1173,9 @@ func convertClusterType(xdsClusterType xdsapi.Cluster_DiscoveryType) v2.ClusterT
 	case xdsapi.Cluster_STATIC:
 		return v2.SIMPLE_CLUSTER
 	case xdsapi.Cluster_STRICT_DNS:
+		return v2.STRICT_DNS_CLUSTER
 	case xdsapi.Cluster_LOGICAL_DNS:
+		return v2.LOGICAL_DNS_CLUSTER
 	case xdsapi.Cluster_EDS:
 		return v2.EDS_CLUSTER
 	case xdsapi.Cluster_ORIGINAL_DST:
@@ -1001,10 +1191,13 @@ func convertLbPolicy(xdsLbPolicy xdsapi.Cluster_LbPolicy) v2.LbType {
 	case xdsapi.Cluster_LEAST_REQUEST:
 		return v2.LB_LEAST_REQUEST
 	case xdsapi.Cluster_RING_HASH:
+		return v2.LB_RING_HASH
 	case xdsapi.Cluster_RANDOM:
 		return v2.LB_RANDOM
 	case xdsapi.Cluster_ORIGINAL_DST_LB:
 	case xdsapi.Cluster_MAGLEV:
+		// the maglev table size can not be configured in xds v2, the default size is used
+		return v2.LB_MAGLEV
 	}
 	//log.DefaultLogger.Fatalf("unsupported lb policy: %s, exchange to LB_RANDOM", xdsLbPolicy.String())
 	return v2.LB_RANDOM
@@ -1081,26 +1274,41 @@ func convertCircuitBreakers(xdsCircuitBreaker *xdscluster.CircuitBreakers) v2.Ci
 	}
 }
 
-/*
-func convertOutlierDetection(xdsOutlierDetection *xdscluster.OutlierDetection) v2.OutlierDetection {
-	if xdsOutlierDetection == nil || xdsOutlierDetection.Size() == 0 {
-		return v2.OutlierDetection{}
-	}
-	return v2.OutlierDetection{
-		Consecutive5xx:                     xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
-		Interval:                           convertDuration(xdsOutlierDetection.GetInterval()),
-		BaseEjectionTime:                   convertDuration(xdsOutlierDetection.GetBaseEjectionTime()),
-		MaxEjectionPercent:                 xdsOutlierDetection.GetMaxEjectionPercent().GetValue(),
-		ConsecutiveGatewayFailure:          xdsOutlierDetection.GetEnforcingConsecutive_5Xx().GetValue(),
-		EnforcingConsecutive5xx:            xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
-		EnforcingConsecutiveGatewayFailure: xdsOutlierDetection.GetEnforcingConsecutiveGatewayFailure().GetValue(),
-		EnforcingSuccessRate:               xdsOutlierDetection.GetEnforcingSuccessRate().GetValue(),
-		SuccessRateMinimumHosts:            xdsOutlierDetection.GetSuccessRateMinimumHosts().GetValue(),
-		SuccessRateRequestVolume:           xdsOutlierDetection.GetSuccessRateRequestVolume().GetValue(),
-		SuccessRateStdevFactor:             xdsOutlierDetection.GetSuccessRateStdevFactor().GetValue(),
+// the defaults of envoy, which are used if the fields are not set
+const (
+	defaultOutlierConsecutive5xx          = 5
+	defaultOutlierSuccessRateMinimumHosts = 5
+)
+
+// convertOutlierDetection converts the outlier detection of envoy, the consecutive 5xx and the success rate detections
+// are disabled if their enforcing percentages are 0, and the other percentages are not supported
+func convertOutlierDetection(xdsOutlierDetection *xdscluster.OutlierDetection) *v2.OutlierDetection {
+	if xdsOutlierDetection == nil {
+		return nil
+	}
+	od := &v2.OutlierDetection{
+		Consecutive5xx:           defaultOutlierConsecutive5xx,
+		Interval:                 api.DurationConfig{Duration: convertDuration(xdsOutlierDetection.GetInterval())},
+		BaseEjectionTime:         api.DurationConfig{Duration: convertDuration(xdsOutlierDetection.GetBaseEjectionTime())},
+		MaxEjectionPercent:       xdsOutlierDetection.GetMaxEjectionPercent().GetValue(),
+		SuccessRateMinimumHosts:  defaultOutlierSuccessRateMinimumHosts,
+		SuccessRateRequestVolume: xdsOutlierDetection.GetSuccessRateRequestVolume().GetValue(),
+		SuccessRateStdevFactor:   xdsOutlierDetection.GetSuccessRateStdevFactor().GetValue(),
+	}
+	if v := xdsOutlierDetection.GetConsecutive_5Xx(); v != nil {
+		od.Consecutive5xx = v.GetValue()
+	}
+	if v := xdsOutlierDetection.GetEnforcingConsecutive_5Xx(); v != nil && v.GetValue() == 0 {
+		od.Consecutive5xx = 0
+	}
+	if v := xdsOutlierDetection.GetSuccessRateMinimumHosts(); v != nil {
+		od.SuccessRateMinimumHosts = v.GetValue()
 	}
+	if v := xdsOutlierDetection.GetEnforcingSuccessRate(); v != nil && v.GetValue() == 0 {
+		od.SuccessRateMinimumHosts = 0
+	}
+	return od
 }
-*/
 
 func convertSpec(xdsCluster *xdsapi.Cluster) v2.ClusterSpecInfo {
 	if xdsCluster == nil || xdsCluster.GetEdsClusterConfig() == nil {
@@ -1116,6 +1324,40 @@ func convertSpec(xdsCluster *xdsapi.Cluster) v2.ClusterSpecInfo {
 	}
 }
 
+// convertDNSCluster converts the dns configs, the hosts' domain names should not be resolved here
+func convertDNSCluster(xdsCluster *xdsapi.Cluster, cluster *v2.Cluster) {
+	if rate := xdsCluster.GetDnsRefreshRate(); rate != nil {
+		cluster.DNSRefreshRate = &api.DurationConfig{Duration: *rate}
+	}
+	for _, resolver := range xdsCluster.GetDnsResolvers() {
+		if addr := convertAddressString(resolver); addr != "" {
+			cluster.DNSResolvers = append(cluster.DNSResolvers, addr)
+		}
+	}
+	hosts := make([]v2.Host, 0, len(xdsCluster.GetHosts()))
+	for _, xdsHost := range xdsCluster.GetHosts() {
+		if addr := convertAddressString(xdsHost); addr != "" {
+			hosts = append(hosts, v2.Host{
+				HostConfig: v2.HostConfig{
+					Address: addr,
+				},
+			})
+		}
+	}
+	cluster.Hosts = hosts
+}
+
+// convertAddressString returns the socket address without resolving
+func convertAddressString(xdsAddress *xdscore.Address) string {
+	if addr, ok := xdsAddress.GetAddress().(*xdscore.Address_SocketAddress); ok {
+		if xdsPort, ok := addr.SocketAddress.GetPortSpecifier().(*xdscore.SocketAddress_PortValue); ok {
+			return net.JoinHostPort(addr.SocketAddress.GetAddress(), strconv.Itoa(int(xdsPort.PortValue)))
+		}
+	}
+	log.DefaultLogger.Errorf("only SocketAddress with port value supported")
+	return ""
+}
+
 func convertClusterHosts(xdsHosts []*xdscore.Address) []v2.Host {
 	if xdsHosts == nil {
 		return nil
@@ -1124,7 +1366,7 @@ func convertClusterHosts(xdsHosts []*xdscore.Address) []v2.Host {
 	for _, xdsHost := range xdsHosts {
 		hostWithMetaData := v2.Host{
 			HostConfig: v2.HostConfig{
-				Address: convertAddress(xdsHost).String(),
+				Address: v2.AddressString(convertAddress(xdsHost)),
 			},
 		}
 		hostsWithMetaData = append(hostsWithMetaData, hostWithMetaData)
@@ -1188,6 +1430,12 @@ func convertTLS(xdsTLSContext interface{}) v2.TLSConfig {
 	if common.GetValidationContext() != nil && common.GetValidationContext().GetTrustedCa() != nil {
 		cfg.CACert = common.GetValidationContext().GetTrustedCa().String()
 	}
+	// verify_subject_alt_name matches the dns and uri subject alternative names
+	for _, san := range common.GetValidationContext().GetVerifySubjectAltName() {
+		cfg.MatchSubjectAltNames = append(cfg.MatchSubjectAltNames,
+			v2.SubjectAltNameMatcher{SanType: v2.SAN_DNS, Exact: san},
+			v2.SubjectAltNameMatcher{SanType: v2.SAN_URI, Exact: san})
+	}
 	if common.GetAlpnProtocols() != nil {
 		cfg.ALPN = strings.Join(common.GetAlpnProtocols(), ",")
 	}
@@ -35,8 +35,10 @@ import (
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
 	"mosn.io/mosn/pkg/upstream/cluster"
+	srds "mosn.io/mosn/pkg/xds/model/srds/v2"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdscluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
@@ -152,6 +154,142 @@ func Test_convertHeaders(t *testing.T) {
 	}
 }
 
+func Test_convertRouteMatch(t *testing.T) {
+	present := true
+	caseSensitive := false
+	xdsMatch := xdsroute.RouteMatch{
+		PathSpecifier: &xdsroute.RouteMatch_Prefix{
+			Prefix: "/api",
+		},
+		CaseSensitive: NewBoolValue(false),
+		RuntimeFraction: &xdscore.RuntimeFractionalPercent{
+			DefaultValue: &xdstype.FractionalPercent{
+				Numerator:   5,
+				Denominator: xdstype.FractionalPercent_TEN_THOUSAND,
+			},
+		},
+		Headers: []*xdsroute.HeaderMatcher{
+			{
+				Name: "x-canary",
+				HeaderMatchSpecifier: &xdsroute.HeaderMatcher_PresentMatch{
+					PresentMatch: true,
+				},
+				InvertMatch: true,
+			},
+		},
+		QueryParameters: []*xdsroute.QueryParameterMatcher{
+			{
+				Name:  "version",
+				Value: "v[12]",
+				Regex: NewBoolValue(true),
+			},
+		},
+	}
+	want := v2.RouterMatch{
+		Prefix:        "/api",
+		CaseSensitive: &caseSensitive,
+		RuntimeFraction: &v2.RuntimeFraction{
+			Numerator:   5,
+			Denominator: 10000,
+		},
+		Headers: []v2.HeaderMatcher{
+			{
+				Name:        "x-canary",
+				Present:     &present,
+				InvertMatch: true,
+			},
+		},
+		QueryParameters: []v2.QueryParameterMatcher{
+			{
+				Name:  "version",
+				Value: "v[12]",
+				Regex: true,
+			},
+		},
+	}
+	if got := convertRouteMatch(xdsMatch); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRouteMatch() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_convertRetryPolicy(t *testing.T) {
+	perTryTimeout := time.Second
+	xdsPolicy := &xdsroute.RetryPolicy{
+		RetryOn:       "5xx, reset,retriable-4xx,retriable-status-codes",
+		NumRetries:    &types.UInt32Value{Value: 3},
+		PerTryTimeout: &perTryTimeout,
+		RetryHostPredicate: []*xdsroute.RetryPolicy_RetryHostPredicate{
+			{Name: "envoy.retry_host_predicates.previous_hosts"},
+		},
+		HostSelectionRetryMaxAttempts: 5,
+		RetriableStatusCodes:          []uint32{409},
+	}
+	want := &v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			RetryOn:                       true,
+			NumRetries:                    3,
+			RetryConditions:               []string{v2.RetryOn5xx, v2.RetryOnReset, v2.RetryOnRetriableStatusCodes},
+			RetriableStatusCodes:          []uint32{409},
+			RetryHostPredicates:           []string{v2.RetryHostPredicatePreviousHosts},
+			HostSelectionRetryMaxAttempts: 5,
+		},
+		RetryTimeout: time.Second,
+	}
+	if got := convertRetryPolicy(xdsPolicy); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRetryPolicy() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_convertRedirectAction(t *testing.T) {
+	xdsAction := &xdsroute.RedirectAction{
+		SchemeRewriteSpecifier: &xdsroute.RedirectAction_HttpsRedirect{HttpsRedirect: true},
+		HostRedirect:           "redirect.com",
+		PathRewriteSpecifier:   &xdsroute.RedirectAction_PathRedirect{PathRedirect: "/new"},
+		ResponseCode:           xdsroute.RedirectAction_TEMPORARY_REDIRECT,
+		StripQuery:             true,
+	}
+	want := &v2.RedirectAction{
+		ResponseCode:  307,
+		HostRedirect:  "redirect.com",
+		PathRedirect:  "/new",
+		HTTPSRedirect: true,
+		StripQuery:    true,
+	}
+	if got := convertRedirectAction(xdsAction); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRedirectAction() = %+v, want %+v", got, want)
+	}
+	if got := convertRedirectAction(nil); got != nil {
+		t.Errorf("convertRedirectAction(nil) = %+v, want nil", got)
+	}
+}
+
+func Test_ConvertRouteScopes(t *testing.T) {
+	xdsScopes := []*srds.ScopedRouteConfiguration{
+		{
+			Name:                   "scope_a",
+			RouteConfigurationName: "table_a",
+			Key: &srds.ScopedRouteConfiguration_Key{
+				Fragments: []*srds.ScopedRouteConfiguration_Key_Fragment{
+					{StringKey: "tenant-a"},
+					{StringKey: "v1"},
+				},
+			},
+		},
+		nil,
+		{
+			Name:                   "scope_empty",
+			RouteConfigurationName: "table_b",
+		},
+	}
+	want := []*v2.RouteScope{
+		{Name: "scope_a", RouterConfigName: "table_a", Key: []string{"tenant-a", "v1"}},
+		{Name: "scope_empty", RouterConfigName: "table_b", Key: []string{}},
+	}
+	if got := ConvertRouteScopes(xdsScopes); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConvertRouteScopes() = %+v, want %+v", got, want)
+	}
+}
+
 func NewBoolValue(val bool) *types.BoolValue {
 	return &types.BoolValue{
 		Value:                val,
@@ -770,3 +908,132 @@ func Test_convertPerRouteConfig(t *testing.T) {
 	}
 
 }
+
+func Test_convertOutlierDetection(t *testing.T) {
+	clusters := ConvertClustersConfig([]*xdsapi.Cluster{
+		{
+			Name: "outlier",
+			OutlierDetection: &xdscluster.OutlierDetection{
+				Interval:             &types.Duration{Seconds: 5},
+				MaxEjectionPercent:   &types.UInt32Value{Value: 50},
+				EnforcingSuccessRate: &types.UInt32Value{Value: 0},
+			},
+		},
+		{
+			Name: "no_outlier",
+		},
+	})
+	od := clusters[0].OutlierDetection
+	if od == nil || od.Consecutive5xx != defaultOutlierConsecutive5xx || od.Interval.Duration != 5*time.Second ||
+		od.MaxEjectionPercent != 50 || od.SuccessRateMinimumHosts != 0 {
+		t.Fatalf("unexpected outlier detection: %+v", od)
+	}
+	if clusters[1].OutlierDetection != nil {
+		t.Fatalf("outlier detection should not be set: %+v", clusters[1].OutlierDetection)
+	}
+	od = convertOutlierDetection(&xdscluster.OutlierDetection{
+		Consecutive_5Xx:          &types.UInt32Value{Value: 3},
+		EnforcingConsecutive_5Xx: &types.UInt32Value{Value: 0},
+		SuccessRateMinimumHosts:  &types.UInt32Value{Value: 3},
+	})
+	if od.Consecutive5xx != 0 || od.SuccessRateMinimumHosts != 3 {
+		t.Fatalf("unexpected outlier detection: %+v", od)
+	}
+}
+
+func Test_convertConsistentHash(t *testing.T) {
+	clusters := ConvertClustersConfig([]*xdsapi.Cluster{
+		{
+			Name:     "ring_hash",
+			LbPolicy: xdsapi.Cluster_RING_HASH,
+			LbConfig: &xdsapi.Cluster_RingHashLbConfig_{
+				RingHashLbConfig: &xdsapi.Cluster_RingHashLbConfig{
+					MinimumRingSize: &types.UInt64Value{Value: 64},
+				},
+			},
+		},
+		{
+			Name:     "maglev",
+			LbPolicy: xdsapi.Cluster_MAGLEV,
+		},
+	})
+	if c := clusters[0]; c.LbType != v2.LB_RING_HASH || c.RingHashLbConfig == nil || c.RingHashLbConfig.MinimumRingSize != 64 {
+		t.Fatalf("unexpected ring hash cluster: %+v", c)
+	}
+	if c := clusters[1]; c.LbType != v2.LB_MAGLEV {
+		t.Fatalf("unexpected maglev cluster: %+v", c)
+	}
+
+	action := convertRouteAction(&xdsroute.RouteAction{
+		HashPolicy: []*xdsroute.RouteAction_HashPolicy{
+			{PolicySpecifier: &xdsroute.RouteAction_HashPolicy_Header_{
+				Header: &xdsroute.RouteAction_HashPolicy_Header{HeaderName: "x-user"},
+			}},
+			{PolicySpecifier: &xdsroute.RouteAction_HashPolicy_Cookie_{
+				Cookie: &xdsroute.RouteAction_HashPolicy_Cookie{Name: "session"},
+			}},
+			{PolicySpecifier: &xdsroute.RouteAction_HashPolicy_ConnectionProperties_{
+				ConnectionProperties: &xdsroute.RouteAction_HashPolicy_ConnectionProperties{SourceIp: true},
+			}},
+		},
+	})
+	expected := []v2.HashPolicy{
+		{Header: &v2.HeaderHashPolicy{Key: "x-user"}},
+		{Cookie: &v2.CookieHashPolicy{Name: "session"}},
+		{SourceIP: &v2.SourceIPHashPolicy{}},
+	}
+	if !reflect.DeepEqual(action.HashPolicy, expected) {
+		t.Fatalf("unexpected hash policy: %+v", action.HashPolicy)
+	}
+}
+
+func Test_convertFilterChainStreamFilters(t *testing.T) {
+	faultStruct, err := xdsutil.MessageToStruct(&xdshttpfault.HTTPFault{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hcmStruct := func(filters ...*xdshttp.HttpFilter) *types.Struct {
+		s, err := xdsutil.MessageToStruct(&xdshttp.HttpConnectionManager{
+			HttpFilters: filters,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	chains := []xdslistener.FilterChain{
+		{
+			Filters: []xdslistener.Filter{
+				{
+					Name: xdsutil.TCPProxy,
+				},
+				{
+					Name: xdsutil.HTTPConnectionManager,
+					ConfigType: &xdslistener.Filter_Config{
+						Config: hcmStruct(&xdshttp.HttpFilter{
+							Name:       IstioFault,
+							ConfigType: &xdshttp.HttpFilter_Config{Config: faultStruct},
+						}),
+					},
+				},
+			},
+		},
+		{
+			Filters: []xdslistener.Filter{
+				{
+					Name: xdsutil.HTTPConnectionManager,
+					ConfigType: &xdslistener.Filter_Config{
+						Config: hcmStruct(),
+					},
+				},
+			},
+		},
+	}
+	// the stream filters in all network filters are converted, not only the first one
+	if sf := convertFilterChainStreamFilters(chains[0].Filters); len(sf) != 1 || sf[0].Type != v2.FaultStream {
+		t.Fatalf("the stream filters of the first filter chain is not expected: %v", sf)
+	}
+	if sf := convertFilterChainStreamFilters(chains[1].Filters); len(sf) != 0 {
+		t.Fatalf("the stream filters of the second filter chain is not expected: %v", sf)
+	}
+}
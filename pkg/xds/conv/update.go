@@ -18,6 +18,7 @@
 package conv
 
 import (
+	"errors"
 	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -27,6 +28,7 @@ import (
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
 	clusterAdapter "mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/mosn/pkg/xds/v2/rds"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -34,30 +36,69 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 // ConvertXXX Function converts protobuf to mosn config, and makes the config effects
 
 // ConvertAddOrUpdateRouters converts router configurationm, used to add or update routers
-func ConvertAddOrUpdateRouters(routers []*envoy_api_v2.RouteConfiguration) {
-	if routersMngIns := router.GetRoutersMangerInstance(); routersMngIns == nil {
+func ConvertAddOrUpdateRouters(routers []*envoy_api_v2.RouteConfiguration) error {
+	routersMngIns := router.GetRoutersMangerInstance()
+	if routersMngIns == nil {
 		log.DefaultLogger.Errorf("xds OnAddOrUpdateRouters error: router manager in nil")
-	} else {
-
-		for _, router := range routers {
-			log.DefaultLogger.Debugf("xds convert router config: %+v", router)
+		return errors.New("router manager is nil")
+	}
+	var errGlobal error
+	for _, router := range routers {
+		log.DefaultLogger.Debugf("xds convert router config: %+v", router)
 
-			mosnRouter, _ := ConvertRouterConf("", router)
-			if err := routersMngIns.AddOrUpdateRouters(mosnRouter); err != nil {
-				log.DefaultLogger.Errorf("xds client  routersMngIns.AddOrUpdateRouters error: %v", err)
-			}
+		if err := checkRouterConfig(router); err != nil {
+			log.DefaultLogger.Errorf("xds convert router config failed: %v", err)
+			errGlobal = err
+			continue
 		}
+		mosnRouter, _ := ConvertRouterConf("", router)
+		if mosnRouter == nil {
+			errGlobal = fmt.Errorf("convert router %s failed", router.Name)
+			continue
+		}
+		if err := routersMngIns.AddOrUpdateRouters(mosnRouter); err != nil {
+			log.DefaultLogger.Errorf("xds client  routersMngIns.AddOrUpdateRouters error: %v", err)
+			errGlobal = fmt.Errorf("add or update router %s failed: %v", router.Name, err)
+		}
+	}
+	return errGlobal
+}
+
+// ConvertUpdateRouteScopes replaces the route scopes of the scoped routes discovering scopes dynamically,
+// the scopes are applied to the scoped routes named by scopedRoutesNames, which own the scopes subscription.
+// the route configurations referenced by the scopes are requested by RDS instead of the removed scopes'.
+func ConvertUpdateRouteScopes(scopedRoutesNames []string, scopes []*v2.RouteScope) error {
+	routersMngIns := router.GetRoutersMangerInstance()
+	if routersMngIns == nil {
+		log.DefaultLogger.Errorf("xds OnUpdateRouteScopes error: router manager in nil")
+		return errors.New("router manager is nil")
+	}
+	scopesByName := make(map[string][]*v2.RouteScope, len(scopedRoutesNames))
+	for _, name := range scopedRoutesNames {
+		scopesByName[name] = scopes
+	}
+	if err := routersMngIns.UpdateDynamicRouteScopes(scopesByName); err != nil {
+		log.DefaultLogger.Errorf("xds client routersMngIns.UpdateDynamicRouteScopes error: %v", err)
+		return fmt.Errorf("update route scopes failed: %v", err)
 	}
+	routerNames := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		routerNames = append(routerNames, scope.RouterConfigName)
+	}
+	rds.SetScopedRouterNames(routerNames)
+	return nil
 }
 
 // ConvertAddOrUpdateListeners converts listener configuration, used to  add or update listeners
-func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
+func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) error {
+	var errGlobal error
 	for _, listener := range listeners {
 		log.DefaultLogger.Debugf("xds convert listener config: %+v", listener)
 
 		mosnListener := ConvertListenerConfig(listener)
 		if mosnListener == nil {
 			log.DefaultLogger.Errorf("xds client ConvertListenerConfig failed")
+			errGlobal = fmt.Errorf("convert listener %s failed", listener.Name)
 			continue
 		}
 
@@ -65,7 +106,7 @@ func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
 		if listenerAdapter == nil {
 			// if listenerAdapter is nil, return directly
 			log.DefaultLogger.Errorf("listenerAdapter is nil and hasn't been initiated at this time")
-			return
+			return errors.New("listener adapter is nil")
 		}
 
 		log.DefaultLogger.Debugf("listenerAdapter.AddOrUpdateListener called, with mosn Listener:%+v", mosnListener)
@@ -75,9 +116,10 @@ func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
 		} else {
 			log.DefaultLogger.Errorf("xds AddOrUpdateListener failure,listener address = %s, msg = %s ",
 				mosnListener.Addr.String(), err.Error())
+			errGlobal = fmt.Errorf("add or update listener %s failed: %v", listener.Name, err)
 		}
 	}
-
+	return errGlobal
 }
 
 // ConvertDeleteListeners converts listener configuration, used to delete listener
@@ -104,14 +146,26 @@ func ConvertDeleteListeners(listeners []*envoy_api_v2.Listener) {
 }
 
 // ConvertUpdateClusters converts cluster configuration, used to udpate cluster
-func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) {
-	for _, cluster := range clusters {
-		if jsonStr, err := json.Marshal(cluster); err == nil {
-			log.DefaultLogger.Tracef("raw cluster config: %s", string(jsonStr))
+func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) error {
+	if log.DefaultLogger.GetLogLevel() >= log.TRACE {
+		for _, cluster := range clusters {
+			if jsonStr, err := json.Marshal(cluster); err == nil {
+				log.DefaultLogger.Tracef("raw cluster config: %s", string(jsonStr))
+			}
 		}
 	}
 
-	mosnClusters := ConvertClustersConfig(clusters)
+	var errGlobal error
+	validClusters := make([]*envoy_api_v2.Cluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		if err := checkClusterConfig(cluster); err != nil {
+			log.DefaultLogger.Errorf("xds convert cluster config failed: %v", err)
+			errGlobal = err
+			continue
+		}
+		validClusters = append(validClusters, cluster)
+	}
+	mosnClusters := ConvertClustersConfig(validClusters)
 
 	for _, cluster := range mosnClusters {
 		var err error
@@ -124,12 +178,12 @@ func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) {
 
 		if err != nil {
 			log.DefaultLogger.Errorf("xds OnUpdateClusters failed,cluster name = %s, error: %v", cluster.Name, err.Error())
-
+			errGlobal = fmt.Errorf("update cluster %s failed: %v", cluster.Name, err)
 		} else {
 			log.DefaultLogger.Debugf("xds OnUpdateClusters success,cluster name = %s", cluster.Name)
 		}
 	}
-
+	return errGlobal
 }
 
 // ConvertDeleteClusters converts cluster configuration, used to delete cluster
@@ -184,3 +238,109 @@ func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignmen
 
 	return errGlobal
 }
+
+// ConvertRemoveClusters removes the clusters by name, the clusters not exists are ignored
+func ConvertRemoveClusters(clusterNames []string) error {
+	var errGlobal error
+	clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+	for _, name := range clusterNames {
+		if !clusterMngAdapter.ClusterExist(name) {
+			log.DefaultLogger.Debugf("xds remove cluster %s, cluster not exists", name)
+			continue
+		}
+		if err := clusterMngAdapter.TriggerClusterDel(name); err != nil {
+			log.DefaultLogger.Errorf("xds remove cluster failed, cluster name = %s, error: %v", name, err)
+			errGlobal = fmt.Errorf("remove cluster %s failed: %v", name, err)
+		}
+	}
+	return errGlobal
+}
+
+// ConvertRemoveRouters removes the routers by name
+func ConvertRemoveRouters(routerNames []string) error {
+	routersMngIns := router.GetRoutersMangerInstance()
+	if routersMngIns == nil {
+		log.DefaultLogger.Errorf("xds OnRemoveRouters error: router manager in nil")
+		return errors.New("router manager is nil")
+	}
+	var errGlobal error
+	for _, name := range routerNames {
+		if err := routersMngIns.RemoveRouters(name); err != nil {
+			log.DefaultLogger.Errorf("xds remove router failed, router name = %s, error: %v", name, err)
+			errGlobal = fmt.Errorf("remove router %s failed: %v", name, err)
+		}
+	}
+	return errGlobal
+}
+
+// ConvertRemoveListeners removes the listeners by name
+func ConvertRemoveListeners(listenerNames []string) error {
+	listenerAdapter := server.GetListenerAdapterInstance()
+	if listenerAdapter == nil {
+		log.DefaultLogger.Errorf("listenerAdapter is nil and hasn't been initiated at this time")
+		return errors.New("listener adapter is nil")
+	}
+	var errGlobal error
+	for _, name := range listenerNames {
+		if err := listenerAdapter.DeleteListener("", name); err != nil {
+			log.DefaultLogger.Errorf("xds remove listener failed, listener name = %s, error: %v", name, err)
+			errGlobal = fmt.Errorf("remove listener %s failed: %v", name, err)
+		}
+	}
+	return errGlobal
+}
+
+// ConvertLoadAssignmentHosts converts the endpoints of all localities in the load assignment
+func ConvertLoadAssignmentHosts(loadAssignment *envoy_api_v2.ClusterLoadAssignment) []v2.Host {
+	var hosts []v2.Host
+	for i := range loadAssignment.Endpoints {
+		hosts = append(hosts, ConvertEndpointsConfig(&loadAssignment.Endpoints[i])...)
+	}
+	return hosts
+}
+
+// checkRouterConfig returns an error if the route configuration cannot be converted completely,
+// so the response is NACKed instead of the routes are dropped silently
+func checkRouterConfig(router *envoy_api_v2.RouteConfiguration) error {
+	if router == nil {
+		return errors.New("route configuration is nil")
+	}
+	if router.GetName() == "" {
+		return errors.New("route configuration name is empty")
+	}
+	for _, vh := range router.GetVirtualHosts() {
+		for i, route := range vh.GetRoutes() {
+			if route.GetRoute() == nil && route.GetRedirect() == nil {
+				return fmt.Errorf("route configuration %s: route #%d of virtual host %s has no route or redirect action", router.GetName(), i, vh.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// checkClusterConfig returns an error if the cluster cannot be converted to a mosn cluster
+func checkClusterConfig(cluster *envoy_api_v2.Cluster) error {
+	if cluster == nil {
+		return errors.New("cluster is nil")
+	}
+	if cluster.GetName() == "" {
+		return errors.New("cluster name is empty")
+	}
+	switch cluster.GetType() {
+	case envoy_api_v2.Cluster_STATIC, envoy_api_v2.Cluster_EDS:
+		for _, host := range cluster.GetHosts() {
+			if convertAddress(host) == nil {
+				return fmt.Errorf("cluster %s: invalid host address %s", cluster.GetName(), host.String())
+			}
+		}
+	case envoy_api_v2.Cluster_STRICT_DNS, envoy_api_v2.Cluster_LOGICAL_DNS:
+		for _, host := range cluster.GetHosts() {
+			if convertAddressString(host) == "" {
+				return fmt.Errorf("cluster %s: invalid dns host address %s", cluster.GetName(), host.String())
+			}
+		}
+	default:
+		return fmt.Errorf("cluster %s: unsupported cluster type %s", cluster.GetName(), cluster.GetType().String())
+	}
+	return nil
+}
@@ -278,3 +278,52 @@ func Test_updateListener(t *testing.T) {
 	}
 
 }
+
+func TestCheckConfig(t *testing.T) {
+	if err := checkRouterConfig(&envoy_api_v2.RouteConfiguration{
+		Name: "invalid_route",
+		VirtualHosts: []xdsroute.VirtualHost{
+			{
+				Name:    "vh",
+				Domains: []string{"*"},
+				Routes: []xdsroute.Route{
+					{
+						Match: xdsroute.RouteMatch{
+							PathSpecifier: &xdsroute.RouteMatch_Prefix{Prefix: "/"},
+						},
+					},
+				},
+			},
+		},
+	}); err == nil {
+		t.Error("route without action should be failed")
+	}
+	if err := ConvertAddOrUpdateRouters([]*envoy_api_v2.RouteConfiguration{nil}); err == nil {
+		t.Error("nil route configuration should be failed")
+	}
+	if err := checkClusterConfig(&envoy_api_v2.Cluster{
+		Name:                 "original_dst",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_ORIGINAL_DST},
+	}); err == nil {
+		t.Error("unsupported cluster type should be failed")
+	}
+	if err := checkClusterConfig(&envoy_api_v2.Cluster{
+		Name:                 "invalid_host",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_STATIC},
+		Hosts: []*core.Address{
+			{
+				Address: &core.Address_SocketAddress{
+					SocketAddress: &core.SocketAddress{
+						Address:       "127.0.0.1",
+						PortSpecifier: &core.SocketAddress_NamedPort{NamedPort: "http"},
+					},
+				},
+			},
+		},
+	}); err == nil {
+		t.Error("host without port value should be failed")
+	}
+	if err := ConvertUpdateClusters([]*envoy_api_v2.Cluster{{Name: ""}}); err == nil {
+		t.Error("cluster without name should be failed")
+	}
+}
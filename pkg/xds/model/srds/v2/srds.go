@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	proto "github.com/gogo/protobuf/proto"
+)
+
+// The messages are the same as envoy.api.v2.ScopedRouteConfiguration in srds.proto, which is not
+// contained in the vendored go-control-plane. The oneof fragment type has only one field, so it is
+// declared as a plain field, the wire format is the same.
+
+func init() {
+	proto.RegisterType((*ScopedRouteConfiguration)(nil), "envoy.api.v2.ScopedRouteConfiguration")
+	proto.RegisterType((*ScopedRouteConfiguration_Key)(nil), "envoy.api.v2.ScopedRouteConfiguration.Key")
+	proto.RegisterType((*ScopedRouteConfiguration_Key_Fragment)(nil), "envoy.api.v2.ScopedRouteConfiguration.Key.Fragment")
+}
+
+// ScopedRouteConfiguration specifies a route table by the scope key
+type ScopedRouteConfiguration struct {
+	Name                   string                        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	RouteConfigurationName string                        `protobuf:"bytes,2,opt,name=route_configuration_name,json=routeConfigurationName,proto3" json:"route_configuration_name,omitempty"`
+	Key                    *ScopedRouteConfiguration_Key `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (m *ScopedRouteConfiguration) Reset()         { *m = ScopedRouteConfiguration{} }
+func (m *ScopedRouteConfiguration) String() string { return proto.CompactTextString(m) }
+func (*ScopedRouteConfiguration) ProtoMessage()    {}
+
+func (m *ScopedRouteConfiguration) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ScopedRouteConfiguration) GetRouteConfigurationName() string {
+	if m != nil {
+		return m.RouteConfigurationName
+	}
+	return ""
+}
+
+func (m *ScopedRouteConfiguration) GetKey() *ScopedRouteConfiguration_Key {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+// ScopedRouteConfiguration_Key is the scope key, the fragments are matched with the fragments
+// built by the scope key builder in order
+type ScopedRouteConfiguration_Key struct {
+	Fragments []*ScopedRouteConfiguration_Key_Fragment `protobuf:"bytes,1,rep,name=fragments,proto3" json:"fragments,omitempty"`
+}
+
+func (m *ScopedRouteConfiguration_Key) Reset()         { *m = ScopedRouteConfiguration_Key{} }
+func (m *ScopedRouteConfiguration_Key) String() string { return proto.CompactTextString(m) }
+func (*ScopedRouteConfiguration_Key) ProtoMessage()    {}
+
+func (m *ScopedRouteConfiguration_Key) GetFragments() []*ScopedRouteConfiguration_Key_Fragment {
+	if m != nil {
+		return m.Fragments
+	}
+	return nil
+}
+
+// ScopedRouteConfiguration_Key_Fragment is a fragment of the scope key
+type ScopedRouteConfiguration_Key_Fragment struct {
+	StringKey string `protobuf:"bytes,1,opt,name=string_key,json=stringKey,proto3" json:"string_key,omitempty"`
+}
+
+func (m *ScopedRouteConfiguration_Key_Fragment) Reset()         { *m = ScopedRouteConfiguration_Key_Fragment{} }
+func (m *ScopedRouteConfiguration_Key_Fragment) String() string { return proto.CompactTextString(m) }
+func (*ScopedRouteConfiguration_Key_Fragment) ProtoMessage()    {}
+
+func (m *ScopedRouteConfiguration_Key_Fragment) GetStringKey() string {
+	if m != nil {
+		return m.StringKey
+	}
+	return ""
+}
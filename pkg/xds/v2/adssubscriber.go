@@ -18,18 +18,26 @@
 package v2
 
 import (
+	"errors"
 	"math/rand"
+	"sort"
+	"sync/atomic"
 	"time"
 
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/googleapis/google/rpc"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
 )
 
 // Start adsClient send goroutine and receive goroutine
-// send goroutine periodic request lds and cds
-// receive goroutine handle response for both client request and server push
+// send goroutine waits for the graceful shut down signal
+// receive goroutine connects to the management server, handles the pushed responses and sends ACK or NACK.
+// all the requests are sent in the receive goroutine, so there is no concurrent sending on the stream.
 func (adsClient *ADSClient) Start() {
-	adsClient.StreamClient = adsClient.AdsConfig.GetStreamClient()
 	utils.GoWithRecover(func() {
 		adsClient.sendThread()
 	}, nil)
@@ -39,31 +47,11 @@ func (adsClient *ADSClient) Start() {
 }
 
 func (adsClient *ADSClient) sendThread() {
-	log.DefaultLogger.Debugf("[xds] [ads client] send thread request cds")
-	err := adsClient.reqClusters(adsClient.StreamClient)
-	if err != nil {
-		log.DefaultLogger.Infof("[xds] [ads client] send thread request cds fail!auto retry next period")
-		adsClient.reconnect()
-	}
-
-	refreshDelay := adsClient.AdsConfig.RefreshDelay
-	t1 := time.NewTimer(*refreshDelay)
-	for {
-		select {
-		case <-adsClient.SendControlChan:
-			log.DefaultLogger.Debugf("[xds] [ads client] send thread receive graceful shut down signal")
-			adsClient.AdsConfig.closeADSStreamClient()
-			adsClient.StopChan <- 1
-			return
-		case <-t1.C:
-			err := adsClient.reqClusters(adsClient.StreamClient)
-			if err != nil {
-				log.DefaultLogger.Infof("[xds] [ads client] send thread request cds fail!auto retry next period")
-				adsClient.reconnect()
-			}
-			t1.Reset(*refreshDelay)
-		}
-	}
+	<-adsClient.SendControlChan
+	log.DefaultLogger.Debugf("[xds] [ads client] send thread receive graceful shut down signal")
+	atomic.StoreUint32(&adsClient.stopping, 1)
+	adsClient.closeStreamClient()
+	adsClient.StopChan <- 1
 }
 
 func (adsClient *ADSClient) receiveThread() {
@@ -74,26 +62,76 @@ func (adsClient *ADSClient) receiveThread() {
 			adsClient.StopChan <- 2
 			return
 		default:
-			adsClient.StreamClientMutex.RLock()
-			sc := adsClient.StreamClient
-			adsClient.StreamClientMutex.RUnlock()
-			if sc == nil {
-				log.DefaultLogger.Infof("[xds] [ads client] stream client closed, sleep 1s and wait for reconnect")
-				time.Sleep(time.Second)
+			if !adsClient.connected() {
+				if !adsClient.reconnect() {
+					return
+				}
 				continue
 			}
-			resp, err := sc.Recv()
-			if err != nil {
-				log.DefaultLogger.Infof("[xds] [ads client] get resp timeout: %v, retry after 1s", err)
-				time.Sleep(time.Second)
+			if err := adsClient.receive(); err != nil {
+				if atomic.LoadUint32(&adsClient.stopping) == 1 {
+					// the stream is closed by the send thread
+					<-adsClient.RecvControlChan
+					log.DefaultLogger.Debugf("[xds] [ads client] receive thread receive graceful shut down signal")
+					adsClient.StopChan <- 2
+					return
+				}
+				log.DefaultLogger.Infof("[xds] [ads client] receive response failed: %v, reconnect", err)
+				if !adsClient.reconnect() {
+					return
+				}
 				continue
 			}
-			typeURL := resp.TypeUrl
-			HandleTypeURL(typeURL, adsClient, resp)
 		}
 	}
 }
 
+func (adsClient *ADSClient) connected() bool {
+	adsClient.StreamClientMutex.RLock()
+	defer adsClient.StreamClientMutex.RUnlock()
+	return adsClient.StreamClient != nil || len(adsClient.DeltaStreamClients) > 0
+}
+
+// receive waits a response from the stream and handles it
+func (adsClient *ADSClient) receive() error {
+	if adsClient.AdsConfig.IsDelta() {
+		return adsClient.receiveDelta()
+	}
+	adsClient.StreamClientMutex.RLock()
+	sc := adsClient.StreamClient
+	adsClient.StreamClientMutex.RUnlock()
+	if sc == nil {
+		return errors.New("stream client is nil")
+	}
+	resp, err := sc.Recv()
+	if err != nil {
+		return err
+	}
+	adsClient.handleResponse(resp)
+	return nil
+}
+
+// handleResponse applies the response, and sends ACK if the response is applied successfully, otherwise sends NACK
+func (adsClient *ADSClient) handleResponse(resp *envoy_api_v2.DiscoveryResponse) {
+	var err error
+	stats := metrics.NewXdsStats(resp.TypeUrl)
+	if handleErr := HandleTypeURL(resp.TypeUrl, adsClient, resp); handleErr != nil {
+		log.DefaultLogger.Errorf("[xds] [ads client] reject response, type url: %s, version: %s, nonce: %s, error: %v",
+			resp.TypeUrl, resp.VersionInfo, resp.Nonce, handleErr)
+		stats.Counter(metrics.XdsUpdateRejected).Inc(1)
+		err = adsClient.nack(resp, handleErr)
+	} else {
+		log.DefaultLogger.Infof("[xds] [ads client] accept response, type url: %s, version: %s, nonce: %s",
+			resp.TypeUrl, resp.VersionInfo, resp.Nonce)
+		stats.Counter(metrics.XdsUpdateSuccess).Inc(1)
+		stats.Counter(metrics.XdsResourceApplied).Inc(int64(len(resp.Resources)))
+		err = adsClient.ack(resp)
+	}
+	if err != nil {
+		log.DefaultLogger.Infof("[xds] [ads client] send ack of %s failed: %v", resp.TypeUrl, err)
+	}
+}
+
 var disableReconnect bool
 
 func DisableReconnect() {
@@ -114,34 +152,81 @@ func computeInterval(t time.Duration) time.Duration {
 	return t
 }
 
-func (adsClient *ADSClient) reconnect() {
-
-	adsClient.AdsConfig.closeADSStreamClient()
-	adsClient.StreamClientMutex.Lock()
-	adsClient.StreamClient = nil
-	adsClient.StreamClientMutex.Unlock()
+// reconnect creates a new stream and subscribes the clusters again,
+// returns false if the graceful shut down signal is received while reconnecting.
+func (adsClient *ADSClient) reconnect() bool {
+	adsClient.closeStreamClient()
 	log.DefaultLogger.Infof("[xds] [ads client] stream client closed")
 
 	interval := time.Second
 
 	for {
 		if !disableReconnect {
-			sc := adsClient.AdsConfig.GetStreamClient()
-			if sc != nil {
-				adsClient.StreamClientMutex.Lock()
-				adsClient.StreamClient = sc
-				adsClient.StreamClientMutex.Unlock()
-				log.DefaultLogger.Infof("[xds] [ads client] stream client reconnected")
-				return
+			if adsClient.newStreamClient() {
+				adsClient.resetStates()
+				err := adsClient.reqClusters()
+				if err == nil && adsClient.AdsConfig.IsDelta() {
+					err = adsClient.resubscribe()
+				}
+				if err == nil {
+					log.DefaultLogger.Infof("[xds] [ads client] stream client reconnected")
+					return true
+				}
+				log.DefaultLogger.Infof("[xds] [ads client] request cds failed: %v", err)
+				adsClient.closeStreamClient()
 			}
 			log.DefaultLogger.Infof("[xds] [ads client] stream client reconnect failed, retry after %v", interval)
 		}
 		// sleep random
-		time.Sleep(interval + time.Duration(rand.Intn(1000))*time.Millisecond)
+		select {
+		case <-adsClient.RecvControlChan:
+			log.DefaultLogger.Debugf("[xds] [ads client] receive thread receive graceful shut down signal")
+			adsClient.StopChan <- 2
+			return false
+		case <-time.After(interval + time.Duration(rand.Intn(1000))*time.Millisecond):
+		}
 		interval = computeInterval(interval)
 	}
 }
 
+// newStreamClient creates a stream client, the incremental stream of clusters is created if the api type is DELTA_GRPC,
+// and the incremental streams of the other type urls are created when they are subscribed.
+func (adsClient *ADSClient) newStreamClient() bool {
+	if adsClient.AdsConfig.IsDelta() {
+		adsClient.StreamClientMutex.Lock()
+		if adsClient.deltaResponses == nil {
+			adsClient.deltaResponses = make(chan *deltaResponse)
+		}
+		adsClient.deltaDone = make(chan struct{})
+		adsClient.StreamClientMutex.Unlock()
+		if _, err := adsClient.deltaStreamClient(EnvoyCluster); err != nil {
+			log.DefaultLogger.Infof("[xds] [ads client] %v", err)
+			return false
+		}
+		return true
+	}
+	sc := adsClient.AdsConfig.GetStreamClient()
+	if sc == nil {
+		return false
+	}
+	adsClient.StreamClientMutex.Lock()
+	adsClient.StreamClient = sc
+	adsClient.StreamClientMutex.Unlock()
+	return true
+}
+
+func (adsClient *ADSClient) closeStreamClient() {
+	adsClient.StreamClientMutex.Lock()
+	defer adsClient.StreamClientMutex.Unlock()
+	adsClient.AdsConfig.closeADSStreamClient()
+	adsClient.StreamClient = nil
+	adsClient.DeltaStreamClients = nil
+	if adsClient.deltaDone != nil {
+		close(adsClient.deltaDone)
+		adsClient.deltaDone = nil
+	}
+}
+
 // Stop adsClient wait for send/receive goroutine graceful exit
 func (adsClient *ADSClient) Stop() {
 	adsClient.SendControlChan <- 1
@@ -156,3 +241,155 @@ func (adsClient *ADSClient) Stop() {
 	close(adsClient.RecvControlChan)
 	close(adsClient.StopChan)
 }
+
+// getState returns the state of type url, the caller should hold the statesMutex
+func (adsClient *ADSClient) getState(typeURL string) *resourceState {
+	if adsClient.states == nil {
+		adsClient.states = make(map[string]*resourceState)
+	}
+	state, ok := adsClient.states[typeURL]
+	if !ok {
+		state = &resourceState{}
+		adsClient.states[typeURL] = state
+	}
+	return state
+}
+
+// resetStates is called when a new stream is created, the accepted versions are kept,
+// so the management server need not to push the resources which are not changed.
+func (adsClient *ADSClient) resetStates() {
+	adsClient.statesMutex.Lock()
+	defer adsClient.statesMutex.Unlock()
+	for _, state := range adsClient.states {
+		state.subscribed = false
+		state.nonce = ""
+	}
+}
+
+// resubscribe subscribes the resources of the previous stream in the incremental xDS.
+// the unchanged resources are not pushed after reconnected, so the subscriptions are not
+// triggered by the responses as the state of the world xDS.
+func (adsClient *ADSClient) resubscribe() error {
+	adsClient.statesMutex.Lock()
+	var clusterNames []string
+	if state, ok := adsClient.states[EnvoyClusterLoadAssignment]; ok {
+		clusterNames = state.resourceNames
+	}
+	_, listenerSubscribed := adsClient.states[EnvoyListener]
+	_, scopesSubscribed := adsClient.states[EnvoyScopedRouteConfiguration]
+	adsClient.statesMutex.Unlock()
+	if len(clusterNames) > 0 {
+		if err := adsClient.reqEndpoints(clusterNames); err != nil {
+			return err
+		}
+	}
+	if listenerSubscribed {
+		if err := adsClient.reqListeners(); err != nil {
+			return err
+		}
+	}
+	if scopesSubscribed {
+		if err := adsClient.reqScopedRoutes(); err != nil {
+			return err
+		}
+	}
+	return adsClient.reqRoutes()
+}
+
+// subscribe sends a request to subscribe the resources of the type url,
+// the request is not sent if the same resources are subscribed in the current stream.
+// empty resource names means all of the resources.
+func (adsClient *ADSClient) subscribe(typeURL string, resourceNames []string) error {
+	adsClient.statesMutex.Lock()
+	state := adsClient.getState(typeURL)
+	if state.subscribed && sameResourceNames(state.resourceNames, resourceNames) {
+		adsClient.statesMutex.Unlock()
+		return nil
+	}
+	if adsClient.AdsConfig.IsDelta() {
+		req := newDeltaDiscoveryRequest(typeURL, state, resourceNames)
+		state.subscribed = true
+		state.resourceNames = resourceNames
+		adsClient.statesMutex.Unlock()
+		return adsClient.sendDelta(req)
+	}
+	state.subscribed = true
+	state.resourceNames = resourceNames
+	req := newDiscoveryRequest(typeURL, state)
+	adsClient.statesMutex.Unlock()
+	return adsClient.send(req)
+}
+
+// ack accepts the response, the version of the type url is updated
+func (adsClient *ADSClient) ack(resp *envoy_api_v2.DiscoveryResponse) error {
+	adsClient.statesMutex.Lock()
+	state := adsClient.getState(resp.TypeUrl)
+	state.versionInfo = resp.VersionInfo
+	state.nonce = resp.Nonce
+	req := newDiscoveryRequest(resp.TypeUrl, state)
+	adsClient.statesMutex.Unlock()
+	return adsClient.send(req)
+}
+
+// nack rejects the response, the version of the type url is not changed
+func (adsClient *ADSClient) nack(resp *envoy_api_v2.DiscoveryResponse, cause error) error {
+	adsClient.statesMutex.Lock()
+	state := adsClient.getState(resp.TypeUrl)
+	state.nonce = resp.Nonce
+	req := newDiscoveryRequest(resp.TypeUrl, state)
+	adsClient.statesMutex.Unlock()
+	req.ErrorDetail = &rpc.Status{
+		Code:    int32(rpc.INVALID_ARGUMENT),
+		Message: cause.Error(),
+	}
+	return adsClient.send(req)
+}
+
+func (adsClient *ADSClient) send(req *envoy_api_v2.DiscoveryRequest) error {
+	adsClient.StreamClientMutex.RLock()
+	sc := adsClient.StreamClient
+	adsClient.StreamClientMutex.RUnlock()
+	if sc == nil {
+		return errors.New("stream client is nil")
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[xds] [ads client] send request, type url: %s, version: %s, nonce: %s, resources: %v",
+			req.TypeUrl, req.VersionInfo, req.ResponseNonce, req.ResourceNames)
+	}
+	return sc.Send(req)
+}
+
+func newDiscoveryRequest(typeURL string, state *resourceState) *envoy_api_v2.DiscoveryRequest {
+	return &envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   state.versionInfo,
+		ResourceNames: state.resourceNames,
+		TypeUrl:       typeURL,
+		ResponseNonce: state.nonce,
+		Node:          newNode(),
+	}
+}
+
+func newNode() *envoy_api_v2_core1.Node {
+	return &envoy_api_v2_core1.Node{
+		Id:       types.GetGlobalXdsInfo().ServiceNode,
+		Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
+		Metadata: types.GetGlobalXdsInfo().Metadata,
+	}
+}
+
+// sameResourceNames checks the resource names regardless of the order
+func sameResourceNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string{}, a...)
+	y := append([]string{}, b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
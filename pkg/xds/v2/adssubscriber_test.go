@@ -0,0 +1,585 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	xdscache "github.com/envoyproxy/go-control-plane/pkg/cache"
+	xdsserver "github.com/envoyproxy/go-control-plane/pkg/server"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/mosn/pkg/xds/v2/rds"
+)
+
+const testNodeID = "mosn_test_node"
+
+// testNodeHash puts all of the clients into one snapshot
+type testNodeHash struct{}
+
+func (testNodeHash) ID(node *core.Node) string {
+	return testNodeID
+}
+
+// testADSServer is a go-control-plane management server backed by a snapshot cache,
+// the requests and responses of the stream are recorded by the server callbacks.
+type testADSServer struct {
+	xdsserver.Server
+	cache       xdscache.SnapshotCache
+	mutex       sync.Mutex
+	requests    map[string]chan *envoy_api_v2.DiscoveryRequest
+	responses   map[string]chan *envoy_api_v2.DiscoveryResponse
+	closeStream int32
+}
+
+func newTestADSServer() *testADSServer {
+	s := &testADSServer{
+		cache:     xdscache.NewSnapshotCache(true, testNodeHash{}, nil),
+		requests:  map[string]chan *envoy_api_v2.DiscoveryRequest{},
+		responses: map[string]chan *envoy_api_v2.DiscoveryResponse{},
+	}
+	s.Server = xdsserver.NewServer(s.cache, s)
+	return s
+}
+
+func (s *testADSServer) requestChan(typeURL string) chan *envoy_api_v2.DiscoveryRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ch, ok := s.requests[typeURL]
+	if !ok {
+		ch = make(chan *envoy_api_v2.DiscoveryRequest, 64)
+		s.requests[typeURL] = ch
+	}
+	return ch
+}
+
+func (s *testADSServer) responseChan(typeURL string) chan *envoy_api_v2.DiscoveryResponse {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ch, ok := s.responses[typeURL]
+	if !ok {
+		ch = make(chan *envoy_api_v2.DiscoveryResponse, 64)
+		s.responses[typeURL] = ch
+	}
+	return ch
+}
+
+func (s *testADSServer) OnStreamOpen(context.Context, int64, string) error {
+	return nil
+}
+
+func (s *testADSServer) OnStreamClosed(int64) {}
+
+// OnStreamRequest records the request, the stream is closed if closeStream is set
+func (s *testADSServer) OnStreamRequest(id int64, req *envoy_api_v2.DiscoveryRequest) error {
+	if atomic.CompareAndSwapInt32(&s.closeStream, 1, 0) {
+		return errors.New("stream closed by server")
+	}
+	select {
+	case s.requestChan(req.TypeUrl) <- req:
+	default:
+	}
+	return nil
+}
+
+func (s *testADSServer) OnStreamResponse(id int64, req *envoy_api_v2.DiscoveryRequest, resp *envoy_api_v2.DiscoveryResponse) {
+	select {
+	case s.responseChan(resp.TypeUrl) <- resp:
+	default:
+	}
+}
+
+func (s *testADSServer) OnFetchRequest(context.Context, *envoy_api_v2.DiscoveryRequest) error {
+	return nil
+}
+
+func (s *testADSServer) OnFetchResponse(*envoy_api_v2.DiscoveryRequest, *envoy_api_v2.DiscoveryResponse) {
+}
+
+func (s *testADSServer) setSnapshot(t *testing.T, version string, endpoints, clusters []xdscache.Resource) {
+	t.Helper()
+	if err := s.cache.SetSnapshot(testNodeID, xdscache.NewSnapshot(version, endpoints, clusters, nil, nil)); err != nil {
+		t.Fatalf("set snapshot failed: %v", err)
+	}
+}
+
+// expectRequest waits for the request of the type url with the expected fields,
+// the other requests are skipped, as the snapshot cache may respond a version more than once.
+func (s *testADSServer) expectRequest(t *testing.T, typeURL, version, nonce string, resourceNames []string, nack bool) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	var last *envoy_api_v2.DiscoveryRequest
+	for {
+		select {
+		case req := <-s.requestChan(typeURL):
+			if req.VersionInfo == version &&
+				req.ResponseNonce == nonce &&
+				sameResourceNames(req.ResourceNames, resourceNames) &&
+				(req.ErrorDetail != nil) == nack {
+				return
+			}
+			last = req
+		case <-timeout:
+			t.Fatalf("wait request of %s timeout, expected version: %s, nonce: %s, resources: %v, nack: %v, last request: %+v",
+				typeURL, version, nonce, resourceNames, nack, last)
+		}
+	}
+}
+
+// expectResponse waits for the response of the type url with the version, and returns its nonce
+func (s *testADSServer) expectResponse(t *testing.T, typeURL, version string) string {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case resp := <-s.responseChan(typeURL):
+			if resp.VersionInfo == version {
+				return resp.Nonce
+			}
+		case <-timeout:
+			t.Fatalf("wait response of %s version %s timeout", typeURL, version)
+		}
+	}
+}
+
+func (s *testADSServer) expectNoRequest(t *testing.T, typeURL string, wait time.Duration) {
+	t.Helper()
+	select {
+	case req := <-s.requestChan(typeURL):
+		t.Fatalf("unexpected request: %+v", req)
+	case <-time.After(wait):
+	}
+}
+
+// mockDeltaADSServer is an in-process incremental management server, the responses are pushed by the test case.
+// the vendored go-control-plane server does not support the incremental xds.
+// each stream subscribes one type url, which is the type url of the first request in the stream.
+type mockDeltaADSServer struct {
+	mutex     sync.Mutex
+	requests  map[string]chan *envoy_api_v2.DeltaDiscoveryRequest
+	responses map[string]chan *envoy_api_v2.DeltaDiscoveryResponse
+	// closeStream closes one of the streams, the client is expected to reconnect all of the streams
+	closeStream chan struct{}
+}
+
+func newMockDeltaADSServer() *mockDeltaADSServer {
+	return &mockDeltaADSServer{
+		requests:    make(map[string]chan *envoy_api_v2.DeltaDiscoveryRequest),
+		responses:   make(map[string]chan *envoy_api_v2.DeltaDiscoveryResponse),
+		closeStream: make(chan struct{}),
+	}
+}
+
+func (s *mockDeltaADSServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return status.Errorf(codes.Unimplemented, "not implemented")
+}
+
+func (s *mockDeltaADSServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	typeURL := req.TypeUrl
+	// the responses are sent in the latest stream of the type url
+	responses := make(chan *envoy_api_v2.DeltaDiscoveryResponse, 16)
+	s.mutex.Lock()
+	s.responses[typeURL] = responses
+	s.mutex.Unlock()
+	requests := s.requestChan(typeURL)
+	requests <- req
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			if req.TypeUrl != typeURL {
+				req.TypeUrl = "unexpected type url in the stream of " + typeURL
+			}
+			requests <- req
+		}
+	}()
+	for {
+		select {
+		case resp := <-responses:
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		case <-s.closeStream:
+			return errors.New("stream closed by server")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *mockDeltaADSServer) requestChan(typeURL string) chan *envoy_api_v2.DeltaDiscoveryRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	requests, ok := s.requests[typeURL]
+	if !ok {
+		requests = make(chan *envoy_api_v2.DeltaDiscoveryRequest, 16)
+		s.requests[typeURL] = requests
+	}
+	return requests
+}
+
+// sendDeltaResponse sends the response in the stream of the type url
+func (s *mockDeltaADSServer) sendDeltaResponse(t *testing.T, typeURL string, resp *envoy_api_v2.DeltaDiscoveryResponse) {
+	t.Helper()
+	s.mutex.Lock()
+	responses, ok := s.responses[typeURL]
+	s.mutex.Unlock()
+	if !ok {
+		t.Fatalf("no stream of %s", typeURL)
+	}
+	responses <- resp
+}
+
+func (s *mockDeltaADSServer) expectDeltaRequest(t *testing.T, typeURL, nonce string, subscribe, unsubscribe []string, versions map[string]string, nack bool) {
+	t.Helper()
+	select {
+	case req := <-s.requestChan(typeURL):
+		if !(req.TypeUrl == typeURL &&
+			req.ResponseNonce == nonce &&
+			sameResourceNames(req.ResourceNamesSubscribe, subscribe) &&
+			sameResourceNames(req.ResourceNamesUnsubscribe, unsubscribe) &&
+			len(req.InitialResourceVersions) == len(versions) &&
+			(req.ErrorDetail != nil) == nack) {
+			t.Fatalf("unexpected delta request: %+v, expected type url: %s, nonce: %s, subscribe: %v, unsubscribe: %v, nack: %v",
+				req, typeURL, nonce, subscribe, unsubscribe, nack)
+		}
+		for name, version := range versions {
+			if req.InitialResourceVersions[name] != version {
+				t.Fatalf("unexpected initial resource versions: %v, expected: %v", req.InitialResourceVersions, versions)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("wait delta request of %s timeout", typeURL)
+	}
+}
+
+// newDeltaDiscoveryResponse creates an incremental response
+func newDeltaDiscoveryResponse(t *testing.T, version, nonce string, removed []string, resources map[string]proto.Message) *envoy_api_v2.DeltaDiscoveryResponse {
+	resp := &envoy_api_v2.DeltaDiscoveryResponse{
+		SystemVersionInfo: version,
+		Nonce:             nonce,
+		RemovedResources:  removed,
+	}
+	for name, msg := range resources {
+		any, err := types.MarshalAny(msg)
+		if err != nil {
+			t.Fatalf("marshal resource failed: %v", err)
+		}
+		resp.Resources = append(resp.Resources, envoy_api_v2.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: any,
+		})
+	}
+	return resp
+}
+
+func newLoadAssignment(clusterName string, ports ...uint32) *envoy_api_v2.ClusterLoadAssignment {
+	var endpoints []endpoint.LbEndpoint
+	for _, port := range ports {
+		endpoints = append(endpoints, endpoint.LbEndpoint{
+			HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+				Endpoint: &endpoint.Endpoint{
+					Address: socketAddress("127.0.0.1", port),
+				},
+			},
+		})
+	}
+	return &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: clusterName,
+		Endpoints: []endpoint.LocalityLbEndpoints{
+			{
+				LbEndpoints: endpoints,
+			},
+		},
+	}
+}
+
+func newDiscoveryResponse(t *testing.T, typeURL, version, nonce string, msgs ...proto.Message) *envoy_api_v2.DiscoveryResponse {
+	resp := &envoy_api_v2.DiscoveryResponse{
+		TypeUrl:     typeURL,
+		VersionInfo: version,
+		Nonce:       nonce,
+	}
+	for _, msg := range msgs {
+		any, err := types.MarshalAny(msg)
+		if err != nil {
+			t.Fatalf("marshal resource failed: %v", err)
+		}
+		resp.Resources = append(resp.Resources, *any)
+	}
+	return resp
+}
+
+func socketAddress(address string, port uint32) *core.Address {
+	return &core.Address{
+		Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address: address,
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: port,
+				},
+			},
+		},
+	}
+}
+
+func startADSClient(t *testing.T, server ads.AggregatedDiscoveryServiceServer, apiType core.ApiConfigSource_ApiType) (*ADSClient, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	ads.RegisterAggregatedDiscoveryServiceServer(grpcServer, server)
+	go grpcServer.Serve(ln)
+	refreshDelay := 50 * time.Millisecond
+	client := &ADSClient{
+		AdsConfig: &ADSConfig{
+			APIType:      apiType,
+			RefreshDelay: &refreshDelay,
+			Services: []*ServiceConfig{
+				{
+					ClusterConfig: &ClusterConfig{
+						LbPolicy: envoy_api_v2.Cluster_RANDOM,
+						Address:  []string{ln.Addr().String()},
+					},
+				},
+			},
+		},
+		SendControlChan: make(chan int),
+		RecvControlChan: make(chan int),
+		StopChan:        make(chan int),
+	}
+	client.Start()
+	return client, func() {
+		client.Stop()
+		grpcServer.Stop()
+	}
+}
+
+func TestADSClientAckAndNack(t *testing.T) {
+	cluster.NewClusterManagerSingleton(nil, nil)
+	server := newTestADSServer()
+	staticCluster := &envoy_api_v2.Cluster{
+		Name:                 "static_cluster",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_STATIC},
+		Hosts:                []*core.Address{socketAddress("127.0.0.1", 8080)},
+	}
+	server.setSnapshot(t, "1", nil, []xdscache.Resource{staticCluster})
+	_, stop := startADSClient(t, server, core.ApiConfigSource_GRPC)
+	defer stop()
+
+	// subscribe all of the clusters
+	server.expectRequest(t, EnvoyCluster, "", "", nil, false)
+	nonce := server.expectResponse(t, EnvoyCluster, "1")
+	// no eds cluster, the listeners are subscribed
+	server.expectRequest(t, EnvoyListener, "", "", nil, false)
+	server.expectRequest(t, EnvoyCluster, "1", nonce, nil, false)
+
+	// the invalid response is rejected, the version is not changed
+	invalidCluster := &envoy_api_v2.Cluster{
+		Name:                 "invalid_cluster",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_ORIGINAL_DST},
+	}
+	server.setSnapshot(t, "2", nil, []xdscache.Resource{staticCluster, invalidCluster})
+	nonce = server.expectResponse(t, EnvoyCluster, "2")
+	server.expectRequest(t, EnvoyCluster, "1", nonce, nil, true)
+
+	// the eds clusters are subscribed by name
+	edsCluster := &envoy_api_v2.Cluster{
+		Name:                 "eds_cluster",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_EDS},
+		EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+			ServiceName: "eds_cluster",
+		},
+	}
+	server.setSnapshot(t, "3", []xdscache.Resource{newLoadAssignment("eds_cluster", 8081)}, []xdscache.Resource{staticCluster, edsCluster})
+	nonce = server.expectResponse(t, EnvoyCluster, "3")
+	server.expectRequest(t, EnvoyClusterLoadAssignment, "", "", []string{"eds_cluster"}, false)
+	server.expectRequest(t, EnvoyCluster, "3", nonce, nil, false)
+	nonce = server.expectResponse(t, EnvoyClusterLoadAssignment, "3")
+	server.expectRequest(t, EnvoyClusterLoadAssignment, "3", nonce, []string{"eds_cluster"}, false)
+	if hosts := clusterHosts(t, "eds_cluster"); !sameResourceNames(hosts, []string{"127.0.0.1:8081"}) {
+		t.Fatalf("unexpected hosts: %v", hosts)
+	}
+
+	// the resources are pushed by the server, no polling requests
+	server.expectNoRequest(t, EnvoyCluster, 500*time.Millisecond)
+}
+
+func TestADSClientReconnect(t *testing.T) {
+	cluster.NewClusterManagerSingleton(nil, nil)
+	server := newTestADSServer()
+	server.setSnapshot(t, "1", nil, nil)
+	_, stop := startADSClient(t, server, core.ApiConfigSource_GRPC)
+	defer stop()
+
+	server.expectRequest(t, EnvoyCluster, "", "", nil, false)
+	nonce := server.expectResponse(t, EnvoyCluster, "1")
+	server.expectRequest(t, EnvoyListener, "", "", nil, false)
+	server.expectRequest(t, EnvoyCluster, "1", nonce, nil, false)
+	// the stream is closed when the new version is acked,
+	// the accepted version is sent in the new stream, and the nonce is reset
+	atomic.StoreInt32(&server.closeStream, 1)
+	server.setSnapshot(t, "2", nil, nil)
+	server.expectRequest(t, EnvoyCluster, "2", "", nil, false)
+}
+
+func clusterHosts(t *testing.T, clusterName string) []string {
+	snapshot := cluster.GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), clusterName)
+	if snapshot == nil {
+		t.Fatalf("cluster %s is not found", clusterName)
+	}
+	var addrs []string
+	for _, host := range snapshot.HostSet().Hosts() {
+		addrs = append(addrs, host.AddressString())
+	}
+	return addrs
+}
+
+func TestADSClientDelta(t *testing.T) {
+	cluster.NewClusterManagerSingleton(nil, nil)
+	server := newMockDeltaADSServer()
+	client, stop := startADSClient(t, server, core.ApiConfigSource_DELTA_GRPC)
+	defer stop()
+
+	stats := metrics.NewXdsStats(EnvoyClusterLoadAssignment)
+	appendedBefore := stats.Counter(metrics.XdsHostsAppended).Count()
+	removedBefore := stats.Counter(metrics.XdsHostsRemoved).Count()
+
+	// subscribe all of the clusters
+	server.expectDeltaRequest(t, EnvoyCluster, "", nil, nil, nil, false)
+	edsCluster := &envoy_api_v2.Cluster{
+		Name:                 "delta_cluster",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_EDS},
+		EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+			ServiceName: "delta_cluster",
+		},
+	}
+	server.sendDeltaResponse(t, EnvoyCluster, newDeltaDiscoveryResponse(t, "1", "nonce1", nil, map[string]proto.Message{
+		"delta_cluster": edsCluster,
+	}))
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "", []string{"delta_cluster"}, nil, nil, false)
+	server.expectDeltaRequest(t, EnvoyListener, "", nil, nil, nil, false)
+	server.expectDeltaRequest(t, EnvoyCluster, "nonce1", nil, nil, nil, false)
+
+	// the hosts are replaced at first time
+	server.sendDeltaResponse(t, EnvoyClusterLoadAssignment, newDeltaDiscoveryResponse(t, "1", "nonce2", nil, map[string]proto.Message{
+		"delta_cluster": newLoadAssignment("delta_cluster", 8081, 8082),
+	}))
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "nonce2", nil, nil, nil, false)
+	if hosts := clusterHosts(t, "delta_cluster"); !sameResourceNames(hosts, []string{"127.0.0.1:8081", "127.0.0.1:8082"}) {
+		t.Fatalf("unexpected hosts: %v", hosts)
+	}
+	// only the changed hosts are applied
+	server.sendDeltaResponse(t, EnvoyClusterLoadAssignment, newDeltaDiscoveryResponse(t, "2", "nonce3", nil, map[string]proto.Message{
+		"delta_cluster": newLoadAssignment("delta_cluster", 8082, 8083),
+	}))
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "nonce3", nil, nil, nil, false)
+	if hosts := clusterHosts(t, "delta_cluster"); !sameResourceNames(hosts, []string{"127.0.0.1:8082", "127.0.0.1:8083"}) {
+		t.Fatalf("unexpected hosts: %v", hosts)
+	}
+	if n := stats.Counter(metrics.XdsHostsAppended).Count() - appendedBefore; n != 3 {
+		t.Errorf("appended hosts expected 3, but got %d", n)
+	}
+	if n := stats.Counter(metrics.XdsHostsRemoved).Count() - removedBefore; n != 1 {
+		t.Errorf("removed hosts expected 1, but got %d", n)
+	}
+
+	// all of the streams are reconnected if one of them is closed,
+	// the accepted resource versions are sent after reconnected, and the subscriptions are recovered
+	server.closeStream <- struct{}{}
+	server.expectDeltaRequest(t, EnvoyCluster, "", nil, nil, map[string]string{"delta_cluster": "1"}, false)
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "", []string{"delta_cluster"}, nil, map[string]string{"delta_cluster": "2"}, false)
+	server.expectDeltaRequest(t, EnvoyListener, "", nil, nil, nil, false)
+
+	// the removed endpoints and clusters
+	server.sendDeltaResponse(t, EnvoyClusterLoadAssignment, newDeltaDiscoveryResponse(t, "3", "nonce4", []string{"delta_cluster"}, nil))
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "nonce4", nil, nil, nil, false)
+	if hosts := clusterHosts(t, "delta_cluster"); len(hosts) != 0 {
+		t.Fatalf("unexpected hosts: %v", hosts)
+	}
+	server.sendDeltaResponse(t, EnvoyCluster, newDeltaDiscoveryResponse(t, "4", "nonce5", []string{"delta_cluster"}, nil))
+	server.expectDeltaRequest(t, EnvoyClusterLoadAssignment, "", nil, []string{"delta_cluster"}, nil, false)
+	server.expectDeltaRequest(t, EnvoyCluster, "nonce5", nil, nil, nil, false)
+	if cluster.GetClusterMngAdapterInstance().ClusterExist("delta_cluster") {
+		t.Fatal("cluster delta_cluster is expected to be removed")
+	}
+
+	// the stream of route configurations is created when they are subscribed,
+	// the route configurations collected by the other cases may be subscribed after reconnected
+	names := append(rds.GetRouterNames(), "delta_route")
+	if err := client.subscribe(EnvoyRouteConfiguration, names); err != nil {
+		t.Fatalf("subscribe route configurations failed: %v", err)
+	}
+	for subscribed := false; !subscribed; {
+		select {
+		case req := <-server.requestChan(EnvoyRouteConfiguration):
+			for _, name := range req.ResourceNamesSubscribe {
+				subscribed = subscribed || name == "delta_route"
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("wait delta request of route configurations timeout")
+		}
+	}
+	// the route configs are added and removed
+	server.sendDeltaResponse(t, EnvoyRouteConfiguration, newDeltaDiscoveryResponse(t, "5", "nonce6", nil, map[string]proto.Message{
+		"delta_route": &envoy_api_v2.RouteConfiguration{
+			Name: "delta_route",
+			VirtualHosts: []route.VirtualHost{
+				{
+					Name:    "delta_vh",
+					Domains: []string{"*"},
+				},
+			},
+		},
+	}))
+	server.expectDeltaRequest(t, EnvoyRouteConfiguration, "nonce6", nil, nil, nil, false)
+	rw := router.GetRoutersMangerInstance().GetRouterWrapperByName("delta_route")
+	if rw == nil || rw.GetRouters() == nil {
+		t.Fatal("route delta_route is expected to be added")
+	}
+	server.sendDeltaResponse(t, EnvoyRouteConfiguration, newDeltaDiscoveryResponse(t, "6", "nonce7", []string{"delta_route"}, nil))
+	server.expectDeltaRequest(t, EnvoyRouteConfiguration, "nonce7", nil, nil, nil, false)
+	if rw.GetRouters() != nil {
+		t.Fatal("route delta_route is expected to be removed")
+	}
+}
@@ -18,46 +18,38 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
-	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
 )
 
-func (c *ADSClient) reqClusters(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Cluster",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id:       types.GetGlobalXdsInfo().ServiceNode,
-			Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
-			Metadata: types.GetGlobalXdsInfo().Metadata,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Infof("get clusters fail: %v", err)
-		return err
-	}
-	return nil
+func (c *ADSClient) reqClusters() error {
+	return c.subscribe(EnvoyCluster, nil)
 }
 
-func (c *ADSClient) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Cluster {
+func (c *ADSClient) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Cluster, error) {
 	clusters := make([]*envoy_api_v2.Cluster, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
 		cluster := envoy_api_v2.Cluster{}
 		if err := cluster.Unmarshal(res.GetValue()); err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal cluster fail: %v", err)
+			return nil, fmt.Errorf("unmarshal cluster failed: %v", err)
+		}
+		clusters = append(clusters, &cluster)
+	}
+	return clusters, nil
+}
+
+func (c *ADSClient) handleClustersDeltaResp(resp *envoy_api_v2.DeltaDiscoveryResponse) ([]*envoy_api_v2.Cluster, error) {
+	clusters := make([]*envoy_api_v2.Cluster, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		cluster := envoy_api_v2.Cluster{}
+		if err := cluster.Unmarshal(res.Resource.GetValue()); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal cluster fail: %v", err)
+			return nil, fmt.Errorf("unmarshal cluster failed: %v", err)
 		}
 		clusters = append(clusters, &cluster)
 	}
-	return clusters
+	return clusters, nil
 }
@@ -29,6 +29,8 @@ const (
 	EnvoyCluster               = "type.googleapis.com/envoy.api.v2.Cluster"
 	EnvoyClusterLoadAssignment = "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment"
 	EnvoyRouteConfiguration    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
+	// EnvoyScopedRouteConfiguration is the type url of the route scopes discovered by SRDS
+	EnvoyScopedRouteConfiguration = "type.googleapis.com/envoy.api.v2.ScopedRouteConfiguration"
 )
 
 func init() {
@@ -36,25 +38,41 @@ func init() {
 	RegisterTypeURLHandleFunc(EnvoyCluster, HandleEnvoyCluster)
 	RegisterTypeURLHandleFunc(EnvoyClusterLoadAssignment, HandleEnvoyClusterLoadAssignment)
 	RegisterTypeURLHandleFunc(EnvoyRouteConfiguration, HandleEnvoyRouteConfiguration)
+	RegisterTypeURLHandleFunc(EnvoyScopedRouteConfiguration, HandleEnvoyScopedRouteConfiguration)
 }
 
 // HandleEnvoyListener parse envoy data to mosn listener config
-func HandleEnvoyListener(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+func HandleEnvoyListener(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
 	log.DefaultLogger.Tracef("get lds resp,handle it")
-	listeners := client.handleListenersResp(resp)
+	listeners, err := client.handleListenersResp(resp)
+	if err != nil {
+		return err
+	}
 	log.DefaultLogger.Infof("get %d listeners from LDS", len(listeners))
-	conv.ConvertAddOrUpdateListeners(listeners)
-	if err := client.reqRoutes(client.StreamClient); err != nil {
-		log.DefaultLogger.Warnf("send thread request rds fail!auto retry next period")
+	if err := conv.ConvertAddOrUpdateListeners(listeners); err != nil {
+		return err
+	}
+	// the route config names are collected when the listeners are converted
+	if err := client.reqRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request rds fail: %v", err)
 	}
+	if err := client.reqScopedRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request srds fail: %v", err)
+	}
+	return nil
 }
 
 // HandleEnvoyCluster parse envoy data to mosn cluster config
-func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
 	log.DefaultLogger.Tracef("get cds resp,handle it")
-	clusters := client.handleClustersResp(resp)
+	clusters, err := client.handleClustersResp(resp)
+	if err != nil {
+		return err
+	}
 	log.DefaultLogger.Infof("get %d clusters from CDS", len(clusters))
-	conv.ConvertUpdateClusters(clusters)
+	if err := conv.ConvertUpdateClusters(clusters); err != nil {
+		return err
+	}
 	clusterNames := make([]string, 0)
 
 	for _, cluster := range clusters {
@@ -63,33 +81,62 @@ func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse)
 		}
 	}
 
+	// the listeners are subscribed after the endpoints received
 	if len(clusterNames) != 0 {
-		if err := client.reqEndpoints(client.StreamClient, clusterNames); err != nil {
-			log.DefaultLogger.Warnf("send thread request eds fail!auto retry next period")
+		if err := client.reqEndpoints(clusterNames); err != nil {
+			log.DefaultLogger.Warnf("send thread request eds fail: %v", err)
 		}
 	} else {
-		if err := client.reqListeners(client.StreamClient); err != nil {
-			log.DefaultLogger.Warnf("send thread request lds fail!auto retry next period")
+		if err := client.reqListeners(); err != nil {
+			log.DefaultLogger.Warnf("send thread request lds fail: %v", err)
 		}
 	}
+	return nil
 }
 
 // HandleEnvoyClusterLoadAssignment parse envoy data to mosn endpoint config
-func HandleEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+func HandleEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
 	log.DefaultLogger.Tracef("get eds resp,handle it ")
-	endpoints := client.handleEndpointsResp(resp)
+	endpoints, err := client.handleEndpointsResp(resp)
+	if err != nil {
+		return err
+	}
 	log.DefaultLogger.Infof("get %d endpoints from EDS", len(endpoints))
-	conv.ConvertUpdateEndpoints(endpoints)
+	if err := conv.ConvertUpdateEndpoints(endpoints); err != nil {
+		return err
+	}
 
-	if err := client.reqListeners(client.StreamClient); err != nil {
-		log.DefaultLogger.Warnf("send thread request lds fail!auto retry next period")
+	if err := client.reqListeners(); err != nil {
+		log.DefaultLogger.Warnf("send thread request lds fail: %v", err)
 	}
+	return nil
 }
 
 // HandleEnvoyRouteConfiguration parse envoy data to mosn route config
-func HandleEnvoyRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+func HandleEnvoyRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
 	log.DefaultLogger.Tracef("get rds resp,handle it")
-	routes := client.handleRoutesResp(resp)
+	routes, err := client.handleRoutesResp(resp)
+	if err != nil {
+		return err
+	}
 	log.DefaultLogger.Infof("get %d routes from RDS", len(routes))
-	conv.ConvertAddOrUpdateRouters(routes)
+	return conv.ConvertAddOrUpdateRouters(routes)
+}
+
+// HandleEnvoyScopedRouteConfiguration parse envoy data to mosn route scopes,
+// and subscribes the route configurations referenced by the scopes
+func HandleEnvoyScopedRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
+	log.DefaultLogger.Tracef("get srds resp,handle it")
+	scopes, err := client.handleScopedRoutesResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d scopes from SRDS", len(scopes))
+	if err := conv.ConvertUpdateRouteScopes(client.scopedRDSNames(), conv.ConvertRouteScopes(scopes)); err != nil {
+		return err
+	}
+	if err := client.reqRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request rds fail: %v", err)
+	}
+	return nil
 }
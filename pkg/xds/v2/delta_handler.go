@@ -0,0 +1,277 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	clusterAdapter "mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/mosn/pkg/xds/conv"
+)
+
+func init() {
+	RegisterDeltaTypeURLHandleFunc(EnvoyListener, HandleDeltaEnvoyListener)
+	RegisterDeltaTypeURLHandleFunc(EnvoyCluster, HandleDeltaEnvoyCluster)
+	RegisterDeltaTypeURLHandleFunc(EnvoyClusterLoadAssignment, HandleDeltaEnvoyClusterLoadAssignment)
+	RegisterDeltaTypeURLHandleFunc(EnvoyRouteConfiguration, HandleDeltaEnvoyRouteConfiguration)
+	RegisterDeltaTypeURLHandleFunc(EnvoyScopedRouteConfiguration, HandleDeltaEnvoyScopedRouteConfiguration)
+}
+
+// HandleDeltaEnvoyListener adds or updates the changed listeners, and removes the removed listeners
+func HandleDeltaEnvoyListener(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	listeners, err := client.handleListenersDeltaResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d listeners and %d removed listeners from delta LDS", len(listeners), len(resp.RemovedResources))
+	if len(listeners) > 0 {
+		if err := conv.ConvertAddOrUpdateListeners(listeners); err != nil {
+			return err
+		}
+	}
+	if len(resp.RemovedResources) > 0 {
+		if err := conv.ConvertRemoveListeners(resp.RemovedResources); err != nil {
+			return err
+		}
+	}
+	if err := client.reqRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request rds fail: %v", err)
+	}
+	if err := client.reqScopedRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request srds fail: %v", err)
+	}
+	return nil
+}
+
+// HandleDeltaEnvoyCluster adds or updates the changed clusters, and removes the removed clusters.
+// the endpoints of eds clusters are subscribed incrementally.
+func HandleDeltaEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	clusters, err := client.handleClustersDeltaResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d clusters and %d removed clusters from delta CDS", len(clusters), len(resp.RemovedResources))
+	if len(clusters) > 0 {
+		if err := conv.ConvertUpdateClusters(clusters); err != nil {
+			return err
+		}
+	}
+	if len(resp.RemovedResources) > 0 {
+		if err := conv.ConvertRemoveClusters(resp.RemovedResources); err != nil {
+			return err
+		}
+	}
+
+	// the unchanged clusters are not pushed, so the subscribed eds clusters are changed incrementally
+	edsClusters := make(map[string]struct{})
+	subscribed := false
+	client.statesMutex.Lock()
+	if state, ok := client.states[EnvoyClusterLoadAssignment]; ok {
+		subscribed = state.subscribed
+		for _, name := range state.resourceNames {
+			edsClusters[name] = struct{}{}
+		}
+	}
+	client.statesMutex.Unlock()
+	for _, name := range resp.RemovedResources {
+		delete(edsClusters, name)
+		delete(client.endpoints, name)
+	}
+	for _, cluster := range clusters {
+		if cluster.GetType() == envoy_api_v2.Cluster_EDS {
+			edsClusters[cluster.Name] = struct{}{}
+		} else {
+			delete(edsClusters, cluster.Name)
+			delete(client.endpoints, cluster.Name)
+		}
+	}
+	clusterNames := make([]string, 0, len(edsClusters))
+	for name := range edsClusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	if len(clusterNames) != 0 || subscribed {
+		if err := client.reqEndpoints(clusterNames); err != nil {
+			log.DefaultLogger.Warnf("send thread request eds fail: %v", err)
+		}
+	}
+	// the unchanged endpoints are not pushed after reconnected,
+	// so the listeners are subscribed without waiting for the endpoints
+	if err := client.reqListeners(); err != nil {
+		log.DefaultLogger.Warnf("send thread request lds fail: %v", err)
+	}
+	return nil
+}
+
+// HandleDeltaEnvoyClusterLoadAssignment applies the changed hosts of the clusters
+func HandleDeltaEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	endpoints, err := client.handleEndpointsDeltaResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d endpoints and %d removed endpoints from delta EDS", len(endpoints), len(resp.RemovedResources))
+	var errGlobal error
+	for _, loadAssignment := range endpoints {
+		if err := client.applyEndpoints(loadAssignment.ClusterName, conv.ConvertLoadAssignmentHosts(loadAssignment)); err != nil {
+			log.DefaultLogger.Errorf("xds client apply endpoints of cluster %s failed: %v", loadAssignment.ClusterName, err)
+			errGlobal = fmt.Errorf("apply endpoints of cluster %s failed: %v", loadAssignment.ClusterName, err)
+		}
+	}
+	for _, clusterName := range resp.RemovedResources {
+		if err := client.removeEndpoints(clusterName); err != nil {
+			log.DefaultLogger.Errorf("xds client remove endpoints of cluster %s failed: %v", clusterName, err)
+			errGlobal = fmt.Errorf("remove endpoints of cluster %s failed: %v", clusterName, err)
+		}
+	}
+	return errGlobal
+}
+
+// HandleDeltaEnvoyRouteConfiguration adds or updates the changed routers, and removes the removed routers
+func HandleDeltaEnvoyRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	routes, err := client.handleRoutesDeltaResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d routes and %d removed routes from delta RDS", len(routes), len(resp.RemovedResources))
+	if len(routes) > 0 {
+		if err := conv.ConvertAddOrUpdateRouters(routes); err != nil {
+			return err
+		}
+	}
+	if len(resp.RemovedResources) > 0 {
+		if err := conv.ConvertRemoveRouters(resp.RemovedResources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleDeltaEnvoyScopedRouteConfiguration applies the changed and removed route scopes,
+// all of the scopes received in the stream are replaced as a whole, and the applied scopes
+// are changed only if the new scopes are accepted.
+func HandleDeltaEnvoyScopedRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	scopes, err := client.handleScopedRoutesDeltaResp(resp)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger.Infof("get %d scopes and %d removed scopes from delta SRDS", len(scopes), len(resp.RemovedResources))
+	current := make(map[string]*v2.RouteScope, len(client.scopes)+len(scopes))
+	for name, scope := range client.scopes {
+		current[name] = scope
+	}
+	for _, scope := range conv.ConvertRouteScopes(scopes) {
+		current[scope.Name] = scope
+	}
+	for _, name := range resp.RemovedResources {
+		delete(current, name)
+	}
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	applied := make([]*v2.RouteScope, 0, len(names))
+	for _, name := range names {
+		applied = append(applied, current[name])
+	}
+	if err := conv.ConvertUpdateRouteScopes(client.scopedRDSNames(), applied); err != nil {
+		return err
+	}
+	client.scopes = current
+	if err := client.reqRoutes(); err != nil {
+		log.DefaultLogger.Warnf("send thread request rds fail: %v", err)
+	}
+	return nil
+}
+
+// applyEndpoints changes the hosts of cluster by the difference from the last applied hosts,
+// the hosts are replaced if the cluster is not applied before.
+func (c *ADSClient) applyEndpoints(clusterName string, hosts []v2.Host) error {
+	clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+	stats := metrics.NewXdsStats(EnvoyClusterLoadAssignment)
+	current := make(map[string]v2.Host, len(hosts))
+	for _, host := range hosts {
+		current[host.Address] = host
+	}
+	applied, ok := c.endpoints[clusterName]
+	if !ok {
+		if err := clusterMngAdapter.TriggerClusterHostUpdate(clusterName, hosts); err != nil {
+			return err
+		}
+		stats.Counter(metrics.XdsHostsAppended).Inc(int64(len(hosts)))
+	} else {
+		// the changed hosts are removed and appended again
+		var removed []string
+		var appended []v2.Host
+		for addr, host := range applied {
+			if h, ok := current[addr]; !ok || !reflect.DeepEqual(h, host) {
+				removed = append(removed, addr)
+			}
+		}
+		for addr, host := range current {
+			if h, ok := applied[addr]; !ok || !reflect.DeepEqual(h, host) {
+				appended = append(appended, host)
+			}
+		}
+		if len(removed) > 0 {
+			if err := clusterMngAdapter.TriggerHostDel(clusterName, removed); err != nil {
+				return err
+			}
+			stats.Counter(metrics.XdsHostsRemoved).Inc(int64(len(removed)))
+		}
+		if len(appended) > 0 {
+			if err := clusterMngAdapter.TriggerHostAppend(clusterName, appended); err != nil {
+				return err
+			}
+			stats.Counter(metrics.XdsHostsAppended).Inc(int64(len(appended)))
+		}
+	}
+	if c.endpoints == nil {
+		c.endpoints = make(map[string]map[string]v2.Host)
+	}
+	c.endpoints[clusterName] = current
+	return nil
+}
+
+// removeEndpoints removes the hosts of cluster applied by the incremental eds
+func (c *ADSClient) removeEndpoints(clusterName string) error {
+	applied, ok := c.endpoints[clusterName]
+	if !ok {
+		return nil
+	}
+	delete(c.endpoints, clusterName)
+	clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+	if len(applied) == 0 || !clusterMngAdapter.ClusterExist(clusterName) {
+		return nil
+	}
+	addrs := make([]string, 0, len(applied))
+	for addr := range applied {
+		addrs = append(addrs, addr)
+	}
+	if err := clusterMngAdapter.TriggerHostDel(clusterName, addrs); err != nil {
+		return err
+	}
+	metrics.NewXdsStats(EnvoyClusterLoadAssignment).Counter(metrics.XdsHostsRemoved).Inc(int64(len(addrs)))
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/googleapis/google/rpc"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+// deltaResponse is a response or an error received from the incremental stream of a type url
+type deltaResponse struct {
+	typeURL string
+	stream  ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+	resp    *envoy_api_v2.DeltaDiscoveryResponse
+	err     error
+}
+
+// deltaStreamClient returns the incremental stream of the type url, the stream is created if it does not exist,
+// and the responses of the stream are received in a new goroutine until the stream is closed.
+func (adsClient *ADSClient) deltaStreamClient(typeURL string) (ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient, error) {
+	adsClient.StreamClientMutex.Lock()
+	defer adsClient.StreamClientMutex.Unlock()
+	if dsc, ok := adsClient.DeltaStreamClients[typeURL]; ok {
+		return dsc, nil
+	}
+	done := adsClient.deltaDone
+	if done == nil {
+		return nil, errors.New("delta stream client is closed")
+	}
+	dsc := adsClient.AdsConfig.GetDeltaStreamClient(typeURL)
+	if dsc == nil {
+		return nil, fmt.Errorf("create delta stream client of %s failed", typeURL)
+	}
+	if adsClient.DeltaStreamClients == nil {
+		adsClient.DeltaStreamClients = make(map[string]ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient)
+	}
+	adsClient.DeltaStreamClients[typeURL] = dsc
+	responses := adsClient.deltaResponses
+	utils.GoWithRecover(func() {
+		for {
+			resp, err := dsc.Recv()
+			select {
+			case responses <- &deltaResponse{typeURL: typeURL, stream: dsc, resp: resp, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}, nil)
+	return dsc, nil
+}
+
+// receiveDelta waits a response from the incremental streams and handles it,
+// the responses of the closed streams are ignored.
+func (adsClient *ADSClient) receiveDelta() error {
+	adsClient.StreamClientMutex.RLock()
+	done := adsClient.deltaDone
+	adsClient.StreamClientMutex.RUnlock()
+	if done == nil {
+		return errors.New("delta stream client is nil")
+	}
+	for {
+		select {
+		case <-done:
+			return errors.New("delta stream client is closed")
+		case r := <-adsClient.deltaResponses:
+			adsClient.StreamClientMutex.RLock()
+			current := adsClient.DeltaStreamClients[r.typeURL] == r.stream
+			adsClient.StreamClientMutex.RUnlock()
+			if !current {
+				continue
+			}
+			if r.err != nil {
+				return r.err
+			}
+			adsClient.handleDeltaResponse(r.typeURL, r.resp)
+			return nil
+		}
+	}
+}
+
+// handleDeltaResponse applies the incremental response of the type url, and sends ACK if the response
+// is applied successfully, otherwise sends NACK
+func (adsClient *ADSClient) handleDeltaResponse(typeURL string, resp *envoy_api_v2.DeltaDiscoveryResponse) {
+	var err error
+	stats := metrics.NewXdsStats(typeURL)
+	if handleErr := HandleDeltaTypeURL(typeURL, adsClient, resp); handleErr != nil {
+		log.DefaultLogger.Errorf("[xds] [ads client] reject delta response, type url: %s, version: %s, nonce: %s, error: %v",
+			typeURL, resp.SystemVersionInfo, resp.Nonce, handleErr)
+		stats.Counter(metrics.XdsUpdateRejected).Inc(1)
+		err = adsClient.deltaNack(typeURL, resp, handleErr)
+	} else {
+		log.DefaultLogger.Infof("[xds] [ads client] accept delta response, type url: %s, version: %s, nonce: %s, added: %d, removed: %d",
+			typeURL, resp.SystemVersionInfo, resp.Nonce, len(resp.Resources), len(resp.RemovedResources))
+		stats.Counter(metrics.XdsUpdateSuccess).Inc(1)
+		stats.Counter(metrics.XdsResourceApplied).Inc(int64(len(resp.Resources)))
+		stats.Counter(metrics.XdsResourceRemoved).Inc(int64(len(resp.RemovedResources)))
+		err = adsClient.deltaAck(typeURL, resp)
+	}
+	if err != nil {
+		log.DefaultLogger.Infof("[xds] [ads client] send ack of %s failed: %v", typeURL, err)
+	}
+}
+
+// deltaAck accepts the incremental response, the versions of the resources are updated
+func (adsClient *ADSClient) deltaAck(typeURL string, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	adsClient.statesMutex.Lock()
+	state := adsClient.getState(typeURL)
+	state.versionInfo = resp.SystemVersionInfo
+	state.nonce = resp.Nonce
+	if state.resourceVersions == nil {
+		state.resourceVersions = make(map[string]string)
+	}
+	for _, res := range resp.Resources {
+		state.resourceVersions[res.Name] = res.Version
+	}
+	for _, name := range resp.RemovedResources {
+		delete(state.resourceVersions, name)
+	}
+	adsClient.statesMutex.Unlock()
+	return adsClient.sendDelta(&envoy_api_v2.DeltaDiscoveryRequest{
+		Node:          newNode(),
+		TypeUrl:       typeURL,
+		ResponseNonce: resp.Nonce,
+	})
+}
+
+// deltaNack rejects the incremental response, the versions of the resources are not changed
+func (adsClient *ADSClient) deltaNack(typeURL string, resp *envoy_api_v2.DeltaDiscoveryResponse, cause error) error {
+	adsClient.statesMutex.Lock()
+	state := adsClient.getState(typeURL)
+	state.nonce = resp.Nonce
+	adsClient.statesMutex.Unlock()
+	return adsClient.sendDelta(&envoy_api_v2.DeltaDiscoveryRequest{
+		Node:          newNode(),
+		TypeUrl:       typeURL,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail: &rpc.Status{
+			Code:    int32(rpc.INVALID_ARGUMENT),
+			Message: cause.Error(),
+		},
+	})
+}
+
+// sendDelta sends the request in the incremental stream of its type url
+func (adsClient *ADSClient) sendDelta(req *envoy_api_v2.DeltaDiscoveryRequest) error {
+	dsc, err := adsClient.deltaStreamClient(req.TypeUrl)
+	if err != nil {
+		return err
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[xds] [ads client] send delta request, type url: %s, nonce: %s, subscribe: %v, unsubscribe: %v",
+			req.TypeUrl, req.ResponseNonce, req.ResourceNamesSubscribe, req.ResourceNamesUnsubscribe)
+	}
+	return dsc.Send(req)
+}
+
+// newDeltaDiscoveryRequest creates a request to change the subscribed resources of the type url.
+// the first request in a stream contains the versions of the accepted resources,
+// the following requests only contain the changes of the resource names.
+func newDeltaDiscoveryRequest(typeURL string, state *resourceState, resourceNames []string) *envoy_api_v2.DeltaDiscoveryRequest {
+	req := &envoy_api_v2.DeltaDiscoveryRequest{
+		Node:    newNode(),
+		TypeUrl: typeURL,
+	}
+	if !state.subscribed {
+		req.ResourceNamesSubscribe = resourceNames
+		if len(state.resourceVersions) > 0 {
+			req.InitialResourceVersions = make(map[string]string, len(state.resourceVersions))
+			for name, version := range state.resourceVersions {
+				req.InitialResourceVersions[name] = version
+			}
+		}
+		return req
+	}
+	req.ResourceNamesSubscribe = diffResourceNames(resourceNames, state.resourceNames)
+	req.ResourceNamesUnsubscribe = diffResourceNames(state.resourceNames, resourceNames)
+	return req
+}
+
+// diffResourceNames returns the names in a but not in b
+func diffResourceNames(a, b []string) []string {
+	exists := make(map[string]struct{}, len(b))
+	for _, name := range b {
+		exists[name] = struct{}{}
+	}
+	var diff []string
+	for _, name := range a {
+		if _, ok := exists[name]; !ok {
+			diff = append(diff, name)
+		}
+	}
+	return diff
+}
@@ -18,46 +18,38 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
-	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
 )
 
-func (c *ADSClient) reqEndpoints(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, clusterNames []string) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: clusterNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id:       types.GetGlobalXdsInfo().ServiceNode,
-			Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
-			Metadata: types.GetGlobalXdsInfo().Metadata,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Infof("get endpoints fail: %v", err)
-		return err
-	}
-	return nil
+func (c *ADSClient) reqEndpoints(clusterNames []string) error {
+	return c.subscribe(EnvoyClusterLoadAssignment, clusterNames)
 }
 
-func (c *ADSClient) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.ClusterLoadAssignment {
+func (c *ADSClient) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.ClusterLoadAssignment, error) {
 	lbAssignments := make([]*envoy_api_v2.ClusterLoadAssignment, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
 		lbAssignment := envoy_api_v2.ClusterLoadAssignment{}
 		if err := lbAssignment.Unmarshal(res.GetValue()); err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal lbAssignment fail: %v", err)
+			return nil, fmt.Errorf("unmarshal cluster load assignment failed: %v", err)
+		}
+		lbAssignments = append(lbAssignments, &lbAssignment)
+	}
+	return lbAssignments, nil
+}
+
+func (c *ADSClient) handleEndpointsDeltaResp(resp *envoy_api_v2.DeltaDiscoveryResponse) ([]*envoy_api_v2.ClusterLoadAssignment, error) {
+	lbAssignments := make([]*envoy_api_v2.ClusterLoadAssignment, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		lbAssignment := envoy_api_v2.ClusterLoadAssignment{}
+		if err := lbAssignment.Unmarshal(res.Resource.GetValue()); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal lbAssignment fail: %v", err)
+			return nil, fmt.Errorf("unmarshal cluster load assignment failed: %v", err)
 		}
 		lbAssignments = append(lbAssignments, &lbAssignment)
 	}
-	return lbAssignments
+	return lbAssignments, nil
 }
@@ -17,10 +17,16 @@
 
 package v2
 
-import envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+import (
+	"fmt"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+)
 
 var typeURLHandleFuncs map[string]TypeURLHandleFunc
 
+var deltaTypeURLHandleFuncs map[string]DeltaTypeURLHandleFunc
+
 func RegisterTypeURLHandleFunc(url string, f TypeURLHandleFunc) {
 	if typeURLHandleFuncs == nil {
 		typeURLHandleFuncs = make(map[string]TypeURLHandleFunc, 10)
@@ -28,8 +34,23 @@ func RegisterTypeURLHandleFunc(url string, f TypeURLHandleFunc) {
 	typeURLHandleFuncs[url] = f
 }
 
-func HandleTypeURL(url string, client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+func HandleTypeURL(url string, client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error {
 	if f, ok := typeURLHandleFuncs[url]; ok {
-		f(client, resp)
+		return f(client, resp)
+	}
+	return fmt.Errorf("unsupported type url: %s", url)
+}
+
+func RegisterDeltaTypeURLHandleFunc(url string, f DeltaTypeURLHandleFunc) {
+	if deltaTypeURLHandleFuncs == nil {
+		deltaTypeURLHandleFuncs = make(map[string]DeltaTypeURLHandleFunc, 10)
+	}
+	deltaTypeURLHandleFuncs[url] = f
+}
+
+func HandleDeltaTypeURL(url string, client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	if f, ok := deltaTypeURLHandleFuncs[url]; ok {
+		return f(client, resp)
 	}
+	return fmt.Errorf("unsupported type url: %s", url)
 }
@@ -18,46 +18,38 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
-	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
 )
 
-func (c *ADSClient) reqListeners(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Listener",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id:       types.GetGlobalXdsInfo().ServiceNode,
-			Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
-			Metadata: types.GetGlobalXdsInfo().Metadata,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Infof("get listener fail: %v", err)
-		return err
-	}
-	return nil
+func (c *ADSClient) reqListeners() error {
+	return c.subscribe(EnvoyListener, nil)
 }
 
-func (c *ADSClient) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Listener {
+func (c *ADSClient) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Listener, error) {
 	listeners := make([]*envoy_api_v2.Listener, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
 		listener := envoy_api_v2.Listener{}
 		if err := listener.Unmarshal(res.GetValue()); err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal listener fail: %v", err)
+			return nil, fmt.Errorf("unmarshal listener failed: %v", err)
+		}
+		listeners = append(listeners, &listener)
+	}
+	return listeners, nil
+}
+
+func (c *ADSClient) handleListenersDeltaResp(resp *envoy_api_v2.DeltaDiscoveryResponse) ([]*envoy_api_v2.Listener, error) {
+	listeners := make([]*envoy_api_v2.Listener, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		listener := envoy_api_v2.Listener{}
+		if err := listener.Unmarshal(res.Resource.GetValue()); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal listener fail: %v", err)
+			return nil, fmt.Errorf("unmarshal listener failed: %v", err)
 		}
 		listeners = append(listeners, &listener)
 	}
-	return listeners
+	return listeners, nil
 }
@@ -28,6 +28,9 @@ rds store the router config names which need to fetch virtualhosts configuration
 var (
 	mu          sync.Mutex
 	routerNames map[string]bool
+	// scopedRouterNames are the router config names referenced by the route scopes,
+	// which are replaced as a whole when the scopes are changed
+	scopedRouterNames map[string]bool
 )
 
 // AppendRouterName use to append rds router configname to subscript
@@ -40,15 +43,29 @@ func AppendRouterName(name string) {
 	routerNames[name] = true
 }
 
+// SetScopedRouterNames replaces the router config names referenced by the route scopes,
+// the names referenced by the removed scopes are not subscripted any more
+func SetScopedRouterNames(names []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	scopedRouterNames = make(map[string]bool, len(names))
+	for _, name := range names {
+		scopedRouterNames[name] = true
+	}
+}
+
 // GetRouterNames return disctict router config names
 func GetRouterNames() []string {
 	mu.Lock()
 	defer mu.Unlock()
-	names := make([]string, len(routerNames))
-	i := 0
+	names := make([]string, 0, len(routerNames)+len(scopedRouterNames))
 	for name, _ := range routerNames {
-		names[i] = name
-		i++
+		names = append(names, name)
+	}
+	for name := range scopedRouterNames {
+		if !routerNames[name] {
+			names = append(names, name)
+		}
 	}
 	return names
 }
@@ -19,6 +19,7 @@ package rds
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -70,3 +71,26 @@ func Test_GetRouterNames(t *testing.T) {
 		})
 	}
 }
+
+func Test_SetScopedRouterNames(t *testing.T) {
+	routerNames = map[string]bool{"http.80": true}
+	SetScopedRouterNames([]string{"http.80", "scope_a"})
+	if got := GetRouterNames(); !sameNames(got, []string{"http.80", "scope_a"}) {
+		t.Errorf("GetRouterNames() = %v", got)
+	}
+	// the names of the removed scopes are removed, the other names are kept
+	SetScopedRouterNames([]string{"scope_b"})
+	if got := GetRouterNames(); !sameNames(got, []string{"http.80", "scope_b"}) {
+		t.Errorf("GetRouterNames() = %v", got)
+	}
+	SetScopedRouterNames(nil)
+	if got := GetRouterNames(); !sameNames(got, []string{"http.80"}) {
+		t.Errorf("GetRouterNames() = %v", got)
+	}
+}
+
+func sameNames(a, b []string) bool {
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
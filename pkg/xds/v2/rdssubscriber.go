@@ -18,53 +18,45 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
-	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/xds/v2/rds"
 )
 
-func (c *ADSClient) reqRoutes(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
+func (c *ADSClient) reqRoutes() error {
 	routerNames := rds.GetRouterNames()
 	if len(routerNames) < 1 {
 		log.DefaultLogger.Tracef("0 routers, skip rds request")
 		return nil
 	}
 	log.DefaultLogger.Tracef("routers to subcriber: %+v", routerNames)
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: routerNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.RouteConfiguration",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id:       types.GetGlobalXdsInfo().ServiceNode,
-			Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
-			Metadata: types.GetGlobalXdsInfo().Metadata,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Infof("get routers fail: %v", err)
-		return err
-	}
-	return nil
+	return c.subscribe(EnvoyRouteConfiguration, routerNames)
 }
 
-func (c *ADSClient) handleRoutesResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.RouteConfiguration {
+func (c *ADSClient) handleRoutesResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.RouteConfiguration, error) {
 	routes := make([]*envoy_api_v2.RouteConfiguration, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
 		route := envoy_api_v2.RouteConfiguration{}
 		if err := route.Unmarshal(res.GetValue()); err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal route fail: %v", err)
+			return nil, fmt.Errorf("unmarshal route configuration failed: %v", err)
+		}
+		routes = append(routes, &route)
+	}
+	return routes, nil
+}
+
+func (c *ADSClient) handleRoutesDeltaResp(resp *envoy_api_v2.DeltaDiscoveryResponse) ([]*envoy_api_v2.RouteConfiguration, error) {
+	routes := make([]*envoy_api_v2.RouteConfiguration, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		route := envoy_api_v2.RouteConfiguration{}
+		if err := route.Unmarshal(res.Resource.GetValue()); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal route fail: %v", err)
+			return nil, fmt.Errorf("unmarshal route configuration failed: %v", err)
 		}
 		routes = append(routes, &route)
 	}
-	return routes
+	return routes, nil
 }
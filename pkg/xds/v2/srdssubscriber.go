@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"fmt"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/gogo/protobuf/proto"
+	"mosn.io/mosn/pkg/log"
+	srds "mosn.io/mosn/pkg/xds/model/srds/v2"
+)
+
+// reqScopedRoutes subscribes all of the route scopes if any routers discover the scopes by SRDS
+func (c *ADSClient) reqScopedRoutes() error {
+	if len(c.scopedRDSNames()) == 0 {
+		return nil
+	}
+	return c.subscribe(EnvoyScopedRouteConfiguration, nil)
+}
+
+// scopedRDSNames returns the names of the scoped routes that discover the scopes by SRDS,
+// the scopes received by the subscription are applied to all of them.
+func (c *ADSClient) scopedRDSNames() []string {
+	if c.MosnConfig == nil {
+		return nil
+	}
+	var names []string
+	exists := make(map[string]bool)
+	for _, server := range c.MosnConfig.Servers {
+		for _, routers := range server.Routers {
+			if routers == nil || routers.ScopedRoutes == nil || !routers.ScopedRoutes.ScopedRDS {
+				continue
+			}
+			if name := routers.ScopedRoutes.Name; !exists[name] {
+				exists[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func (c *ADSClient) handleScopedRoutesResp(resp *envoy_api_v2.DiscoveryResponse) ([]*srds.ScopedRouteConfiguration, error) {
+	scopes := make([]*srds.ScopedRouteConfiguration, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		scope := &srds.ScopedRouteConfiguration{}
+		if err := proto.Unmarshal(res.GetValue(), scope); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal scoped route fail: %v", err)
+			return nil, fmt.Errorf("unmarshal scoped route configuration failed: %v", err)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func (c *ADSClient) handleScopedRoutesDeltaResp(resp *envoy_api_v2.DeltaDiscoveryResponse) ([]*srds.ScopedRouteConfiguration, error) {
+	scopes := make([]*srds.ScopedRouteConfiguration, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		scope := &srds.ScopedRouteConfiguration{}
+		if err := proto.Unmarshal(res.Resource.GetValue(), scope); err != nil {
+			log.DefaultLogger.Errorf("ADSClient unmarshal scoped route fail: %v", err)
+			return nil, fmt.Errorf("unmarshal scoped route configuration failed: %v", err)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/proto"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	srds "mosn.io/mosn/pkg/xds/model/srds/v2"
+	"mosn.io/mosn/pkg/xds/v2/rds"
+)
+
+func newScopedRouteConfiguration(name, routeConfigName string, key ...string) *srds.ScopedRouteConfiguration {
+	scope := &srds.ScopedRouteConfiguration{
+		Name:                   name,
+		RouteConfigurationName: routeConfigName,
+		Key:                    &srds.ScopedRouteConfiguration_Key{},
+	}
+	for _, k := range key {
+		scope.Key.Fragments = append(scope.Key.Fragments, &srds.ScopedRouteConfiguration_Key_Fragment{StringKey: k})
+	}
+	return scope
+}
+
+func Test_handleScopedRoutesResp(t *testing.T) {
+	var client ADSClient
+	resp := newDiscoveryResponse(t, EnvoyScopedRouteConfiguration, "1", "nonce1",
+		newScopedRouteConfiguration("scope_a", "table_a", "tenant-a"))
+	scopes, err := client.handleScopedRoutesResp(resp)
+	if err != nil {
+		t.Fatalf("handle scoped routes failed: %v", err)
+	}
+	if len(scopes) != 1 || scopes[0].Name != "scope_a" || scopes[0].RouteConfigurationName != "table_a" ||
+		len(scopes[0].Key.Fragments) != 1 || scopes[0].Key.Fragments[0].StringKey != "tenant-a" {
+		t.Fatalf("unexpected scopes: %v", scopes)
+	}
+}
+
+func TestScopedRDSNames(t *testing.T) {
+	client := &ADSClient{}
+	if names := client.scopedRDSNames(); len(names) != 0 {
+		t.Fatalf("srds should not be enabled without mosn config, but got %v", names)
+	}
+	client.MosnConfig = &v2.MOSNConfig{
+		Servers: []v2.ServerConfig{
+			{
+				Routers: []*v2.RouterConfiguration{
+					{RouterConfigurationConfig: v2.RouterConfigurationConfig{
+						RouterConfigName: "scoped",
+						ScopedRoutes:     &v2.ScopedRoutes{Name: "tenants"},
+					}},
+					{RouterConfigurationConfig: v2.RouterConfigurationConfig{
+						RouterConfigName: "scoped_dup",
+						ScopedRoutes:     &v2.ScopedRoutes{Name: "tenants"},
+					}},
+				},
+			},
+		},
+	}
+	if names := client.scopedRDSNames(); len(names) != 0 {
+		t.Fatalf("srds should not be enabled with static scopes, but got %v", names)
+	}
+	client.MosnConfig.Servers[0].Routers[0].ScopedRoutes.ScopedRDS = true
+	client.MosnConfig.Servers[0].Routers[1].ScopedRoutes.ScopedRDS = true
+	if names := client.scopedRDSNames(); !sameResourceNames(names, []string{"tenants"}) {
+		t.Fatalf("unexpected scoped routes names: %v", names)
+	}
+}
+
+func TestHandleDeltaEnvoyScopedRouteConfiguration(t *testing.T) {
+	router.NewRouterManager()
+	rm := router.GetRoutersMangerInstance()
+	for _, table := range []string{"srds_table_a", "srds_table_b"} {
+		if err := rm.AddOrUpdateRouters(&v2.RouterConfiguration{
+			RouterConfigurationConfig: v2.RouterConfigurationConfig{
+				RouterConfigName: table,
+			},
+			VirtualHosts: []*v2.VirtualHost{
+				{
+					Name:    table,
+					Domains: []string{"*"},
+					Routers: []v2.Router{
+						{RouterConfig: v2.RouterConfig{
+							Match: v2.RouterMatch{Prefix: "/"},
+							Route: v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: table}},
+						}},
+					},
+				},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the scopes are applied to the scoped routes subscribing them only
+	scoped := &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: "srds_scoped",
+			ScopedRoutes: &v2.ScopedRoutes{
+				Name: "srds",
+				ScopeKeyBuilder: v2.ScopeKeyBuilder{
+					Fragments: []v2.ScopeKeyFragment{{HeaderName: "x-tenant"}},
+				},
+				ScopedRDS: true,
+			},
+		},
+	}
+	other := &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: "srds_other",
+			ScopedRoutes: &v2.ScopedRoutes{
+				Name: "other",
+				ScopeKeyBuilder: v2.ScopeKeyBuilder{
+					Fragments: []v2.ScopeKeyFragment{{HeaderName: "x-tenant"}},
+				},
+				ScopedRDS: true,
+			},
+		},
+	}
+	for _, cfg := range []*v2.RouterConfiguration{scoped, other} {
+		if err := rm.AddOrUpdateRouters(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	matchRouters := func(routerConfigName, tenant string) string {
+		headers := protocol.CommonHeader{
+			"x-tenant":                 tenant,
+			protocol.MosnHeaderPathKey: "/",
+		}
+		route := rm.GetRouterWrapperByName(routerConfigName).GetRouters().MatchRoute(headers, 1)
+		if route == nil {
+			return ""
+		}
+		return route.RouteRule().ClusterName()
+	}
+	match := func(tenant string) string {
+		return matchRouters("srds_scoped", tenant)
+	}
+	routerNames := func() map[string]bool {
+		names := map[string]bool{}
+		for _, name := range rds.GetRouterNames() {
+			names[name] = true
+		}
+		return names
+	}
+
+	client := &ADSClient{
+		AdsConfig: &ADSConfig{APIType: core.ApiConfigSource_DELTA_GRPC},
+		MosnConfig: &v2.MOSNConfig{
+			Servers: []v2.ServerConfig{
+				{Routers: []*v2.RouterConfiguration{scoped}},
+			},
+		},
+	}
+	resp := newDeltaDiscoveryResponse(t, "1", "nonce1", nil, map[string]proto.Message{
+		"scope_a": newScopedRouteConfiguration("scope_a", "srds_table_a", "a"),
+		"scope_b": newScopedRouteConfiguration("scope_b", "srds_table_b", "b"),
+	})
+	if err := HandleDeltaEnvoyScopedRouteConfiguration(client, resp); err != nil {
+		t.Fatalf("handle delta srds failed: %v", err)
+	}
+	if c := match("a"); c != "srds_table_a" {
+		t.Fatalf("tenant a expected route to srds_table_a, but got %s", c)
+	}
+	if c := match("b"); c != "srds_table_b" {
+		t.Fatalf("tenant b expected route to srds_table_b, but got %s", c)
+	}
+	if c := matchRouters("srds_other", "a"); c != "" {
+		t.Fatalf("scoped routes other expected no route, but got %s", c)
+	}
+	// the referenced route configurations are requested by rds
+	if names := routerNames(); !names["srds_table_a"] || !names["srds_table_b"] {
+		t.Fatalf("route configurations of the scopes are not collected: %v", names)
+	}
+	// the unchanged scopes are kept, and the removed scopes are deleted
+	resp = newDeltaDiscoveryResponse(t, "2", "nonce2", []string{"scope_a"}, map[string]proto.Message{
+		"scope_c": newScopedRouteConfiguration("scope_c", "srds_table_a", "c"),
+	})
+	if err := HandleDeltaEnvoyScopedRouteConfiguration(client, resp); err != nil {
+		t.Fatalf("handle delta srds failed: %v", err)
+	}
+	if c := match("a"); c != "" {
+		t.Fatalf("tenant a expected no route, but got %s", c)
+	}
+	if c := match("b"); c != "srds_table_b" {
+		t.Fatalf("tenant b expected route to srds_table_b, but got %s", c)
+	}
+	if c := match("c"); c != "srds_table_a" {
+		t.Fatalf("tenant c expected route to srds_table_a, but got %s", c)
+	}
+	// the rejected scopes are not applied
+	resp = newDeltaDiscoveryResponse(t, "3", "nonce3", []string{"scope_b"}, map[string]proto.Message{
+		"scope_d": newScopedRouteConfiguration("scope_d", "srds_table_b", "c"),
+	})
+	if err := HandleDeltaEnvoyScopedRouteConfiguration(client, resp); err == nil {
+		t.Fatal("scopes with duplicate keys should be rejected")
+	}
+	if c := match("b"); c != "srds_table_b" {
+		t.Fatalf("tenant b expected route to srds_table_b, but got %s", c)
+	}
+	if _, ok := client.scopes["scope_d"]; ok || len(client.scopes) != 2 {
+		t.Fatalf("unexpected scopes after rejected: %v", client.scopes)
+	}
+	// the route configurations of the removed scopes are not requested any more
+	resp = newDeltaDiscoveryResponse(t, "4", "nonce4", []string{"scope_c"}, nil)
+	if err := HandleDeltaEnvoyScopedRouteConfiguration(client, resp); err != nil {
+		t.Fatalf("handle delta srds failed: %v", err)
+	}
+	if names := routerNames(); names["srds_table_a"] || !names["srds_table_b"] {
+		t.Fatalf("unexpected route configurations of the scopes: %v", names)
+	}
+}
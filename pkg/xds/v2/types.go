@@ -46,7 +46,9 @@ type ClusterConfig struct {
 
 // ADSConfig contains ADS config from dynamic resources
 type ADSConfig struct {
-	APIType      core.ApiConfigSource_ApiType
+	// APIType is GRPC or DELTA_GRPC, the incremental xDS is used if it is DELTA_GRPC
+	APIType core.ApiConfigSource_ApiType
+	// Deprecated: the resources are pushed by the management server, the client does not poll them any more
 	RefreshDelay *time.Duration
 	Services     []*ServiceConfig
 	StreamClient *StreamClient
@@ -57,10 +59,40 @@ type ADSClient struct {
 	AdsConfig         *ADSConfig
 	StreamClientMutex sync.RWMutex
 	StreamClient      ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
-	MosnConfig        *v2.MOSNConfig
-	SendControlChan   chan int
-	RecvControlChan   chan int
-	StopChan          chan int
+	// DeltaStreamClients are the incremental streams keyed by type url, each stream subscribes only one type url,
+	// so the type url of the responses is known, the delta response of the vendored api has no type url.
+	DeltaStreamClients map[string]ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+	MosnConfig         *v2.MOSNConfig
+	SendControlChan    chan int
+	RecvControlChan    chan int
+	StopChan           chan int
+	// the responses of the incremental streams are handled in the receive goroutine,
+	// deltaDone is closed when the incremental streams are closed
+	deltaResponses chan *deltaResponse
+	deltaDone      chan struct{}
+	// the version and nonce of each type url in the current stream
+	statesMutex sync.Mutex
+	states      map[string]*resourceState
+	stopping    uint32
+	// the hosts of each cluster applied by incremental eds, keyed by address.
+	// it is only accessed in the receive goroutine
+	endpoints map[string]map[string]v2.Host
+	// the route scopes applied by incremental srds, keyed by scope name.
+	// it is only accessed in the receive goroutine
+	scopes map[string]*v2.RouteScope
+}
+
+// resourceState records the subscription of a type url.
+// the versionInfo is the version of last accepted response, which is kept after reconnected,
+// the nonce is the nonce of last received response in the current stream.
+// the resourceVersions is the version of each resource accepted in incremental xDS,
+// which is sent as the initial resource versions after reconnected.
+type resourceState struct {
+	subscribed       bool
+	versionInfo      string
+	nonce            string
+	resourceNames    []string
+	resourceVersions map[string]string
 }
 
 // ServiceConfig for grpc service
@@ -71,10 +103,19 @@ type ServiceConfig struct {
 
 // StreamClient is an grpc client
 type StreamClient struct {
-	Client ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
-	Conn   *grpc.ClientConn
-	Cancel context.CancelFunc
+	Client       ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+	DeltaClients map[string]ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+	Conn         *grpc.ClientConn
+	Cancel       context.CancelFunc
+	// the incremental streams of type urls are created on the same connection
+	discovery ads.AggregatedDiscoveryServiceClient
+	ctx       context.Context
 }
 
-// TypeURLHandleFunc is a function that used to parse ads type url data
-type TypeURLHandleFunc func(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse)
+// TypeURLHandleFunc is a function that used to parse ads type url data,
+// the response is ACK if no error returned, otherwise the response is NACK with the error detail
+type TypeURLHandleFunc func(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) error
+
+// DeltaTypeURLHandleFunc is a function that used to parse incremental ads type url data,
+// the response is ACK if no error returned, otherwise the response is NACK with the error detail
+type DeltaTypeURLHandleFunc func(client *ADSClient, resp *envoy_api_v2.DeltaDiscoveryResponse) error
@@ -73,9 +73,9 @@ func (c *XDSConfig) loadADSConfig(dynamicResources *bootstrap.Bootstrap_DynamicR
 
 func (c *XDSConfig) getAPISourceEndpoint(source *core.ApiConfigSource) (*ADSConfig, error) {
 	config := &ADSConfig{}
-	if source.ApiType != core.ApiConfigSource_GRPC {
+	if source.ApiType != core.ApiConfigSource_GRPC && source.ApiType != core.ApiConfigSource_DELTA_GRPC {
 		log.DefaultLogger.Errorf("unsupported api type: %v", source.ApiType)
-		err := errors.New("only support GRPC api type yet")
+		err := errors.New("only support GRPC and DELTA_GRPC api type yet")
 		return nil, err
 	}
 	config.APIType = source.ApiType
@@ -172,17 +172,67 @@ func (c *ClusterConfig) GetEndpoint() (string, *time.Duration) {
 	return c.Address[idx], c.ConnectTimeout
 }
 
+// IsDelta returns true if the incremental xDS is used
+func (c *ADSConfig) IsDelta() bool {
+	return c.APIType == core.ApiConfigSource_DELTA_GRPC
+}
+
 // GetStreamClient return a grpc stream client that connected to ads
 func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient {
 	if c.StreamClient != nil && c.StreamClient.Client != nil {
 		return c.StreamClient.Client
 	}
+	sc, client, ctx := c.dial()
+	if sc == nil {
+		return nil
+	}
+	streamClient, err := client.StreamAggregatedResources(ctx)
+	if err != nil {
+		log.DefaultLogger.Infof("fail to create stream client: %v", err)
+		sc.Cancel()
+		sc.Conn.Close()
+		return nil
+	}
+	sc.Client = streamClient
+	c.StreamClient = sc
+	return streamClient
+}
+
+// GetDeltaStreamClient return a grpc incremental stream client of the type url that connected to ads,
+// the streams of all type urls share one connection
+func (c *ADSConfig) GetDeltaStreamClient(typeURL string) ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient {
+	if c.StreamClient != nil {
+		if streamClient, ok := c.StreamClient.DeltaClients[typeURL]; ok {
+			return streamClient
+		}
+	} else if sc, _, _ := c.dial(); sc != nil {
+		c.StreamClient = sc
+	} else {
+		return nil
+	}
+	sc := c.StreamClient
+	streamClient, err := sc.discovery.DeltaAggregatedResources(sc.ctx)
+	if err != nil {
+		log.DefaultLogger.Infof("fail to create delta stream client of %s: %v", typeURL, err)
+		if len(sc.DeltaClients) == 0 {
+			c.closeADSStreamClient()
+		}
+		return nil
+	}
+	if sc.DeltaClients == nil {
+		sc.DeltaClients = make(map[string]ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient)
+	}
+	sc.DeltaClients[typeURL] = streamClient
+	return streamClient
+}
 
+// dial connects to an ads endpoint, the returned context is used to create the stream
+func (c *ADSConfig) dial() (*StreamClient, ads.AggregatedDiscoveryServiceClient, context.Context) {
 	sc := &StreamClient{}
 
 	if c.Services == nil {
 		log.DefaultLogger.Errorf("no available ads service")
-		return nil
+		return nil, nil, nil
 	}
 	var endpoint string
 	var tlsContext *envoy_api_v2_auth.UpstreamTlsContext
@@ -199,14 +249,14 @@ func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggre
 	}
 	if len(endpoint) == 0 {
 		log.DefaultLogger.Errorf("no available ads endpoint")
-		return nil
+		return nil, nil, nil
 	}
 
 	if tlsContext == nil || !featuregate.Enabled(featuregate.XdsMtlsEnable) {
 		conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
 		if err != nil {
 			log.DefaultLogger.Errorf("did not connect: %v", err)
-			return nil
+			return nil, nil, nil
 		}
 		log.DefaultLogger.Infof("mosn estab grpc connection to pilot at %v", endpoint)
 		sc.Conn = conn
@@ -215,12 +265,12 @@ func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggre
 		creds, err := c.getTLSCreds(tlsContext)
 		if err != nil {
 			log.DefaultLogger.Errorf("xds-grpc get tls creds fail: err= %v", err)
-			return nil
+			return nil, nil, nil
 		}
 		conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
 		if err != nil {
 			log.DefaultLogger.Errorf("did not connect: %v", err)
-			return nil
+			return nil, nil, nil
 		}
 		log.DefaultLogger.Infof("mosn estab grpc connection to pilot at %v", endpoint)
 		sc.Conn = conn
@@ -229,17 +279,9 @@ func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggre
 
 	ctx, cancel := context.WithCancel(context.Background())
 	sc.Cancel = cancel
-	streamClient, err := client.StreamAggregatedResources(ctx)
-	if err != nil {
-		log.DefaultLogger.Infof("fail to create stream client: %v", err)
-		if sc.Conn != nil {
-			sc.Conn.Close()
-		}
-		return nil
-	}
-	sc.Client = streamClient
-	c.StreamClient = sc
-	return streamClient
+	sc.discovery = client
+	sc.ctx = ctx
+	return sc, client, ctx
 }
 
 func (c *ADSConfig) getTLSCreds(tlsContext *envoy_api_v2_auth.UpstreamTlsContext) (credentials.TransportCredentials, error) {
@@ -294,5 +336,6 @@ func (c *ADSConfig) closeADSStreamClient() {
 		c.StreamClient.Conn = nil
 	}
 	c.StreamClient.Client = nil
+	c.StreamClient.DeltaClients = nil
 	c.StreamClient = nil
 }
@@ -0,0 +1,129 @@
+package integrate
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mosn"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/test/util"
+)
+
+// mirrorHTTPHandler counts the received requests
+type mirrorHTTPHandler struct {
+	util.HTTPHandler
+	count uint32
+}
+
+func (h *mirrorHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddUint32(&h.count, 1)
+	h.HTTPHandler.ServeHTTP(w, r)
+}
+
+func (h *mirrorHTTPHandler) Count() uint32 {
+	return atomic.LoadUint32(&h.count)
+}
+
+type mirrorCase struct {
+	*TestCase
+	subProtocol types.ProtocolName
+	shadow      util.UpstreamServer
+	// returns the requests received by the server
+	appCount    func() uint32
+	shadowCount func() uint32
+	run         func(n int, interval int)
+	mesh        *mosn.Mosn
+}
+
+func newHTTPMirrorCase(t *testing.T, proto types.ProtocolName) *mirrorCase {
+	app := &mirrorHTTPHandler{}
+	shadow := &mirrorHTTPHandler{}
+	c := &mirrorCase{
+		appCount:    app.Count,
+		shadowCount: shadow.Count,
+	}
+	switch proto {
+	case protocol.HTTP1:
+		c.TestCase = NewTestCase(t, proto, proto, util.NewHTTPServer(t, app))
+		c.shadow = util.NewHTTPServer(t, shadow)
+	case protocol.HTTP2:
+		c.TestCase = NewTestCase(t, proto, proto, util.NewUpstreamHTTP2(t, "127.0.0.1:8080", app))
+		c.shadow = util.NewUpstreamHTTP2(t, "127.0.0.1:8081", shadow)
+	}
+	c.run = c.TestCase.RunCase
+	return c
+}
+
+func newXMirrorCase(t *testing.T, subProtocol types.ProtocolName) *mirrorCase {
+	app := util.NewRPCServer(t, "127.0.0.1:8080", subProtocol)
+	shadow := util.NewRPCServer(t, "127.0.0.1:8081", subProtocol)
+	xc := NewXTestCase(t, subProtocol, app)
+	return &mirrorCase{
+		TestCase:    &xc.TestCase,
+		subProtocol: subProtocol,
+		shadow:      shadow,
+		appCount: func() uint32 {
+			return atomic.LoadUint32(&app.(*util.RPCServer).Count)
+		},
+		shadowCount: func() uint32 {
+			return atomic.LoadUint32(&shadow.(*util.RPCServer).Count)
+		},
+		run: xc.RunCase,
+	}
+}
+
+func (c *mirrorCase) Start() {
+	c.AppServer.GoServe()
+	c.shadow.GoServe()
+	c.ClientMeshAddr = util.CurrentMeshAddr()
+	cfg := util.CreateMirrorProxyMesh(c.ClientMeshAddr, []string{c.AppServer.Addr()}, []string{c.shadow.Addr()}, c.AppProtocol, c.subProtocol)
+	c.mesh = mosn.NewMosn(cfg)
+	go c.mesh.Start()
+	time.Sleep(5 * time.Second) //wait server and mesh start
+}
+
+func (c *mirrorCase) Finish() {
+	c.mesh.Close()
+	c.AppServer.Close()
+	c.shadow.Close()
+}
+
+func TestRequestMirror(t *testing.T) {
+	testCases := []*mirrorCase{
+		newHTTPMirrorCase(t, protocol.HTTP1),
+		newHTTPMirrorCase(t, protocol.HTTP2),
+		newXMirrorCase(t, bolt.ProtocolName),
+	}
+	for i, tc := range testCases {
+		t.Logf("start case #%d\n", i)
+		stats := metrics.NewClusterStats("mirrorCluster")
+		successBefore := stats.Counter(metrics.UpstreamRequestMirrorSuccess).Count()
+		tc.Start()
+		go tc.run(5, 0)
+		select {
+		case err := <-tc.C:
+			if err != nil {
+				t.Errorf("[ERROR MESSAGE] #%d %v mirror test failed, error: %v\n", i, tc.AppProtocol, err)
+			}
+		case <-time.After(15 * time.Second):
+			t.Errorf("[ERROR MESSAGE] #%d %v mirror hang\n", i, tc.AppProtocol)
+		}
+		// the mirror requests are sent asynchronously
+		time.Sleep(time.Second)
+		if n := tc.appCount(); n != 5 {
+			t.Errorf("#%d server expected receive 5 requests, but got %d", i, n)
+		}
+		if n := tc.shadowCount(); n != 5 {
+			t.Errorf("#%d shadow server expected receive 5 requests, but got %d", i, n)
+		}
+		if n := stats.Counter(metrics.UpstreamRequestMirrorSuccess).Count() - successBefore; n != 5 {
+			t.Errorf("#%d mirror success expected 5, but got %d", i, n)
+		}
+		tc.Finish()
+	}
+}
@@ -0,0 +1,163 @@
+package integrate
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	_ "mosn.io/mosn/pkg/filter/network/udpproxy"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mosn"
+	"mosn.io/mosn/test/util"
+)
+
+type udpProxyCase struct {
+	t        *testing.T
+	servers  []*util.UDPEchoServer
+	meshAddr string
+	mesh     *mosn.Mosn
+}
+
+func newUDPProxyCase(t *testing.T, serverCount int) *udpProxyCase {
+	c := &udpProxyCase{
+		t:        t,
+		meshAddr: util.CurrentMeshAddr(),
+	}
+	for i := 0; i < serverCount; i++ {
+		c.servers = append(c.servers, util.NewUDPEchoServer(t, "127.0.0.1:0"))
+	}
+	return c
+}
+
+func (c *udpProxyCase) Start(idleTimeout time.Duration) {
+	var hosts []string
+	for _, s := range c.servers {
+		s.GoServe()
+		hosts = append(hosts, s.Addr())
+	}
+	cfg := util.CreateUDPProxyConfig(c.meshAddr, hosts, idleTimeout)
+	c.mesh = mosn.NewMosn(cfg)
+	go c.mesh.Start()
+	time.Sleep(2 * time.Second) //wait server and mesh start
+}
+
+func (c *udpProxyCase) Finish() {
+	c.mesh.Close()
+	for _, s := range c.servers {
+		s.Close()
+	}
+}
+
+// echo sends a datagram by the client and waits the echo
+func (c *udpProxyCase) echo(client net.Conn, data []byte) error {
+	if _, err := client.Write(data); err != nil {
+		return err
+	}
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 65535)
+	n, err := client.Read(buf)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(buf[:n], data) {
+		return fmt.Errorf("echo data is not expected, got %d bytes, expected %d bytes", n, len(data))
+	}
+	return nil
+}
+
+func TestUDPProxy(t *testing.T) {
+	c := newUDPProxyCase(t, 2)
+	c.Start(time.Minute)
+	defer c.Finish()
+	stats := metrics.NewUDPProxyStats("udp_test")
+	rxBefore := stats.Counter(metrics.UDPProxyDownstreamDatagramsRx).Count()
+	txBefore := stats.Counter(metrics.UDPProxyDownstreamDatagramsTx).Count()
+	bytesBefore := stats.Counter(metrics.UDPProxyUpstreamBytesTx).Count()
+	// each client has its own session
+	var clients []net.Conn
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("udp", c.meshAddr)
+		if err != nil {
+			t.Fatalf("dial udp failed: %v", err)
+		}
+		defer client.Close()
+		clients = append(clients, client)
+	}
+	sent := int64(0)
+	for i := 0; i < 5; i++ {
+		for idx, client := range clients {
+			data := []byte(fmt.Sprintf("client %d datagram %d", idx, i))
+			if err := c.echo(client, data); err != nil {
+				t.Fatalf("client %d echo failed: %v", idx, err)
+			}
+			sent += int64(len(data))
+		}
+	}
+	// the boundaries of datagram are kept
+	large := bytes.Repeat([]byte("m"), 8000)
+	if err := c.echo(clients[0], large); err != nil {
+		t.Fatalf("large datagram echo failed: %v", err)
+	}
+	sent += int64(len(large))
+	// the sessions are load balanced to different hosts, and the upstream of a session is not changed
+	for _, s := range c.servers {
+		if s.Peers() != 1 {
+			t.Fatalf("server %s expected receive datagrams from 1 session, but got %d", s.Addr(), s.Peers())
+		}
+	}
+	if c.servers[0].Received()+c.servers[1].Received() != 11 {
+		t.Fatalf("servers expected receive 11 datagrams, but got %d, %d", c.servers[0].Received(), c.servers[1].Received())
+	}
+	if rx := stats.Counter(metrics.UDPProxyDownstreamDatagramsRx).Count() - rxBefore; rx != 11 {
+		t.Errorf("downstream datagrams rx expected 11, but got %d", rx)
+	}
+	if tx := stats.Counter(metrics.UDPProxyDownstreamDatagramsTx).Count() - txBefore; tx != 11 {
+		t.Errorf("downstream datagrams tx expected 11, but got %d", tx)
+	}
+	if b := stats.Counter(metrics.UDPProxyUpstreamBytesTx).Count() - bytesBefore; b != sent {
+		t.Errorf("upstream bytes tx expected %d, but got %d", sent, b)
+	}
+	if active := stats.Gauge(metrics.UDPProxySessionActive).Value(); active != 2 {
+		t.Errorf("active sessions expected 2, but got %d", active)
+	}
+}
+
+func TestUDPProxyIdleTimeout(t *testing.T) {
+	c := newUDPProxyCase(t, 1)
+	c.Start(500 * time.Millisecond)
+	defer c.Finish()
+	stats := metrics.NewUDPProxyStats("udp_test")
+	timeoutBefore := stats.Counter(metrics.UDPProxySessionIdleTimeout).Count()
+	totalBefore := stats.Counter(metrics.UDPProxySessionTotal).Count()
+	client, err := net.Dial("udp", c.meshAddr)
+	if err != nil {
+		t.Fatalf("dial udp failed: %v", err)
+	}
+	defer client.Close()
+	// the session is kept if it is active
+	for i := 0; i < 4; i++ {
+		if err := c.echo(client, []byte("keep alive")); err != nil {
+			t.Fatalf("echo failed: %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if n := stats.Counter(metrics.UDPProxySessionIdleTimeout).Count() - timeoutBefore; n != 0 {
+		t.Fatalf("active session should not be timeout, but got %d timeout", n)
+	}
+	time.Sleep(time.Second)
+	if n := stats.Counter(metrics.UDPProxySessionIdleTimeout).Count() - timeoutBefore; n != 1 {
+		t.Fatalf("session expected idle timeout, but got %d timeout", n)
+	}
+	if active := stats.Gauge(metrics.UDPProxySessionActive).Value(); active != 0 {
+		t.Fatalf("active sessions expected 0, but got %d", active)
+	}
+	// a new session is created
+	if err := c.echo(client, []byte("new session")); err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if n := stats.Counter(metrics.UDPProxySessionTotal).Count() - totalBefore; n != 2 {
+		t.Fatalf("sessions expected 2, but got %d", n)
+	}
+}
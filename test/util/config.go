@@ -3,9 +3,12 @@ package util
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/json-iterator/go"
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -111,6 +114,36 @@ func CreateXProtocolProxyMesh(addr string, hosts []string, proto types.ProtocolN
 	return NewMOSNConfig([]v2.Listener{listener}, cmconfig)
 }
 
+// mesh as a proxy, the requests are mirrored to the mirror hosts
+// subProtocol is used if the proto is xprotocol
+func CreateMirrorProxyMesh(addr string, hosts, mirrorHosts []string, proto, subProtocol types.ProtocolName) *v2.MOSNConfig {
+	clusterName := "proxyCluster"
+	mirrorClusterName := "mirrorCluster"
+	cmconfig := v2.ClusterManagerConfig{
+		Clusters: []v2.Cluster{
+			NewBasicCluster(clusterName, hosts),
+			NewBasicCluster(mirrorClusterName, mirrorHosts),
+		},
+	}
+	routers := []v2.Router{
+		NewPrefixRouter(clusterName, "/"),
+		NewHeaderRouter(clusterName, ".*"),
+	}
+	for i := range routers {
+		routers[i].Route.RequestMirrorPolicies = []v2.RequestMirrorPolicy{
+			{Cluster: mirrorClusterName},
+		}
+	}
+	var chains []v2.FilterChain
+	if proto == protocol.Xprotocol {
+		chains = append(chains, NewXProtocolFilterChain("proxyVirtualHost", subProtocol, routers))
+	} else {
+		chains = append(chains, NewFilterChain("proxyVirtualHost", proto, proto, routers))
+	}
+	listener := NewListener("proxyListener", addr, chains)
+	return NewMOSNConfig([]v2.Listener{listener}, cmconfig)
+}
+
 // XProtocol mesh to mesh
 // currently, support Path/Prefix is "/" only
 func CreateXProtocolMesh(clientaddr string, serveraddr string, subProtocol types.ProtocolName, hosts []string, tls bool) *v2.MOSNConfig {
@@ -289,6 +322,40 @@ func CreateTCPProxyConfig(meshaddr string, hosts []string, isRouteEntryMode bool
 	}, cmconfig)
 }
 
+// UDP Proxy
+func CreateUDPProxyConfig(meshaddr string, hosts []string, idleTimeout time.Duration) *v2.MOSNConfig {
+	clusterName := "udp_cluster"
+	udpConfig := v2.UDPProxy{
+		StatPrefix: "udp_test",
+		Cluster:    clusterName,
+		IdleTimeout: &api.DurationConfig{
+			Duration: idleTimeout,
+		},
+	}
+	chains := make(map[string]interface{})
+	b, _ := json.Marshal(udpConfig)
+	json.Unmarshal(b, &chains)
+	filterChains := []v2.FilterChain{
+		{
+			FilterChainConfig: v2.FilterChainConfig{
+				Filters: []v2.Filter{
+					{Type: v2.UDP_PROXY, Config: chains},
+				},
+			},
+		},
+	}
+	cmconfig := v2.ClusterManagerConfig{
+		Clusters: []v2.Cluster{
+			NewBasicCluster(clusterName, hosts),
+		},
+	}
+	listener := NewListener("udp_listener", meshaddr, filterChains)
+	listener.Network = v2.NetworkUDP
+	return NewMOSNConfig([]v2.Listener{
+		listener,
+	}, cmconfig)
+}
+
 type WeightCluster struct {
 	Name   string
 	Hosts  []*WeightHost
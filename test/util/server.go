@@ -159,3 +159,73 @@ func NewHTTPServer(t *testing.T, h http.Handler) UpstreamServer {
 	s.server = httptest.NewUnstartedServer(s.Handler)
 	return s
 }
+
+// UDPEchoServer echoes the datagrams back to the sender
+type UDPEchoServer struct {
+	t        *testing.T
+	Address  string
+	conn     *net.UDPConn
+	received int64
+	mu       sync.Mutex
+	peers    map[string]bool
+}
+
+func NewUDPEchoServer(t *testing.T, addr string) *UDPEchoServer {
+	return &UDPEchoServer{
+		t:       t,
+		Address: addr,
+		peers:   map[string]bool{},
+	}
+}
+
+func (s *UDPEchoServer) GoServe() {
+	addr, err := net.ResolveUDPAddr("udp", s.Address)
+	if err != nil {
+		s.t.Fatalf("resolve %s failed, error : %v\n", s.Address, err)
+	}
+	s.conn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		s.t.Fatalf("listen %s failed, error : %v\n", s.Address, err)
+	}
+	s.Address = s.conn.LocalAddr().String()
+	go s.serve()
+}
+
+func (s *UDPEchoServer) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.received++
+		s.peers[addr.String()] = true
+		s.mu.Unlock()
+		s.conn.WriteTo(buf[:n], addr)
+	}
+}
+
+// Received returns the count of datagrams received
+func (s *UDPEchoServer) Received() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received
+}
+
+// Peers returns the count of different addresses that send datagrams
+func (s *UDPEchoServer) Peers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.peers)
+}
+
+func (s *UDPEchoServer) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+func (s *UDPEchoServer) Addr() string {
+	return s.Address
+}